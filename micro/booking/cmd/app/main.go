@@ -3,6 +3,7 @@ package main
 import (
 	"booking/internal/config"
 	"booking/internal/handler"
+	"booking/internal/infrastructure/meeting"
 	"booking/internal/infrastructure/payment"
 	"booking/internal/infrastructure/schedule"
 	"booking/internal/infrastructure/supabase"
@@ -11,6 +12,7 @@ import (
 	model "booking/internal/model"
 	"booking/internal/repository"
 	"booking/internal/service"
+	"flag"
 	"fmt"
 	"os"
 
@@ -21,12 +23,27 @@ import (
 )
 
 func main() {
+	// One-off data migrations only run when asked for, not on every start.
+	migrate := flag.Bool("migrate", false, "run one-off data migrations before serving")
+	flag.Parse()
+
 	c := config.LoadConfig()
 	db := config.InitDB(c)
 	// Auto migrate booking-related models to ensure the bookings table exists.
 	{
-		type Booking = model.Booking
-		if err := db.AutoMigrate(&Booking{}); err != nil {
+		type (
+			Booking           = model.Booking
+			CalendarFeed      = model.CalendarFeed
+			WaitlistEntry     = model.WaitlistEntry
+			BookingSeries     = model.BookingSeries
+			RescheduleRequest = model.RescheduleRequest
+			RescheduleOption  = model.RescheduleOption
+			LessonRecord      = model.LessonRecord
+			Homework          = model.Homework
+			Conversation      = model.Conversation
+			Message           = model.Message
+		)
+		if err := db.AutoMigrate(&Booking{}, &CalendarFeed{}, &WaitlistEntry{}, &BookingSeries{}, &RescheduleRequest{}, &RescheduleOption{}, &LessonRecord{}, &Homework{}, &Conversation{}, &Message{}); err != nil {
 			zerolog.Info().Err(err).Msg("failed to auto migrate booking service database")
 		}
 	}
@@ -56,12 +73,51 @@ func main() {
 
 	paymentService := payment.NewPaymentHttp(c.ServicePayment, restyInit)
 
-	service := service.NewService(repo, serviceSchedule, userService, paymentService)
+	meetingProvider, err := meeting.NewProvider(c.Meeting)
+	if err != nil {
+		zerolog.Fatal().Err(err).Msg("failed to set up meeting provider")
+	}
+
+	service := service.NewService(repo, serviceSchedule, userService, paymentService, c.Calendar, meetingProvider, c.Meeting, c.Waitlist, c.Reschedule, supabaseService)
 
 	uploadHandler := handler.NewUploadHandler(supabaseService, &c.Client)
 
+	lessonHandler := handler.NewLessonHandler(service, supabaseService)
+
+	messageHandler := handler.NewMessageHandler(service, supabaseService, &c.JWT)
+
 	handler := handler.NewHandler(service)
 
+	// Bookings made before the lesson date was stored locally are filled in
+	// from the teacher service so they show up in the analytics.
+	go service.BackfillLessonDetails()
+	// Bookings rescheduled in place before successor records existed are
+	// turned back into live bookings.
+	if *migrate {
+		if err := repo.MigrateLegacyReschedules(); err != nil {
+			zerolog.Info().Err(err).Msg("failed to migrate legacy reschedules")
+		}
+	}
+	// Expired waitlist holds pass the slot on to the next student.
+	go service.RunWaitlist()
+	// Unanswered teacher reschedule requests get their default policy.
+	go service.RunRescheduleRequests()
+	// Paid lessons get reminders a day and an hour before they start.
+	go service.RunLessonReminders()
+	// Reschedule refunds that failed are paid out later.
+	go service.RunRefundRetries()
+	// Paid lessons that ended count as completed.
+	go service.RunLessonCompletion()
+
+	// The message stream is opened with a stream token in the URL, as
+	// EventSource cannot send the Authorization header, so it is not behind
+	// the group's middleware.
+	if c.IsNFT {
+		r.GET("/api/v1/messages/stream", messageHandler.StreamMessages)
+	} else {
+		r.GET("/api/v1/messages/stream", middleware.StreamAuthMiddleware(&c.JWT), messageHandler.StreamMessages)
+	}
+
 	api := r.Group("/api/v1")
 	if !c.IsNFT {
 		api.Use(middleware.AuthMiddleware(&c.JWT))
@@ -69,6 +125,54 @@ func main() {
 	auth := api.Group("")
 	auth.POST("/auth/logout", handler.Logout)
 	auth.GET("/admin/bookings", handler.GetBookingsAdmin)
+
+	// ICS feed of the student's lessons. The feed itself is served by the
+	// public route below, authenticated by the secret token in its URL.
+	auth.GET("/calendar/feed", handler.GetCalendarFeed)
+	auth.POST("/calendar/feed/rotate", handler.RotateCalendarFeed)
+	auth.DELETE("/calendar/feed", handler.RevokeCalendarFeed)
+	auth.GET("/waitlist", handler.GetMyWaitlist)
+	auth.POST("/waitlist", handler.JoinWaitlist)
+	auth.DELETE("/waitlist/:id", handler.LeaveWaitlist)
+	auth.POST("/waitlist/:id/claim", handler.ClaimWaitlistOffer)
+
+	// Recurring bookings. Single occurrences are cancelled and rescheduled
+	// like any other booking; these routes act on the rest of the series.
+	auth.POST("/bookings/series", handler.CreateBookingSeries)
+	auth.GET("/bookings/series/:id", handler.GetBookingSeries)
+	auth.POST("/bookings/series/:id/cancel", handler.CancelBookingSeries)
+	auth.POST("/bookings/series/:id/reschedule", handler.RescheduleBookingSeries)
+
+	// Reschedules proposed by the teacher, answered by the student.
+	auth.GET("/reschedule-requests", handler.GetMyRescheduleRequests)
+	auth.POST("/reschedule-requests/:id/accept", handler.AcceptRescheduleRequest)
+	auth.POST("/reschedule-requests/:id/decline", handler.DeclineRescheduleRequest)
+
+	// Lesson records and homework, shared by the student and the teacher of
+	// a booking.
+	auth.GET("/bookings/:id/lesson-record", lessonHandler.GetLessonRecord)
+	auth.PUT("/bookings/:id/lesson-record", lessonHandler.SaveLessonRecord)
+	auth.POST("/bookings/:id/homework", lessonHandler.AssignHomework)
+	auth.POST("/bookings/:id/attachments", lessonHandler.UploadAttachment)
+	auth.GET("/lesson-records", lessonHandler.GetLessonHistory)
+	auth.GET("/homework", lessonHandler.GetMyHomework)
+	auth.PUT("/homework/:id/submission", lessonHandler.SubmitHomework)
+	auth.PUT("/homework/:id/review", lessonHandler.ReviewHomework)
+
+	// Messaging between a student and a teacher they have a booking with.
+	// New messages and read receipts are pushed over the SSE stream; clients
+	// without it poll the messages with after_id.
+	auth.POST("/conversations", messageHandler.StartConversation)
+	auth.GET("/conversations", messageHandler.GetConversations)
+	auth.GET("/conversations/:id/messages", messageHandler.GetMessages)
+	auth.POST("/conversations/:id/messages", messageHandler.SendMessage)
+	auth.POST("/conversations/:id/read", messageHandler.MarkRead)
+	auth.POST("/conversations/:id/attachments", messageHandler.UploadAttachment)
+	auth.GET("/messages/unread-count", messageHandler.GetUnreadCount)
+	auth.POST("/messages/stream-token", messageHandler.CreateStreamToken)
+	auth.GET("/admin/conversations", messageHandler.GetConversationsAdmin)
+	auth.GET("/admin/conversations/:id/messages", messageHandler.GetMessages)
+	auth.PUT("/admin/messages/:id/moderation", messageHandler.ModerateMessage)
 	{
 		api.POST("/bookings", handler.CreateBooking)
 		api.GET("/bookings", handler.GetBookings)
@@ -76,6 +180,7 @@ func main() {
 		api.DELETE("/booking/:id", handler.DeleteBooking)
 		api.GET("/booking-detail/:id", handler.GetBookingDetail)
 		api.POST("/bookings/:id/reschedule", handler.RescheduleBooking)
+		api.GET("/bookings/:id/history", handler.GetBookingHistory)
 		api.POST("/bookings/:id/cancel", handler.CancelBooking)
 		api.GET("/bookings/user/:user_id", handler.GetBookingsByUserID)
 		// Endpoint to fetch upcoming lessons for a user.  Returns the list of
@@ -97,6 +202,13 @@ func main() {
 	// deliberately placed outside the authentication middleware group.
 	// Endpoint to fetch teacher bookings for dashboard and other services
 	r.GET("/api/v1/internal/bookings/teacher/:teacher_id", handler.GetTeacherBookingsInternal)
+	// Aggregated booking figures for the teacher analytics dashboard.
+	r.GET("/api/v1/internal/bookings/teacher/:teacher_id/analytics", handler.GetTeacherAnalyticsInternal)
+	// Paginated student roster of a teacher, aggregated per student.
+	r.GET("/api/v1/internal/bookings/teacher/:teacher_id/students", handler.GetTeacherStudentsInternal)
+	// Completed lessons of a student for the progress tracking of the user
+	// service.
+	r.GET("/api/v1/internal/bookings/user/:user_id/completed-lessons", handler.GetCompletedLessonsInternal)
 
 	// Endpoint to fetch a single booking by ID (including user ID) for internal
 	// services.  This internal endpoint exposes the raw booking record with
@@ -105,8 +217,30 @@ func main() {
 	// be exposed to clients directly.
 	r.GET("/api/v1/internal/bookings/:id", handler.GetBookingInternal)
 
+	// Internal routes that cancel bookings or move money are reachable on
+	// the public port, so they require the secret the services share.
+	internal := r.Group("/api/v1/internal")
+	internal.Use(middleware.InternalMiddleware(c.InternalSecret))
+
+	// Endpoint for the teacher service to cancel (and refund) every booking
+	// holding one of the given schedules, e.g. when slots are cancelled in
+	// bulk.
+	internal.POST("/bookings/cancel-by-schedules", handler.CancelBookingsBySchedulesInternal)
+
+	// Endpoint for the teacher service to announce newly bookable slots so
+	// they can be offered to the waitlist.
+	r.POST("/api/v1/internal/waitlist/slots-published", handler.SlotsPublishedInternal)
+	// Teacher reschedule requests, made through the teacher service after it
+	// checked the teacher may act on the booking.
+	r.POST("/api/v1/internal/reschedule-requests", handler.ProposeRescheduleInternal)
+	r.GET("/api/v1/internal/reschedule-requests/teacher/:teacher_id", handler.GetTeacherRescheduleRequestsInternal)
+	r.POST("/api/v1/internal/reschedule-requests/:id/withdraw", handler.WithdrawRescheduleRequestInternal)
+
 	r.PUT("/private/bookings/:id/status", handler.UpdateBookingStatus)
 
+	// Public ICS feed for calendar apps, e.g. /api/v1/calendar/ics/<token>.ics
+	r.GET("/api/v1/calendar/ics/:token", handler.GetStudentCalendar)
+
 	zerolog.Info().Msg("Server running on port " + fmt.Sprint(":", c.AppPort))
 
 	r.Run(fmt.Sprint(":", c.AppPort))
@@ -19,10 +19,15 @@ type Config struct {
 	AppPort           string
 	GIN_MODE          string
 	JWT               JWT
+	InternalSecret    string
 	ServiceSchedule   Service
 	ServiceUser       Service
 	ServicePayment    Service
 	Client            Client
+	Calendar          Calendar
+	Meeting           Meeting
+	Waitlist          Waitlist
+	Reschedule        Reschedule
 	IsNFT             bool
 }
 
@@ -31,17 +36,61 @@ type JWT struct {
 	TokenDuration int
 }
 
+// InternalSecretHeader carries the secret the services share. Internal
+// routes that change bookings, move money or expose users require it.
+const InternalSecretHeader = "X-Internal-Secret"
+
 type Service struct {
 	Host string
 	Port string
+	// InternalSecret is sent in InternalSecretHeader to the internal routes
+	// of the service that require it.
+	InternalSecret string
+}
+
+// Calendar configures the ICS feeds. Lesson times are stored as local times
+// of Timezone. PublicURL is the externally reachable base URL of this
+// service, used to build feed links.
+type Calendar struct {
+	Timezone  string
+	PublicURL string
+}
+
+// Meeting configures the online meeting rooms of paid lessons. AppID and
+// AppSecret are optional and enable signed room tokens. RevealBefore is how
+// long before the lesson the link is shown.
+type Meeting struct {
+	Provider     string
+	BaseURL      string
+	AppID        string
+	AppSecret    string
+	RevealBefore time.Duration
+}
+
+// Waitlist configures how long a freed slot is held for the waitlisted
+// student it is offered to.
+type Waitlist struct {
+	HoldDuration time.Duration
 }
 
+// Reschedule configures reschedule requests made by teachers: how long the
+// student has to answer and what happens when they do not.
+type Reschedule struct {
+	ResponseWindow time.Duration
+	DefaultPolicy  string
+}
+
+// Client configures the object storage. BucketName is public; lesson and
+// message attachments go to PrivateBucketName and are only handed out as
+// signed URLs valid for SignedURLExpiry.
 type Client struct {
-	Endpoint   string
-	AccessKey  string
-	SecretKey  string
-	Region     string
-	BucketName string
+	Endpoint          string
+	AccessKey         string
+	SecretKey         string
+	Region            string
+	BucketName        string
+	PrivateBucketName string
+	SignedURLExpiry   time.Duration
 }
 
 func LoadConfig() *Config {
@@ -50,6 +99,8 @@ func LoadConfig() *Config {
 		log.Println("No .env file found or failed to load")
 	}
 
+	internalSecret := os.Getenv("INTERNAL_SERVICE_SECRET")
+
 	return &Config{
 		MysqlDSN:          os.Getenv("MYSQL_DSN"),
 		AppPort:           os.Getenv("APP_PORT"),
@@ -61,22 +112,46 @@ func LoadConfig() *Config {
 			SecretKey:     os.Getenv("JWT_SECRET_KEY"),
 			TokenDuration: cast.ToInt(os.Getenv("JWT_TOKEN_DURATION")),
 		},
+		InternalSecret: internalSecret,
 		ServiceSchedule: Service{
-			Host: os.Getenv("SERVICE_SCHEDULE_HOST"),
-			Port: os.Getenv("SERVICE_SCHEDULE_PORT"),
+			Host:           os.Getenv("SERVICE_SCHEDULE_HOST"),
+			Port:           os.Getenv("SERVICE_SCHEDULE_PORT"),
+			InternalSecret: internalSecret,
 		},
 		ServiceUser: Service{
-			Host: os.Getenv("SERVICE_USER_HOST"),
+			Host:           os.Getenv("SERVICE_USER_HOST"),
+			InternalSecret: internalSecret,
 		},
 		ServicePayment: Service{
-			Host: os.Getenv("SERVICE_PAYMENT_HOST"),
+			Host:           os.Getenv("SERVICE_PAYMENT_HOST"),
+			InternalSecret: internalSecret,
 		},
 		Client: Client{
-			Endpoint:   os.Getenv("CLIENT_ENDPOINT"),
-			AccessKey:  os.Getenv("CLIENT_ACCESS_KEY"),
-			SecretKey:  os.Getenv("CLIENT_SECRET_KEY"),
-			Region:     os.Getenv("CLIENT_REGION"),
-			BucketName: os.Getenv("CLIENT_BUCKET_NAME"),
+			Endpoint:          os.Getenv("CLIENT_ENDPOINT"),
+			AccessKey:         os.Getenv("CLIENT_ACCESS_KEY"),
+			SecretKey:         os.Getenv("CLIENT_SECRET_KEY"),
+			Region:            os.Getenv("CLIENT_REGION"),
+			BucketName:        os.Getenv("CLIENT_BUCKET_NAME"),
+			PrivateBucketName: os.Getenv("CLIENT_PRIVATE_BUCKET_NAME"),
+			SignedURLExpiry:   cast.ToDuration(os.Getenv("CLIENT_SIGNED_URL_EXPIRY")),
+		},
+		Calendar: Calendar{
+			Timezone:  os.Getenv("CALENDAR_TIMEZONE"),
+			PublicURL: os.Getenv("CALENDAR_PUBLIC_URL"),
+		},
+		Meeting: Meeting{
+			Provider:     os.Getenv("MEETING_PROVIDER"),
+			BaseURL:      os.Getenv("MEETING_BASE_URL"),
+			AppID:        os.Getenv("MEETING_APP_ID"),
+			AppSecret:    os.Getenv("MEETING_APP_SECRET"),
+			RevealBefore: cast.ToDuration(os.Getenv("MEETING_REVEAL_BEFORE")),
+		},
+		Waitlist: Waitlist{
+			HoldDuration: cast.ToDuration(os.Getenv("WAITLIST_HOLD_DURATION")),
+		},
+		Reschedule: Reschedule{
+			ResponseWindow: cast.ToDuration(os.Getenv("RESCHEDULE_RESPONSE_WINDOW")),
+			DefaultPolicy:  os.Getenv("RESCHEDULE_DEFAULT_POLICY"),
 		},
 		IsNFT: cast.ToBool(os.Getenv("IS_NFT")),
 	}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"booking/internal/infrastructure/supabase"
+	"booking/internal/model"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAttachmentSize bounds a single attachment upload.
+const maxAttachmentSize = 20 << 20
+
+// storeAttachment uploads the request's "file" into folder of the private
+// bucket and returns it with a signed URL. On failure it writes the error
+// response and reports false.
+func storeAttachment(c *gin.Context, uploadService *supabase.Client, folder string) (*model.Attachment, bool) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get file"})
+		return nil, false
+	}
+	if fileHeader.Size > maxAttachmentSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is too large"})
+		return nil, false
+	}
+
+	safeFilename := sanitizeFilename(fileHeader.Filename)
+	if safeFilename == "" {
+		safeFilename = "attachment"
+	}
+	tempPath := filepath.Join(os.TempDir(), fmt.Sprintf("%d_%s", time.Now().UnixNano(), safeFilename))
+	if err := c.SaveUploadedFile(fileHeader, tempPath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+		return nil, false
+	}
+	defer os.Remove(tempPath)
+
+	// The timestamp keeps uploads of files with the same name apart.
+	objectName := fmt.Sprintf("%s/%d_%s", folder, time.Now().UnixNano(), safeFilename)
+	if err := uploadService.UploadPrivate(objectName, tempPath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload file"})
+		return nil, false
+	}
+
+	attachment := &model.Attachment{Name: fileHeader.Filename, Path: objectName}
+	if signed, err := uploadService.SignedURLs([]string{objectName}); err == nil {
+		attachment.URL = signed[objectName]
+	}
+	return attachment, true
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// GetCalendarFeed returns the ICS feed URL of the authenticated user,
+// creating the feed on first use.
+func (h *Handler) GetCalendarFeed(c *gin.Context) {
+	feed, err := h.service.GetCalendarFeed(cast.ToUint(c.GetString("user_id")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": feed})
+}
+
+// RotateCalendarFeed issues a new feed URL; the previous one stops working.
+func (h *Handler) RotateCalendarFeed(c *gin.Context) {
+	feed, err := h.service.RotateCalendarFeed(cast.ToUint(c.GetString("user_id")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed rotated", "data": feed})
+}
+
+// RevokeCalendarFeed disables the user's feed URL.
+func (h *Handler) RevokeCalendarFeed(c *gin.Context) {
+	if err := h.service.RevokeCalendarFeed(cast.ToUint(c.GetString("user_id"))); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked"})
+}
+
+// GetStudentCalendar serves the ICS feed identified by the secret token in
+// the URL. Calendar apps cannot send a JWT, so the route is public.
+func (h *Handler) GetStudentCalendar(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	calendar, err := h.service.RenderStudentCalendar(token)
+	if err != nil {
+		if err.Error() == "calendar feed not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(calendar))
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cast"
@@ -32,7 +33,7 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 
 	resp, err := h.service.CreateBooking(c, req)
 	if err != nil {
-		if err.Error() == "schedule is not available" {
+		if err.Error() == "schedule is not available" || err.Error() == "schedule is on hold for a waitlisted student" {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			return
 		}
@@ -51,12 +52,13 @@ func (h *Handler) RescheduleBooking(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.RescheduleBooking(c, req.BookingID, req.NewScheduleID, req.UserID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule booking"})
+	booking, err := h.service.RescheduleBooking(c, req.BookingID, req.NewScheduleID, req.UserID)
+	if err != nil {
+		respondRescheduleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Booking rescheduled successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Booking rescheduled successfully", "data": booking})
 }
 
 func (h *Handler) CancelBooking(c *gin.Context) {
@@ -203,6 +205,86 @@ func (h *Handler) GetTeacherBookingsInternal(c *gin.Context) {
 	})
 }
 
+// GetTeacherAnalyticsInternal returns the aggregated booking figures of a
+// teacher for the teacher dashboard. Query parameters: from and to
+// (YYYY-MM-DD, inclusive) and interval (day, week or month).
+func (h *Handler) GetTeacherAnalyticsInternal(c *gin.Context) {
+	teacherID, err := strconv.Atoi(c.Param("teacher_id"))
+	if err != nil || teacherID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid teacher ID"})
+		return
+	}
+
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+		return
+	}
+
+	analytics, err := h.service.GetTeacherAnalytics(uint(teacherID), from, to, c.DefaultQuery("interval", "day"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") || strings.HasPrefix(err.Error(), "to must") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch analytics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// GetTeacherStudentsInternal returns a page of the teacher's student roster
+// with per-student lesson counts, spend and last/next lesson dates. Query
+// parameters: page, limit and sort (recent or upcoming).
+func (h *Handler) GetTeacherStudentsInternal(c *gin.Context) {
+	teacherID, err := strconv.Atoi(c.Param("teacher_id"))
+	if err != nil || teacherID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid teacher ID"})
+		return
+	}
+
+	pagination := pkg.Paginate{
+		Page:  cast.ToInt(c.Query("page")),
+		Limit: cast.ToInt(c.Query("limit")),
+	}
+
+	students, err := h.service.GetTeacherStudents(uint(teacherID), c.Query("sort"), pagination)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch students"})
+		return
+	}
+
+	c.JSON(http.StatusOK, students)
+}
+
+// GetCompletedLessonsInternal lists the completed lessons of a student with
+// their teacher and length, for the progress tracking of the user service.
+func (h *Handler) GetCompletedLessonsInternal(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	lessons, err := h.service.GetCompletedLessons(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lessons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": lessons})
+}
+
 // GetBookingInternal handles internal requests to retrieve a booking record
 // by its ID.  This endpoint is intended for service-to-service calls
 // (e.g. payment service) that need access to the raw booking model including
@@ -236,12 +318,38 @@ func (h *Handler) GetBookingInternal(c *gin.Context) {
 		"id":          booking.ID,
 		"user_id":     booking.UserID,
 		"schedule_id": booking.ScheduleID,
+		"teacher_id":  booking.TeacherID,
 		"status":      booking.Status,
 		"payment_id":  booking.PaymentID,
 		"total_price": booking.TotalPrice,
+		"amount_due":  h.service.AmountDue(booking),
 	}})
 }
 
+// CancelBookingsBySchedulesInternal cancels and refunds the bookings holding
+// the given schedules. It is called by the teacher service when slots are
+// cancelled in bulk and is registered outside the authenticated group.
+// Bookings that could not be cancelled are listed under "failed".
+func (h *Handler) CancelBookingsBySchedulesInternal(c *gin.Context) {
+	var req model.CancelBySchedulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	bookings, failed, err := h.service.CancelBookingsBySchedules(req.ScheduleIDs, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Bookings cancelled successfully",
+		"bookings": bookings,
+		"failed":   failed,
+	})
+}
+
 func (h *Handler) GetBooking(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
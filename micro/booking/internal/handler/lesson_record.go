@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"booking/internal/infrastructure/supabase"
+	"booking/internal/model"
+	"booking/internal/pkg"
+	"booking/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// LessonHandler serves lesson records, homework and their attachments to
+// the student and the teacher of a booking.
+type LessonHandler struct {
+	service       *service.Service
+	uploadService *supabase.Client
+}
+
+func NewLessonHandler(service *service.Service, uploadService *supabase.Client) *LessonHandler {
+	return &LessonHandler{service: service, uploadService: uploadService}
+}
+
+func (h *LessonHandler) GetLessonRecord(c *gin.Context) {
+	record, err := h.service.GetLessonRecord(viewerID(c), cast.ToUint(c.Param("id")), isAdmin(c))
+	if err != nil {
+		respondLessonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": record})
+}
+
+// SaveLessonRecord writes the summary, vocabulary and materials of a lesson.
+func (h *LessonHandler) SaveLessonRecord(c *gin.Context) {
+	var req model.LessonRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	record, err := h.service.SaveLessonRecord(viewerID(c), cast.ToUint(c.Param("id")), req)
+	if err != nil {
+		respondLessonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Lesson record saved", "data": record})
+}
+
+func (h *LessonHandler) AssignHomework(c *gin.Context) {
+	var req model.HomeworkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	homework, err := h.service.AssignHomework(viewerID(c), cast.ToUint(c.Param("id")), req)
+	if err != nil {
+		respondLessonError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Homework assigned", "data": homework})
+}
+
+func (h *LessonHandler) SubmitHomework(c *gin.Context) {
+	var req model.HomeworkSubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	homework, err := h.service.SubmitHomework(viewerID(c), cast.ToUint(c.Param("id")), req)
+	if err != nil {
+		respondLessonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Homework submitted", "data": homework})
+}
+
+func (h *LessonHandler) ReviewHomework(c *gin.Context) {
+	var req model.HomeworkReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	homework, err := h.service.ReviewHomework(viewerID(c), cast.ToUint(c.Param("id")), req)
+	if err != nil {
+		respondLessonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Homework reviewed", "data": homework})
+}
+
+// GetLessonHistory lists the student's lesson records across teachers.
+// Query: page, limit and teacher_id.
+func (h *LessonHandler) GetLessonHistory(c *gin.Context) {
+	pagination := pkg.Paginate{
+		Page:  cast.ToInt(c.Query("page")),
+		Limit: cast.ToInt(c.Query("limit")),
+	}
+	history, err := h.service.GetLessonHistory(viewerID(c), cast.ToUint(c.Query("teacher_id")), pagination)
+	if err != nil {
+		respondLessonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// GetMyHomework lists the student's homework. Query: status.
+func (h *LessonHandler) GetMyHomework(c *gin.Context) {
+	homework, err := h.service.GetMyHomework(viewerID(c), c.Query("status"))
+	if err != nil {
+		respondLessonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": homework})
+}
+
+// UploadAttachment stores a file ("file") for the lesson of a booking and
+// returns the attachment to put into a record, homework or submission.
+func (h *LessonHandler) UploadAttachment(c *gin.Context) {
+	bookingID := cast.ToUint(c.Param("id"))
+	if err := h.service.CanAccessLesson(viewerID(c), bookingID, isAdmin(c)); err != nil {
+		respondLessonError(c, err)
+		return
+	}
+
+	attachment, ok := storeAttachment(c, h.uploadService, model.LessonAttachmentFolder(bookingID))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "File uploaded successfully", "data": attachment})
+}
+
+func viewerID(c *gin.Context) uint {
+	return cast.ToUint(c.GetString("user_id"))
+}
+
+func isAdmin(c *gin.Context) bool {
+	return c.GetString("role") == "admin"
+}
+
+func respondLessonError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.HasSuffix(msg, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "only the teacher"):
+		c.JSON(http.StatusForbidden, gin.H{"error": msg})
+	case msg == "homework has already been reviewed":
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+	}
+}
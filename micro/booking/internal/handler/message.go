@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"booking/internal/config"
+	"booking/internal/infrastructure/supabase"
+	"booking/internal/middleware"
+	"booking/internal/model"
+	"booking/internal/pkg"
+	"booking/internal/service"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// messageHeartbeat keeps idle message streams open through proxies.
+const messageHeartbeat = 25 * time.Second
+
+// MessageHandler serves the conversations between students and teachers,
+// their real-time stream and the admin moderation endpoints.
+type MessageHandler struct {
+	service       *service.Service
+	uploadService *supabase.Client
+	jwt           *config.JWT
+}
+
+func NewMessageHandler(service *service.Service, uploadService *supabase.Client, jwt *config.JWT) *MessageHandler {
+	return &MessageHandler{service: service, uploadService: uploadService, jwt: jwt}
+}
+
+// StartConversation opens the conversation with the other participant of a
+// booking, or returns the existing one.
+func (h *MessageHandler) StartConversation(c *gin.Context) {
+	var req model.StartConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	conversation, err := h.service.StartConversation(viewerID(c), req.BookingID)
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": conversation})
+}
+
+// GetConversations lists the user's conversations. Query: page and limit.
+func (h *MessageHandler) GetConversations(c *gin.Context) {
+	conversations, err := h.service.GetConversations(viewerID(c), paginateQuery(c))
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, conversations)
+}
+
+// GetMessages pages through a conversation. Query: before_id to load older
+// messages, after_id to poll for new ones, and limit.
+func (h *MessageHandler) GetMessages(c *gin.Context) {
+	page, err := h.service.GetMessages(viewerID(c), cast.ToUint(c.Param("id")), isAdmin(c),
+		cast.ToUint(c.Query("before_id")), cast.ToUint(c.Query("after_id")), cast.ToInt(c.Query("limit")))
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *MessageHandler) SendMessage(c *gin.Context) {
+	var req model.SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	message, err := h.service.SendMessage(viewerID(c), cast.ToUint(c.Param("id")), req)
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": message})
+}
+
+// MarkRead marks the conversation read. Query: upto_id to stop at the last
+// message actually shown.
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	err := h.service.MarkConversationRead(viewerID(c), cast.ToUint(c.Param("id")), cast.ToUint(c.Query("upto_id")))
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation marked as read"})
+}
+
+func (h *MessageHandler) GetUnreadCount(c *gin.Context) {
+	count, err := h.service.GetUnreadMessageCount(viewerID(c))
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread_count": count})
+}
+
+// UploadAttachment stores a file ("file") to send in the conversation.
+func (h *MessageHandler) UploadAttachment(c *gin.Context) {
+	conversationID := cast.ToUint(c.Param("id"))
+	if err := h.service.CanAccessConversation(viewerID(c), conversationID); err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	attachment, ok := storeAttachment(c, h.uploadService, model.MessageAttachmentFolder(conversationID))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "File uploaded successfully", "data": attachment})
+}
+
+// CreateStreamToken issues a short-lived token to open the message stream
+// with, as EventSource cannot send the Authorization header.
+func (h *MessageHandler) CreateStreamToken(c *gin.Context) {
+	token, expiresAt, err := middleware.NewStreamToken(h.jwt, c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create stream token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"token": token, "expires_at": expiresAt}})
+}
+
+// StreamMessages pushes the user's message events as server-sent events
+// until the client disconnects. Clients that cannot keep a stream open poll
+// GET /conversations/:id/messages with after_id instead.
+func (h *MessageHandler) StreamMessages(c *gin.Context) {
+	events, unsubscribe := h.service.SubscribeMessages(viewerID(c))
+	defer unsubscribe()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("ready", gin.H{"user_id": viewerID(c)})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(messageHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
+
+// GetConversationsAdmin lists all conversations for moderation.
+func (h *MessageHandler) GetConversationsAdmin(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	conversations, err := h.service.GetConversations(0, paginateQuery(c))
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, conversations)
+}
+
+// ModerateMessage hides a message from the participants or restores it.
+func (h *MessageHandler) ModerateMessage(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	var req model.ModerateMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	message, err := h.service.ModerateMessage(viewerID(c), cast.ToUint(c.Param("id")), req)
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Message moderated", "data": message})
+}
+
+func paginateQuery(c *gin.Context) pkg.Paginate {
+	return pkg.Paginate{
+		Page:  cast.ToInt(c.Query("page")),
+		Limit: cast.ToInt(c.Query("limit")),
+	}
+}
+
+func respondMessageError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.HasSuffix(msg, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+	}
+}
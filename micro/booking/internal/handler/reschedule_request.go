@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"booking/internal/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// GetMyRescheduleRequests lists the reschedule requests teachers made for
+// the authenticated student's bookings. Query: status.
+func (h *Handler) GetMyRescheduleRequests(c *gin.Context) {
+	requests, err := h.service.GetMyRescheduleRequests(cast.ToUint(c.GetString("user_id")), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+// AcceptRescheduleRequest moves the booking to the proposed "schedule_id".
+func (h *Handler) AcceptRescheduleRequest(c *gin.Context) {
+	var req model.AcceptRescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	request, err := h.service.AcceptRescheduleRequest(c, cast.ToUint(c.GetString("user_id")), cast.ToUint(c.Param("id")), req.ScheduleID)
+	if err != nil {
+		respondRescheduleRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Booking rescheduled successfully", "data": request})
+}
+
+// DeclineRescheduleRequest cancels the booking with a full refund.
+func (h *Handler) DeclineRescheduleRequest(c *gin.Context) {
+	request, err := h.service.DeclineRescheduleRequest(c, cast.ToUint(c.GetString("user_id")), cast.ToUint(c.Param("id")))
+	if err != nil {
+		respondRescheduleRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled successfully", "data": request})
+}
+
+// ProposeRescheduleInternal is called by the teacher service on behalf of
+// the teacher of the booking.
+func (h *Handler) ProposeRescheduleInternal(c *gin.Context) {
+	var req model.ProposeRescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	request, err := h.service.ProposeReschedule(req)
+	if err != nil {
+		respondRescheduleRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Reschedule request sent", "data": request})
+}
+
+// GetTeacherRescheduleRequestsInternal lists the requests of a teacher.
+// Query: status.
+func (h *Handler) GetTeacherRescheduleRequestsInternal(c *gin.Context) {
+	requests, err := h.service.GetTeacherRescheduleRequests(cast.ToUint(c.Param("teacher_id")), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+// WithdrawRescheduleRequestInternal takes back a pending request of the
+// teacher in "teacher_id".
+func (h *Handler) WithdrawRescheduleRequestInternal(c *gin.Context) {
+	var req struct {
+		TeacherID uint `json:"teacher_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	request, err := h.service.WithdrawRescheduleRequest(req.TeacherID, cast.ToUint(c.Param("id")))
+	if err != nil {
+		respondRescheduleRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reschedule request withdrawn", "data": request})
+}
+
+func respondRescheduleRequestError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.HasSuffix(msg, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+	case strings.HasSuffix(msg, "not available"),
+		strings.HasSuffix(msg, "no longer pending"),
+		msg == "booking already has a pending reschedule request",
+		msg == "schedule is on hold for a waitlisted student":
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"booking/internal/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// CreateBookingSeries books a recurring lesson starting at "schedule_id".
+func (h *Handler) CreateBookingSeries(c *gin.Context) {
+	var req model.BookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	series, err := h.service.CreateBookingSeries(c, cast.ToUint(c.GetString("user_id")), req)
+	if err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Booking series created successfully", "data": series})
+}
+
+func (h *Handler) GetBookingSeries(c *gin.Context) {
+	series, err := h.service.GetBookingSeries(cast.ToUint(c.GetString("user_id")), cast.ToUint(c.Param("id")))
+	if err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": series})
+}
+
+// CancelBookingSeries cancels the rest of a series. Single occurrences are
+// cancelled through CancelBooking.
+func (h *Handler) CancelBookingSeries(c *gin.Context) {
+	var req model.SeriesCancelRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	series, err := h.service.CancelBookingSeries(c, cast.ToUint(c.GetString("user_id")), cast.ToUint(c.Param("id")), req)
+	if err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Booking series cancelled successfully", "data": series})
+}
+
+// RescheduleBookingSeries moves the rest of a series to a new slot. Single
+// occurrences are moved through RescheduleBooking.
+func (h *Handler) RescheduleBookingSeries(c *gin.Context) {
+	var req model.SeriesRescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	series, err := h.service.RescheduleBookingSeries(c, cast.ToUint(c.GetString("user_id")), cast.ToUint(c.Param("id")), req)
+	if err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Booking series rescheduled successfully", "data": series})
+}
+
+func respondSeriesError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "occurrences not available"),
+		strings.HasPrefix(err.Error(), "occurrence on"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"booking/internal/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// JoinWaitlist puts the student on the waitlist of a fully booked slot
+// ("schedule_id") or of any slot of a teacher in a date range ("teacher_id",
+// "date_from", "date_to").
+func (h *Handler) JoinWaitlist(c *gin.Context) {
+	var req model.WaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	entry, err := h.service.JoinWaitlist(cast.ToUint(c.GetString("user_id")), req)
+	if err != nil {
+		respondWaitlistError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Joined waitlist", "data": entry})
+}
+
+// GetMyWaitlist lists the open waitlist entries of the authenticated user.
+func (h *Handler) GetMyWaitlist(c *gin.Context) {
+	entries, err := h.service.GetMyWaitlist(cast.ToUint(c.GetString("user_id")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+func (h *Handler) LeaveWaitlist(c *gin.Context) {
+	if err := h.service.LeaveWaitlist(cast.ToUint(c.GetString("user_id")), cast.ToUint(c.Param("id"))); err != nil {
+		respondWaitlistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Left waitlist"})
+}
+
+// ClaimWaitlistOffer books the slot held for the user by the entry.
+func (h *Handler) ClaimWaitlistOffer(c *gin.Context) {
+	booking, err := h.service.ClaimWaitlistOffer(c, cast.ToUint(c.GetString("user_id")), cast.ToUint(c.Param("id")))
+	if err != nil {
+		respondWaitlistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Booking created successfully", "booking": booking})
+}
+
+// SlotsPublishedInternal is called by the teacher service when slots become
+// bookable, so they can be offered to waiting students.
+func (h *Handler) SlotsPublishedInternal(c *gin.Context) {
+	var req model.SlotsPublishedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	go h.service.SlotsPublished(req)
+	c.JSON(http.StatusAccepted, gin.H{"message": "Slots queued for waitlist"})
+}
+
+func respondWaitlistError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "you already"),
+		err.Error() == "the offer has expired",
+		err.Error() == "schedule is on hold for a waitlisted student":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
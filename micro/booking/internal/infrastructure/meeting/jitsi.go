@@ -0,0 +1,71 @@
+package meeting
+
+import (
+	"booking/internal/config"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const defaultJitsiURL = "https://meet.jit.si"
+
+// jitsiTokenLeeway lets participants join a bit before the lesson starts
+// and stay a bit after it ends.
+const jitsiTokenLeeway = 30 * time.Minute
+
+// Jitsi creates rooms with a random name on a Jitsi Meet server. When an
+// app id and secret are configured the join URL carries a room token signed
+// for the lesson time, as expected by servers with token authentication.
+type Jitsi struct {
+	baseURL   string
+	appID     string
+	appSecret string
+}
+
+func NewJitsi(c config.Meeting) *Jitsi {
+	baseURL := strings.TrimRight(c.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultJitsiURL
+	}
+	return &Jitsi{
+		baseURL:   baseURL,
+		appID:     c.AppID,
+		appSecret: c.AppSecret,
+	}
+}
+
+func (j *Jitsi) CreateRoom(req RoomRequest) (*Room, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	name := "japanlearn-" + hex.EncodeToString(b)
+
+	joinURL := j.baseURL + "/" + name
+	if j.appID != "" && j.appSecret != "" {
+		token, err := j.roomToken(name, req)
+		if err != nil {
+			return nil, err
+		}
+		joinURL += "?jwt=" + url.QueryEscape(token)
+	}
+
+	return &Room{Provider: "jitsi", Name: name, JoinURL: joinURL}, nil
+}
+
+func (j *Jitsi) roomToken(room string, req RoomRequest) (string, error) {
+	host := strings.TrimPrefix(strings.TrimPrefix(j.baseURL, "https://"), "http://")
+	claims := jwt.MapClaims{
+		"aud":  "jitsi",
+		"iss":  j.appID,
+		"sub":  host,
+		"room": room,
+		"nbf":  req.Start.Add(-jitsiTokenLeeway).Unix(),
+		"exp":  req.End.Add(jitsiTokenLeeway).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(j.appSecret))
+}
@@ -0,0 +1,38 @@
+package meeting
+
+import (
+	"booking/internal/config"
+	"fmt"
+	"time"
+)
+
+// Room is the online meeting room of a lesson. JoinURL is what participants
+// open; it may embed an access token, so it must only be shown to them.
+type Room struct {
+	Provider string
+	Name     string
+	JoinURL  string
+}
+
+type RoomRequest struct {
+	BookingID uint
+	Start     time.Time
+	End       time.Time
+}
+
+// Provider creates meeting rooms. Room names must not be guessable from the
+// booking.
+type Provider interface {
+	CreateRoom(req RoomRequest) (*Room, error)
+}
+
+// NewProvider returns the provider selected in the configuration. Jitsi is
+// the default.
+func NewProvider(c config.Meeting) (Provider, error) {
+	switch c.Provider {
+	case "", "jitsi":
+		return NewJitsi(c), nil
+	default:
+		return nil, fmt.Errorf("unknown meeting provider %q", c.Provider)
+	}
+}
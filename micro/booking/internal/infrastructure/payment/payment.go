@@ -85,3 +85,39 @@ func (p *Payment) GetPaymentDetail(c *gin.Context, paymentID uint) (*PaymentResp
 	return &respPayment.Data, nil
 
 }
+
+// RefundPayment asks the payment service to refund a settled payment and
+// returns the amount refunded. A zero amount refunds the remaining paid
+// amount. The payment service refunds a reference only once, so the call
+// can be retried with the same reference. The call goes through the
+// payment service's internal endpoint, authenticated by the shared secret
+// instead of a user token.
+func (p *Payment) RefundPayment(paymentID uint, amount float64, reason, reference string) (float64, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/payments/%d/refund", p.service.Host, paymentID)
+
+	var result struct {
+		Refund struct {
+			Amount float64 `json:"amount"`
+		} `json:"refund"`
+	}
+	resp, err := p.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader(config.InternalSecretHeader, p.service.InternalSecret).
+		SetBody(map[string]interface{}{
+			"amount":    amount,
+			"reason":    reason,
+			"reference": reference,
+		}).
+		SetResult(&result).
+		Post(url)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return 0, fmt.Errorf("failed to refund payment: %s", resp.String())
+	}
+
+	return result.Refund.Amount, nil
+}
@@ -3,8 +3,10 @@ package schedule
 import (
 	"booking/internal/config"
 	"booking/internal/model"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-resty/resty/v2"
@@ -69,6 +71,9 @@ func (s *ScheduleHttp) UpdateScheduleStatus(c *gin.Context, scheduleID uint, sta
 		return fmt.Errorf("failed to update schedule status: %v", err)
 	}
 
+	if resp.StatusCode() == http.StatusConflict {
+		return errors.New("schedule is not available")
+	}
 	if resp.StatusCode() != http.StatusOK {
 		return fmt.Errorf("teacher service returned non-200: %v", resp.Status())
 	}
@@ -95,13 +100,23 @@ func (s *ScheduleHttp) CancelSchedule(c *gin.Context, scheduleID uint) error {
 }
 
 func (s *ScheduleHttp) FetchSchedulesByIDs(c *gin.Context, scheduleIDs []uint) (map[uint]model.ScheduleResponse, error) {
+	return s.fetchSchedules(c.GetHeader("Authorization"), scheduleIDs)
+}
+
+// FetchScheduleDetails is FetchSchedulesByIDs for background jobs that have
+// no request to take a token from.
+func (s *ScheduleHttp) FetchScheduleDetails(scheduleIDs []uint) (map[uint]model.ScheduleResponse, error) {
+	return s.fetchSchedules("", scheduleIDs)
+}
+
+func (s *ScheduleHttp) fetchSchedules(token string, scheduleIDs []uint) (map[uint]model.ScheduleResponse, error) {
 	url := fmt.Sprintf("%s:%s/api/v1/schedule/batch-detail", s.service.Host, s.service.Port)
 
 	schedules := []model.ScheduleResponse{}
 
 	resp, err := s.restyClient.R().
 		SetHeader("Content-Type", "application/json").
-		SetAuthToken(c.GetHeader("Authorization")).
+		SetAuthToken(token).
 		SetBody(gin.H{"ids": scheduleIDs}).
 		SetResult(&schedules).
 		Post(url)
@@ -122,6 +137,101 @@ func (s *ScheduleHttp) FetchSchedulesByIDs(c *gin.Context, scheduleIDs []uint) (
 
 }
 
+// MatchSlots returns the teacher's slots running from startTime to endTime on
+// the given dates (YYYY-MM-DD). Dates without such a slot are missing from
+// the result.
+func (s *ScheduleHttp) MatchSlots(teacherID uint, startTime, endTime string, dates []string) ([]model.ScheduleResponse, error) {
+	url := fmt.Sprintf("%s:%s/api/v1/internal/schedules/match", s.service.Host, s.service.Port)
+
+	schedules := []model.ScheduleResponse{}
+
+	resp, err := s.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(gin.H{
+			"teacher_id": teacherID,
+			"start_time": startTime,
+			"end_time":   endTime,
+			"dates":      dates,
+		}).
+		SetResult(&schedules).
+		Post(url)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to match schedules: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("teacher service returned non-200: %v", resp.Status())
+	}
+
+	return schedules, nil
+}
+
+// ReserveSchedules books all the given slots, or none of them when one is
+// not available anymore.
+func (s *ScheduleHttp) ReserveSchedules(scheduleIDs []uint) error {
+	url := fmt.Sprintf("%s:%s/api/v1/internal/schedules/reserve", s.service.Host, s.service.Port)
+
+	var failure struct {
+		Error string `json:"error"`
+	}
+	resp, err := s.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(gin.H{"schedule_ids": scheduleIDs}).
+		SetError(&failure).
+		Post(url)
+
+	if err != nil {
+		return fmt.Errorf("failed to reserve schedules: %v", err)
+	}
+	if resp.StatusCode() == http.StatusConflict {
+		return errors.New(failure.Error)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("teacher service returned non-200: %v", resp.Status())
+	}
+
+	return nil
+}
+
+// ReleaseSchedules gives back slots booked by ReserveSchedules.
+func (s *ScheduleHttp) ReleaseSchedules(scheduleIDs []uint) error {
+	url := fmt.Sprintf("%s:%s/api/v1/internal/schedules/release", s.service.Host, s.service.Port)
+
+	resp, err := s.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(gin.H{"schedule_ids": scheduleIDs}).
+		Post(url)
+
+	if err != nil {
+		return fmt.Errorf("failed to release schedules: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("teacher service returned non-200: %v", resp.Status())
+	}
+
+	return nil
+}
+
+// HoldSchedule hides an open slot from searches while it is offered to a
+// waitlisted student. A nil until releases the hold.
+func (s *ScheduleHttp) HoldSchedule(scheduleID uint, until *time.Time) error {
+	url := fmt.Sprintf("%s:%s/api/v1/internal/schedules/%d/hold", s.service.Host, s.service.Port, scheduleID)
+
+	resp, err := s.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(gin.H{"held_until": until}).
+		Put(url)
+
+	if err != nil {
+		return fmt.Errorf("failed to hold schedule: %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("teacher service returned non-200: %v", resp.Status())
+	}
+
+	return nil
+}
+
 func (s *ScheduleHttp) CallScheduleServiceToGetByTeacher(teacherID string, scheduleIDs []string) (*ScheduleFilterResponse, error) {
 	req := ScheduleFilterRequest{
 		TeacherID:   teacherID,
@@ -4,10 +4,14 @@ import (
 	"booking/internal/config"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
+// defaultSignedURLExpiry applies when CLIENT_SIGNED_URL_EXPIRY is not set.
+const defaultSignedURLExpiry = 15 * time.Minute
+
 type Client struct {
 	Client *resty.Client
 	Cfg    *config.Client
@@ -18,10 +22,23 @@ func InitUploadClient(cfg *config.Client, restyClient *resty.Client) *Client {
 	return &Client{Client: restyClient, Cfg: cfg}
 }
 func (u *Client) UploadToSupabase(objectName, filePath string) error {
+	return u.upload(u.Cfg.BucketName, objectName, filePath)
+}
+
+// UploadPrivate uploads a file to the private bucket. It can only be read
+// through a signed URL.
+func (u *Client) UploadPrivate(objectName, filePath string) error {
+	if u.Cfg.PrivateBucketName == "" {
+		return fmt.Errorf("private bucket is not configured")
+	}
+	return u.upload(u.Cfg.PrivateBucketName, objectName, filePath)
+}
+
+func (u *Client) upload(bucket, objectName, filePath string) error {
 
 	url := fmt.Sprintf("%s/storage/v1/object/%s/%s",
 		u.Cfg.Endpoint,
-		u.Cfg.BucketName,
+		bucket,
 		objectName,
 	)
 
@@ -46,3 +63,51 @@ func (u *Client) UploadToSupabase(objectName, filePath string) error {
 
 	return nil
 }
+
+// SignedURLs returns short-lived URLs to objects of the private bucket,
+// keyed by object name, in one request. Objects that could not be signed
+// are missing from the result.
+func (u *Client) SignedURLs(objectNames []string) (map[string]string, error) {
+	signed := make(map[string]string, len(objectNames))
+	if len(objectNames) == 0 {
+		return signed, nil
+	}
+
+	expiry := u.Cfg.SignedURLExpiry
+	if expiry <= 0 {
+		expiry = defaultSignedURLExpiry
+	}
+
+	url := fmt.Sprintf("%s/storage/v1/object/sign/%s",
+		u.Cfg.Endpoint,
+		u.Cfg.PrivateBucketName,
+	)
+
+	var result []struct {
+		Path      string  `json:"path"`
+		SignedURL string  `json:"signedURL"`
+		Error     *string `json:"error"`
+	}
+	resp, err := u.Client.R().
+		SetHeader("Authorization", "Bearer "+u.Cfg.AccessKey).
+		SetBody(map[string]interface{}{
+			"expiresIn": int(expiry.Seconds()),
+			"paths":     objectNames,
+		}).
+		SetResult(&result).
+		Post(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() >= 300 {
+		return nil, fmt.Errorf("signing failed: %s", resp.String())
+	}
+
+	for _, item := range result {
+		if item.Error != nil || item.SignedURL == "" {
+			continue
+		}
+		signed[item.Path] = u.Cfg.Endpoint + "/storage/v1" + item.SignedURL
+	}
+	return signed, nil
+}
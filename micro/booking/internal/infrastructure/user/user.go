@@ -51,3 +51,71 @@ func (s *UserService) GetUserById(userId uint) (UserResponse, error) {
 	return userResponse, nil
 
 }
+
+// NotificationEvent is a booking event of a user. The user service renders
+// it from the event's template with Data and sends it on the channels the
+// user enabled. Reference keeps the same event from being sent twice.
+type NotificationEvent struct {
+	UserID    uint              `json:"user_id"`
+	EventType string            `json:"event_type"`
+	Reference string            `json:"reference"`
+	Role      string            `json:"role"`
+	Data      map[string]string `json:"data"`
+}
+
+// SendNotificationEvent reports a booking event to the user service,
+// authenticated by the shared secret.
+func (s *UserService) SendNotificationEvent(event NotificationEvent) error {
+	url := fmt.Sprintf("%s/api/v1/internal/notifications/events", s.service.Host)
+
+	resp, err := s.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader(config.InternalSecretHeader, s.service.InternalSecret).
+		SetBody(event).
+		Post(url)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusAccepted {
+		return fmt.Errorf("user service returned status: %d", resp.StatusCode())
+	}
+
+	return nil
+}
+
+// ActivityNotification puts an activity into the user's in-app
+// notification center. The reference keeps it from showing up twice.
+type ActivityNotification struct {
+	Title     string `json:"title"`
+	Message   string `json:"message,omitempty"`
+	Link      string `json:"link,omitempty"`
+	Reference string `json:"reference,omitempty"`
+}
+
+// CreateActivityNotification records an activity of the user and shows it
+// in their notification center, for booking events that have no email
+// template. The call is authenticated by the shared secret.
+func (s *UserService) CreateActivityNotification(userID uint, action, description string, notification ActivityNotification) error {
+	url := fmt.Sprintf("%s/api/v1/internal/activity", s.service.Host)
+
+	resp, err := s.restyClient.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader(config.InternalSecretHeader, s.service.InternalSecret).
+		SetBody(map[string]interface{}{
+			"user_id":      userID,
+			"action":       action,
+			"description":  description,
+			"notification": notification,
+		}).
+		Post(url)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("user service returned status: %d", resp.StatusCode())
+	}
+
+	return nil
+}
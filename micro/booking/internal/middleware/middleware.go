@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"booking/internal/config"
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"strings"
 	"sync"
@@ -42,6 +44,59 @@ func IsBlacklisted(token string) bool {
 	return true
 }
 
+// streamTokenPurpose marks the short-lived tokens that only open event
+// streams.
+const streamTokenPurpose = "stream"
+
+// StreamTokenTTL is how long a stream token can be used to open a stream.
+// A stream opened with it stays open after it expired.
+const StreamTokenTTL = time.Minute
+
+// NewStreamToken issues a token that opens the event streams of the user.
+// Browsers cannot set headers on an EventSource, so it is passed as the
+// token query parameter instead of the user's access token.
+func NewStreamToken(jwtConfig *config.JWT, userID, role string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(StreamTokenTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"role":    role,
+		"purpose": streamTokenPurpose,
+		"exp":     expiresAt.Unix(),
+	})
+	signed, err := token.SignedString([]byte(jwtConfig.SecretKey))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Messages of the errors parseToken returns, as sent to the client.
+var (
+	errInvalidToken = errors.New("Invalid token")
+	errTokenExpired = errors.New("Token expired")
+)
+
+// parseToken validates a token and returns its claims.
+func parseToken(jwtConfig *config.JWT, tokenStr string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(jwtConfig.SecretKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, errTokenExpired
+	}
+	if err != nil || !token.Valid {
+		return nil, errInvalidToken
+	}
+	if _, ok := claims["user_id"].(string); !ok {
+		return nil, errInvalidToken
+	}
+	if _, ok := claims["role"].(string); !ok {
+		return nil, errInvalidToken
+	}
+	return claims, nil
+}
+
 func AuthMiddleware(jwtConfig *config.JWT) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -53,19 +108,15 @@ func AuthMiddleware(jwtConfig *config.JWT) gin.HandlerFunc {
 
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 
-		claims := jwt.MapClaims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtConfig.SecretKey), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		claims, err := parseToken(jwtConfig, tokenStr)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
-
-		if float64(time.Now().Unix()) > claims["exp"].(float64) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
+		// Stream tokens only open streams.
+		if claims["purpose"] != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidToken.Error()})
 			c.Abort()
 			return
 		}
@@ -78,3 +129,49 @@ func AuthMiddleware(jwtConfig *config.JWT) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// StreamAuthMiddleware authenticates an event stream by the stream token
+// in the token query parameter, or like AuthMiddleware by the
+// Authorization header for clients that can set it.
+func StreamAuthMiddleware(jwtConfig *config.JWT) gin.HandlerFunc {
+	headerAuth := AuthMiddleware(jwtConfig)
+	return func(c *gin.Context) {
+		tokenStr := c.Query("token")
+		if tokenStr == "" {
+			headerAuth(c)
+			return
+		}
+
+		claims, err := parseToken(jwtConfig, tokenStr)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if claims["purpose"] != streamTokenPurpose {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidToken.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", claims["user_id"].(string))
+		c.Set("role", claims["role"].(string))
+
+		c.Next()
+	}
+}
+
+// InternalMiddleware admits only the other services, which send the secret
+// they share in config.InternalSecretHeader. Without a configured secret
+// every call is refused.
+func InternalMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := c.GetHeader(config.InternalSecretHeader)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"booking/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testJWT = &config.JWT{SecretKey: "test-secret"}
+
+func accessToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWT.SecretKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func serve(handler gin.HandlerFunc, target, authorization string) (int, string) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/stream", handler, func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id")+":"+c.GetString("role"))
+	})
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, w.Body.String()
+}
+
+func TestStreamAuthMiddleware(t *testing.T) {
+	streamToken, expiresAt, err := NewStreamToken(testJWT, "7", "student")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(expiresAt) > StreamTokenTTL {
+		t.Errorf("stream token expires at %v, after its TTL", expiresAt)
+	}
+	access := accessToken(t, jwt.MapClaims{"user_id": "7", "role": "student", "exp": time.Now().Add(time.Hour).Unix()})
+	expired := accessToken(t, jwt.MapClaims{
+		"user_id": "7", "role": "student", "purpose": streamTokenPurpose, "exp": time.Now().Add(-time.Minute).Unix(),
+	})
+	noExpiry := accessToken(t, jwt.MapClaims{"user_id": "7", "role": "student", "purpose": streamTokenPurpose})
+
+	tests := []struct {
+		name          string
+		target        string
+		authorization string
+		wantCode      int
+		wantBody      string
+	}{
+		{name: "stream token", target: "/stream?token=" + streamToken, wantCode: http.StatusOK, wantBody: "7:student"},
+		{name: "access token in the header", target: "/stream", authorization: "Bearer " + access, wantCode: http.StatusOK, wantBody: "7:student"},
+		{name: "no token", target: "/stream", wantCode: http.StatusUnauthorized},
+		{name: "access token in the query", target: "/stream?token=" + access, wantCode: http.StatusUnauthorized},
+		{name: "expired stream token", target: "/stream?token=" + expired, wantCode: http.StatusUnauthorized},
+		{name: "stream token without expiry", target: "/stream?token=" + noExpiry, wantCode: http.StatusUnauthorized},
+		{name: "malformed token", target: "/stream?token=abc", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := serve(StreamAuthMiddleware(testJWT), tt.target, tt.authorization)
+			if code != tt.wantCode || (tt.wantBody != "" && body != tt.wantBody) {
+				t.Errorf("got %d %q, want %d %q", code, body, tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareRejectsStreamTokens(t *testing.T) {
+	streamToken, _, err := NewStreamToken(testJWT, "7", "student")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := serve(AuthMiddleware(testJWT), "/stream", "Bearer "+streamToken); code != http.StatusUnauthorized {
+		t.Errorf("got %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestInternalMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		secret   string
+		given    string
+		wantCode int
+	}{
+		{name: "matching secret", secret: "s3cret", given: "s3cret", wantCode: http.StatusOK},
+		{name: "wrong secret", secret: "s3cret", given: "guess", wantCode: http.StatusUnauthorized},
+		{name: "no secret sent", secret: "s3cret", wantCode: http.StatusUnauthorized},
+		{name: "no secret configured", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/internal", InternalMiddleware(tt.secret), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodPost, "/internal", nil)
+			if tt.given != "" {
+				req.Header.Set(config.InternalSecretHeader, tt.given)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+// Intervals the teacher analytics can be grouped by.
+const (
+	AnalyticsIntervalDay   = "day"
+	AnalyticsIntervalWeek  = "week"
+	AnalyticsIntervalMonth = "month"
+)
+
+// TeacherAnalyticsPeriod aggregates the bookings of one teacher whose lesson
+// falls into Period. Period is YYYY-MM-DD, YYYY-Www (ISO week) or YYYY-MM
+// depending on the interval.
+type TeacherAnalyticsPeriod struct {
+	Period        string  `json:"period"`
+	Bookings      int     `json:"bookings"`
+	Cancellations int     `json:"cancellations"`
+	Lessons       int     `json:"lessons"`
+	Earnings      float64 `json:"earnings"`
+	NewStudents   int     `json:"new_students"`
+}
+
+// TeacherAnalyticsSummary aggregates the whole requested range. Repeat
+// students are students with at least two non-cancelled bookings in range.
+type TeacherAnalyticsSummary struct {
+	Bookings          int     `json:"bookings"`
+	Cancellations     int     `json:"cancellations"`
+	CancellationRate  float64 `json:"cancellation_rate"`
+	Lessons           int     `json:"lessons"`
+	Earnings          float64 `json:"earnings"`
+	NewStudents       int     `json:"new_students"`
+	Students          int     `json:"students"`
+	RepeatStudents    int     `json:"repeat_students"`
+	RepeatStudentRate float64 `json:"repeat_student_rate"`
+}
+
+type TeacherAnalyticsResponse struct {
+	Summary TeacherAnalyticsSummary  `json:"summary"`
+	Series  []TeacherAnalyticsPeriod `json:"series"`
+}
@@ -12,6 +12,7 @@ type TeacherResponse struct {
 	Bio          string  `json:"bio"`
 	Price        float64 `json:"price_per_hour"`
 	ProfileImage string  `json:"profile_image"`
+	UserID       uint    `json:"user_id"`
 }
 
 type ScheduleResponse struct {
@@ -22,6 +23,19 @@ type ScheduleResponse struct {
 	Status     string           `json:"status"`
 	TotalPrice float64          `json:"total_price"`
 	Teacher    *TeacherResponse `json:"teacher,omitempty"`
+
+	TeacherID       uint   `json:"teacher_id"`
+	LessonTypeID    *uint  `json:"lesson_type_id"`
+	LessonTypeName  string `json:"lesson_type_name,omitempty"`
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+	IsTrial         bool   `json:"is_trial"`
+
+	// Group classes have a capacity above 1.
+	Capacity   int `json:"capacity,omitempty"`
+	SeatsTaken int `json:"seats_taken,omitempty"`
+
+	// Blocked slots overlap a busy time of the teacher and cannot be booked.
+	Blocked bool `json:"blocked,omitempty"`
 }
 
 type BookingResponse struct {
@@ -33,6 +47,17 @@ type BookingResponse struct {
 	Schedule   *ScheduleResponse        `json:"schedule,omitempty"`
 	User       *user.UserResponse       `json:"user,omitempty"`
 	Payment    *payment.PaymentResponse `json:"payment,omitempty"`
+	Meeting    *MeetingInfo             `json:"meeting,omitempty"`
+}
+
+// MeetingInfo is the online room of a paid lesson as seen by its student or
+// teacher. JoinURL is only filled in from AvailableFrom until the lesson
+// ends.
+type MeetingInfo struct {
+	Provider      string    `json:"provider,omitempty"`
+	Available     bool      `json:"available"`
+	AvailableFrom time.Time `json:"available_from"`
+	JoinURL       string    `json:"join_url,omitempty"`
 }
 
 type PaginatedBookingsResponse struct {
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// CalendarFeed holds the secret token of a user's ICS feed. Anyone with the
+// token can read the feed, so it is rotated or deleted to revoke access.
+type CalendarFeed struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"uniqueIndex;not null" json:"user_id"`
+	Token     string    `gorm:"size:64;uniqueIndex;not null" json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CalendarFeedResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
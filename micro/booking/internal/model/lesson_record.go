@@ -0,0 +1,103 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Homework statuses. Homework is assigned by the teacher, submitted by the
+// student and then reviewed by the teacher.
+const (
+	HomeworkAssigned  = "assigned"
+	HomeworkSubmitted = "submitted"
+	HomeworkReviewed  = "reviewed"
+)
+
+// LessonRecord is the teacher's record of a lesson that took place: what
+// was covered and the homework given.
+type LessonRecord struct {
+	ID            uint             `gorm:"primaryKey" json:"id"`
+	BookingID     uint             `gorm:"uniqueIndex;not null" json:"booking_id"`
+	UserID        uint             `gorm:"index;not null" json:"user_id"`
+	TeacherID     uint             `gorm:"index;not null" json:"teacher_id"`
+	TeacherUserID uint             `json:"-"`
+	LessonDate    *time.Time       `gorm:"type:date;index" json:"lesson_date"`
+	Summary       string           `gorm:"type:text" json:"summary"`
+	Vocabulary    []VocabularyItem `gorm:"type:json;serializer:json" json:"vocabulary"`
+	Materials     []Attachment     `gorm:"type:json;serializer:json" json:"materials"`
+	Homework      []Homework       `gorm:"foreignKey:RecordID" json:"homework"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// VocabularyItem is a word or expression covered in a lesson.
+type VocabularyItem struct {
+	Term    string `json:"term"`
+	Reading string `json:"reading,omitempty"`
+	Meaning string `json:"meaning,omitempty"`
+}
+
+// Attachment is a file uploaded through one of the attachment endpoints.
+// The file lives in the private bucket under Path; URL is a short-lived
+// signed URL made when the attachment is read. Attachments stored before
+// the private bucket only have a URL.
+type Attachment struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// LessonAttachmentFolder is the folder the attachments of a booking's
+// lesson are uploaded to.
+func LessonAttachmentFolder(bookingID uint) string {
+	return fmt.Sprintf("lessons/%d", bookingID)
+}
+
+// Homework is an assignment given in a lesson, with the student's
+// submission and the teacher's feedback on it.
+type Homework struct {
+	ID                    uint         `gorm:"primaryKey" json:"id"`
+	RecordID              uint         `gorm:"index;not null" json:"record_id"`
+	BookingID             uint         `gorm:"index;not null" json:"booking_id"`
+	UserID                uint         `gorm:"index;not null" json:"user_id"`
+	TeacherID             uint         `gorm:"index;not null" json:"teacher_id"`
+	Title                 string       `gorm:"size:200;not null" json:"title"`
+	Instructions          string       `gorm:"type:text" json:"instructions"`
+	DueDate               *time.Time   `gorm:"type:date" json:"due_date"`
+	Attachments           []Attachment `gorm:"type:json;serializer:json" json:"attachments"`
+	Status                string       `gorm:"type:enum('assigned','submitted','reviewed');default:'assigned';index" json:"status"`
+	Submission            string       `gorm:"type:text" json:"submission,omitempty"`
+	SubmissionAttachments []Attachment `gorm:"type:json;serializer:json" json:"submission_attachments,omitempty"`
+	SubmittedAt           *time.Time   `json:"submitted_at,omitempty"`
+	Feedback              string       `gorm:"type:text" json:"feedback,omitempty"`
+	ReviewedAt            *time.Time   `json:"reviewed_at,omitempty"`
+	CreatedAt             time.Time    `json:"created_at"`
+	UpdatedAt             time.Time    `json:"updated_at"`
+}
+
+// LessonRecordRequest writes the summary, vocabulary and materials of a
+// lesson. It replaces what was recorded before.
+type LessonRecordRequest struct {
+	Summary    string           `json:"summary"`
+	Vocabulary []VocabularyItem `json:"vocabulary"`
+	Materials  []Attachment     `json:"materials"`
+}
+
+// HomeworkRequest assigns homework. DueDate is YYYY-MM-DD.
+type HomeworkRequest struct {
+	Title        string       `json:"title" binding:"required"`
+	Instructions string       `json:"instructions"`
+	DueDate      string       `json:"due_date"`
+	Attachments  []Attachment `json:"attachments"`
+}
+
+// HomeworkSubmissionRequest hands homework in. It can be sent again until
+// the teacher reviewed it.
+type HomeworkSubmissionRequest struct {
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+type HomeworkReviewRequest struct {
+	Feedback string `json:"feedback" binding:"required"`
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Message kinds.
+const (
+	MessageText       = "text"
+	MessageAttachment = "attachment"
+)
+
+// Events pushed to the message stream of a user.
+const (
+	MessageEventNew       = "message"
+	MessageEventRead      = "read"
+	MessageEventModerated = "moderated"
+)
+
+// Conversation is the message thread between a student and a teacher. There
+// is at most one per pair and it can only be opened from a booking between
+// them.
+type Conversation struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	UserID             uint       `gorm:"uniqueIndex:idx_conversation_pair;not null" json:"user_id"`
+	TeacherID          uint       `gorm:"uniqueIndex:idx_conversation_pair;not null" json:"teacher_id"`
+	TeacherUserID      uint       `gorm:"index;not null" json:"teacher_user_id"`
+	LastMessageAt      *time.Time `gorm:"index" json:"last_message_at"`
+	LastMessagePreview string     `gorm:"size:200" json:"last_message_preview"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Messages sent by the other participant the viewer has not read yet.
+	UnreadCount int `gorm:"-" json:"unread_count"`
+}
+
+// Message is a text or attachment message in a conversation. ReadAt is set
+// once the recipient read it. Messages hidden by a moderator keep their
+// content for admins but are shown empty to the participants.
+type Message struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	ConversationID uint         `gorm:"index:idx_message_conversation;not null" json:"conversation_id"`
+	SenderID       uint         `gorm:"not null" json:"sender_id"`
+	Kind           string       `gorm:"size:20;not null;default:'text'" json:"kind"`
+	Body           string       `gorm:"type:text" json:"body"`
+	Attachments    []Attachment `gorm:"type:json;serializer:json" json:"attachments"`
+	ReadAt         *time.Time   `json:"read_at"`
+	Hidden         bool         `gorm:"not null;default:false" json:"hidden"`
+	HiddenBy       *uint        `json:"hidden_by,omitempty"`
+	HiddenReason   string       `gorm:"size:255" json:"hidden_reason,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// MessageAttachmentFolder is the folder the attachments of a conversation
+// are uploaded to.
+func MessageAttachmentFolder(conversationID uint) string {
+	return fmt.Sprintf("messages/%d", conversationID)
+}
+
+type StartConversationRequest struct {
+	BookingID uint `json:"booking_id" binding:"required"`
+}
+
+type SendMessageRequest struct {
+	Body        string       `json:"body"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+type ModerateMessageRequest struct {
+	Hidden bool   `json:"hidden"`
+	Reason string `json:"reason"`
+}
+
+// MessagePage is a page of messages, oldest first. HasMore tells whether
+// older messages exist when paging back with before_id.
+type MessagePage struct {
+	Data    []Message `json:"data"`
+	HasMore bool      `json:"has_more"`
+}
+
+// MessageEvent is delivered over the message stream. Message is set for new
+// and moderated messages, ReadAt for read receipts.
+type MessageEvent struct {
+	Type           string     `json:"type"`
+	ConversationID uint       `json:"conversation_id"`
+	Message        *Message   `json:"message,omitempty"`
+	ReaderID       uint       `json:"reader_id,omitempty"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+}
@@ -3,18 +3,52 @@ package model
 import "time"
 
 type Booking struct {
-	ID             uint      `gorm:"primaryKey" json:"id"`
-	UserID         uint      `gorm:"index" json:"user_id"`
-	ScheduleID     uint      `gorm:"index" json:"schedule_id"`
-	Status         string    `gorm:"type:enum('pending','paid','cancelled','rescheduled','completed');default:'pending'" json:"status"`
-	PaymentID      *uint     `json:"payment_id"`
-	RescheduleFrom *uint     `json:"reschedule_from"`
-	Note           string    `json:"note"`
-	TotalPrice     float64   `json:"total_price"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"index" json:"user_id"`
+	ScheduleID     uint       `gorm:"index" json:"schedule_id"`
+	TeacherID      uint       `gorm:"index" json:"teacher_id"`
+	LessonTypeID   *uint      `json:"lesson_type_id"`
+	LessonDate     *time.Time `gorm:"type:date;index" json:"lesson_date"`
+	Status         string     `gorm:"type:enum('pending','paid','cancelled','rescheduled','completed');default:'pending'" json:"status"`
+	PaymentID      *uint      `json:"payment_id"`
+	RescheduleFrom *uint      `json:"reschedule_from"`
+	Note           string     `json:"note"`
+	TotalPrice     float64    `json:"total_price"`
+	Sequence       int        `gorm:"not null;default:0" json:"sequence"` // bumped on reschedule and cancellation for calendar feeds
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Online meeting room of a paid lesson. Not serialized because the join
+	// URL may carry an access token; it is exposed through MeetingInfo.
+	MeetingProvider string `gorm:"size:20" json:"-"`
+	MeetingRoom     string `gorm:"size:100" json:"-"`
+	MeetingURL      string `gorm:"size:2048" json:"-"`
+
+	// Recurring series the booking is an occurrence of (1-based).
+	SeriesID   *uint `gorm:"index" json:"series_id,omitempty"`
+	Occurrence int   `json:"occurrence,omitempty"`
+
+	// Price difference still owed after a paid booking was rescheduled to a
+	// dearer slot, and the payment that settled it.
+	BalanceDue       float64 `json:"balance_due,omitempty"`
+	BalancePaymentID *uint   `json:"balance_payment_id,omitempty"`
+	// Refund still owed after a paid booking was rescheduled to a cheaper
+	// slot or cancelled with a refund, and the refund failed;
+	// RunRefundRetries retries it.
+	RefundDue float64 `gorm:"not null;default:0" json:"refund_due,omitempty"`
+
+	// When the lesson reminders were sent, or skipped because the lesson
+	// was booked too late for them.
+	Reminder24hSentAt *time.Time `gorm:"column:reminder_24h_sent_at" json:"-"`
+	Reminder1hSentAt  *time.Time `gorm:"column:reminder_1h_sent_at" json:"-"`
 }
 
+// Lesson reminders, named after their column on Booking.
+const (
+	Reminder24h = "reminder_24h_sent_at"
+	Reminder1h  = "reminder_1h_sent_at"
+)
+
 // BookingInfo struct untuk response endpoint teacher bookings
 type BookingInfo struct {
 	ID          uint    `json:"id"`
@@ -45,6 +79,10 @@ type BookingRequest struct {
 	UserID     uint    `json:"user_id"`
 	Note       string  `json:"note"`
 	TotalPrice float64 `json:"total_price"`
+
+	// Set when the booking is an occurrence of a series.
+	SeriesID   *uint `json:"-"`
+	Occurrence int   `json:"-"`
 }
 
 type BookingRescheduleRequest struct {
@@ -52,3 +90,16 @@ type BookingRescheduleRequest struct {
 	UserID        uint `json:"user_id"`
 	BookingID     uint `json:"booking_id"`
 }
+
+type CancelBySchedulesRequest struct {
+	ScheduleIDs []uint `json:"schedule_ids" binding:"required"`
+	Reason      string `json:"reason"`
+}
+
+// CancelBySchedulesFailure is a booking that could not be cancelled with its
+// schedule. It stays active until the cancellation is retried.
+type CancelBySchedulesFailure struct {
+	BookingID  uint   `json:"booking_id"`
+	ScheduleID uint   `json:"schedule_id"`
+	Error      string `json:"error"`
+}
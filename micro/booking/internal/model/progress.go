@@ -0,0 +1,13 @@
+package model
+
+// CompletedLesson is a completed booking of a student as used for their
+// learning progress. LessonDate is YYYY-MM-DD.
+type CompletedLesson struct {
+	BookingID       uint   `json:"booking_id"`
+	TeacherID       uint   `json:"teacher_id"`
+	TeacherUserID   uint   `json:"teacher_user_id"`
+	LessonDate      string `json:"lesson_date"`
+	StartTime       string `json:"start_time"`
+	EndTime         string `json:"end_time"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
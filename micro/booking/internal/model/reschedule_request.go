@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// Reschedule request statuses. A request waits for the student's answer
+// until its deadline; after that the request's policy is applied and it
+// ends expired.
+const (
+	RescheduleRequestPending   = "pending"
+	RescheduleRequestAccepted  = "accepted"
+	RescheduleRequestDeclined  = "declined"
+	RescheduleRequestExpired   = "expired"
+	RescheduleRequestWithdrawn = "withdrawn"
+)
+
+// Policies applied to a reschedule request the student did not answer in
+// time.
+const (
+	ReschedulePolicyCancel      = "cancel"
+	ReschedulePolicyKeep        = "keep"
+	ReschedulePolicyFirstOption = "first_option"
+)
+
+// RescheduleRequest is a teacher's proposal to move a booked lesson to one
+// of several alternative slots.
+type RescheduleRequest struct {
+	ID            uint               `gorm:"primaryKey" json:"id"`
+	BookingID     uint               `gorm:"index;not null" json:"booking_id"`
+	UserID        uint               `gorm:"index;not null" json:"user_id"`
+	TeacherID     uint               `gorm:"index;not null" json:"teacher_id"`
+	TeacherUserID uint               `json:"-"`
+	Reason        string             `gorm:"size:500" json:"reason"`
+	Status        string             `gorm:"type:enum('pending','accepted','declined','expired','withdrawn');default:'pending';index" json:"status"`
+	Policy        string             `gorm:"size:20" json:"policy"`
+	Deadline      time.Time          `gorm:"index" json:"deadline"`
+	Outcome       string             `gorm:"size:100" json:"outcome,omitempty"`
+	NewBookingID  *uint              `json:"new_booking_id,omitempty"`
+	ResolvedAt    *time.Time         `json:"resolved_at,omitempty"`
+	Options       []RescheduleOption `gorm:"foreignKey:RequestID" json:"options"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// RescheduleOption is one slot proposed in a reschedule request.
+type RescheduleOption struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	RequestID  uint   `gorm:"index;not null" json:"-"`
+	ScheduleID uint   `gorm:"not null" json:"schedule_id"`
+	Date       string `gorm:"size:10" json:"date"`
+	StartTime  string `gorm:"size:8" json:"start_time"`
+	EndTime    string `gorm:"size:8" json:"end_time"`
+}
+
+// ProposeRescheduleRequest is sent by the teacher service on behalf of the
+// teacher of the booking.
+type ProposeRescheduleRequest struct {
+	TeacherID     uint   `json:"teacher_id" binding:"required"`
+	TeacherUserID uint   `json:"teacher_user_id"`
+	BookingID     uint   `json:"booking_id" binding:"required"`
+	ScheduleIDs   []uint `json:"schedule_ids" binding:"required"`
+	Reason        string `json:"reason"`
+}
+
+// AcceptRescheduleRequest picks one of the proposed slots.
+type AcceptRescheduleRequest struct {
+	ScheduleID uint `json:"schedule_id" binding:"required"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// Sort orders of the teacher's student roster.
+const (
+	RosterSortRecent   = "recent"
+	RosterSortUpcoming = "upcoming"
+)
+
+// TeacherStudentStats aggregates the bookings of one student with a
+// teacher. Lesson dates are YYYY-MM-DD and nil when there is none.
+type TeacherStudentStats struct {
+	StudentID      uint    `gorm:"column:user_id" json:"student_id"`
+	TotalLessons   int     `json:"total_lessons"`
+	TotalBookings  int     `json:"total_bookings"`
+	TotalSpent     float64 `json:"total_spent"`
+	LastLessonDate *string `json:"last_lesson_date"`
+	NextLessonDate *string `json:"next_lesson_date"`
+}
@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// Booking series patterns: every week or every other week on the weekday
+// and time of the first occurrence.
+const (
+	SeriesPatternWeekly   = "weekly"
+	SeriesPatternBiweekly = "biweekly"
+)
+
+// Series payment modes. Upfront series are paid with a single payment of
+// their first occurrence; otherwise every occurrence is paid on its own.
+const (
+	SeriesPaymentUpfront       = "upfront"
+	SeriesPaymentPerOccurrence = "per_occurrence"
+)
+
+const (
+	SeriesStatusActive    = "active"
+	SeriesStatusCancelled = "cancelled"
+)
+
+// BookingSeries groups the bookings of a recurring lesson with the same
+// teacher, e.g. every Tuesday at 19:00. Each occurrence is a regular
+// booking pointing back to the series.
+type BookingSeries struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"index;not null" json:"user_id"`
+	TeacherID   uint      `gorm:"index;not null" json:"teacher_id"`
+	Pattern     string    `gorm:"type:enum('weekly','biweekly');default:'weekly'" json:"pattern"`
+	StartTime   string    `gorm:"size:8" json:"start_time"`
+	EndTime     string    `gorm:"size:8" json:"end_time"`
+	Occurrences int       `json:"occurrences"`
+	PaymentMode string    `gorm:"type:enum('upfront','per_occurrence');default:'per_occurrence'" json:"payment_mode"`
+	Status      string    `gorm:"type:enum('active','cancelled');default:'active'" json:"status"`
+	TotalPrice  float64   `json:"total_price"`
+	Bookings    []Booking `gorm:"foreignKey:SeriesID" json:"bookings,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BookingSeriesRequest starts a series at ScheduleID. Exactly one of Count
+// and EndDate (YYYY-MM-DD, inclusive) limits the occurrences.
+type BookingSeriesRequest struct {
+	ScheduleID  uint   `json:"schedule_id" binding:"required"`
+	Pattern     string `json:"pattern"`
+	Count       int    `json:"count"`
+	EndDate     string `json:"end_date"`
+	PaymentMode string `json:"payment_mode"`
+	Note        string `json:"note"`
+}
+
+// SeriesCancelRequest cancels the occurrences from FromBookingID on, or all
+// upcoming ones when it is not set.
+type SeriesCancelRequest struct {
+	FromBookingID uint `json:"from_booking_id"`
+}
+
+// SeriesRescheduleRequest moves the occurrences from FromBookingID on to
+// NewScheduleID and the slots following it in the series pattern.
+type SeriesRescheduleRequest struct {
+	FromBookingID uint `json:"from_booking_id" binding:"required"`
+	NewScheduleID uint `json:"new_schedule_id" binding:"required"`
+}
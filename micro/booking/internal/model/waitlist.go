@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// Waitlist entry statuses. An entry waits until a matching slot is freed,
+// is then offered that slot for a limited time, and ends claimed, expired
+// or cancelled.
+const (
+	WaitlistStatusWaiting   = "waiting"
+	WaitlistStatusOffered   = "offered"
+	WaitlistStatusClaimed   = "claimed"
+	WaitlistStatusExpired   = "expired"
+	WaitlistStatusCancelled = "cancelled"
+)
+
+// WaitlistEntry is a student waiting either for a specific slot
+// (ScheduleID) or for any slot of the teacher between DateFrom and DateTo.
+type WaitlistEntry struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	UserID            uint       `gorm:"index;not null" json:"user_id"`
+	TeacherID         uint       `gorm:"index;not null" json:"teacher_id"`
+	ScheduleID        *uint      `gorm:"index" json:"schedule_id"`
+	DateFrom          *time.Time `gorm:"type:date" json:"date_from"`
+	DateTo            *time.Time `gorm:"type:date" json:"date_to"`
+	Status            string     `gorm:"type:enum('waiting','offered','claimed','expired','cancelled');default:'waiting';index" json:"status"`
+	OfferedScheduleID *uint      `gorm:"index" json:"offered_schedule_id"`
+	OfferExpiresAt    *time.Time `json:"offer_expires_at"`
+	BookingID         *uint      `json:"booking_id"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// WaitlistRequest joins the waitlist of a slot when ScheduleID is set,
+// otherwise of any slot of TeacherID between DateFrom and DateTo
+// (YYYY-MM-DD, inclusive).
+type WaitlistRequest struct {
+	ScheduleID uint   `json:"schedule_id"`
+	TeacherID  uint   `json:"teacher_id"`
+	DateFrom   string `json:"date_from"`
+	DateTo     string `json:"date_to"`
+}
+
+// SlotsPublishedRequest lists slots the teacher service just made
+// bookable.
+type SlotsPublishedRequest struct {
+	ScheduleIDs []uint `json:"schedule_ids" binding:"required"`
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"booking/internal/model"
+	"booking/internal/pkg"
+	"fmt"
+	"math"
+	"time"
+)
+
+// analyticsPeriodFormats maps an analytics interval to the MySQL date format
+// of its period key.
+var analyticsPeriodFormats = map[string]string{
+	model.AnalyticsIntervalDay:   "%Y-%m-%d",
+	model.AnalyticsIntervalWeek:  "%x-W%v",
+	model.AnalyticsIntervalMonth: "%Y-%m",
+}
+
+func analyticsPeriod(column, interval string) (string, error) {
+	format, ok := analyticsPeriodFormats[interval]
+	if !ok {
+		return "", fmt.Errorf("unknown interval %q", interval)
+	}
+	return fmt.Sprintf("DATE_FORMAT(%s, '%s')", column, format), nil
+}
+
+// GetTeacherPeriodStats groups the teacher's bookings with a lesson date
+// between from and to (inclusive) by period.
+func (r *Repository) GetTeacherPeriodStats(teacherID uint, from, to time.Time, interval string) ([]model.TeacherAnalyticsPeriod, error) {
+	period, err := analyticsPeriod("lesson_date", interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []model.TeacherAnalyticsPeriod
+	err = r.Db.Model(&model.Booking{}).
+		Select(period+" AS period, "+
+			"COUNT(*) AS bookings, "+
+			"SUM(CASE WHEN status = 'cancelled' THEN 1 ELSE 0 END) AS cancellations, "+
+			"SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END) AS lessons, "+
+			"COALESCE(SUM(CASE WHEN status = 'completed' THEN total_price END), 0) AS earnings").
+		Where("teacher_id = ? AND lesson_date BETWEEN ? AND ?", teacherID, from, to).
+		Group("period").
+		Order("period").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetTeacherNewStudents counts, per period, the students whose first
+// non-cancelled lesson with the teacher falls between from and to.
+func (r *Repository) GetTeacherNewStudents(teacherID uint, from, to time.Time, interval string) (map[string]int, error) {
+	period, err := analyticsPeriod("first_lesson", interval)
+	if err != nil {
+		return nil, err
+	}
+
+	firstLessons := r.Db.Model(&model.Booking{}).
+		Select("user_id, MIN(lesson_date) AS first_lesson").
+		Where("teacher_id = ? AND status <> ? AND lesson_date IS NOT NULL", teacherID, "cancelled").
+		Group("user_id")
+
+	var rows []struct {
+		Period string
+		Count  int
+	}
+	err = r.Db.Table("(?) AS first_lessons", firstLessons).
+		Select(period+" AS period, COUNT(*) AS count").
+		Where("first_lesson BETWEEN ? AND ?", from, to).
+		Group("period").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int, len(rows))
+	for _, row := range rows {
+		result[row.Period] = row.Count
+	}
+	return result, nil
+}
+
+// GetTeacherStudentCounts returns how many students had a non-cancelled
+// booking with the teacher in range, and how many of them had more than one.
+func (r *Repository) GetTeacherStudentCounts(teacherID uint, from, to time.Time) (students, repeat int, err error) {
+	perStudent := r.Db.Model(&model.Booking{}).
+		Select("user_id, COUNT(*) AS bookings").
+		Where("teacher_id = ? AND status <> ? AND lesson_date BETWEEN ? AND ?", teacherID, "cancelled", from, to).
+		Group("user_id")
+
+	var row struct {
+		Students int
+		Repeat   int
+	}
+	err = r.Db.Table("(?) AS per_student", perStudent).
+		Select("COUNT(*) AS students, COALESCE(SUM(CASE WHEN bookings > 1 THEN 1 ELSE 0 END), 0) AS `repeat`").
+		Scan(&row).Error
+	return row.Students, row.Repeat, err
+}
+
+// GetBookingsMissingLessonDetails returns bookings created before the
+// teacher and lesson date were stored on the booking, in id order starting
+// after afterID.
+func (r *Repository) GetBookingsMissingLessonDetails(afterID uint, limit int) ([]model.Booking, error) {
+	var bookings []model.Booking
+	err := r.Db.Where("id > ? AND (lesson_date IS NULL OR teacher_id = 0)", afterID).
+		Order("id").Limit(limit).Find(&bookings).Error
+	return bookings, err
+}
+
+func (r *Repository) SetLessonDetails(id, teacherID uint, lessonDate *time.Time) error {
+	return r.Db.Model(&model.Booking{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"teacher_id": teacherID, "lesson_date": lessonDate}).Error
+}
+
+// GetTeacherStudents pages through the students that have at least one
+// non-cancelled booking with the teacher. Lessons before today count as
+// past, the others as upcoming. Spend covers paid and completed bookings.
+func (r *Repository) GetTeacherStudents(teacherID uint, today time.Time, sort string, pagination pkg.Paginate) (pkg.ResponsePaginate, error) {
+	if pagination.Page < 1 {
+		pagination.Page = 1
+	}
+	if pagination.Limit < 1 || pagination.Limit > 100 {
+		pagination.Limit = 10
+	}
+
+	students := r.Db.Model(&model.Booking{}).
+		Select("user_id, "+
+			"SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END) AS total_lessons, "+
+			"SUM(CASE WHEN status <> 'cancelled' THEN 1 ELSE 0 END) AS total_bookings, "+
+			"COALESCE(SUM(CASE WHEN status IN ('paid', 'completed') THEN total_price END), 0) AS total_spent, "+
+			"DATE_FORMAT(MAX(CASE WHEN status <> 'cancelled' AND lesson_date < ? THEN lesson_date END), '%Y-%m-%d') AS last_lesson_date, "+
+			"DATE_FORMAT(MIN(CASE WHEN status IN ('pending', 'paid') AND lesson_date >= ? THEN lesson_date END), '%Y-%m-%d') AS next_lesson_date",
+			today, today).
+		Where("teacher_id = ?", teacherID).
+		Group("user_id").
+		Having("SUM(CASE WHEN status <> 'cancelled' THEN 1 ELSE 0 END) > 0")
+
+	var total int64
+	if err := r.Db.Table("(?) AS students", students).Count(&total).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	query := r.Db.Table("(?) AS students", students)
+	switch sort {
+	case model.RosterSortUpcoming:
+		query = query.Order("next_lesson_date IS NULL").Order("next_lesson_date ASC").Order("last_lesson_date DESC")
+	default:
+		query = query.Order("last_lesson_date IS NULL").Order("last_lesson_date DESC").Order("next_lesson_date ASC")
+	}
+
+	var rows []model.TeacherStudentStats
+	offset := (pagination.Page - 1) * pagination.Limit
+	if err := query.Order("user_id ASC").Offset(offset).Limit(pagination.Limit).Scan(&rows).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+	if rows == nil {
+		rows = []model.TeacherStudentStats{}
+	}
+
+	return pkg.ResponsePaginate{
+		Data: rows,
+		Pagination: pkg.PaginationPage{
+			CurrentPage: pagination.Page,
+			TotalPage:   int(math.Ceil(float64(total) / float64(pagination.Limit))),
+			TotalData:   int(total),
+			Limit:       pagination.Limit,
+		},
+	}, nil
+}
+
+// GetPaidBookingsUntil returns up to limit paid bookings after afterID with
+// a lesson on or before day and no balance due, in id order.
+func (r *Repository) GetPaidBookingsUntil(day time.Time, afterID uint, limit int) ([]model.Booking, error) {
+	var bookings []model.Booking
+	err := r.Db.Where("status = ? AND lesson_date <= ? AND (balance_due IS NULL OR balance_due = 0) AND id > ?", "paid", day, afterID).
+		Order("id").Limit(limit).Find(&bookings).Error
+	return bookings, err
+}
+
+// CompleteBookings marks the given bookings as completed if they are still
+// paid.
+func (r *Repository) CompleteBookings(ids []uint) error {
+	return r.Db.Model(&model.Booking{}).
+		Where("id IN ? AND status = ?", ids, "paid").
+		Update("status", "completed").Error
+}
+
+// GetCompletedBookings returns the student's completed bookings in lesson
+// order.
+func (r *Repository) GetCompletedBookings(userID uint) ([]model.Booking, error) {
+	var bookings []model.Booking
+	err := r.Db.Where("user_id = ? AND status = ?", userID, "completed").
+		Order("lesson_date").Order("id").Find(&bookings).Error
+	return bookings, err
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"booking/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (r *Repository) GetCalendarFeedByUserID(userID uint) (*model.CalendarFeed, error) {
+	var feed model.CalendarFeed
+	if err := r.Db.Where("user_id = ?", userID).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("calendar feed not found")
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (r *Repository) GetCalendarFeedByToken(token string) (*model.CalendarFeed, error) {
+	var feed model.CalendarFeed
+	if err := r.Db.Where("token = ?", token).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("calendar feed not found")
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (r *Repository) SaveCalendarFeed(feed *model.CalendarFeed) error {
+	return r.Db.Save(feed).Error
+}
+
+func (r *Repository) DeleteCalendarFeed(userID uint) error {
+	return r.Db.Where("user_id = ?", userID).Delete(&model.CalendarFeed{}).Error
+}
+
+// GetCalendarBookings returns the user's bookings with a lesson on or after
+// since, including cancelled ones so subscribers can remove them.
+func (r *Repository) GetCalendarBookings(userID uint, since time.Time) ([]model.Booking, error) {
+	var bookings []model.Booking
+	err := r.Db.Where("user_id = ? AND lesson_date >= ?", userID, since).
+		Order("lesson_date, id").Find(&bookings).Error
+	return bookings, err
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"booking/internal/model"
+	"booking/internal/pkg"
+	"errors"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+func preloadHomework(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
+
+// GetLessonRecordByBooking returns the record of a booking's lesson with its
+// homework.
+func (r *Repository) GetLessonRecordByBooking(bookingID uint) (*model.LessonRecord, error) {
+	var record model.LessonRecord
+	err := r.Db.Preload("Homework", preloadHomework).
+		Where("booking_id = ?", bookingID).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("lesson record not found")
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *Repository) SaveLessonRecord(record *model.LessonRecord) error {
+	return r.Db.Omit("Homework").Save(record).Error
+}
+
+// GetLessonRecords returns one page of a student's lesson records, latest
+// lesson first. A teacherID other than zero only returns that teacher's.
+func (r *Repository) GetLessonRecords(userID, teacherID uint, pagination pkg.Paginate) (pkg.ResponsePaginate, error) {
+	if pagination.Page < 1 {
+		pagination.Page = 1
+	}
+	if pagination.Limit < 1 || pagination.Limit > 100 {
+		pagination.Limit = 10
+	}
+
+	query := r.Db.Model(&model.LessonRecord{}).Where("user_id = ?", userID)
+	if teacherID != 0 {
+		query = query.Where("teacher_id = ?", teacherID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	var records []model.LessonRecord
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Preload("Homework", preloadHomework).
+		Order("lesson_date DESC").Order("id DESC").
+		Offset(offset).Limit(pagination.Limit).Find(&records).Error
+	if err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	return pkg.ResponsePaginate{
+		Data: records,
+		Pagination: pkg.PaginationPage{
+			CurrentPage: pagination.Page,
+			TotalPage:   int(math.Ceil(float64(total) / float64(pagination.Limit))),
+			TotalData:   int(total),
+			Limit:       pagination.Limit,
+		},
+	}, nil
+}
+
+func (r *Repository) CreateHomework(homework *model.Homework) error {
+	return r.Db.Create(homework).Error
+}
+
+func (r *Repository) GetHomework(id uint) (*model.Homework, error) {
+	var homework model.Homework
+	if err := r.Db.First(&homework, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("homework not found")
+		}
+		return nil, err
+	}
+	return &homework, nil
+}
+
+func (r *Repository) UpdateHomework(homework *model.Homework) error {
+	return r.Db.Save(homework).Error
+}
+
+// GetUserHomework returns a student's homework, earliest due first. An
+// empty status returns all of it.
+func (r *Repository) GetUserHomework(userID uint, status string) ([]model.Homework, error) {
+	query := r.Db.Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var homework []model.Homework
+	err := query.Order("due_date IS NULL").Order("due_date").Order("id").Find(&homework).Error
+	return homework, err
+}
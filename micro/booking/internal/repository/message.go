@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"booking/internal/model"
+	"booking/internal/pkg"
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetOrCreateConversation returns the conversation between the student and
+// the teacher, creating it on first use.
+func (r *Repository) GetOrCreateConversation(conversation *model.Conversation) error {
+	err := r.Db.Clauses(clause.OnConflict{DoNothing: true}).Create(conversation).Error
+	if err != nil {
+		return err
+	}
+	return r.Db.Where("user_id = ? AND teacher_id = ?", conversation.UserID, conversation.TeacherID).
+		First(conversation).Error
+}
+
+func (r *Repository) GetConversation(id uint) (*model.Conversation, error) {
+	var conversation model.Conversation
+	if err := r.Db.First(&conversation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("conversation not found")
+		}
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (r *Repository) SetConversationTeacherUser(id, teacherUserID uint) error {
+	return r.Db.Model(&model.Conversation{}).Where("id = ?", id).Update("teacher_user_id", teacherUserID).Error
+}
+
+// GetConversations pages through conversations, latest activity first.
+// The user is matched as either the student or the teacher; zero lists all
+// conversations, for moderation.
+func (r *Repository) GetConversations(userID uint, pagination pkg.Paginate) (pkg.ResponsePaginate, error) {
+	if pagination.Page < 1 {
+		pagination.Page = 1
+	}
+	if pagination.Limit < 1 || pagination.Limit > 100 {
+		pagination.Limit = 20
+	}
+
+	query := r.Db.Model(&model.Conversation{})
+	if userID != 0 {
+		query = query.Where("user_id = ? OR teacher_user_id = ?", userID, userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	var conversations []model.Conversation
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Order("last_message_at IS NULL").Order("last_message_at DESC").Order("id DESC").
+		Offset(offset).Limit(pagination.Limit).Find(&conversations).Error
+	if err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	return pkg.ResponsePaginate{
+		Data: conversations,
+		Pagination: pkg.PaginationPage{
+			CurrentPage: pagination.Page,
+			TotalPage:   int(math.Ceil(float64(total) / float64(pagination.Limit))),
+			TotalData:   int(total),
+			Limit:       pagination.Limit,
+		},
+	}, nil
+}
+
+// unreadMessages selects the visible messages in conversations of the user
+// that the other participant sent and the user has not read.
+func (r *Repository) unreadMessages(userID uint) *gorm.DB {
+	return r.Db.Model(&model.Message{}).
+		Joins("JOIN conversations ON conversations.id = messages.conversation_id").
+		Where("(conversations.user_id = ? OR conversations.teacher_user_id = ?)", userID, userID).
+		Where("messages.sender_id <> ? AND messages.read_at IS NULL AND messages.hidden = ?", userID, false)
+}
+
+// GetUnreadCounts returns the unread messages of the user per conversation.
+func (r *Repository) GetUnreadCounts(userID uint, conversationIDs []uint) (map[uint]int, error) {
+	var rows []struct {
+		ConversationID uint
+		Count          int
+	}
+	err := r.unreadMessages(userID).
+		Select("messages.conversation_id, COUNT(*) AS count").
+		Where("messages.conversation_id IN ?", conversationIDs).
+		Group("messages.conversation_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		counts[row.ConversationID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *Repository) CountUnreadMessages(userID uint) (int64, error) {
+	var count int64
+	err := r.unreadMessages(userID).Count(&count).Error
+	return count, err
+}
+
+// GetMessages returns up to limit messages of the conversation, oldest
+// first. With beforeID it returns the ones right before that message, with
+// afterID the ones after it, otherwise the latest. hasMore reports whether
+// older messages are left when paging back.
+func (r *Repository) GetMessages(conversationID, beforeID, afterID uint, limit int) (messages []model.Message, hasMore bool, err error) {
+	query := r.Db.Where("conversation_id = ?", conversationID)
+	if afterID != 0 {
+		err = query.Where("id > ?", afterID).Order("id").Limit(limit).Find(&messages).Error
+		return messages, false, err
+	}
+	if beforeID != 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if err = query.Order("id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, false, err
+	}
+	messages, hasMore = pageMessages(messages, limit)
+	return messages, hasMore, nil
+}
+
+// pageMessages turns up to limit+1 messages fetched newest first into a page
+// of at most limit messages, oldest first, and reports whether older ones
+// are left.
+func pageMessages(messages []model.Message, limit int) ([]model.Message, bool) {
+	hasMore := false
+	if len(messages) > limit {
+		messages, hasMore = messages[:limit], true
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, hasMore
+}
+
+// CreateMessage stores the message and moves its conversation to the top.
+func (r *Repository) CreateMessage(message *model.Message, preview string) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Conversation{}).Where("id = ?", message.ConversationID).
+			Updates(map[string]interface{}{
+				"last_message_at":      message.CreatedAt,
+				"last_message_preview": preview,
+			}).Error
+	})
+}
+
+// MarkMessagesRead marks the messages the other participant sent up to and
+// including uptoID as read by the reader and returns how many were marked.
+func (r *Repository) MarkMessagesRead(conversationID, readerID, uptoID uint, readAt time.Time) (int64, error) {
+	result := r.Db.Model(&model.Message{}).
+		Where("conversation_id = ? AND sender_id <> ? AND read_at IS NULL AND id <= ?", conversationID, readerID, uptoID).
+		Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
+
+func (r *Repository) GetMessage(id uint) (*model.Message, error) {
+	var message model.Message
+	if err := r.Db.First(&message, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("message not found")
+		}
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (r *Repository) UpdateMessageModeration(message *model.Message) error {
+	return r.Db.Model(message).Select("hidden", "hidden_by", "hidden_reason").Updates(message).Error
+}
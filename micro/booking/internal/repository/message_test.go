@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"booking/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func messageIDs(messages []model.Message) []uint {
+	ids := []uint{}
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func newestFirst(ids ...uint) []model.Message {
+	messages := make([]model.Message, len(ids))
+	for i, id := range ids {
+		messages[i].ID = id
+	}
+	return messages
+}
+
+func TestPageMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		messages    []model.Message
+		limit       int
+		want        []uint
+		wantHasMore bool
+	}{
+		{name: "empty", limit: 3, want: []uint{}},
+		{name: "fewer than the limit", messages: newestFirst(5, 4), limit: 3, want: []uint{4, 5}},
+		{name: "exactly the limit", messages: newestFirst(6, 5, 4), limit: 3, want: []uint{4, 5, 6}},
+		{name: "more are left", messages: newestFirst(9, 8, 7, 6), limit: 3, want: []uint{7, 8, 9}, wantHasMore: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, hasMore := pageMessages(tt.messages, tt.limit)
+			if ids := messageIDs(got); !reflect.DeepEqual(ids, tt.want) || hasMore != tt.wantHasMore {
+				t.Errorf("pageMessages() = %v, %v, want %v, %v", ids, hasMore, tt.want, tt.wantHasMore)
+			}
+		})
+	}
+}
+
+// dryRunRepository builds SQL without a database and records each query.
+func dryRunRepository(t *testing.T) (*Repository, *[]*gorm.Statement) {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/booking",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statements []*gorm.Statement
+	err = db.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewRepository(db), &statements
+}
+
+func TestGetMessagesCursors(t *testing.T) {
+	tests := []struct {
+		name      string
+		beforeID  uint
+		afterID   uint
+		wantWhere string
+		wantOrder string
+		wantVars  []interface{}
+	}{
+		{
+			name:      "latest",
+			wantWhere: "WHERE conversation_id = ?",
+			wantOrder: "ORDER BY id DESC LIMIT ?",
+			wantVars:  []interface{}{uint(3), 21},
+		},
+		{
+			name:      "before a message",
+			beforeID:  40,
+			wantWhere: "WHERE conversation_id = ? AND id < ?",
+			wantOrder: "ORDER BY id DESC LIMIT ?",
+			wantVars:  []interface{}{uint(3), uint(40), 21},
+		},
+		{
+			name:      "after a message",
+			afterID:   40,
+			beforeID:  90,
+			wantWhere: "WHERE conversation_id = ? AND id > ?",
+			wantOrder: "ORDER BY id LIMIT ?",
+			wantVars:  []interface{}{uint(3), uint(40), 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, statements := dryRunRepository(t)
+			if _, _, err := repo.GetMessages(3, tt.beforeID, tt.afterID, 20); err != nil {
+				t.Fatal(err)
+			}
+			if len(*statements) != 1 {
+				t.Fatalf("ran %d queries, want 1", len(*statements))
+			}
+			stmt := (*statements)[0]
+			sql := stmt.SQL.String()
+			if !strings.Contains(sql, tt.wantWhere) || !strings.HasSuffix(sql, tt.wantOrder) {
+				t.Errorf("SQL = %q, want %q and %q", sql, tt.wantWhere, tt.wantOrder)
+			}
+			if !reflect.DeepEqual(stmt.Vars, tt.wantVars) {
+				t.Errorf("vars = %#v, want %#v", stmt.Vars, tt.wantVars)
+			}
+		})
+	}
+}
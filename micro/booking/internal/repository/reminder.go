@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"booking/internal/model"
+	"fmt"
+	"time"
+)
+
+// GetBookingsDueReminders returns paid bookings with a lesson between from
+// and to whose last reminder has not gone out yet.
+func (r *Repository) GetBookingsDueReminders(from, to time.Time) ([]model.Booking, error) {
+	var bookings []model.Booking
+	err := r.Db.Where("status = ? AND lesson_date BETWEEN ? AND ? AND reminder_1h_sent_at IS NULL", "paid", from, to).
+		Order("id").Find(&bookings).Error
+	return bookings, err
+}
+
+// ClaimReminder marks the reminder of a booking as sent and reports whether
+// it was still unsent.
+func (r *Repository) ClaimReminder(id uint, reminder string, at time.Time) (bool, error) {
+	if reminder != model.Reminder24h && reminder != model.Reminder1h {
+		return false, fmt.Errorf("unknown reminder %q", reminder)
+	}
+	result := r.Db.Model(&model.Booking{}).
+		Where("id = ? AND "+reminder+" IS NULL", id).
+		UpdateColumn(reminder, at)
+	return result.RowsAffected > 0, result.Error
+}
@@ -23,6 +23,17 @@ func (r *Repository) UpdateBooking(b *model.Booking) error {
 	return r.Db.Save(b).Error
 }
 
+// SetMeetingRoom stores the meeting room of a booking without touching its
+// other fields.
+func (r *Repository) SetMeetingRoom(id uint, provider, room, joinURL string) error {
+	return r.Db.Model(&model.Booking{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"meeting_provider": provider,
+			"meeting_room":     room,
+			"meeting_url":      joinURL,
+		}).Error
+}
+
 func (r *Repository) DeleteBooking(id uint) error {
 	return r.Db.Delete(&model.Booking{}, id).Error
 }
@@ -187,3 +198,29 @@ func (r *Repository) SumTotalPricePaidBookings() (float64, error) {
 	}
 	return sum, nil
 }
+
+// GetActiveBookingsByScheduleIDs returns pending or paid bookings that hold
+// one of the given schedules.
+func (r *Repository) GetActiveBookingsByScheduleIDs(scheduleIDs []uint) ([]model.Booking, error) {
+	var bookings []model.Booking
+	if len(scheduleIDs) == 0 {
+		return bookings, nil
+	}
+	if err := r.Db.Where("schedule_id IN ? AND status IN ?", scheduleIDs, []string{"pending", "paid"}).
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// HasBookingWithTeacher reports whether the user has any non-cancelled
+// booking with the teacher. Used to restrict trial lessons to new students.
+func (r *Repository) HasBookingWithTeacher(userID, teacherID uint) (bool, error) {
+	var count int64
+	if err := r.Db.Model(&model.Booking{}).
+		Where("user_id = ? AND teacher_id = ? AND status <> ?", userID, teacherID, "cancelled").
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
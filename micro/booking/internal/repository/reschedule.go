@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"booking/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// CreateSuccessorBooking stores the booking that replaces a rescheduled one
+// and saves the old booking in the same transaction, so the chain is never
+// left half linked.
+func (r *Repository) CreateSuccessorBooking(old, successor *model.Booking) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(old).Error; err != nil {
+			return err
+		}
+		return tx.Create(successor).Error
+	})
+}
+
+// CreateSuccessorBookings is CreateSuccessorBooking for several bookings at
+// once; old[i] is replaced by successors[i]. Either all of them are stored
+// or none.
+func (r *Repository) CreateSuccessorBookings(old, successors []model.Booking) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		for i := range old {
+			if err := tx.Save(&old[i]).Error; err != nil {
+				return err
+			}
+			successors[i].RescheduleFrom = &old[i].ID
+			if err := tx.Create(&successors[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetBookingsWithRefundDue returns the bookings whose refund still has to
+// be paid out.
+func (r *Repository) GetBookingsWithRefundDue() ([]model.Booking, error) {
+	var bookings []model.Booking
+	err := r.Db.Where("refund_due > 0").Order("id").Find(&bookings).Error
+	return bookings, err
+}
+
+// GetSuccessorBooking returns the booking a rescheduled booking was replaced
+// by, or nil when there is none.
+func (r *Repository) GetSuccessorBooking(id uint) (*model.Booking, error) {
+	var booking model.Booking
+	err := r.Db.Where("reschedule_from = ? AND id <> ?", id, id).First(&booking).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// MigrateLegacyReschedules repairs bookings rescheduled before reschedules
+// created successor records. Those were moved in place and point at
+// themselves, so they are still the live booking and get their payment
+// status back.
+func (r *Repository) MigrateLegacyReschedules() error {
+	return r.Db.Exec(`
+		UPDATE bookings
+		SET status = CASE WHEN payment_id IS NULL OR payment_id = 0 THEN 'pending' ELSE 'paid' END,
+			reschedule_from = NULL
+		WHERE status = 'rescheduled' AND reschedule_from = id`).Error
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"booking/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateRescheduleRequest stores a request together with its options.
+func (r *Repository) CreateRescheduleRequest(request *model.RescheduleRequest) error {
+	return r.Db.Create(request).Error
+}
+
+func (r *Repository) GetRescheduleRequest(id uint) (*model.RescheduleRequest, error) {
+	var request model.RescheduleRequest
+	err := r.Db.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&request, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("reschedule request not found")
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *Repository) UpdateRescheduleRequest(request *model.RescheduleRequest) error {
+	return r.Db.Omit("Options").Save(request).Error
+}
+
+// HasPendingRescheduleRequest reports whether the booking already waits for
+// the student's answer to a reschedule request.
+func (r *Repository) HasPendingRescheduleRequest(bookingID uint) (bool, error) {
+	var count int64
+	err := r.Db.Model(&model.RescheduleRequest{}).
+		Where("booking_id = ? AND status = ?", bookingID, model.RescheduleRequestPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetRescheduleRequests returns the requests of a student (userID) or of a
+// teacher (teacherID), newest first. An empty status returns all of them.
+func (r *Repository) GetRescheduleRequests(userID, teacherID uint, status string) ([]model.RescheduleRequest, error) {
+	query := r.Db.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if teacherID != 0 {
+		query = query.Where("teacher_id = ?", teacherID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var requests []model.RescheduleRequest
+	err := query.Order("id DESC").Find(&requests).Error
+	return requests, err
+}
+
+// GetDueRescheduleRequests returns pending requests whose deadline passed.
+func (r *Repository) GetDueRescheduleRequests(now time.Time) ([]model.RescheduleRequest, error) {
+	var requests []model.RescheduleRequest
+	err := r.Db.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Where("status = ? AND deadline <= ?", model.RescheduleRequestPending, now).
+		Order("id").Find(&requests).Error
+	return requests, err
+}
+
+// SetRescheduleRequestStatus moves a request from one status to another and
+// reports whether it was still in the expected status.
+func (r *Repository) SetRescheduleRequestStatus(id uint, from, to string) (bool, error) {
+	result := r.Db.Model(&model.RescheduleRequest{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"booking/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// CreateSeries stores a series together with its occurrence bookings in one
+// transaction.
+func (r *Repository) CreateSeries(series *model.BookingSeries, bookings []model.Booking) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Bookings").Create(series).Error; err != nil {
+			return err
+		}
+		for i := range bookings {
+			bookings[i].SeriesID = &series.ID
+		}
+		return tx.Create(&bookings).Error
+	})
+}
+
+// GetSeries returns the series with its bookings in occurrence order.
+func (r *Repository) GetSeries(id uint) (*model.BookingSeries, error) {
+	var series model.BookingSeries
+	err := r.Db.Preload("Bookings", func(db *gorm.DB) *gorm.DB {
+		return db.Order("occurrence, id")
+	}).First(&series, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("booking series not found")
+		}
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *Repository) UpdateSeries(series *model.BookingSeries) error {
+	return r.Db.Omit("Bookings").Save(series).Error
+}
+
+// GetSeriesBookings returns the bookings of a series in occurrence order.
+func (r *Repository) GetSeriesBookings(seriesID uint) ([]model.Booking, error) {
+	var bookings []model.Booking
+	err := r.Db.Where("series_id = ?", seriesID).Order("occurrence, id").Find(&bookings).Error
+	return bookings, err
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"booking/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (r *Repository) CreateWaitlistEntry(entry *model.WaitlistEntry) error {
+	return r.Db.Create(entry).Error
+}
+
+func (r *Repository) GetWaitlistEntry(id uint) (*model.WaitlistEntry, error) {
+	var entry model.WaitlistEntry
+	if err := r.Db.First(&entry, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("waitlist entry not found")
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetUserWaitlist returns the user's waiting and offered entries, oldest
+// first.
+func (r *Repository) GetUserWaitlist(userID uint) ([]model.WaitlistEntry, error) {
+	var entries []model.WaitlistEntry
+	err := r.Db.Where("user_id = ? AND status IN ?", userID,
+		[]string{model.WaitlistStatusWaiting, model.WaitlistStatusOffered}).
+		Order("id").Find(&entries).Error
+	return entries, err
+}
+
+// HasOpenWaitlistEntry reports whether the user already waits for the slot.
+func (r *Repository) HasOpenWaitlistEntry(userID, scheduleID uint) (bool, error) {
+	var count int64
+	err := r.Db.Model(&model.WaitlistEntry{}).
+		Where("user_id = ? AND schedule_id = ? AND status IN ?", userID, scheduleID,
+			[]string{model.WaitlistStatusWaiting, model.WaitlistStatusOffered}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// HasActiveBookingForSchedule reports whether the user holds a pending or
+// paid booking for the slot.
+func (r *Repository) HasActiveBookingForSchedule(userID, scheduleID uint) (bool, error) {
+	var count int64
+	err := r.Db.Model(&model.Booking{}).
+		Where("user_id = ? AND schedule_id = ? AND status IN ?", userID, scheduleID, []string{"pending", "paid"}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetActiveOffer returns the unexpired offer holding the slot, or nil.
+func (r *Repository) GetActiveOffer(scheduleID uint, now time.Time) (*model.WaitlistEntry, error) {
+	var entry model.WaitlistEntry
+	err := r.Db.Where("offered_schedule_id = ? AND status = ? AND offer_expires_at > ?",
+		scheduleID, model.WaitlistStatusOffered, now).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// OfferSlotToNext offers the slot to the longest waiting entry that wants
+// it: one waiting for exactly this slot, or for any slot of the teacher in
+// a range containing date. Students already holding a booking for the slot
+// are skipped. It returns nil when nobody is waiting or the slot is already
+// on hold.
+func (r *Repository) OfferSlotToNext(scheduleID, teacherID uint, date time.Time, now, expiresAt time.Time) (*model.WaitlistEntry, error) {
+	var offered *model.WaitlistEntry
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var held int64
+		if err := tx.Model(&model.WaitlistEntry{}).
+			Where("offered_schedule_id = ? AND status = ? AND offer_expires_at > ?", scheduleID, model.WaitlistStatusOffered, now).
+			Count(&held).Error; err != nil {
+			return err
+		}
+		if held > 0 {
+			return nil
+		}
+
+		booked := tx.Model(&model.Booking{}).Select("user_id").
+			Where("schedule_id = ? AND status IN ?", scheduleID, []string{"pending", "paid"})
+
+		day := date.Format("2006-01-02")
+		var entry model.WaitlistEntry
+		err := tx.Where("status = ?", model.WaitlistStatusWaiting).
+			Where("schedule_id = ? OR (schedule_id IS NULL AND teacher_id = ? AND date_from <= ? AND date_to >= ?)",
+				scheduleID, teacherID, day, day).
+			Where("user_id NOT IN (?)", booked).
+			Order("id").First(&entry).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.WaitlistEntry{}).
+			Where("id = ? AND status = ?", entry.ID, model.WaitlistStatusWaiting).
+			Updates(map[string]interface{}{
+				"status":              model.WaitlistStatusOffered,
+				"offered_schedule_id": scheduleID,
+				"offer_expires_at":    expiresAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		entry.Status = model.WaitlistStatusOffered
+		entry.OfferedScheduleID = &scheduleID
+		entry.OfferExpiresAt = &expiresAt
+		offered = &entry
+		return nil
+	})
+	return offered, err
+}
+
+// GetExpiredOffers returns offers whose hold ran out before now.
+func (r *Repository) GetExpiredOffers(now time.Time) ([]model.WaitlistEntry, error) {
+	var entries []model.WaitlistEntry
+	err := r.Db.Where("status = ? AND offer_expires_at <= ?", model.WaitlistStatusOffered, now).
+		Order("id").Find(&entries).Error
+	return entries, err
+}
+
+// ExpireWaitingEntries ends range entries whose range lies before today.
+func (r *Repository) ExpireWaitingEntries(today time.Time) error {
+	return r.Db.Model(&model.WaitlistEntry{}).
+		Where("status = ? AND date_to IS NOT NULL AND date_to < ?", model.WaitlistStatusWaiting, today.Format("2006-01-02")).
+		Update("status", model.WaitlistStatusExpired).Error
+}
+
+// SetWaitlistStatus moves an entry from one status to another and reports
+// whether it was still in the expected status.
+func (r *Repository) SetWaitlistStatus(id uint, from, to string, bookingID *uint) (bool, error) {
+	updates := map[string]interface{}{"status": to}
+	if bookingID != nil {
+		updates["booking_id"] = *bookingID
+	}
+	result := r.Db.Model(&model.WaitlistEntry{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"booking/internal/model"
+	"booking/internal/pkg"
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	lessonDetailsBackfillBatch = 100
+	// scheduleBatch bounds the schedules fetched from the teacher service
+	// in one call.
+	scheduleBatch = 100
+
+	lessonCompletionTick = 10 * time.Minute
+)
+
+// GetTeacherAnalytics aggregates the teacher's bookings by lesson date. from
+// and to are inclusive dates.
+func (s *Service) GetTeacherAnalytics(teacherID uint, from, to time.Time, interval string) (*model.TeacherAnalyticsResponse, error) {
+	switch interval {
+	case model.AnalyticsIntervalDay, model.AnalyticsIntervalWeek, model.AnalyticsIntervalMonth:
+	default:
+		return nil, errors.New("invalid interval, use day, week or month")
+	}
+	if to.Before(from) {
+		return nil, errors.New("to must not be before from")
+	}
+
+	series, err := s.bookingRepository.GetTeacherPeriodStats(teacherID, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+	newStudents, err := s.bookingRepository.GetTeacherNewStudents(teacherID, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+	students, repeat, err := s.bookingRepository.GetTeacherStudentCounts(teacherID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// A period can have new students only if it has bookings, so every key
+	// of newStudents is already in series.
+	var summary model.TeacherAnalyticsSummary
+	for i := range series {
+		series[i].NewStudents = newStudents[series[i].Period]
+
+		summary.Bookings += series[i].Bookings
+		summary.Cancellations += series[i].Cancellations
+		summary.Lessons += series[i].Lessons
+		summary.Earnings += series[i].Earnings
+		summary.NewStudents += series[i].NewStudents
+	}
+	summary.Students = students
+	summary.RepeatStudents = repeat
+	summary.CancellationRate = ratio(summary.Cancellations, summary.Bookings)
+	summary.RepeatStudentRate = ratio(repeat, students)
+
+	if series == nil {
+		series = []model.TeacherAnalyticsPeriod{}
+	}
+	return &model.TeacherAnalyticsResponse{Summary: summary, Series: series}, nil
+}
+
+// GetTeacherStudents returns one page of the teacher's student roster.
+func (s *Service) GetTeacherStudents(teacherID uint, sort string, pagination pkg.Paginate) (pkg.ResponsePaginate, error) {
+	switch sort {
+	case "", model.RosterSortRecent, model.RosterSortUpcoming:
+	default:
+		return pkg.ResponsePaginate{}, errors.New("invalid sort, use recent or upcoming")
+	}
+
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	return s.bookingRepository.GetTeacherStudents(teacherID, today, sort, pagination)
+}
+
+// BackfillLessonDetails copies teacher and lesson date from the teacher
+// service onto bookings created before they were stored locally. Bookings
+// whose schedule no longer resolves are skipped.
+func (s *Service) BackfillLessonDetails() {
+	var afterID uint
+	for {
+		bookings, err := s.bookingRepository.GetBookingsMissingLessonDetails(afterID, lessonDetailsBackfillBatch)
+		if err != nil {
+			log.Printf("failed to load bookings for lesson backfill: %v", err)
+			return
+		}
+		if len(bookings) == 0 {
+			return
+		}
+
+		ids := make([]uint, 0, len(bookings))
+		for _, booking := range bookings {
+			ids = append(ids, booking.ScheduleID)
+		}
+		schedules, err := s.serviceHttp.FetchScheduleDetails(ids)
+		if err != nil {
+			log.Printf("failed to fetch schedules for lesson backfill: %v", err)
+			return
+		}
+
+		for _, booking := range bookings {
+			afterID = booking.ID
+			schedule, ok := schedules[booking.ScheduleID]
+			if !ok {
+				continue
+			}
+			if err := s.bookingRepository.SetLessonDetails(booking.ID, schedule.TeacherID, parseLessonDate(schedule.Date)); err != nil {
+				log.Printf("failed to backfill booking %d: %v", booking.ID, err)
+			}
+		}
+	}
+}
+
+// RunLessonCompletion marks paid lessons as completed once they ended, so
+// they count in the analytics and the student's progress. It blocks and is
+// meant to run in its own goroutine.
+func (s *Service) RunLessonCompletion() {
+	ticker := time.NewTicker(lessonCompletionTick)
+	defer ticker.Stop()
+	for {
+		s.completeLessons(time.Now())
+		<-ticker.C
+	}
+}
+
+// completeLessons completes the paid bookings whose lesson ended before
+// now. Bookings still waiting for a reschedule balance stay paid until it
+// is settled.
+func (s *Service) completeLessons(now time.Time) {
+	today := startOfDay(now.In(s.calendarLocation()))
+	var afterID uint
+	for {
+		bookings, err := s.bookingRepository.GetPaidBookingsUntil(today, afterID, scheduleBatch)
+		if err != nil {
+			log.Printf("lesson completion: failed to get bookings: %v", err)
+			return
+		}
+		if len(bookings) == 0 {
+			return
+		}
+
+		ids := make([]uint, len(bookings))
+		for i, booking := range bookings {
+			ids[i] = booking.ScheduleID
+		}
+		schedules, err := s.serviceHttp.FetchScheduleDetails(uniqueIDs(ids))
+		if err != nil {
+			log.Printf("lesson completion: failed to fetch schedules: %v", err)
+			return
+		}
+
+		var ended []uint
+		for _, booking := range bookings {
+			afterID = booking.ID
+			schedule, ok := schedules[booking.ScheduleID]
+			if !ok {
+				continue
+			}
+			_, lessonEnd, err := s.lessonPeriod(schedule)
+			if err == nil && !lessonEnd.After(now) {
+				ended = append(ended, booking.ID)
+			}
+		}
+		if len(ended) > 0 {
+			if err := s.bookingRepository.CompleteBookings(ended); err != nil {
+				log.Printf("lesson completion: %v", err)
+			}
+		}
+	}
+}
+
+// parseLessonDate reads the date part of a schedule date, which the teacher
+// service sends either as YYYY-MM-DD or as a full timestamp.
+func parseLessonDate(date string) *time.Time {
+	if len(date) < 10 {
+		return nil
+	}
+	parsed, err := time.Parse("2006-01-02", date[:10])
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+// GetCompletedLessons lists the student's completed lessons with their
+// teacher and length, for the progress tracking of the user service.
+// Lessons whose schedule no longer resolves keep their date but have no
+// length.
+func (s *Service) GetCompletedLessons(userID uint) ([]model.CompletedLesson, error) {
+	bookings, err := s.bookingRepository.GetCompletedBookings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make(map[uint]model.ScheduleResponse, len(bookings))
+	for start := 0; start < len(bookings); start += scheduleBatch {
+		end := start + scheduleBatch
+		if end > len(bookings) {
+			end = len(bookings)
+		}
+		ids := make([]uint, 0, end-start)
+		for _, booking := range bookings[start:end] {
+			ids = append(ids, booking.ScheduleID)
+		}
+		batch, err := s.serviceHttp.FetchScheduleDetails(ids)
+		if err != nil {
+			return nil, err
+		}
+		for id, schedule := range batch {
+			schedules[id] = schedule
+		}
+	}
+
+	lessons := make([]model.CompletedLesson, 0, len(bookings))
+	for _, booking := range bookings {
+		lesson := model.CompletedLesson{
+			BookingID: booking.ID,
+			TeacherID: booking.TeacherID,
+		}
+		if booking.LessonDate != nil {
+			lesson.LessonDate = booking.LessonDate.Format("2006-01-02")
+		}
+		if schedule, ok := schedules[booking.ScheduleID]; ok {
+			if schedule.Teacher != nil {
+				lesson.TeacherUserID = schedule.Teacher.UserID
+			}
+			if lesson.LessonDate == "" {
+				lesson.LessonDate = dateOnly(schedule.Date)
+			}
+			lesson.StartTime = schedule.StartTime
+			lesson.EndTime = schedule.EndTime
+			lesson.DurationMinutes = lessonMinutes(schedule)
+		}
+		lessons = append(lessons, lesson)
+	}
+	return lessons, nil
+}
+
+// lessonMinutes is the length of a lesson, from its lesson type or else its
+// start and end time.
+func lessonMinutes(schedule model.ScheduleResponse) int {
+	if schedule.DurationMinutes > 0 {
+		return schedule.DurationMinutes
+	}
+	start, err1 := lessonTime(schedule.Date, schedule.StartTime, time.UTC)
+	end, err2 := lessonTime(schedule.Date, schedule.EndTime, time.UTC)
+	if err1 != nil || err2 != nil || !end.After(start) {
+		return 0
+	}
+	return int(end.Sub(start).Minutes())
+}
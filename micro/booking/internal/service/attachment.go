@@ -0,0 +1,80 @@
+package service
+
+import (
+	"booking/internal/model"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+)
+
+const maxAttachments = 10
+
+// cleanAttachments checks that every attachment was uploaded into folder
+// through one of the attachment endpoints and names unnamed ones after
+// their file. Only the path is kept; URLs are signed when the attachments
+// are read.
+func cleanAttachments(attachments []model.Attachment, folder string) ([]model.Attachment, error) {
+	if len(attachments) > maxAttachments {
+		return nil, fmt.Errorf("at most %d attachments are allowed", maxAttachments)
+	}
+	cleaned := make([]model.Attachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		objectName := strings.TrimSpace(attachment.Path)
+		file, found := strings.CutPrefix(objectName, folder+"/")
+		if !found || file == "" || strings.Contains(file, "/") || path.Clean(objectName) != objectName {
+			return nil, errors.New("attachments must be uploaded through the attachment endpoint")
+		}
+		name := strings.TrimSpace(attachment.Name)
+		if name == "" {
+			name = file
+		}
+		cleaned = append(cleaned, model.Attachment{Name: name, Path: objectName})
+	}
+	return cleaned, nil
+}
+
+// signAttachments fills in the signed URLs of the attachments with one
+// request for all of them. An attachment that cannot be signed is listed
+// without URL.
+func (s *Service) signAttachments(lists ...[]model.Attachment) {
+	var objectNames []string
+	for _, attachments := range lists {
+		for _, attachment := range attachments {
+			if attachment.Path != "" {
+				objectNames = append(objectNames, attachment.Path)
+			}
+		}
+	}
+	if len(objectNames) == 0 || s.storage == nil {
+		return
+	}
+
+	signed, err := s.storage.SignedURLs(objectNames)
+	if err != nil {
+		log.Printf("failed to sign %d attachments: %v", len(objectNames), err)
+		return
+	}
+	for _, attachments := range lists {
+		for i := range attachments {
+			if attachments[i].Path != "" {
+				attachments[i].URL = signed[attachments[i].Path]
+			}
+		}
+	}
+}
+
+// recordAttachments lists the attachment lists of a lesson record and its
+// homework.
+func recordAttachments(record *model.LessonRecord) [][]model.Attachment {
+	lists := [][]model.Attachment{record.Materials}
+	for i := range record.Homework {
+		lists = append(lists, homeworkAttachments(&record.Homework[i])...)
+	}
+	return lists
+}
+
+func homeworkAttachments(homework *model.Homework) [][]model.Attachment {
+	return [][]model.Attachment{homework.Attachments, homework.SubmissionAttachments}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"booking/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestCleanAttachments(t *testing.T) {
+	folder := model.LessonAttachmentFolder(7)
+
+	tests := []struct {
+		name    string
+		in      []model.Attachment
+		want    []model.Attachment
+		wantErr bool
+	}{
+		{
+			name: "keeps the path and drops the url",
+			in:   []model.Attachment{{Name: "Worksheet", Path: "lessons/7/1_sheet.pdf", URL: "https://example.com/signed"}},
+			want: []model.Attachment{{Name: "Worksheet", Path: "lessons/7/1_sheet.pdf"}},
+		},
+		{
+			name: "names an unnamed attachment after its file",
+			in:   []model.Attachment{{Path: " lessons/7/1_sheet.pdf "}},
+			want: []model.Attachment{{Name: "1_sheet.pdf", Path: "lessons/7/1_sheet.pdf"}},
+		},
+		{name: "external url", in: []model.Attachment{{URL: "https://example.com/file.pdf"}}, wantErr: true},
+		{name: "other booking", in: []model.Attachment{{Path: "lessons/8/1_sheet.pdf"}}, wantErr: true},
+		{name: "folder prefix of another booking", in: []model.Attachment{{Path: "lessons/70/1_sheet.pdf"}}, wantErr: true},
+		{name: "parent directory", in: []model.Attachment{{Path: "lessons/7/../8/1_sheet.pdf"}}, wantErr: true},
+		{name: "subfolder", in: []model.Attachment{{Path: "lessons/7/a/1_sheet.pdf"}}, wantErr: true},
+		{name: "folder only", in: []model.Attachment{{Path: "lessons/7/"}}, wantErr: true},
+		{name: "too many", in: make([]model.Attachment, maxAttachments+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cleanAttachments(tt.in, folder)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("cleanAttachments() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cleanAttachments() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cleanAttachments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"booking/internal/model"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ics"
+)
+
+// calendarPastDays keeps recent lessons in the feed so a cancellation that
+// happened shortly before still reaches subscribers.
+const calendarPastDays = 30
+
+// GetCalendarFeed returns the user's feed, creating it on first use.
+func (s *Service) GetCalendarFeed(userID uint) (*model.CalendarFeedResponse, error) {
+	feed, err := s.bookingRepository.GetCalendarFeedByUserID(userID)
+	if err != nil && err.Error() != "calendar feed not found" {
+		log.Println(err)
+		return nil, errors.New("failed to get calendar feed")
+	}
+	if feed == nil {
+		return s.RotateCalendarFeed(userID)
+	}
+	return s.calendarFeedResponse(feed), nil
+}
+
+// RotateCalendarFeed replaces the feed token. Subscriptions using the old
+// URL stop working.
+func (s *Service) RotateCalendarFeed(userID uint) (*model.CalendarFeedResponse, error) {
+	token, err := ics.NewToken()
+	if err != nil {
+		return nil, errors.New("failed to generate calendar token")
+	}
+
+	feed, err := s.bookingRepository.GetCalendarFeedByUserID(userID)
+	if err != nil {
+		feed = &model.CalendarFeed{UserID: userID}
+	}
+	feed.Token = token
+	feed.CreatedAt = time.Now()
+
+	if err := s.bookingRepository.SaveCalendarFeed(feed); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to save calendar feed")
+	}
+	return s.calendarFeedResponse(feed), nil
+}
+
+// RevokeCalendarFeed deletes the feed so its URL stops working.
+func (s *Service) RevokeCalendarFeed(userID uint) error {
+	if err := s.bookingRepository.DeleteCalendarFeed(userID); err != nil {
+		log.Println(err)
+		return errors.New("failed to revoke calendar feed")
+	}
+	return nil
+}
+
+// RenderStudentCalendar renders the ICS feed of the student owning token.
+func (s *Service) RenderStudentCalendar(token string) (string, error) {
+	feed, err := s.bookingRepository.GetCalendarFeedByToken(token)
+	if err != nil {
+		return "", errors.New("calendar feed not found")
+	}
+
+	loc := s.calendarLocation()
+	today, _ := time.ParseInLocation("2006-01-02", time.Now().In(loc).Format("2006-01-02"), loc)
+	bookings, err := s.bookingRepository.GetCalendarBookings(feed.UserID, today.AddDate(0, 0, -calendarPastDays))
+	if err != nil {
+		log.Println(err)
+		return "", errors.New("failed to get bookings")
+	}
+
+	events := make([]ics.Event, 0, len(bookings))
+	if len(bookings) > 0 {
+		ids := make([]uint, 0, len(bookings))
+		for _, booking := range bookings {
+			ids = append(ids, booking.ScheduleID)
+		}
+		schedules, err := s.serviceHttp.FetchScheduleDetails(ids)
+		if err != nil {
+			log.Println(err)
+			return "", errors.New("failed to get schedules")
+		}
+
+		for _, booking := range bookings {
+			schedule, ok := schedules[booking.ScheduleID]
+			if !ok {
+				continue
+			}
+			event, err := bookingEvent(booking, schedule, loc)
+			if err != nil {
+				log.Printf("skipping booking %d in calendar: %v", booking.ID, err)
+				continue
+			}
+			events = append(events, event)
+		}
+	}
+
+	return ics.Write("booking", "Japanese lessons", loc.String(), events), nil
+}
+
+func bookingEvent(booking model.Booking, schedule model.ScheduleResponse, loc *time.Location) (ics.Event, error) {
+	start, err := lessonTime(schedule.Date, schedule.StartTime, loc)
+	if err != nil {
+		return ics.Event{}, err
+	}
+	end, err := lessonTime(schedule.Date, schedule.EndTime, loc)
+	if err != nil {
+		return ics.Event{}, err
+	}
+
+	summary := "Japanese lesson"
+	if schedule.Teacher != nil && schedule.Teacher.Name != "" {
+		summary += " with " + schedule.Teacher.Name
+	}
+	description := fmt.Sprintf("Booking #%d", booking.ID)
+	if schedule.LessonTypeName != "" {
+		description += "\n" + schedule.LessonTypeName
+	}
+	if booking.Note != "" {
+		description += "\n" + booking.Note
+	}
+
+	status := ics.StatusConfirmed
+	switch booking.Status {
+	case "pending":
+		status = ics.StatusTentative
+	case "cancelled", "rescheduled":
+		// A rescheduled booking lives on as its successor, which is a
+		// separate event.
+		status = ics.StatusCancelled
+	}
+
+	// The feed URL is private to the student, so it may carry the join
+	// link of a paid lesson.
+	location := ""
+	if hasMeeting(&booking) {
+		location = booking.MeetingURL
+	}
+
+	return ics.Event{
+		UID:         fmt.Sprintf("booking-%d@booking-teacher-app", booking.ID),
+		Summary:     summary,
+		Description: description,
+		Location:    location,
+		URL:         location,
+		Start:       start,
+		End:         end,
+		Updated:     booking.UpdatedAt,
+		Status:      status,
+		Sequence:    booking.Sequence,
+	}, nil
+}
+
+// lessonTime combines a schedule date (YYYY-MM-DD or a timestamp) with a
+// HH:MM or HH:MM:SS time in loc.
+func lessonTime(date, clock string, loc *time.Location) (time.Time, error) {
+	if len(date) < 10 {
+		return time.Time{}, fmt.Errorf("invalid date %q", date)
+	}
+	if strings.Count(clock, ":") == 1 {
+		clock += ":00"
+	}
+	return time.ParseInLocation("2006-01-02 15:04:05", date[:10]+" "+clock, loc)
+}
+
+func (s *Service) calendarLocation() *time.Location {
+	loc, err := time.LoadLocation(s.calendar.Timezone)
+	if err != nil || s.calendar.Timezone == "" {
+		return time.Local
+	}
+	return loc
+}
+
+func (s *Service) calendarFeedResponse(feed *model.CalendarFeed) *model.CalendarFeedResponse {
+	return &model.CalendarFeedResponse{
+		Token:     feed.Token,
+		URL:       fmt.Sprintf("%s/api/v1/calendar/ics/%s.ics", strings.TrimRight(s.calendar.PublicURL, "/"), feed.Token),
+		CreatedAt: feed.CreatedAt,
+	}
+}
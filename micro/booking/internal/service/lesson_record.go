@@ -0,0 +1,350 @@
+package service
+
+import (
+	"booking/internal/model"
+	"booking/internal/pkg"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	maxLessonSummary   = 5000
+	maxVocabularyItems = 200
+)
+
+// Roles of a user towards a lesson.
+const (
+	lessonStudent = "student"
+	lessonTeacher = "teacher"
+	lessonAdmin   = "admin"
+)
+
+// GetLessonRecord returns the record of a booking's lesson to its student,
+// its teacher or an admin.
+func (s *Service) GetLessonRecord(userID, bookingID uint, isAdmin bool) (*model.LessonRecord, error) {
+	if _, _, err := s.lessonRole(userID, bookingID, isAdmin); err != nil {
+		return nil, err
+	}
+	record, err := s.bookingRepository.GetLessonRecordByBooking(bookingID)
+	if err != nil {
+		if err.Error() == "lesson record not found" {
+			return nil, err
+		}
+		return nil, errors.New("failed to get lesson record")
+	}
+	s.signAttachments(recordAttachments(record)...)
+	return record, nil
+}
+
+// SaveLessonRecord lets the teacher of a paid or completed lesson write its
+// summary, vocabulary and materials.
+func (s *Service) SaveLessonRecord(userID, bookingID uint, req model.LessonRecordRequest) (*model.LessonRecord, error) {
+	record, err := s.teacherLessonRecord(userID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := strings.TrimSpace(req.Summary)
+	if len(summary) > maxLessonSummary {
+		return nil, fmt.Errorf("summary must be at most %d characters", maxLessonSummary)
+	}
+	vocabulary, err := cleanVocabulary(req.Vocabulary)
+	if err != nil {
+		return nil, err
+	}
+	materials, err := cleanAttachments(req.Materials, model.LessonAttachmentFolder(bookingID))
+	if err != nil {
+		return nil, err
+	}
+
+	record.Summary = summary
+	record.Vocabulary = vocabulary
+	record.Materials = materials
+	if err := s.bookingRepository.SaveLessonRecord(record); err != nil {
+		return nil, errors.New("failed to save lesson record")
+	}
+	saved, err := s.bookingRepository.GetLessonRecordByBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	s.signAttachments(recordAttachments(saved)...)
+	return saved, nil
+}
+
+// AssignHomework gives the student of a lesson homework and notifies them.
+func (s *Service) AssignHomework(userID, bookingID uint, req model.HomeworkRequest) (*model.Homework, error) {
+	record, err := s.teacherLessonRecord(userID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if record.ID == 0 {
+		if err := s.bookingRepository.SaveLessonRecord(record); err != nil {
+			return nil, errors.New("failed to save lesson record")
+		}
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" || len(title) > 200 {
+		return nil, errors.New("title must be between 1 and 200 characters")
+	}
+	attachments, err := cleanAttachments(req.Attachments, model.LessonAttachmentFolder(record.BookingID))
+	if err != nil {
+		return nil, err
+	}
+	var dueDate *time.Time
+	if req.DueDate != "" {
+		due, err := time.ParseInLocation("2006-01-02", req.DueDate, s.calendarLocation())
+		if err != nil {
+			return nil, errors.New("invalid due_date, use YYYY-MM-DD")
+		}
+		if due.Before(startOfDay(time.Now().In(s.calendarLocation()))) {
+			return nil, errors.New("due_date must not be in the past")
+		}
+		dueDate = &due
+	}
+
+	homework := &model.Homework{
+		RecordID:     record.ID,
+		BookingID:    record.BookingID,
+		UserID:       record.UserID,
+		TeacherID:    record.TeacherID,
+		Title:        title,
+		Instructions: strings.TrimSpace(req.Instructions),
+		DueDate:      dueDate,
+		Attachments:  attachments,
+		Status:       model.HomeworkAssigned,
+	}
+	if err := s.bookingRepository.CreateHomework(homework); err != nil {
+		return nil, errors.New("failed to assign homework")
+	}
+
+	s.notifyHomework(homework, eventHomeworkAssigned, homework.UserID, lessonStudent)
+	s.signAttachments(homeworkAttachments(homework)...)
+	return homework, nil
+}
+
+// SubmitHomework hands in the student's answer. It can be sent again until
+// the teacher reviewed it.
+func (s *Service) SubmitHomework(userID, homeworkID uint, req model.HomeworkSubmissionRequest) (*model.Homework, error) {
+	homework, record, err := s.homeworkWithRecord(homeworkID)
+	if err != nil {
+		return nil, err
+	}
+	if homework.UserID != userID {
+		return nil, errors.New("homework not found")
+	}
+	if homework.Status == model.HomeworkReviewed {
+		return nil, errors.New("homework has already been reviewed")
+	}
+
+	text := strings.TrimSpace(req.Text)
+	attachments, err := cleanAttachments(req.Attachments, model.LessonAttachmentFolder(homework.BookingID))
+	if err != nil {
+		return nil, err
+	}
+	if text == "" && len(attachments) == 0 {
+		return nil, errors.New("submission needs a text or an attachment")
+	}
+
+	now := time.Now()
+	homework.Submission = text
+	homework.SubmissionAttachments = attachments
+	homework.SubmittedAt = &now
+	homework.Status = model.HomeworkSubmitted
+	if err := s.bookingRepository.UpdateHomework(homework); err != nil {
+		return nil, errors.New("failed to submit homework")
+	}
+
+	s.notifyHomework(homework, eventHomeworkSubmitted, record.TeacherUserID, lessonTeacher)
+	s.signAttachments(homeworkAttachments(homework)...)
+	return homework, nil
+}
+
+// ReviewHomework lets the teacher answer a submission.
+func (s *Service) ReviewHomework(userID, homeworkID uint, req model.HomeworkReviewRequest) (*model.Homework, error) {
+	homework, record, err := s.homeworkWithRecord(homeworkID)
+	if err != nil {
+		return nil, err
+	}
+	if record.TeacherUserID != userID {
+		return nil, errors.New("homework not found")
+	}
+	if homework.Status != model.HomeworkSubmitted {
+		return nil, errors.New("only submitted homework can be reviewed")
+	}
+
+	feedback := strings.TrimSpace(req.Feedback)
+	if feedback == "" {
+		return nil, errors.New("feedback is required")
+	}
+
+	now := time.Now()
+	homework.Feedback = feedback
+	homework.ReviewedAt = &now
+	homework.Status = model.HomeworkReviewed
+	if err := s.bookingRepository.UpdateHomework(homework); err != nil {
+		return nil, errors.New("failed to review homework")
+	}
+
+	s.notifyHomework(homework, eventHomeworkReviewed, homework.UserID, lessonStudent)
+	s.signAttachments(homeworkAttachments(homework)...)
+	return homework, nil
+}
+
+// GetLessonHistory returns the student's lesson records across all their
+// teachers, or of one teacher when teacherID is set.
+func (s *Service) GetLessonHistory(userID, teacherID uint, pagination pkg.Paginate) (pkg.ResponsePaginate, error) {
+	history, err := s.bookingRepository.GetLessonRecords(userID, teacherID, pagination)
+	if err != nil {
+		log.Println(err)
+		return pkg.ResponsePaginate{}, errors.New("failed to get lesson history")
+	}
+	if records, ok := history.Data.([]model.LessonRecord); ok {
+		var attachments [][]model.Attachment
+		for i := range records {
+			attachments = append(attachments, recordAttachments(&records[i])...)
+		}
+		s.signAttachments(attachments...)
+	}
+	return history, nil
+}
+
+// GetMyHomework returns the student's homework, earliest due first.
+func (s *Service) GetMyHomework(userID uint, status string) ([]model.Homework, error) {
+	switch status {
+	case "", model.HomeworkAssigned, model.HomeworkSubmitted, model.HomeworkReviewed:
+	default:
+		return nil, errors.New("invalid status, use assigned, submitted or reviewed")
+	}
+	homework, err := s.bookingRepository.GetUserHomework(userID, status)
+	if err != nil {
+		return nil, errors.New("failed to get homework")
+	}
+	if homework == nil {
+		homework = []model.Homework{}
+	}
+	var attachments [][]model.Attachment
+	for i := range homework {
+		attachments = append(attachments, homeworkAttachments(&homework[i])...)
+	}
+	s.signAttachments(attachments...)
+	return homework, nil
+}
+
+// CanAccessLesson reports an error unless the user takes part in the
+// booking's lesson or is an admin. Attachments are uploaded under it.
+func (s *Service) CanAccessLesson(userID, bookingID uint, isAdmin bool) error {
+	_, _, err := s.lessonRole(userID, bookingID, isAdmin)
+	return err
+}
+
+// lessonRole returns the booking and how the user takes part in its lesson.
+// Outsiders get "booking not found" so bookings cannot be probed.
+func (s *Service) lessonRole(userID, bookingID uint, isAdmin bool) (*model.Booking, string, error) {
+	booking, err := s.bookingRepository.GetBooking(bookingID)
+	if err != nil {
+		return nil, "", errors.New("booking not found")
+	}
+	if userID != 0 && booking.UserID == userID {
+		return booking, lessonStudent, nil
+	}
+
+	teacherUserID, err := s.lessonTeacherUserID(booking)
+	if err != nil {
+		return nil, "", err
+	}
+	switch {
+	case userID != 0 && teacherUserID == userID:
+		return booking, lessonTeacher, nil
+	case isAdmin:
+		return booking, lessonAdmin, nil
+	}
+	return nil, "", errors.New("booking not found")
+}
+
+// lessonTeacherUserID returns the user account of the booking's teacher,
+// from its lesson record when there is one.
+func (s *Service) lessonTeacherUserID(booking *model.Booking) (uint, error) {
+	if record, err := s.bookingRepository.GetLessonRecordByBooking(booking.ID); err == nil && record.TeacherUserID != 0 {
+		return record.TeacherUserID, nil
+	}
+	schedules, err := s.serviceHttp.FetchScheduleDetails([]uint{booking.ScheduleID})
+	if err != nil {
+		log.Printf("lesson of booking %d: %v", booking.ID, err)
+		return 0, errors.New("failed to check lesson participants")
+	}
+	schedule, ok := schedules[booking.ScheduleID]
+	if !ok || schedule.Teacher == nil {
+		return 0, nil
+	}
+	return schedule.Teacher.UserID, nil
+}
+
+// teacherLessonRecord returns the lesson record of a booking for its
+// teacher to write, or a new unsaved one.
+func (s *Service) teacherLessonRecord(userID, bookingID uint) (*model.LessonRecord, error) {
+	booking, role, err := s.lessonRole(userID, bookingID, false)
+	if err != nil {
+		return nil, err
+	}
+	if role != lessonTeacher {
+		return nil, errors.New("only the teacher of the lesson can do this")
+	}
+	if booking.Status != "paid" && booking.Status != "completed" {
+		return nil, errors.New("lesson records are only kept for paid or completed lessons")
+	}
+
+	record, err := s.bookingRepository.GetLessonRecordByBooking(bookingID)
+	if err == nil {
+		return record, nil
+	}
+	if err.Error() != "lesson record not found" {
+		return nil, errors.New("failed to get lesson record")
+	}
+	return &model.LessonRecord{
+		BookingID:     booking.ID,
+		UserID:        booking.UserID,
+		TeacherID:     booking.TeacherID,
+		TeacherUserID: userID,
+		LessonDate:    booking.LessonDate,
+	}, nil
+}
+
+func (s *Service) homeworkWithRecord(homeworkID uint) (*model.Homework, *model.LessonRecord, error) {
+	homework, err := s.bookingRepository.GetHomework(homeworkID)
+	if err != nil {
+		if err.Error() == "homework not found" {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("failed to get homework")
+	}
+	record, err := s.bookingRepository.GetLessonRecordByBooking(homework.BookingID)
+	if err != nil {
+		return nil, nil, errors.New("failed to get lesson record")
+	}
+	return homework, record, nil
+}
+
+func cleanVocabulary(items []model.VocabularyItem) ([]model.VocabularyItem, error) {
+	if len(items) > maxVocabularyItems {
+		return nil, fmt.Errorf("at most %d vocabulary items are allowed", maxVocabularyItems)
+	}
+	cleaned := make([]model.VocabularyItem, 0, len(items))
+	for _, item := range items {
+		item.Term = strings.TrimSpace(item.Term)
+		item.Reading = strings.TrimSpace(item.Reading)
+		item.Meaning = strings.TrimSpace(item.Meaning)
+		if item.Term == "" {
+			return nil, errors.New("every vocabulary item needs a term")
+		}
+		cleaned = append(cleaned, item)
+	}
+	return cleaned, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"booking/internal/infrastructure/meeting"
+	"booking/internal/model"
+	"log"
+	"time"
+)
+
+const defaultMeetingRevealBefore = 15 * time.Minute
+
+// hasMeeting reports whether the booking is a paid lesson, which gets an
+// online meeting room.
+func hasMeeting(booking *model.Booking) bool {
+	return booking.Status == "paid"
+}
+
+// openMeetingRoom creates the room of a booking that just became paid.
+// Failures are only logged: the room is created lazily when the link is
+// first revealed.
+func (s *Service) openMeetingRoom(booking *model.Booking) {
+	schedules, err := s.serviceHttp.FetchScheduleDetails([]uint{booking.ScheduleID})
+	if err != nil {
+		log.Printf("meeting room for booking %d: %v", booking.ID, err)
+		return
+	}
+	schedule, ok := schedules[booking.ScheduleID]
+	if !ok {
+		log.Printf("meeting room for booking %d: schedule %d not found", booking.ID, booking.ScheduleID)
+		return
+	}
+	if err := s.ensureMeetingRoom(booking, schedule); err != nil {
+		log.Printf("meeting room for booking %d: %v", booking.ID, err)
+	}
+}
+
+// ensureMeetingRoom gives the booking a meeting room unless it has one.
+func (s *Service) ensureMeetingRoom(booking *model.Booking, schedule model.ScheduleResponse) error {
+	if booking.MeetingURL != "" {
+		return nil
+	}
+
+	start, end, err := s.lessonPeriod(schedule)
+	if err != nil {
+		return err
+	}
+	room, err := s.meetingProvider.CreateRoom(meeting.RoomRequest{BookingID: booking.ID, Start: start, End: end})
+	if err != nil {
+		return err
+	}
+	if err := s.bookingRepository.SetMeetingRoom(booking.ID, room.Provider, room.Name, room.JoinURL); err != nil {
+		return err
+	}
+
+	booking.MeetingProvider = room.Provider
+	booking.MeetingRoom = room.Name
+	booking.MeetingURL = room.JoinURL
+	return nil
+}
+
+// meetingInfo returns the meeting of a paid booking as seen by viewerID. It
+// is nil for anybody but the booking's student and teacher, and the join
+// URL is only revealed shortly before the lesson until it ends.
+func (s *Service) meetingInfo(booking *model.Booking, schedule *model.ScheduleResponse, viewerID uint) *model.MeetingInfo {
+	if schedule == nil || viewerID == 0 || !hasMeeting(booking) {
+		return nil
+	}
+	isStudent := viewerID == booking.UserID
+	isTeacher := schedule.Teacher != nil && schedule.Teacher.UserID == viewerID
+	if !isStudent && !isTeacher {
+		return nil
+	}
+
+	start, end, err := s.lessonPeriod(*schedule)
+	if err != nil {
+		return nil
+	}
+	revealBefore := s.meeting.RevealBefore
+	if revealBefore <= 0 {
+		revealBefore = defaultMeetingRevealBefore
+	}
+
+	info := &model.MeetingInfo{
+		Provider:      booking.MeetingProvider,
+		AvailableFrom: start.Add(-revealBefore),
+	}
+	now := time.Now()
+	if now.Before(info.AvailableFrom) || now.After(end) {
+		return info
+	}
+
+	if err := s.ensureMeetingRoom(booking, *schedule); err != nil {
+		log.Printf("meeting room for booking %d: %v", booking.ID, err)
+		return info
+	}
+	info.Provider = booking.MeetingProvider
+	info.Available = true
+	info.JoinURL = booking.MeetingURL
+	return info
+}
+
+func (s *Service) lessonPeriod(schedule model.ScheduleResponse) (time.Time, time.Time, error) {
+	loc := s.calendarLocation()
+	start, err := lessonTime(schedule.Date, schedule.StartTime, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := lessonTime(schedule.Date, schedule.EndTime, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"booking/internal/model"
+	"booking/internal/pkg"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxMessageBody      = 5000
+	maxMessagePreview   = 200
+	defaultMessagePage  = 50
+	maxMessagePage      = 100
+	maxModerationReason = 255
+)
+
+// StartConversation opens, or returns the existing, conversation between
+// the student and the teacher of a booking. Only they can open it.
+func (s *Service) StartConversation(userID, bookingID uint) (*model.Conversation, error) {
+	booking, role, err := s.lessonRole(userID, bookingID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	teacherUserID := userID
+	if role == lessonStudent {
+		if teacherUserID, err = s.lessonTeacherUserID(booking); err != nil {
+			return nil, err
+		}
+		if teacherUserID == 0 {
+			return nil, errors.New("teacher of the booking not found")
+		}
+	}
+	if teacherUserID == booking.UserID {
+		return nil, errors.New("you cannot message yourself")
+	}
+
+	conversation := &model.Conversation{
+		UserID:        booking.UserID,
+		TeacherID:     booking.TeacherID,
+		TeacherUserID: teacherUserID,
+	}
+	if err := s.bookingRepository.GetOrCreateConversation(conversation); err != nil {
+		log.Printf("conversation for booking %d: %v", bookingID, err)
+		return nil, errors.New("failed to start conversation")
+	}
+	// The teacher may have linked another account since the conversation
+	// was opened.
+	if conversation.TeacherUserID != teacherUserID && role == lessonStudent {
+		conversation.TeacherUserID = teacherUserID
+		if err := s.bookingRepository.SetConversationTeacherUser(conversation.ID, teacherUserID); err != nil {
+			return nil, errors.New("failed to start conversation")
+		}
+	}
+
+	counts, err := s.bookingRepository.GetUnreadCounts(userID, []uint{conversation.ID})
+	if err == nil {
+		conversation.UnreadCount = counts[conversation.ID]
+	}
+	return conversation, nil
+}
+
+// GetConversations lists the conversations of the user with their unread
+// counts. Admins pass userID zero to moderate all conversations.
+func (s *Service) GetConversations(userID uint, pagination pkg.Paginate) (pkg.ResponsePaginate, error) {
+	page, err := s.bookingRepository.GetConversations(userID, pagination)
+	if err != nil {
+		return pkg.ResponsePaginate{}, errors.New("failed to fetch conversations")
+	}
+
+	conversations := page.Data.([]model.Conversation)
+	if conversations == nil {
+		conversations = []model.Conversation{}
+	}
+	if userID != 0 && len(conversations) > 0 {
+		ids := make([]uint, len(conversations))
+		for i, conversation := range conversations {
+			ids[i] = conversation.ID
+		}
+		counts, err := s.bookingRepository.GetUnreadCounts(userID, ids)
+		if err != nil {
+			return pkg.ResponsePaginate{}, errors.New("failed to fetch conversations")
+		}
+		for i := range conversations {
+			conversations[i].UnreadCount = counts[conversations[i].ID]
+		}
+	}
+	page.Data = conversations
+	return page, nil
+}
+
+// GetMessages pages through the messages of a conversation for one of its
+// participants or an admin. See Repository.GetMessages for the cursors.
+func (s *Service) GetMessages(userID, conversationID uint, isAdmin bool, beforeID, afterID uint, limit int) (*model.MessagePage, error) {
+	if _, err := s.conversationFor(userID, conversationID, isAdmin); err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > maxMessagePage {
+		limit = defaultMessagePage
+	}
+
+	messages, hasMore, err := s.bookingRepository.GetMessages(conversationID, beforeID, afterID, limit)
+	if err != nil {
+		return nil, errors.New("failed to fetch messages")
+	}
+	if messages == nil {
+		messages = []model.Message{}
+	}
+	attachments := make([][]model.Attachment, len(messages))
+	for i := range messages {
+		if !isAdmin {
+			messages[i] = visibleMessage(messages[i])
+		}
+		attachments[i] = messages[i].Attachments
+	}
+	s.signAttachments(attachments...)
+	return &model.MessagePage{Data: messages, HasMore: hasMore}, nil
+}
+
+// SendMessage posts a text message, attachments or both to a conversation
+// of the user and pushes it to both participants.
+func (s *Service) SendMessage(userID, conversationID uint, req model.SendMessageRequest) (*model.Message, error) {
+	conversation, err := s.conversationFor(userID, conversationID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if utf8.RuneCountInString(body) > maxMessageBody {
+		return nil, fmt.Errorf("message must be at most %d characters", maxMessageBody)
+	}
+	attachments, err := cleanAttachments(req.Attachments, model.MessageAttachmentFolder(conversation.ID))
+	if err != nil {
+		return nil, err
+	}
+	if body == "" && len(attachments) == 0 {
+		return nil, errors.New("message must have a body or attachments")
+	}
+
+	message := &model.Message{
+		ConversationID: conversation.ID,
+		SenderID:       userID,
+		Kind:           model.MessageText,
+		Body:           body,
+		Attachments:    attachments,
+		CreatedAt:      time.Now(),
+	}
+	preview := truncateRunes(body, maxMessagePreview)
+	if len(attachments) > 0 {
+		message.Kind = model.MessageAttachment
+		if preview == "" {
+			preview = truncateRunes("Attachment: "+attachments[0].Name, maxMessagePreview)
+		}
+	}
+	if err := s.bookingRepository.CreateMessage(message, preview); err != nil {
+		log.Printf("message in conversation %d: %v", conversation.ID, err)
+		return nil, errors.New("failed to send message")
+	}
+	s.signAttachments(message.Attachments)
+
+	s.messages.publish(model.MessageEvent{
+		Type:           model.MessageEventNew,
+		ConversationID: conversation.ID,
+		Message:        message,
+	}, conversation.UserID, conversation.TeacherUserID)
+	return message, nil
+}
+
+// MarkConversationRead marks the messages the other participant sent up to
+// uptoID, or all of them when zero, as read and sends them a read receipt.
+func (s *Service) MarkConversationRead(userID, conversationID, uptoID uint) error {
+	conversation, err := s.conversationFor(userID, conversationID, false)
+	if err != nil {
+		return err
+	}
+	if uptoID == 0 {
+		uptoID = math.MaxUint32
+	}
+
+	readAt := time.Now()
+	marked, err := s.bookingRepository.MarkMessagesRead(conversation.ID, userID, uptoID, readAt)
+	if err != nil {
+		return errors.New("failed to mark messages as read")
+	}
+	if marked > 0 {
+		s.messages.publish(model.MessageEvent{
+			Type:           model.MessageEventRead,
+			ConversationID: conversation.ID,
+			ReaderID:       userID,
+			ReadAt:         &readAt,
+		}, conversation.UserID, conversation.TeacherUserID)
+	}
+	return nil
+}
+
+// GetUnreadMessageCount returns the unread messages of the user across all
+// conversations.
+func (s *Service) GetUnreadMessageCount(userID uint) (int64, error) {
+	count, err := s.bookingRepository.CountUnreadMessages(userID)
+	if err != nil {
+		return 0, errors.New("failed to count unread messages")
+	}
+	return count, nil
+}
+
+// ModerateMessage hides a message from the participants, or shows it again.
+func (s *Service) ModerateMessage(adminID, messageID uint, req model.ModerateMessageRequest) (*model.Message, error) {
+	message, err := s.bookingRepository.GetMessage(messageID)
+	if err != nil {
+		return nil, err
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if utf8.RuneCountInString(reason) > maxModerationReason {
+		return nil, fmt.Errorf("reason must be at most %d characters", maxModerationReason)
+	}
+
+	message.Hidden = req.Hidden
+	message.HiddenBy = nil
+	message.HiddenReason = ""
+	if req.Hidden {
+		message.HiddenBy = &adminID
+		message.HiddenReason = reason
+	}
+	if err := s.bookingRepository.UpdateMessageModeration(message); err != nil {
+		return nil, errors.New("failed to moderate message")
+	}
+	s.signAttachments(message.Attachments)
+
+	if conversation, err := s.bookingRepository.GetConversation(message.ConversationID); err == nil {
+		visible := visibleMessage(*message)
+		s.messages.publish(model.MessageEvent{
+			Type:           model.MessageEventModerated,
+			ConversationID: conversation.ID,
+			Message:        &visible,
+		}, conversation.UserID, conversation.TeacherUserID)
+	}
+	return message, nil
+}
+
+// CanAccessConversation reports, as an error, whether the user takes part
+// in the conversation.
+func (s *Service) CanAccessConversation(userID, conversationID uint) error {
+	_, err := s.conversationFor(userID, conversationID, false)
+	return err
+}
+
+// SubscribeMessages opens a stream of the user's message events. The
+// returned function closes it.
+func (s *Service) SubscribeMessages(userID uint) (<-chan model.MessageEvent, func()) {
+	return s.messages.subscribe(userID)
+}
+
+// conversationFor returns the conversation if the user takes part in it or
+// is an admin. Others are told it does not exist.
+func (s *Service) conversationFor(userID, conversationID uint, isAdmin bool) (*model.Conversation, error) {
+	conversation, err := s.bookingRepository.GetConversation(conversationID)
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			return nil, err
+		}
+		return nil, errors.New("failed to fetch conversation")
+	}
+	if userID != 0 && (conversation.UserID == userID || conversation.TeacherUserID == userID) {
+		return conversation, nil
+	}
+	if isAdmin {
+		return conversation, nil
+	}
+	return nil, errors.New("conversation not found")
+}
+
+// visibleMessage strips the content of a hidden message for participants.
+func visibleMessage(message model.Message) model.Message {
+	if message.Hidden {
+		message.Body = ""
+		message.Attachments = nil
+		message.HiddenBy = nil
+	}
+	return message
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"booking/internal/model"
+	"sync"
+)
+
+// messageStreamBuffer is how many events a slow stream may fall behind
+// before further events are dropped for it.
+const messageStreamBuffer = 32
+
+// messageHub fans message events out to the open streams of each user. It
+// only reaches streams connected to this instance; clients that miss
+// events, or run behind another instance, catch up by polling the messages
+// with after_id.
+type messageHub struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan model.MessageEvent]struct{}
+}
+
+func newMessageHub() *messageHub {
+	return &messageHub{subscribers: make(map[uint]map[chan model.MessageEvent]struct{})}
+}
+
+// subscribe opens a stream for the user. The returned function closes it.
+func (h *messageHub) subscribe(userID uint) (<-chan model.MessageEvent, func()) {
+	events := make(chan model.MessageEvent, messageStreamBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan model.MessageEvent]struct{})
+	}
+	h.subscribers[userID][events] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], events)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(events)
+		})
+	}
+}
+
+// publish sends the event to every stream of the users without blocking.
+func (h *messageHub) publish(event model.MessageEvent, userIDs ...uint) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, userID := range userIDs {
+		for events := range h.subscribers[userID] {
+			select {
+			case events <- event:
+			default:
+			}
+		}
+	}
+}
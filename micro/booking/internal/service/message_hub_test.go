@@ -0,0 +1,59 @@
+package service
+
+import (
+	"booking/internal/model"
+	"testing"
+)
+
+func TestMessageHub(t *testing.T) {
+	hub := newMessageHub()
+	student, unsubscribeStudent := hub.subscribe(1)
+	teacher, unsubscribeTeacher := hub.subscribe(2)
+	defer unsubscribeTeacher()
+
+	hub.publish(model.MessageEvent{Type: model.MessageEventNew, ConversationID: 7}, 1, 2)
+	for name, events := range map[string]<-chan model.MessageEvent{"student": student, "teacher": teacher} {
+		select {
+		case event := <-events:
+			if event.Type != model.MessageEventNew || event.ConversationID != 7 {
+				t.Errorf("%s got %+v", name, event)
+			}
+		default:
+			t.Errorf("%s got no event", name)
+		}
+	}
+
+	hub.publish(model.MessageEvent{Type: model.MessageEventRead, ConversationID: 7}, 2)
+	select {
+	case event := <-student:
+		t.Errorf("student got %+v meant for the teacher", event)
+	default:
+	}
+	<-teacher
+
+	unsubscribeStudent()
+	unsubscribeStudent()
+	if _, ok := <-student; ok {
+		t.Error("stream still open after unsubscribe")
+	}
+	if _, ok := hub.subscribers[1]; ok {
+		t.Error("unsubscribed user still in the hub")
+	}
+	hub.publish(model.MessageEvent{Type: model.MessageEventNew}, 1)
+}
+
+func TestMessageHubDropsWhenFull(t *testing.T) {
+	hub := newMessageHub()
+	events, unsubscribe := hub.subscribe(1)
+	defer unsubscribe()
+
+	for i := 0; i < messageStreamBuffer+5; i++ {
+		hub.publish(model.MessageEvent{Type: model.MessageEventNew, ConversationID: uint(i)}, 1)
+	}
+	if len(events) != messageStreamBuffer {
+		t.Fatalf("buffered %d events, want %d", len(events), messageStreamBuffer)
+	}
+	if event := <-events; event.ConversationID != 0 {
+		t.Errorf("first event is for conversation %d, want 0", event.ConversationID)
+	}
+}
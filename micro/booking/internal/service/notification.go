@@ -0,0 +1,244 @@
+package service
+
+import (
+	"booking/internal/infrastructure/user"
+	"booking/internal/model"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Booking events reported to the user service, which notifies the student
+// and the teacher as their preferences allow.
+const (
+	eventBookingConfirmed   = "booking_confirmed"
+	eventBookingCancelled   = "booking_cancelled"
+	eventBookingRescheduled = "booking_rescheduled"
+	eventLessonReminder24h  = "lesson_reminder_24h"
+	eventLessonReminder1h   = "lesson_reminder_1h"
+
+	eventRescheduleRequested = "reschedule_requested"
+	eventRescheduleWithdrawn = "reschedule_withdrawn"
+	eventRescheduleExpired   = "reschedule_expired"
+
+	eventHomeworkAssigned  = "homework_assigned"
+	eventHomeworkSubmitted = "homework_submitted"
+	eventHomeworkReviewed  = "homework_reviewed"
+)
+
+// studentEvents are only sent to the student; the teacher caused them.
+var studentEvents = map[string]bool{
+	eventRescheduleRequested: true,
+	eventRescheduleWithdrawn: true,
+}
+
+const lessonReminderTick = 5 * time.Minute
+
+// notifyBooking reports an event of the booking in the background.
+func (s *Service) notifyBooking(booking model.Booking, event string, extra map[string]string) {
+	go s.sendBookingEvent(booking, event, bookingReference(booking.ID), 0, extra)
+}
+
+// notifyReschedule reports that the lesson of booking moved to successor.
+func (s *Service) notifyReschedule(booking, successor model.Booking) {
+	go s.sendBookingEvent(successor, eventBookingRescheduled, bookingReference(successor.ID), booking.ScheduleID, nil)
+}
+
+// notifyRescheduleRequest reports an event of a teacher's reschedule
+// request in the background. The request is the reference, so a second
+// request for the same booking is notified too.
+func (s *Service) notifyRescheduleRequest(request *model.RescheduleRequest, event string, extra map[string]string) {
+	reference := fmt.Sprintf("reschedule_request:%d", request.ID)
+	bookingID := request.BookingID
+	go func() {
+		booking, err := s.bookingRepository.GetBooking(bookingID)
+		if err != nil {
+			log.Printf("notification %s for booking %d: %v", event, bookingID, err)
+			return
+		}
+		s.sendBookingEvent(*booking, event, reference, 0, extra)
+	}()
+}
+
+// notifyCancellation reports a cancelled booking with the amount refunded
+// for it, if any.
+func (s *Service) notifyCancellation(booking model.Booking, reason string, refunded float64) {
+	extra := map[string]string{"reason": reason}
+	if refunded > 0 {
+		extra["refund_amount"] = fmt.Sprintf("Rp %.0f", refunded)
+	}
+	s.notifyBooking(booking, eventBookingCancelled, extra)
+}
+
+// notifyHomework reports an event of the homework to one of its users in
+// the background. Each event is sent once per homework, so a submission
+// sent again is not notified again.
+func (s *Service) notifyHomework(homework *model.Homework, event string, userID uint, role string) {
+	if userID == 0 {
+		return
+	}
+	data := map[string]string{
+		"booking_id":     fmt.Sprint(homework.BookingID),
+		"homework_title": homework.Title,
+	}
+	if homework.DueDate != nil {
+		data["due_date"] = homework.DueDate.Format("2006-01-02")
+	}
+	notification := user.NotificationEvent{
+		UserID:    userID,
+		EventType: event,
+		Reference: fmt.Sprintf("homework:%d", homework.ID),
+		Role:      role,
+		Data:      data,
+	}
+	go func() {
+		if err := s.serviceUser.SendNotificationEvent(notification); err != nil {
+			log.Printf("notification %s for homework %d to user %d: %v", event, homework.ID, userID, err)
+		}
+	}()
+}
+
+func bookingReference(bookingID uint) string {
+	return fmt.Sprintf("booking:%d", bookingID)
+}
+
+// sendBookingEvent sends the event to the student and the teacher of the
+// booking. Teachers of group classes get no reminder per student.
+// Failures are only logged.
+func (s *Service) sendBookingEvent(booking model.Booking, event, reference string, previousScheduleID uint, extra map[string]string) {
+	ids := []uint{booking.ScheduleID}
+	if previousScheduleID != 0 {
+		ids = append(ids, previousScheduleID)
+	}
+	schedules, err := s.serviceHttp.FetchScheduleDetails(ids)
+	if err != nil {
+		log.Printf("notification %s for booking %d: %v", event, booking.ID, err)
+		return
+	}
+	schedule, ok := schedules[booking.ScheduleID]
+	if !ok {
+		log.Printf("notification %s for booking %d: schedule %d not found", event, booking.ID, booking.ScheduleID)
+		return
+	}
+
+	data := map[string]string{
+		"booking_id":  fmt.Sprint(booking.ID),
+		"lesson_date": dateOnly(schedule.Date),
+		"start_time":  clockOnly(schedule.StartTime),
+		"end_time":    clockOnly(schedule.EndTime),
+	}
+	if previous, ok := schedules[previousScheduleID]; ok {
+		data["previous_date"] = dateOnly(previous.Date)
+		data["previous_start_time"] = clockOnly(previous.StartTime)
+	}
+	if schedule.Teacher != nil {
+		data["teacher_name"] = schedule.Teacher.Name
+	}
+	if student, err := s.serviceUser.GetUserById(booking.UserID); err == nil {
+		data["student_name"] = student.Name
+	}
+	for key, value := range extra {
+		if value != "" {
+			data[key] = value
+		}
+	}
+
+	recipients := []user.NotificationEvent{{UserID: booking.UserID, Role: "student"}}
+	isReminder := event == eventLessonReminder24h || event == eventLessonReminder1h
+	if schedule.Teacher != nil && schedule.Teacher.UserID != 0 && !studentEvents[event] && !(isReminder && schedule.Capacity > 1) {
+		recipients = append(recipients, user.NotificationEvent{UserID: schedule.Teacher.UserID, Role: "teacher"})
+	}
+	for _, recipient := range recipients {
+		recipient.EventType = event
+		recipient.Reference = reference
+		recipient.Data = data
+		if err := s.serviceUser.SendNotificationEvent(recipient); err != nil {
+			log.Printf("notification %s for booking %d to user %d: %v", event, booking.ID, recipient.UserID, err)
+		}
+	}
+}
+
+// RunLessonReminders sends the 24 hour and 1 hour reminders of paid
+// lessons until the process exits.
+func (s *Service) RunLessonReminders() {
+	ticker := time.NewTicker(lessonReminderTick)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.sendLessonReminders(time.Now())
+	}
+}
+
+// sendLessonReminders claims each reminder before sending it, so a slow
+// run or a second instance does not send it twice. Lessons booked less
+// than a day ahead skip the 24 hour reminder; their confirmation is recent.
+func (s *Service) sendLessonReminders(now time.Time) {
+	today := startOfDay(now.In(s.calendarLocation()))
+	bookings, err := s.bookingRepository.GetBookingsDueReminders(today, today.AddDate(0, 0, 2))
+	if err != nil {
+		log.Printf("lesson reminders: failed to get bookings: %v", err)
+		return
+	}
+
+	for start := 0; start < len(bookings); start += scheduleBatch {
+		end := start + scheduleBatch
+		if end > len(bookings) {
+			end = len(bookings)
+		}
+		batch := bookings[start:end]
+		ids := make([]uint, len(batch))
+		for i, booking := range batch {
+			ids[i] = booking.ScheduleID
+		}
+		schedules, err := s.serviceHttp.FetchScheduleDetails(uniqueIDs(ids))
+		if err != nil {
+			log.Printf("lesson reminders: failed to fetch schedules: %v", err)
+			return
+		}
+
+		for _, booking := range batch {
+			schedule, ok := schedules[booking.ScheduleID]
+			if !ok {
+				continue
+			}
+			lessonStart, _, err := s.lessonPeriod(schedule)
+			if err != nil || !lessonStart.After(now) {
+				continue
+			}
+			s.remindLesson(booking, lessonStart, now)
+		}
+	}
+}
+
+func (s *Service) remindLesson(booking model.Booking, lessonStart, now time.Time) {
+	untilStart := lessonStart.Sub(now)
+	switch {
+	case untilStart <= time.Hour:
+		if s.claimReminder(booking.ID, model.Reminder1h, now) {
+			s.notifyBooking(booking, eventLessonReminder1h, nil)
+		}
+		// Too late for the day-ahead reminder once the hour has come.
+		s.claimReminder(booking.ID, model.Reminder24h, now)
+	case untilStart <= 24*time.Hour && booking.Reminder24hSentAt == nil:
+		claimed := s.claimReminder(booking.ID, model.Reminder24h, now)
+		if claimed && lessonStart.Sub(booking.CreatedAt) > 24*time.Hour {
+			s.notifyBooking(booking, eventLessonReminder24h, nil)
+		}
+	}
+}
+
+func (s *Service) claimReminder(bookingID uint, reminder string, now time.Time) bool {
+	claimed, err := s.bookingRepository.ClaimReminder(bookingID, reminder, now)
+	if err != nil {
+		log.Printf("lesson reminders: failed to claim %s reminder of booking %d: %v", reminder, bookingID, err)
+		return false
+	}
+	return claimed
+}
+
+// clockOnly shortens a HH:MM:SS time to HH:MM.
+func clockOnly(clock string) string {
+	if len(clock) > 5 {
+		return clock[:5]
+	}
+	return clock
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"booking/internal/model"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxRescheduleChain bounds the walk along a reschedule chain.
+	maxRescheduleChain = 100
+	refundRetryTick    = 10 * time.Minute
+)
+
+// rescheduleBooking replaces booking with a successor on newScheduleID. The
+// new slot is reserved before the old one is released, so a failed
+// reschedule never leaves the student without a lesson. The old booking is
+// kept as "rescheduled" and the successor points back at it.
+//
+// For a paid booking the price difference is settled: a cheaper slot is
+// refunded, a dearer one leaves a balance due on the successor. keepPrice
+// carries the old price over instead, for moves the student did not ask for.
+func (s *Service) rescheduleBooking(c *gin.Context, booking *model.Booking, newScheduleID uint, keepPrice bool) (*model.Booking, error) {
+	successor, err := s.newSuccessor(c, booking, newScheduleID, keepPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.serviceHttp.UpdateScheduleStatus(c, newScheduleID, "booked"); err != nil {
+		if err.Error() == "schedule is not available" {
+			return nil, errors.New("new schedule is not available")
+		}
+		return nil, fmt.Errorf("failed to book new schedule: %w", err)
+	}
+
+	markRescheduled(booking)
+	if err := s.bookingRepository.CreateSuccessorBooking(booking, successor); err != nil {
+		if err := s.serviceHttp.UpdateScheduleStatus(c, newScheduleID, "available"); err != nil {
+			log.Printf("reschedule of booking %d: failed to release schedule %d: %v", booking.ID, newScheduleID, err)
+		}
+		return nil, fmt.Errorf("failed to create rescheduled booking: %w", err)
+	}
+
+	s.finishReschedule(c, booking, successor, keepPrice)
+	return successor, nil
+}
+
+// newSuccessor checks that booking can move to newScheduleID and returns the
+// booking that would replace it there. Nothing is stored or reserved yet.
+func (s *Service) newSuccessor(c *gin.Context, booking *model.Booking, newScheduleID uint, keepPrice bool) (*model.Booking, error) {
+	if booking.Status != "paid" && booking.Status != "pending" {
+		return nil, errors.New("cannot reschedule this booking")
+	}
+	if newScheduleID == booking.ScheduleID {
+		return nil, errors.New("booking is already on this schedule")
+	}
+
+	newSchedule, err := s.serviceHttp.CheckScheduleAvailability(newScheduleID)
+	if err != nil {
+		return nil, errors.New("new schedule not found")
+	}
+	if booking.TeacherID != 0 && newSchedule.TeacherID != booking.TeacherID {
+		return nil, errors.New("new schedule must be with the same teacher")
+	}
+	if newSchedule.Status != "available" {
+		return nil, errors.New("new schedule is not available")
+	}
+
+	offer, err := s.bookingRepository.GetActiveOffer(newScheduleID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to check waitlist: %w", err)
+	}
+	if offer != nil && offer.UserID != booking.UserID {
+		return nil, errors.New("schedule is on hold for a waitlisted student")
+	}
+
+	priced, err := s.serviceHttp.FetchSchedulesByIDs(c, []uint{newScheduleID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule price: %w", err)
+	}
+	detail, ok := priced[newScheduleID]
+	if !ok {
+		return nil, errors.New("new schedule is not available")
+	}
+
+	successor := &model.Booking{
+		UserID:         booking.UserID,
+		ScheduleID:     newScheduleID,
+		TeacherID:      newSchedule.TeacherID,
+		LessonTypeID:   detail.LessonTypeID,
+		LessonDate:     parseLessonDate(detail.Date),
+		Status:         booking.Status,
+		PaymentID:      booking.PaymentID,
+		RescheduleFrom: &booking.ID,
+		Note:           booking.Note,
+		TotalPrice:     detail.TotalPrice,
+		SeriesID:       booking.SeriesID,
+		Occurrence:     booking.Occurrence,
+		// An unsettled balance, or the payment that settled it, moves
+		// along with the lesson.
+		BalanceDue:       booking.BalanceDue,
+		BalancePaymentID: booking.BalancePaymentID,
+	}
+	if keepPrice {
+		successor.TotalPrice = booking.TotalPrice
+	}
+	return successor, nil
+}
+
+// markRescheduled retires a booking that is about to be replaced by its
+// successor.
+func markRescheduled(booking *model.Booking) {
+	booking.Status = "rescheduled"
+	booking.Sequence++
+	// Room tokens are signed for the lesson time, so the new time gets a
+	// new room.
+	booking.MeetingProvider, booking.MeetingRoom, booking.MeetingURL = "", "", ""
+	booking.UpdatedAt = time.Now()
+}
+
+// finishReschedule releases the old slot of a stored reschedule, settles the
+// price and tells the student and the teacher.
+func (s *Service) finishReschedule(c *gin.Context, booking, successor *model.Booking, keepPrice bool) {
+	if err := s.serviceHttp.UpdateScheduleStatus(c, booking.ScheduleID, "available"); err != nil {
+		log.Printf("reschedule of booking %d: failed to free schedule %d: %v", booking.ID, booking.ScheduleID, err)
+	} else {
+		go s.offerSlot(booking.ScheduleID)
+	}
+
+	if successor.Status == "paid" {
+		if !keepPrice {
+			s.settlePriceDifference(booking, successor)
+		}
+		s.openMeetingRoom(successor)
+	}
+	s.notifyReschedule(*booking, *successor)
+}
+
+// settlePriceDifference refunds or charges the difference between the price
+// of a paid booking and its successor. The reschedule itself already
+// succeeded, so a failed refund is stored as owed on the successor and
+// retried by RunRefundRetries.
+func (s *Service) settlePriceDifference(old, successor *model.Booking) {
+	diff := math.Round((successor.TotalPrice-old.TotalPrice)*100) / 100
+	switch {
+	case diff < 0:
+		if _, err := s.refundBooking(successor, -diff, rescheduleRefundReason(old.ID), rescheduleRefundReference(successor.ID)); err != nil {
+			log.Printf("reschedule of booking %d: %v", old.ID, err)
+			successor.RefundDue = -diff
+			if err := s.bookingRepository.UpdateBooking(successor); err != nil {
+				log.Printf("reschedule of booking %d: failed to store refund due: %v", old.ID, err)
+			}
+		}
+	case diff > 0:
+		successor.BalanceDue += diff
+		if err := s.bookingRepository.UpdateBooking(successor); err != nil {
+			log.Printf("reschedule of booking %d: failed to store balance due: %v", old.ID, err)
+		}
+	}
+}
+
+// RunRefundRetries pays out refunds that failed, until the process exits.
+// It blocks and is meant to run in its own goroutine.
+func (s *Service) RunRefundRetries() {
+	ticker := time.NewTicker(refundRetryTick)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.retryOwedRefunds()
+	}
+}
+
+// retryOwedRefunds retries the refunds stored by settlePriceDifference and
+// cancelAndRefund. The refund reference is the same as in the first
+// attempt, so a refund that went through but was not recorded is not paid
+// twice. A cancelled booking is refunded like at its cancellation, under
+// the same references; when the cancellation was refunded already, that
+// refund covered what was owed.
+func (s *Service) retryOwedRefunds() {
+	bookings, err := s.bookingRepository.GetBookingsWithRefundDue()
+	if err != nil {
+		log.Printf("refund retries: %v", err)
+		return
+	}
+	for i := range bookings {
+		booking := &bookings[i]
+		if booking.PaymentID != nil {
+			// The refund is owed by the payment, also when the booking
+			// was rescheduled again since.
+			oldID := booking.ID
+			if booking.RescheduleFrom != nil {
+				oldID = *booking.RescheduleFrom
+			}
+			var err error
+			if booking.Status == "cancelled" {
+				_, err = s.refundPayments(booking, "")
+			} else {
+				_, err = s.servicePayment.RefundPayment(*booking.PaymentID, booking.RefundDue, rescheduleRefundReason(oldID), rescheduleRefundReference(booking.ID))
+			}
+			if err != nil {
+				log.Printf("refund retries: booking %d: %v", booking.ID, err)
+				continue
+			}
+		}
+		booking.RefundDue = 0
+		if err := s.bookingRepository.UpdateBooking(booking); err != nil {
+			log.Printf("refund retries: booking %d: failed to clear refund due: %v", booking.ID, err)
+		}
+	}
+}
+
+func rescheduleRefundReason(oldID uint) string {
+	return fmt.Sprintf("Booking #%d rescheduled to a cheaper lesson", oldID)
+}
+
+// rescheduleRefundReference identifies the refund of the price difference
+// of a reschedule; successorID is the booking that replaced the old one.
+func rescheduleRefundReference(successorID uint) string {
+	return fmt.Sprintf("booking:%d:reschedule", successorID)
+}
+
+// GetBookingHistory returns the reschedule chain the booking belongs to,
+// from the original booking to the current one. Only the student, the
+// teacher and admins can see it.
+func (s *Service) GetBookingHistory(userID, id uint, isAdmin bool) ([]model.Booking, error) {
+	booking, _, err := s.lessonRole(userID, id, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[uint]bool{booking.ID: true}
+	chain := []model.Booking{*booking}
+	for current := booking; current.RescheduleFrom != nil && len(chain) < maxRescheduleChain; {
+		previousID := *current.RescheduleFrom
+		if seen[previousID] {
+			break
+		}
+		previous, err := s.bookingRepository.GetBooking(previousID)
+		if err != nil {
+			break
+		}
+		seen[previous.ID] = true
+		chain = append([]model.Booking{*previous}, chain...)
+		current = previous
+	}
+
+	for current := booking; len(chain) < maxRescheduleChain; {
+		next, err := s.bookingRepository.GetSuccessorBooking(current.ID)
+		if err != nil {
+			return nil, errors.New("failed to get booking history")
+		}
+		if next == nil || seen[next.ID] {
+			break
+		}
+		seen[next.ID] = true
+		chain = append(chain, *next)
+		current = next
+	}
+
+	return chain, nil
+}
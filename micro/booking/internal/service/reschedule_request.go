@@ -0,0 +1,399 @@
+package service
+
+import (
+	"booking/internal/model"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRescheduleWindow = 48 * time.Hour
+	maxRescheduleOptions    = 5
+	rescheduleRequestTick   = time.Minute
+
+	// rescheduleOutcomeKept is the outcome of an expired request whose
+	// booking stays as it is.
+	rescheduleOutcomeKept = "booking kept"
+)
+
+// ProposeReschedule records a teacher's request to move a booking to one of
+// the given slots and notifies the student. The student has until the
+// response window ends, or the lesson starts if that is sooner, to answer.
+func (s *Service) ProposeReschedule(req model.ProposeRescheduleRequest) (*model.RescheduleRequest, error) {
+	booking, err := s.bookingRepository.GetBooking(req.BookingID)
+	if err != nil || booking.TeacherID != req.TeacherID {
+		return nil, errors.New("booking not found")
+	}
+	if booking.Status != "pending" && booking.Status != "paid" {
+		return nil, errors.New("only pending or paid bookings can be rescheduled")
+	}
+	pending, err := s.bookingRepository.HasPendingRescheduleRequest(booking.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check reschedule requests: %w", err)
+	}
+	if pending {
+		return nil, errors.New("booking already has a pending reschedule request")
+	}
+
+	scheduleIDs := uniqueIDs(req.ScheduleIDs)
+	if len(scheduleIDs) == 0 || len(scheduleIDs) > maxRescheduleOptions {
+		return nil, fmt.Errorf("propose between 1 and %d schedules", maxRescheduleOptions)
+	}
+	schedules, err := s.serviceHttp.FetchScheduleDetails(append([]uint{booking.ScheduleID}, scheduleIDs...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	current, ok := schedules[booking.ScheduleID]
+	if !ok {
+		return nil, errors.New("failed to get the booked schedule")
+	}
+	lessonStart, _, err := s.lessonPeriod(current)
+	if err != nil {
+		return nil, errors.New("booked schedule has an invalid date")
+	}
+	now := time.Now()
+	if !lessonStart.After(now) {
+		return nil, errors.New("lesson has already started")
+	}
+
+	options := make([]model.RescheduleOption, 0, len(scheduleIDs))
+	for _, id := range scheduleIDs {
+		schedule, ok := schedules[id]
+		if !ok || schedule.TeacherID != req.TeacherID {
+			return nil, fmt.Errorf("schedule %d not found", id)
+		}
+		if id == booking.ScheduleID || schedule.Status != "available" || schedule.Blocked {
+			return nil, fmt.Errorf("schedule %d is not available", id)
+		}
+		options = append(options, model.RescheduleOption{
+			ScheduleID: id,
+			Date:       dateOnly(schedule.Date),
+			StartTime:  schedule.StartTime,
+			EndTime:    schedule.EndTime,
+		})
+	}
+
+	window := s.reschedule.ResponseWindow
+	if window <= 0 {
+		window = defaultRescheduleWindow
+	}
+	deadline := now.Add(window)
+	if deadline.After(lessonStart) {
+		deadline = lessonStart
+	}
+
+	request := &model.RescheduleRequest{
+		BookingID:     booking.ID,
+		UserID:        booking.UserID,
+		TeacherID:     booking.TeacherID,
+		TeacherUserID: req.TeacherUserID,
+		Reason:        strings.TrimSpace(req.Reason),
+		Status:        model.RescheduleRequestPending,
+		Policy:        s.reschedulePolicy(),
+		Deadline:      deadline,
+		Options:       options,
+	}
+	if err := s.bookingRepository.CreateRescheduleRequest(request); err != nil {
+		return nil, fmt.Errorf("failed to create reschedule request: %w", err)
+	}
+
+	s.notifyRescheduleRequest(request, eventRescheduleRequested, map[string]string{
+		"option_count": fmt.Sprint(len(options)),
+		"deadline":     deadline.In(s.calendarLocation()).Format("2006-01-02 15:04"),
+		"policy":       request.Policy,
+		"reason":       request.Reason,
+	})
+
+	return request, nil
+}
+
+// GetMyRescheduleRequests returns the student's reschedule requests.
+func (s *Service) GetMyRescheduleRequests(userID uint, status string) ([]model.RescheduleRequest, error) {
+	requests, err := s.bookingRepository.GetRescheduleRequests(userID, 0, status)
+	if err != nil {
+		return nil, errors.New("failed to get reschedule requests")
+	}
+	return requests, nil
+}
+
+// GetTeacherRescheduleRequests returns the reschedule requests a teacher
+// made.
+func (s *Service) GetTeacherRescheduleRequests(teacherID uint, status string) ([]model.RescheduleRequest, error) {
+	requests, err := s.bookingRepository.GetRescheduleRequests(0, teacherID, status)
+	if err != nil {
+		return nil, errors.New("failed to get reschedule requests")
+	}
+	return requests, nil
+}
+
+// AcceptRescheduleRequest moves the booking to the chosen option. The
+// student keeps the price they booked at.
+func (s *Service) AcceptRescheduleRequest(c *gin.Context, userID, id, scheduleID uint) (*model.RescheduleRequest, error) {
+	request, err := s.pendingRescheduleRequest(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !hasOption(request, scheduleID) {
+		return nil, errors.New("schedule is not one of the proposed options")
+	}
+	if err := s.claimRescheduleRequest(request, model.RescheduleRequestAccepted); err != nil {
+		return nil, err
+	}
+
+	booking, err := s.bookingRepository.GetBooking(request.BookingID)
+	if err != nil {
+		s.reopenRescheduleRequest(request)
+		return nil, errors.New("booking not found")
+	}
+	successor, err := s.rescheduleBooking(c, booking, scheduleID, true)
+	if err != nil {
+		s.reopenRescheduleRequest(request)
+		return nil, err
+	}
+
+	// rescheduleBooking tells the student and the teacher about the new
+	// time.
+	s.resolveRescheduleRequest(request, fmt.Sprintf("moved to schedule %d", scheduleID), &successor.ID)
+	return request, nil
+}
+
+// DeclineRescheduleRequest cancels the booking and refunds it in full.
+func (s *Service) DeclineRescheduleRequest(c *gin.Context, userID, id uint) (*model.RescheduleRequest, error) {
+	request, err := s.pendingRescheduleRequest(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.claimRescheduleRequest(request, model.RescheduleRequestDeclined); err != nil {
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("Booking #%d cancelled: teacher reschedule declined", request.BookingID)
+	if err := s.cancelAndRefund(c, request.BookingID, reason); err != nil {
+		s.reopenRescheduleRequest(request)
+		return nil, err
+	}
+
+	// The cancellation is notified with the reason.
+	s.resolveRescheduleRequest(request, "booking cancelled and refunded", nil)
+	return request, nil
+}
+
+// WithdrawRescheduleRequest lets the teacher take back a request the
+// student has not answered yet. The booking stays as it is.
+func (s *Service) WithdrawRescheduleRequest(teacherID, id uint) (*model.RescheduleRequest, error) {
+	request, err := s.bookingRepository.GetRescheduleRequest(id)
+	if err != nil {
+		if err.Error() == "reschedule request not found" {
+			return nil, err
+		}
+		return nil, errors.New("failed to get reschedule request")
+	}
+	if request.TeacherID != teacherID {
+		return nil, errors.New("reschedule request not found")
+	}
+	if err := s.claimRescheduleRequest(request, model.RescheduleRequestWithdrawn); err != nil {
+		return nil, err
+	}
+
+	s.resolveRescheduleRequest(request, "withdrawn by teacher", nil)
+	s.notifyRescheduleRequest(request, eventRescheduleWithdrawn, nil)
+	return request, nil
+}
+
+// RunRescheduleRequests applies the default policy to requests the student
+// did not answer in time. It blocks and is meant to run in its own
+// goroutine.
+func (s *Service) RunRescheduleRequests() {
+	ticker := time.NewTicker(rescheduleRequestTick)
+	defer ticker.Stop()
+	for {
+		s.expireRescheduleRequests()
+		<-ticker.C
+	}
+}
+
+func (s *Service) expireRescheduleRequests() {
+	requests, err := s.bookingRepository.GetDueRescheduleRequests(time.Now())
+	if err != nil {
+		log.Printf("reschedule requests: failed to get due requests: %v", err)
+		return
+	}
+
+	for i := range requests {
+		request := &requests[i]
+		if err := s.claimRescheduleRequest(request, model.RescheduleRequestExpired); err != nil {
+			continue
+		}
+		outcome, newBookingID := s.applyReschedulePolicy(request)
+		s.resolveRescheduleRequest(request, outcome, newBookingID)
+
+		// A moved or cancelled lesson is notified as such.
+		if outcome == rescheduleOutcomeKept {
+			s.notifyRescheduleRequest(request, eventRescheduleExpired, nil)
+		}
+	}
+}
+
+// applyReschedulePolicy carries out the request's policy and describes the
+// outcome.
+func (s *Service) applyReschedulePolicy(request *model.RescheduleRequest) (string, *uint) {
+	booking, err := s.bookingRepository.GetBooking(request.BookingID)
+	if err != nil || (booking.Status != "pending" && booking.Status != "paid") {
+		return "booking no longer active", nil
+	}
+
+	c := jobContext()
+	switch request.Policy {
+	case model.ReschedulePolicyKeep:
+		return rescheduleOutcomeKept, nil
+	case model.ReschedulePolicyFirstOption:
+		for _, option := range request.Options {
+			successor, err := s.rescheduleBooking(c, booking, option.ScheduleID, true)
+			if err == nil {
+				return fmt.Sprintf("moved to schedule %d", option.ScheduleID), &successor.ID
+			}
+			log.Printf("reschedule request %d: option %d: %v", request.ID, option.ScheduleID, err)
+		}
+	}
+
+	reason := fmt.Sprintf("Booking #%d cancelled: teacher reschedule not answered", booking.ID)
+	if err := s.cancelAndRefund(c, booking.ID, reason); err != nil {
+		log.Printf("reschedule request %d: %v", request.ID, err)
+		return "failed to cancel booking", nil
+	}
+	return "booking cancelled and refunded", nil
+}
+
+// cancelAndRefund cancels a booking and refunds it in full, including a
+// balance paid after a reschedule. A payment of an upfront series covers
+// several lessons, so only this lesson's price is refunded from it. The booking is cancelled first, so a refund is never
+// paid for a lesson that stays booked; a refund that fails is kept as
+// refund_due and retried by RunRefundRetries.
+func (s *Service) cancelAndRefund(c *gin.Context, bookingID uint, reason string) error {
+	booking, err := s.bookingRepository.GetBooking(bookingID)
+	if err != nil {
+		return errors.New("booking not found")
+	}
+	if booking.Status != "pending" && booking.Status != "paid" {
+		return errors.New("booking is no longer active")
+	}
+
+	cancelled, cancelErr := s.cancelBooking(c, booking.ID, reason)
+	if cancelled == nil {
+		return fmt.Errorf("failed to cancel booking: %w", cancelErr)
+	}
+	refunded, err := s.refundCancelled(booking, reason)
+	if err != nil {
+		log.Printf("booking %d: %v; retrying later", booking.ID, err)
+		refunded = booking.TotalPrice
+		cancelled.RefundDue = refunded
+		if err := s.bookingRepository.UpdateBooking(cancelled); err != nil {
+			log.Printf("booking %d: failed to store refund due: %v", booking.ID, err)
+		}
+	}
+	s.notifyCancellation(*cancelled, reason, refunded)
+	if cancelErr != nil {
+		return fmt.Errorf("failed to cancel booking: %w", cancelErr)
+	}
+	return nil
+}
+
+func (s *Service) pendingRescheduleRequest(userID, id uint) (*model.RescheduleRequest, error) {
+	request, err := s.bookingRepository.GetRescheduleRequest(id)
+	if err != nil {
+		if err.Error() == "reschedule request not found" {
+			return nil, err
+		}
+		return nil, errors.New("failed to get reschedule request")
+	}
+	if request.UserID != userID {
+		return nil, errors.New("reschedule request not found")
+	}
+	if request.Status != model.RescheduleRequestPending || !request.Deadline.After(time.Now()) {
+		return nil, errors.New("reschedule request is no longer pending")
+	}
+	return request, nil
+}
+
+// claimRescheduleRequest moves a pending request to status, so that an
+// answer and the expiry job cannot both act on it.
+func (s *Service) claimRescheduleRequest(request *model.RescheduleRequest, status string) error {
+	ok, err := s.bookingRepository.SetRescheduleRequestStatus(request.ID, model.RescheduleRequestPending, status)
+	if err != nil {
+		return errors.New("failed to update reschedule request")
+	}
+	if !ok {
+		return errors.New("reschedule request is no longer pending")
+	}
+	request.Status = status
+	return nil
+}
+
+// reopenRescheduleRequest undoes a claim whose action failed, so the
+// student can try again.
+func (s *Service) reopenRescheduleRequest(request *model.RescheduleRequest) {
+	if _, err := s.bookingRepository.SetRescheduleRequestStatus(request.ID, request.Status, model.RescheduleRequestPending); err != nil {
+		log.Printf("reschedule request %d: failed to reopen: %v", request.ID, err)
+	}
+	request.Status = model.RescheduleRequestPending
+}
+
+func (s *Service) resolveRescheduleRequest(request *model.RescheduleRequest, outcome string, newBookingID *uint) {
+	now := time.Now()
+	request.Outcome = outcome
+	request.NewBookingID = newBookingID
+	request.ResolvedAt = &now
+	if err := s.bookingRepository.UpdateRescheduleRequest(request); err != nil {
+		log.Printf("reschedule request %d: failed to store outcome: %v", request.ID, err)
+	}
+}
+
+func (s *Service) reschedulePolicy() string {
+	switch s.reschedule.DefaultPolicy {
+	case model.ReschedulePolicyKeep, model.ReschedulePolicyFirstOption:
+		return s.reschedule.DefaultPolicy
+	default:
+		return model.ReschedulePolicyCancel
+	}
+}
+
+func hasOption(request *model.RescheduleRequest, scheduleID uint) bool {
+	for _, option := range request.Options {
+		if option.ScheduleID == scheduleID {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func dateOnly(date string) string {
+	if len(date) > 10 {
+		return date[:10]
+	}
+	return date
+}
+
+// jobContext stands in for the request context in background jobs. Their
+// calls to the teacher service carry no user token.
+func jobContext() *gin.Context {
+	return &gin.Context{Request: &http.Request{Header: http.Header{}}}
+}
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"booking/internal/model"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// maxSeriesOccurrences bounds a series to about a year of weekly lessons.
+const maxSeriesOccurrences = 52
+
+// CreateBookingSeries books the slot req.ScheduleID and the teacher's slots
+// with the same times following it in the series pattern. All occurrences
+// are checked first, then their slots are reserved in one call to the
+// teacher service and the series is stored in one transaction, so either
+// every occurrence is booked or none.
+func (s *Service) CreateBookingSeries(c *gin.Context, userID uint, req model.BookingSeriesRequest) (*model.BookingSeries, error) {
+	pattern := req.Pattern
+	if pattern == "" {
+		pattern = model.SeriesPatternWeekly
+	}
+	if pattern != model.SeriesPatternWeekly && pattern != model.SeriesPatternBiweekly {
+		return nil, errors.New("pattern must be weekly or biweekly")
+	}
+	paymentMode := req.PaymentMode
+	if paymentMode == "" {
+		paymentMode = model.SeriesPaymentPerOccurrence
+	}
+	if paymentMode != model.SeriesPaymentUpfront && paymentMode != model.SeriesPaymentPerOccurrence {
+		return nil, errors.New("payment_mode must be upfront or per_occurrence")
+	}
+
+	first, err := s.serviceHttp.CheckScheduleAvailability(req.ScheduleID)
+	if err != nil {
+		return nil, errors.New("schedule not found")
+	}
+	firstDate := parseLessonDate(first.Date)
+	if firstDate == nil {
+		return nil, errors.New("schedule has an invalid date")
+	}
+
+	dates, err := seriesDates(*firstDate, pattern, req.Count, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := s.seriesSlots(first.TeacherID, first.StartTime, first.EndTime, dates)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &model.BookingSeries{
+		UserID:      userID,
+		TeacherID:   first.TeacherID,
+		Pattern:     pattern,
+		StartTime:   first.StartTime,
+		EndTime:     first.EndTime,
+		Occurrences: len(slots),
+		PaymentMode: paymentMode,
+		Status:      model.SeriesStatusActive,
+	}
+
+	bookings := make([]model.Booking, 0, len(slots))
+	scheduleIDs := make([]uint, 0, len(slots))
+	for i, slot := range slots {
+		booking, detail, err := s.newBooking(c, model.BookingRequest{
+			ScheduleID: slot.ID,
+			UserID:     userID,
+			Note:       req.Note,
+			Occurrence: i + 1,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("occurrence on %s could not be booked: %v", dates[i], err)
+		}
+		if detail.IsTrial {
+			return nil, errors.New("trial lessons cannot be booked as a series")
+		}
+		bookings = append(bookings, *booking)
+		scheduleIDs = append(scheduleIDs, slot.ID)
+		series.TotalPrice += booking.TotalPrice
+	}
+
+	if err := s.reserveSchedules(scheduleIDs); err != nil {
+		return nil, err
+	}
+	if err := s.bookingRepository.CreateSeries(series, bookings); err != nil {
+		log.Println(err)
+		s.releaseSchedules(scheduleIDs)
+		return nil, errors.New("failed to create booking series")
+	}
+
+	series.Bookings = bookings
+	return series, nil
+}
+
+// GetBookingSeries returns a series of the user with its occurrences.
+func (s *Service) GetBookingSeries(userID, id uint) (*model.BookingSeries, error) {
+	return s.userSeries(userID, id)
+}
+
+// CancelBookingSeries cancels the occurrences from req.FromBookingID on, or
+// every upcoming occurrence. Paid occurrences get their share of the series
+// payment back.
+func (s *Service) CancelBookingSeries(c *gin.Context, userID, id uint, req model.SeriesCancelRequest) (*model.BookingSeries, error) {
+	series, err := s.userSeries(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fromOccurrence := 1
+	if req.FromBookingID != 0 {
+		from, err := seriesOccurrence(series, req.FromBookingID)
+		if err != nil {
+			return nil, err
+		}
+		fromOccurrence = from.Occurrence
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for _, booking := range series.Bookings {
+		if booking.Occurrence < fromOccurrence || !isActiveOccurrence(booking) {
+			continue
+		}
+		if req.FromBookingID == 0 && booking.LessonDate != nil && booking.LessonDate.Format("2006-01-02") < today {
+			continue
+		}
+		if err := s.cancelAndRefund(c, booking.ID, "Booking series cancelled"); err != nil {
+			return nil, fmt.Errorf("failed to cancel occurrence %d: %v", booking.Occurrence, err)
+		}
+	}
+
+	return s.refreshSeriesStatus(series.ID)
+}
+
+// RescheduleBookingSeries moves the occurrences from req.FromBookingID on to
+// req.NewScheduleID and the teacher's slots following it in the series
+// pattern. All target slots are checked and reserved together, and the
+// moves are stored in one transaction, so either every occurrence moves or
+// none.
+func (s *Service) RescheduleBookingSeries(c *gin.Context, userID, id uint, req model.SeriesRescheduleRequest) (*model.BookingSeries, error) {
+	series, err := s.userSeries(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	from, err := seriesOccurrence(series, req.FromBookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	var remaining []model.Booking
+	for _, booking := range series.Bookings {
+		if booking.Occurrence >= from.Occurrence && isActiveOccurrence(booking) {
+			remaining = append(remaining, booking)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil, errors.New("no occurrences left to reschedule")
+	}
+
+	first, err := s.serviceHttp.CheckScheduleAvailability(req.NewScheduleID)
+	if err != nil {
+		return nil, errors.New("new schedule not found")
+	}
+	if first.TeacherID != series.TeacherID {
+		return nil, errors.New("new schedule must be with the same teacher")
+	}
+	firstDate := parseLessonDate(first.Date)
+	if firstDate == nil {
+		return nil, errors.New("new schedule has an invalid date")
+	}
+
+	step := seriesStep(series.Pattern)
+	dates := make([]string, 0, len(remaining))
+	for i := range remaining {
+		dates = append(dates, firstDate.AddDate(0, 0, i*step).Format("2006-01-02"))
+	}
+	slots, err := s.seriesSlots(series.TeacherID, first.StartTime, first.EndTime, dates)
+	if err != nil {
+		return nil, err
+	}
+
+	successors := make([]model.Booking, 0, len(remaining))
+	scheduleIDs := make([]uint, 0, len(remaining))
+	for i := range remaining {
+		successor, err := s.newSuccessor(c, &remaining[i], slots[i].ID, false)
+		if err != nil {
+			return nil, fmt.Errorf("occurrence %d cannot be rescheduled: %v", remaining[i].Occurrence, err)
+		}
+		successors = append(successors, *successor)
+		scheduleIDs = append(scheduleIDs, slots[i].ID)
+	}
+
+	if err := s.reserveSchedules(scheduleIDs); err != nil {
+		return nil, err
+	}
+	for i := range remaining {
+		markRescheduled(&remaining[i])
+	}
+	if err := s.bookingRepository.CreateSuccessorBookings(remaining, successors); err != nil {
+		log.Println(err)
+		s.releaseSchedules(scheduleIDs)
+		return nil, errors.New("failed to reschedule booking series")
+	}
+	for i := range remaining {
+		s.finishReschedule(c, &remaining[i], &successors[i], false)
+	}
+
+	series.StartTime = first.StartTime
+	series.EndTime = first.EndTime
+	if err := s.bookingRepository.UpdateSeries(series); err != nil {
+		log.Printf("series %d: failed to store new times: %v", series.ID, err)
+	}
+	return s.bookingRepository.GetSeries(series.ID)
+}
+
+// AmountDue is what a payment for the booking has to cover. A paid booking
+// only owes the balance left by a reschedule to a dearer slot. The first
+// occurrence of an upfront series pays for all its pending occurrences.
+func (s *Service) AmountDue(booking *model.Booking) float64 {
+	if booking.Status == "paid" {
+		return booking.BalanceDue
+	}
+	if booking.SeriesID == nil || booking.Occurrence != 1 {
+		return booking.TotalPrice
+	}
+	series, err := s.bookingRepository.GetSeries(*booking.SeriesID)
+	if err != nil || series.PaymentMode != model.SeriesPaymentUpfront {
+		return booking.TotalPrice
+	}
+
+	var amount float64
+	for _, occurrence := range series.Bookings {
+		if occurrence.ID == booking.ID || occurrence.Status == "pending" {
+			amount += occurrence.TotalPrice
+		}
+	}
+	return amount
+}
+
+// applySeriesPayment carries the outcome of the payment of an upfront
+// series, made for its first occurrence, over to the other pending
+// occurrences.
+func (s *Service) applySeriesPayment(booking *model.Booking, paymentID uint, status string) {
+	if booking.SeriesID == nil || booking.Occurrence != 1 || (status != "paid" && status != "cancelled") {
+		return
+	}
+	series, err := s.bookingRepository.GetSeries(*booking.SeriesID)
+	if err != nil || series.PaymentMode != model.SeriesPaymentUpfront {
+		return
+	}
+
+	for i := range series.Bookings {
+		occurrence := &series.Bookings[i]
+		if occurrence.ID == booking.ID || occurrence.Status != "pending" {
+			continue
+		}
+		occurrence.Status = status
+		occurrence.PaymentID = &paymentID
+		occurrence.UpdatedAt = time.Now()
+		if err := s.bookingRepository.UpdateBooking(occurrence); err != nil {
+			log.Printf("series %d: failed to update occurrence %d: %v", series.ID, occurrence.Occurrence, err)
+			continue
+		}
+		if status == "paid" {
+			s.openMeetingRoom(occurrence)
+		}
+	}
+}
+
+// seriesSlots picks a bookable slot for every date, in date order. All
+// dates without one are reported together.
+func (s *Service) seriesSlots(teacherID uint, startTime, endTime string, dates []string) ([]model.ScheduleResponse, error) {
+	found, err := s.serviceHttp.MatchSlots(teacherID, startTime, endTime, dates)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to look up schedules")
+	}
+	return pickSeriesSlots(found, dates)
+}
+
+// pickSeriesSlots picks the first bookable slot of found for every date.
+func pickSeriesSlots(found []model.ScheduleResponse, dates []string) ([]model.ScheduleResponse, error) {
+	byDate := make(map[string][]model.ScheduleResponse)
+	for _, slot := range found {
+		if len(slot.Date) < 10 {
+			continue
+		}
+		byDate[slot.Date[:10]] = append(byDate[slot.Date[:10]], slot)
+	}
+
+	slots := make([]model.ScheduleResponse, 0, len(dates))
+	var problems []string
+	for _, date := range dates {
+		candidates := byDate[date]
+		reason := "no slot"
+		picked := false
+		for _, slot := range candidates {
+			if slot.Status == "available" && !slot.Blocked {
+				slots = append(slots, slot)
+				picked = true
+				break
+			}
+			reason = slot.Status
+			if slot.Blocked {
+				reason = "teacher busy"
+			}
+		}
+		if !picked {
+			problems = append(problems, fmt.Sprintf("%s (%s)", date, reason))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("occurrences not available: %s", strings.Join(problems, ", "))
+	}
+	return slots, nil
+}
+
+// reserveSchedules books the slots of a series in one call, or none of them.
+func (s *Service) reserveSchedules(scheduleIDs []uint) error {
+	if cast.ToBool(os.Getenv("IS_NFT")) {
+		return nil
+	}
+	if err := s.serviceHttp.ReserveSchedules(scheduleIDs); err != nil {
+		if strings.HasSuffix(err.Error(), "is not available") {
+			return fmt.Errorf("occurrences not available: %v", err)
+		}
+		log.Println(err)
+		return errors.New("failed to reserve schedules")
+	}
+	return nil
+}
+
+// releaseSchedules gives back the slots reserved for a series that could
+// not be stored.
+func (s *Service) releaseSchedules(scheduleIDs []uint) {
+	if cast.ToBool(os.Getenv("IS_NFT")) {
+		return
+	}
+	if err := s.serviceHttp.ReleaseSchedules(scheduleIDs); err != nil {
+		log.Printf("failed to release schedules %v: %v", scheduleIDs, err)
+	}
+}
+
+// refreshSeriesStatus marks a series cancelled once none of its occurrences
+// is active anymore.
+func (s *Service) refreshSeriesStatus(id uint) (*model.BookingSeries, error) {
+	series, err := s.bookingRepository.GetSeries(id)
+	if err != nil {
+		return nil, errors.New("failed to get booking series")
+	}
+	for _, booking := range series.Bookings {
+		if isActiveOccurrence(booking) {
+			return series, nil
+		}
+	}
+	series.Status = model.SeriesStatusCancelled
+	if err := s.bookingRepository.UpdateSeries(series); err != nil {
+		return nil, errors.New("failed to update booking series")
+	}
+	return series, nil
+}
+
+func (s *Service) userSeries(userID, id uint) (*model.BookingSeries, error) {
+	series, err := s.bookingRepository.GetSeries(id)
+	if err != nil {
+		if err.Error() == "booking series not found" {
+			return nil, err
+		}
+		return nil, errors.New("failed to get booking series")
+	}
+	if series.UserID != userID {
+		return nil, errors.New("booking series not found")
+	}
+	return series, nil
+}
+
+func seriesOccurrence(series *model.BookingSeries, bookingID uint) (*model.Booking, error) {
+	for i := range series.Bookings {
+		if series.Bookings[i].ID == bookingID {
+			return &series.Bookings[i], nil
+		}
+	}
+	return nil, errors.New("booking is not part of this series")
+}
+
+func isActiveOccurrence(booking model.Booking) bool {
+	return booking.Status == "pending" || booking.Status == "paid"
+}
+
+func seriesStep(pattern string) int {
+	if pattern == model.SeriesPatternBiweekly {
+		return 14
+	}
+	return 7
+}
+
+// seriesDates lists the dates (YYYY-MM-DD) of a series starting at first,
+// limited by either count or endDate.
+func seriesDates(first time.Time, pattern string, count int, endDate string) ([]string, error) {
+	step := seriesStep(pattern)
+
+	switch {
+	case count != 0 && endDate != "":
+		return nil, errors.New("set either count or end_date, not both")
+	case endDate != "":
+		end, err := time.Parse("2006-01-02", endDate)
+		if err != nil {
+			return nil, errors.New("invalid end_date format, should be 2006-01-02")
+		}
+		if end.Before(first) {
+			return nil, errors.New("end_date must not be before the first lesson")
+		}
+		count = int(end.Sub(first).Hours()/24)/step + 1
+	case count == 0:
+		return nil, errors.New("count or end_date is required")
+	}
+
+	if count < 2 || count > maxSeriesOccurrences {
+		return nil, fmt.Errorf("a series must have between 2 and %d occurrences", maxSeriesOccurrences)
+	}
+
+	dates := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		dates = append(dates, first.AddDate(0, 0, i*step).Format("2006-01-02"))
+	}
+	return dates, nil
+}
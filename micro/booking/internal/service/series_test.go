@@ -0,0 +1,147 @@
+package service
+
+import (
+	"booking/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSeriesDates(t *testing.T) {
+	first := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		pattern string
+		count   int
+		endDate string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "weekly by count",
+			pattern: model.SeriesPatternWeekly,
+			count:   3,
+			want:    []string{"2025-03-03", "2025-03-10", "2025-03-17"},
+		},
+		{
+			name:    "biweekly by count",
+			pattern: model.SeriesPatternBiweekly,
+			count:   3,
+			want:    []string{"2025-03-03", "2025-03-17", "2025-03-31"},
+		},
+		{
+			name:    "weekly until an end date between occurrences",
+			pattern: model.SeriesPatternWeekly,
+			endDate: "2025-03-20",
+			want:    []string{"2025-03-03", "2025-03-10", "2025-03-17"},
+		},
+		{
+			name:    "end date on an occurrence is included",
+			pattern: model.SeriesPatternBiweekly,
+			endDate: "2025-03-17",
+			want:    []string{"2025-03-03", "2025-03-17"},
+		},
+		{name: "count and end date", pattern: model.SeriesPatternWeekly, count: 2, endDate: "2025-04-01", wantErr: "either count or end_date"},
+		{name: "neither count nor end date", pattern: model.SeriesPatternWeekly, wantErr: "count or end_date is required"},
+		{name: "invalid end date", pattern: model.SeriesPatternWeekly, endDate: "01-04-2025", wantErr: "invalid end_date format"},
+		{name: "end date before first", pattern: model.SeriesPatternWeekly, endDate: "2025-03-01", wantErr: "must not be before"},
+		{name: "single occurrence", pattern: model.SeriesPatternWeekly, count: 1, wantErr: "between 2 and"},
+		{name: "end date leaves one occurrence", pattern: model.SeriesPatternWeekly, endDate: "2025-03-09", wantErr: "between 2 and"},
+		{name: "too many occurrences", pattern: model.SeriesPatternWeekly, count: maxSeriesOccurrences + 1, wantErr: "between 2 and"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := seriesDates(first, tt.pattern, tt.count, tt.endDate)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("seriesDates() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("seriesDates() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("seriesDates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeriesDatesMaxOccurrences(t *testing.T) {
+	first := time.Date(2025, time.December, 29, 0, 0, 0, 0, time.UTC)
+	dates, err := seriesDates(first, model.SeriesPatternWeekly, maxSeriesOccurrences, "")
+	if err != nil {
+		t.Fatalf("seriesDates() error = %v", err)
+	}
+	if len(dates) != maxSeriesOccurrences {
+		t.Fatalf("len(seriesDates()) = %d, want %d", len(dates), maxSeriesOccurrences)
+	}
+	if dates[1] != "2026-01-05" {
+		t.Errorf("second date = %s, want 2026-01-05", dates[1])
+	}
+}
+
+func TestPickSeriesSlots(t *testing.T) {
+	slot := func(id uint, date, status string, blocked bool) model.ScheduleResponse {
+		return model.ScheduleResponse{ID: id, Date: date, Status: status, Blocked: blocked}
+	}
+
+	tests := []struct {
+		name    string
+		found   []model.ScheduleResponse
+		dates   []string
+		wantIDs []uint
+		wantErr string
+	}{
+		{
+			name:    "one slot per date in date order",
+			found:   []model.ScheduleResponse{slot(2, "2025-03-10", "available", false), slot(1, "2025-03-03T00:00:00+07:00", "available", false)},
+			dates:   []string{"2025-03-03", "2025-03-10"},
+			wantIDs: []uint{1, 2},
+		},
+		{
+			name:    "skips booked and blocked candidates",
+			found:   []model.ScheduleResponse{slot(1, "2025-03-03", "booked", false), slot(2, "2025-03-03", "available", true), slot(3, "2025-03-03", "available", false)},
+			dates:   []string{"2025-03-03"},
+			wantIDs: []uint{3},
+		},
+		{
+			name:    "ignores slots with an invalid date",
+			found:   []model.ScheduleResponse{slot(1, "bad", "available", false), slot(2, "2025-03-03", "available", false)},
+			dates:   []string{"2025-03-03"},
+			wantIDs: []uint{2},
+		},
+		{
+			name:    "reports every missing date with its reason",
+			found:   []model.ScheduleResponse{slot(1, "2025-03-03", "available", false), slot(2, "2025-03-10", "booked", false), slot(3, "2025-03-17", "available", true)},
+			dates:   []string{"2025-03-03", "2025-03-10", "2025-03-17", "2025-03-24"},
+			wantErr: "occurrences not available: 2025-03-10 (booked), 2025-03-17 (teacher busy), 2025-03-24 (no slot)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickSeriesSlots(tt.found, tt.dates)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("pickSeriesSlots() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("pickSeriesSlots() error = %v", err)
+			}
+			ids := make([]uint, 0, len(got))
+			for _, slot := range got {
+				ids = append(ids, slot.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("pickSeriesSlots() ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
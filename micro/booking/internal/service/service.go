@@ -1,14 +1,18 @@
 package service
 
 import (
+	"booking/internal/config"
+	"booking/internal/infrastructure/meeting"
 	"booking/internal/infrastructure/payment"
 	"booking/internal/infrastructure/schedule"
+	"booking/internal/infrastructure/supabase"
 	"booking/internal/infrastructure/user"
 	"booking/internal/model"
 	"booking/internal/pkg"
 	"booking/internal/repository"
 	"errors"
 	"fmt"
+	"log"
 	"math"
 	"os"
 	"strconv"
@@ -26,6 +30,13 @@ type Service struct {
 	serviceHttp       *schedule.ScheduleHttp
 	serviceUser       *user.UserService
 	servicePayment    *payment.Payment
+	calendar          config.Calendar
+	meetingProvider   meeting.Provider
+	meeting           config.Meeting
+	waitlist          config.Waitlist
+	reschedule        config.Reschedule
+	messages          *messageHub
+	storage           *supabase.Client
 }
 
 func NewService(
@@ -33,43 +44,35 @@ func NewService(
 	serviceHttp *schedule.ScheduleHttp,
 	serviceUser *user.UserService,
 	servicePayment *payment.Payment,
+	calendar config.Calendar,
+	meetingProvider meeting.Provider,
+	meetingConfig config.Meeting,
+	waitlist config.Waitlist,
+	reschedule config.Reschedule,
+	storage *supabase.Client,
 ) *Service {
 	return &Service{
 		bookingRepository: bookingRepository,
 		serviceHttp:       serviceHttp,
 		serviceUser:       serviceUser,
 		servicePayment:    servicePayment,
+		calendar:          calendar,
+		meetingProvider:   meetingProvider,
+		meeting:           meetingConfig,
+		waitlist:          waitlist,
+		reschedule:        reschedule,
+		messages:          newMessageHub(),
+		storage:           storage,
 	}
 }
 
 func (s *Service) CreateBooking(c *gin.Context, req model.BookingRequest) (*model.Booking, error) {
-	// Check if schedule exists and is available
-	schedule, err := s.serviceHttp.CheckScheduleAvailability(req.ScheduleID)
+	booking, _, err := s.newBooking(c, req)
 	if err != nil {
-		return nil, fmt.Errorf("schedule not available: %w", err)
-	}
-
-	if schedule.Status != "available" {
-		return nil, fmt.Errorf("schedule is not available for booking")
-	}
-
-	// Check for existing booking by same user for this schedule
-	if !cast.ToBool(os.Getenv("IS_NFT")) {
-		existing, err := s.bookingRepository.GetBookingsByUserIDAndId(req.UserID, req.ScheduleID)
-		if err == nil && existing != nil {
-			return nil, fmt.Errorf("you already have a booking for this schedule")
-		}
-	}
-
-	booking := model.Booking{
-		UserID:     req.UserID,
-		ScheduleID: schedule.ID,
-		Note:       req.Note,
-		Status:     "pending",
-		TotalPrice: req.TotalPrice,
+		return nil, err
 	}
 
-	if err := s.bookingRepository.CreateBooking(&booking); err != nil {
+	if err := s.bookingRepository.CreateBooking(booking); err != nil {
 		return nil, fmt.Errorf("failed to create booking: %w", err)
 	}
 
@@ -77,50 +80,111 @@ func (s *Service) CreateBooking(c *gin.Context, req model.BookingRequest) (*mode
 	if cast.ToBool(os.Getenv("IS_NFT")) {
 		statusSchedule = "available"
 	}
-	err = s.serviceHttp.UpdateScheduleStatus(c, schedule.ID, statusSchedule)
+	err = s.serviceHttp.UpdateScheduleStatus(c, booking.ScheduleID, statusSchedule)
 	if err != nil {
 		go s.bookingRepository.DeleteBooking(booking.ID)
 		return nil, fmt.Errorf("failed to update schedule status: %w", err)
 	}
 
-	return &booking, nil
+	return booking, nil
 }
 
-func (s *Service) RescheduleBooking(c *gin.Context, bookingID, newScheduleID, userID uint) error {
-	booking, err := s.bookingRepository.GetBookingsByUserIDAndId(userID, bookingID)
+// newBooking checks that the student may book req.ScheduleID and returns the
+// pending booking for it, priced by the teacher service, together with the
+// schedule. Nothing is stored or reserved yet.
+func (s *Service) newBooking(c *gin.Context, req model.BookingRequest) (*model.Booking, *model.ScheduleResponse, error) {
+	// Check if schedule exists and is available
+	schedule, err := s.serviceHttp.CheckScheduleAvailability(req.ScheduleID)
 	if err != nil {
-		return errors.New("booking not found")
+		return nil, nil, fmt.Errorf("schedule not available: %w", err)
 	}
 
-	if booking.Status != "paid" && booking.Status != "pending" {
-		return errors.New("cannot reschedule this booking")
+	if schedule.Status != "available" {
+		return nil, nil, fmt.Errorf("schedule is not available for booking")
 	}
 
-	_, err = s.serviceHttp.CheckScheduleAvailability(newScheduleID)
+	// A freed slot may be held for a waitlisted student for a while.
+	offer, err := s.bookingRepository.GetActiveOffer(schedule.ID, time.Now())
 	if err != nil {
-		return errors.New("new schedule not available")
+		return nil, nil, fmt.Errorf("failed to check waitlist: %w", err)
+	}
+	if offer != nil && offer.UserID != req.UserID {
+		return nil, nil, fmt.Errorf("schedule is on hold for a waitlisted student")
+	}
+
+	// Check for existing booking by same user for this schedule. Group
+	// classes stay available after the first booking, so one student could
+	// otherwise take several seats.
+	if !cast.ToBool(os.Getenv("IS_NFT")) {
+		booked, err := s.bookingRepository.HasActiveBookingForSchedule(req.UserID, req.ScheduleID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check existing bookings: %w", err)
+		}
+		if booked {
+			return nil, nil, fmt.Errorf("you already have a booking for this schedule")
+		}
 	}
 
-	if err := s.serviceHttp.UpdateScheduleStatus(c, booking.ScheduleID, "available"); err != nil {
-		return errors.New("failed to free previous schedule")
+	// The price is always taken from the teacher service, which derives it
+	// from the schedule's lesson type. The client value is ignored.
+	priced, err := s.serviceHttp.FetchSchedulesByIDs(c, []uint{schedule.ID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get schedule price: %w", err)
 	}
-	if err := s.serviceHttp.UpdateScheduleStatus(c, newScheduleID, "booked"); err != nil {
-		return errors.New("failed to book new schedule")
+	detail, ok := priced[schedule.ID]
+	if !ok {
+		return nil, nil, fmt.Errorf("schedule not available for booking")
 	}
 
-	booking.ScheduleID = newScheduleID
-	booking.Status = "rescheduled"
-	booking.RescheduleFrom = &booking.ID
-	booking.UpdatedAt = time.Now()
+	if detail.IsTrial {
+		hasBooking, err := s.bookingRepository.HasBookingWithTeacher(req.UserID, schedule.TeacherID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check previous bookings: %w", err)
+		}
+		if hasBooking {
+			return nil, nil, fmt.Errorf("trial lesson is only available for new students")
+		}
+	}
 
-	if err := s.bookingRepository.UpdateBooking(booking); err != nil {
-		return errors.New("failed to update booking")
+	booking := model.Booking{
+		UserID:       req.UserID,
+		ScheduleID:   schedule.ID,
+		TeacherID:    schedule.TeacherID,
+		LessonTypeID: detail.LessonTypeID,
+		LessonDate:   parseLessonDate(detail.Date),
+		Note:         req.Note,
+		Status:       "pending",
+		TotalPrice:   detail.TotalPrice,
+		SeriesID:     req.SeriesID,
+		Occurrence:   req.Occurrence,
+	}
+
+	return &booking, &detail, nil
+}
+
+// RescheduleBooking moves a booking to newScheduleID and returns the
+// successor booking that replaces it.
+func (s *Service) RescheduleBooking(c *gin.Context, bookingID, newScheduleID, userID uint) (*model.Booking, error) {
+	booking, err := s.bookingRepository.GetBookingsByUserIDAndId(userID, bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
 	}
 
-	return nil
+	return s.rescheduleBooking(c, booking, newScheduleID, false)
 }
 
 func (s *Service) CancelBookingByID(c *gin.Context, id uint) (*model.Booking, error) {
+	booking, err := s.cancelBooking(c, id, "")
+	if booking != nil {
+		s.notifyCancellation(*booking, "", 0)
+	}
+	return booking, err
+}
+
+// cancelBooking cancels a pending or paid booking and frees its slot. The
+// caller tells the student and the teacher, once it knows what was
+// refunded.
+func (s *Service) cancelBooking(c *gin.Context, id uint, reason string) (*model.Booking, error) {
 	booking, err := s.bookingRepository.GetBooking(id)
 	if err != nil {
 		return nil, fmt.Errorf("booking not found")
@@ -131,20 +195,166 @@ func (s *Service) CancelBookingByID(c *gin.Context, id uint) (*model.Booking, er
 	}
 
 	booking.Status = "cancelled"
+	booking.Sequence++
 	booking.UpdatedAt = time.Now()
 
 	if err := s.bookingRepository.UpdateBooking(booking); err != nil {
 		return nil, err
 	}
 
-	err = s.serviceHttp.CancelSchedule(c, booking.ScheduleID)
+	// The slot is freed rather than cancelled so it can be offered to the
+	// waitlist. The booking is returned with the error, as it is cancelled
+	// already.
+	err = s.serviceHttp.UpdateScheduleStatus(c, booking.ScheduleID, "available")
 	if err != nil {
-		return nil, err
+		return booking, err
 	}
+	go s.offerSlot(booking.ScheduleID)
 
 	return booking, nil
 }
 
+// CancelBookingsBySchedules cancels every pending or paid booking that holds
+// one of the given schedules and refunds the paid ones. It is used by the
+// teacher service after a teacher cancelled slots, so the schedule status
+// itself is left untouched here. Each booking is cancelled on its own; the
+// ones that fail are reported and stay active, so calling this again for
+// the same schedules retries them.
+func (s *Service) CancelBookingsBySchedules(scheduleIDs []uint, reason string) ([]model.Booking, []model.CancelBySchedulesFailure, error) {
+	bookings, err := s.bookingRepository.GetActiveBookingsByScheduleIDs(scheduleIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cancelled := make([]model.Booking, 0, len(bookings))
+	var failed []model.CancelBySchedulesFailure
+	for i := range bookings {
+		booking := &bookings[i]
+		if err := s.cancelForSchedule(booking, reason); err != nil {
+			log.Printf("cancel by schedules: booking %d: %v", booking.ID, err)
+			failed = append(failed, model.CancelBySchedulesFailure{
+				BookingID:  booking.ID,
+				ScheduleID: booking.ScheduleID,
+				Error:      err.Error(),
+			})
+			continue
+		}
+		cancelled = append(cancelled, *booking)
+	}
+
+	return cancelled, failed, nil
+}
+
+// cancelForSchedule refunds and cancels a booking whose slot was cancelled.
+// The refund is keyed to the booking, so if storing the cancellation fails
+// a retry does not refund it twice.
+func (s *Service) cancelForSchedule(booking *model.Booking, reason string) error {
+	refunded, err := s.refundCancelled(booking, reason)
+	if err != nil {
+		return err
+	}
+
+	booking.Status = "cancelled"
+	booking.Sequence++
+	booking.UpdatedAt = time.Now()
+	if err := s.bookingRepository.UpdateBooking(booking); err != nil {
+		return fmt.Errorf("failed to cancel booking: %w", err)
+	}
+	s.notifyCancellation(*booking, reason, refunded)
+	return nil
+}
+
+// refundBooking refunds the payment attached to a paid booking and returns
+// the amount refunded. Bookings that were never paid have nothing to refund
+// and are skipped. A zero amount refunds the remaining paid amount.
+// reference identifies the refund to the payment service, which refunds it
+// only once however often it is asked.
+func (s *Service) refundBooking(booking *model.Booking, amount float64, reason, reference string) (float64, error) {
+	if booking.Status != "paid" || booking.PaymentID == nil || *booking.PaymentID == 0 {
+		return 0, nil
+	}
+
+	if reason == "" {
+		reason = fmt.Sprintf("Booking #%d cancelled", booking.ID)
+	}
+
+	refunded, err := s.servicePayment.RefundPayment(*booking.PaymentID, amount, reason, reference)
+	if err != nil {
+		return 0, fmt.Errorf("failed to refund booking %d: %w", booking.ID, err)
+	}
+
+	return refunded, nil
+}
+
+// refundCancelled refunds a paid booking that is being cancelled and
+// returns the amount refunded. Bookings that were never paid are skipped.
+func (s *Service) refundCancelled(booking *model.Booking, reason string) (float64, error) {
+	if booking.Status != "paid" {
+		return 0, nil
+	}
+	return s.refundPayments(booking, reason)
+}
+
+// refundPayments refunds the lesson of a cancelled booking from its
+// payment and, when a reschedule to a dearer slot left a balance that was
+// paid, the balance payment in full. Each payment is refunded under its
+// own reference, so retrying after one of them failed does not refund the
+// other twice.
+func (s *Service) refundPayments(booking *model.Booking, reason string) (float64, error) {
+	if booking.PaymentID == nil || *booking.PaymentID == 0 {
+		return 0, nil
+	}
+	if reason == "" {
+		reason = fmt.Sprintf("Booking #%d cancelled", booking.ID)
+	}
+
+	var balance float64
+	if booking.BalancePaymentID != nil && *booking.BalancePaymentID != 0 {
+		refunded, err := s.servicePayment.RefundPayment(*booking.BalancePaymentID, 0, reason, balanceRefundReference(booking.ID))
+		if err != nil {
+			return 0, fmt.Errorf("failed to refund balance of booking %d: %w", booking.ID, err)
+		}
+		balance = refunded
+	}
+
+	// The lesson price includes the balance, which was refunded already.
+	amount := lessonRefund(booking)
+	if amount > 0 {
+		amount = math.Round((amount-balance)*100) / 100
+		if amount <= 0 {
+			return balance, nil
+		}
+	}
+	refunded, err := s.servicePayment.RefundPayment(*booking.PaymentID, amount, reason, cancelRefundReference(booking.ID))
+	if err != nil {
+		return balance, fmt.Errorf("failed to refund booking %d: %w", booking.ID, err)
+	}
+	return balance + refunded, nil
+}
+
+// lessonRefund is the amount cancelling a paid booking gives back, as
+// passed to refundBooking. A payment of an upfront series covers several
+// lessons, so only this lesson's price is refunded from it; any other
+// payment is refunded in full.
+func lessonRefund(booking *model.Booking) float64 {
+	if booking.SeriesID != nil {
+		return booking.TotalPrice
+	}
+	return 0
+}
+
+// cancelRefundReference identifies the refund of a cancelled booking. A
+// booking is cancelled only once, so it has at most one.
+func cancelRefundReference(bookingID uint) string {
+	return fmt.Sprintf("booking:%d:cancel", bookingID)
+}
+
+// balanceRefundReference identifies the refund of the balance payment of a
+// cancelled booking.
+func balanceRefundReference(bookingID uint) string {
+	return fmt.Sprintf("booking:%d:cancel:balance", bookingID)
+}
+
 func (s *Service) GetUserBookings(c *gin.Context, userID uint, isAdmin bool, pg pkg.Pagination) (model.PaginatedBookingsResponse, error) {
 
 	page, _ := strconv.Atoi(pg.PageStr)
@@ -281,6 +491,21 @@ func (s *Service) UpdateBookingStatus(id uint, paymentID uint, status string) (*
 		return nil, fmt.Errorf("booking not found")
 	}
 
+	// A paid booking with a balance due only waits for the payment of the
+	// price difference of a reschedule.
+	if booking.Status == "paid" && booking.BalanceDue > 0 {
+		if status != "paid" {
+			return booking, nil
+		}
+		booking.BalanceDue = 0
+		booking.BalancePaymentID = &paymentID
+		booking.UpdatedAt = time.Now()
+		if err := s.bookingRepository.UpdateBooking(booking); err != nil {
+			return nil, err
+		}
+		return booking, nil
+	}
+
 	booking.Status = status
 	booking.PaymentID = &paymentID
 	booking.UpdatedAt = time.Now()
@@ -289,6 +514,12 @@ func (s *Service) UpdateBookingStatus(id uint, paymentID uint, status string) (*
 		return nil, err
 	}
 
+	if status == "paid" {
+		s.openMeetingRoom(booking)
+		s.notifyBooking(*booking, eventBookingConfirmed, nil)
+	}
+	s.applySeriesPayment(booking, paymentID, status)
+
 	return booking, nil
 }
 
@@ -376,6 +607,17 @@ func (s *Service) GetUpcomingLessons(c *gin.Context, userID uint, limit int) ([]
 	if len(upcoming) > limit {
 		upcoming = upcoming[:limit]
 	}
+
+	bookingsByID := make(map[uint]*model.Booking, len(bookings))
+	for i := range bookings {
+		bookingsByID[bookings[i].ID] = &bookings[i]
+	}
+	viewerID := cast.ToUint(c.GetString("user_id"))
+	for i := range upcoming {
+		if booking, ok := bookingsByID[upcoming[i].ID]; ok {
+			upcoming[i].Meeting = s.meetingInfo(booking, upcoming[i].Schedule, viewerID)
+		}
+	}
 	return upcoming, nil
 }
 
@@ -429,6 +671,7 @@ func (s *Service) BookingDetail(c *gin.Context, id uint) (*model.BookingResponse
 		Schedule:   schedule,
 		User:       &user,
 		Payment:    payment,
+		Meeting:    s.meetingInfo(booking, schedule, cast.ToUint(c.GetString("user_id"))),
 	}, nil
 }
 
@@ -533,7 +776,7 @@ func (s *Service) GetTeacherBookings(c *gin.Context, teacherID uint, pg pkg.Pagi
 			StartTime:   schedule.StartTime,
 			EndTime:     schedule.EndTime,
 			Status:      booking.Status,
-			Price:       schedule.TotalPrice,
+			Price:       bookingPrice(booking, schedule),
 			CreatedAt:   booking.CreatedAt,
 		}
 
@@ -565,7 +808,12 @@ func (s *Service) DeleteBooking(c *gin.Context, id uint) error {
 		return err
 	}
 
-	go s.serviceHttp.UpdateScheduleStatus(c, booking.ScheduleID, "available")
+	go func() {
+		if err := s.serviceHttp.UpdateScheduleStatus(c, booking.ScheduleID, "available"); err != nil {
+			return
+		}
+		s.offerSlot(booking.ScheduleID)
+	}()
 
 	return nil
 }
@@ -681,3 +929,12 @@ func (s *Service) GetEnrichedBookings(c *gin.Context, pg pkg.Paginate, status st
 
 	return model.PaginatedBookingsResponse{Data: items, Pagination: model.PaginationMeta{Page: pg.Page, Limit: pg.Limit, Total: resp.Pagination.TotalData, TotalPages: resp.Pagination.TotalPage}}, nil
 }
+
+// bookingPrice returns the price charged for the booking. Bookings created
+// before prices were stored fall back to the current schedule price.
+func bookingPrice(booking model.Booking, schedule model.ScheduleResponse) float64 {
+	if booking.TotalPrice > 0 {
+		return booking.TotalPrice
+	}
+	return schedule.TotalPrice
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"booking/internal/config"
+	"booking/internal/infrastructure/payment"
+	"booking/internal/model"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+)
+
+type refundCall struct {
+	Path      string
+	Amount    float64
+	Reference string
+}
+
+// refundServer fakes the refund endpoint of the payment service. Each
+// payment refunds the amount asked for, or paid[id] when asked for zero.
+func refundServer(t *testing.T, paid map[uint]float64, calls *[]refundCall) *Service {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Amount    float64 `json:"amount"`
+			Reference string  `json:"reference"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode refund body: %v", err)
+		}
+		*calls = append(*calls, refundCall{Path: r.URL.Path, Amount: body.Amount, Reference: body.Reference})
+
+		var id uint
+		fmt.Sscanf(r.URL.Path, "/api/v1/internal/payments/%d/refund", &id)
+		amount := body.Amount
+		if amount == 0 {
+			amount = paid[id]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"refund": map[string]any{"amount": amount}})
+	}))
+	t.Cleanup(srv.Close)
+
+	return &Service{servicePayment: payment.NewPaymentHttp(config.Service{Host: srv.URL}, resty.New())}
+}
+
+func TestRefundPayments(t *testing.T) {
+	paymentID, balanceID, seriesID := uint(7), uint(9), uint(3)
+
+	tests := []struct {
+		name    string
+		booking model.Booking
+		want    float64
+		calls   []refundCall
+	}{
+		{
+			name:    "lesson payment only",
+			booking: model.Booking{ID: 1, PaymentID: &paymentID, TotalPrice: 100},
+			want:    100,
+			calls: []refundCall{
+				{Path: "/api/v1/internal/payments/7/refund", Reference: "booking:1:cancel"},
+			},
+		},
+		{
+			name:    "balance payment is refunded under its own reference",
+			booking: model.Booking{ID: 2, PaymentID: &paymentID, BalancePaymentID: &balanceID, TotalPrice: 150},
+			want:    150,
+			calls: []refundCall{
+				{Path: "/api/v1/internal/payments/9/refund", Reference: "booking:2:cancel:balance"},
+				{Path: "/api/v1/internal/payments/7/refund", Reference: "booking:2:cancel"},
+			},
+		},
+		{
+			name:    "series lesson refunds its price less the balance",
+			booking: model.Booking{ID: 3, PaymentID: &paymentID, BalancePaymentID: &balanceID, SeriesID: &seriesID, TotalPrice: 150},
+			want:    150,
+			calls: []refundCall{
+				{Path: "/api/v1/internal/payments/9/refund", Reference: "booking:3:cancel:balance"},
+				{Path: "/api/v1/internal/payments/7/refund", Amount: 100, Reference: "booking:3:cancel"},
+			},
+		},
+		{
+			name:    "never paid",
+			booking: model.Booking{ID: 4, TotalPrice: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []refundCall
+			s := refundServer(t, map[uint]float64{paymentID: 100, balanceID: 50}, &calls)
+
+			got, err := s.refundPayments(&tt.booking, "")
+			if err != nil {
+				t.Fatalf("refundPayments() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("refundPayments() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(calls, tt.calls) {
+				t.Errorf("refund calls = %+v, want %+v", calls, tt.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"booking/internal/infrastructure/schedule"
+	"booking/internal/infrastructure/user"
+	"booking/internal/model"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultWaitlistHold = 30 * time.Minute
+	// maxWaitlistRangeDays bounds "any slot" entries so they do not wait
+	// forever.
+	maxWaitlistRangeDays = 90
+	waitlistTick         = time.Minute
+)
+
+// JoinWaitlist puts the student on the waitlist of a fully booked slot, or
+// of any slot of a teacher in a date range.
+func (s *Service) JoinWaitlist(userID uint, req model.WaitlistRequest) (*model.WaitlistEntry, error) {
+	entry := &model.WaitlistEntry{UserID: userID, Status: model.WaitlistStatusWaiting}
+
+	if req.ScheduleID != 0 {
+		schedule, err := s.serviceHttp.CheckScheduleAvailability(req.ScheduleID)
+		if err != nil {
+			return nil, errors.New("schedule not found")
+		}
+		switch schedule.Status {
+		case "cancelled":
+			return nil, errors.New("schedule is cancelled")
+		case "available":
+			offer, err := s.bookingRepository.GetActiveOffer(schedule.ID, time.Now())
+			if err != nil {
+				return nil, errors.New("failed to check waitlist")
+			}
+			if offer == nil {
+				return nil, errors.New("schedule is available, book it directly")
+			}
+		}
+
+		booked, err := s.bookingRepository.HasActiveBookingForSchedule(userID, schedule.ID)
+		if err != nil {
+			return nil, errors.New("failed to check bookings")
+		}
+		if booked {
+			return nil, errors.New("you already have a booking for this schedule")
+		}
+		waiting, err := s.bookingRepository.HasOpenWaitlistEntry(userID, schedule.ID)
+		if err != nil {
+			return nil, errors.New("failed to check waitlist")
+		}
+		if waiting {
+			return nil, errors.New("you are already on the waitlist for this schedule")
+		}
+
+		entry.TeacherID = schedule.TeacherID
+		entry.ScheduleID = &schedule.ID
+	} else {
+		if req.TeacherID == 0 {
+			return nil, errors.New("schedule_id or teacher_id is required")
+		}
+		from, err := time.Parse("2006-01-02", req.DateFrom)
+		if err != nil {
+			return nil, errors.New("invalid date_from format, should be 2006-01-02")
+		}
+		to, err := time.Parse("2006-01-02", req.DateTo)
+		if err != nil {
+			return nil, errors.New("invalid date_to format, should be 2006-01-02")
+		}
+		if to.Before(from) {
+			return nil, errors.New("date_to must not be before date_from")
+		}
+		if to.Sub(from) > maxWaitlistRangeDays*24*time.Hour {
+			return nil, fmt.Errorf("date range must not exceed %d days", maxWaitlistRangeDays)
+		}
+		if to.Format("2006-01-02") < time.Now().Format("2006-01-02") {
+			return nil, errors.New("date range is in the past")
+		}
+
+		entry.TeacherID = req.TeacherID
+		entry.DateFrom = &from
+		entry.DateTo = &to
+	}
+
+	if err := s.bookingRepository.CreateWaitlistEntry(entry); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to join waitlist")
+	}
+	return entry, nil
+}
+
+func (s *Service) GetMyWaitlist(userID uint) ([]model.WaitlistEntry, error) {
+	entries, err := s.bookingRepository.GetUserWaitlist(userID)
+	if err != nil {
+		return nil, errors.New("failed to get waitlist")
+	}
+	return entries, nil
+}
+
+// LeaveWaitlist cancels the student's entry. A slot they were holding is
+// offered to the next student right away.
+func (s *Service) LeaveWaitlist(userID, id uint) error {
+	entry, err := s.userWaitlistEntry(userID, id)
+	if err != nil {
+		return err
+	}
+	if entry.Status != model.WaitlistStatusWaiting && entry.Status != model.WaitlistStatusOffered {
+		return errors.New("waitlist entry is already closed")
+	}
+
+	ok, err := s.bookingRepository.SetWaitlistStatus(entry.ID, entry.Status, model.WaitlistStatusCancelled, nil)
+	if err != nil {
+		return errors.New("failed to leave waitlist")
+	}
+	if ok && entry.Status == model.WaitlistStatusOffered && entry.OfferedScheduleID != nil {
+		go s.passOffer(*entry.OfferedScheduleID)
+	}
+	return nil
+}
+
+// ClaimWaitlistOffer books the slot held for the student.
+func (s *Service) ClaimWaitlistOffer(c *gin.Context, userID, id uint) (*model.Booking, error) {
+	entry, err := s.userWaitlistEntry(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != model.WaitlistStatusOffered || entry.OfferedScheduleID == nil {
+		return nil, errors.New("no slot is offered for this waitlist entry")
+	}
+	if entry.OfferExpiresAt == nil || time.Now().After(*entry.OfferExpiresAt) {
+		return nil, errors.New("the offer has expired")
+	}
+
+	booking, err := s.CreateBooking(c, model.BookingRequest{ScheduleID: *entry.OfferedScheduleID, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.bookingRepository.SetWaitlistStatus(entry.ID, model.WaitlistStatusOffered, model.WaitlistStatusClaimed, &booking.ID); err != nil {
+		log.Printf("waitlist: failed to mark entry %d claimed: %v", entry.ID, err)
+	}
+	return booking, nil
+}
+
+// SlotsPublished offers newly published slots to waiting students.
+func (s *Service) SlotsPublished(req model.SlotsPublishedRequest) {
+	for _, id := range req.ScheduleIDs {
+		s.offerSlot(id)
+	}
+}
+
+// RunWaitlist expires offers whose hold ran out, passing their slot on to
+// the next student, and closes range entries that lie in the past. It
+// blocks and is meant to run in its own goroutine.
+func (s *Service) RunWaitlist() {
+	ticker := time.NewTicker(waitlistTick)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.expireWaitlistOffers()
+	}
+}
+
+func (s *Service) expireWaitlistOffers() {
+	now := time.Now()
+	offers, err := s.bookingRepository.GetExpiredOffers(now)
+	if err != nil {
+		log.Printf("waitlist: failed to get expired offers: %v", err)
+		return
+	}
+	for _, offer := range offers {
+		ok, err := s.bookingRepository.SetWaitlistStatus(offer.ID, model.WaitlistStatusOffered, model.WaitlistStatusExpired, nil)
+		if err != nil {
+			log.Printf("waitlist: failed to expire entry %d: %v", offer.ID, err)
+			continue
+		}
+		if ok && offer.OfferedScheduleID != nil {
+			s.passOffer(*offer.OfferedScheduleID)
+		}
+	}
+
+	today, _ := time.Parse("2006-01-02", now.Format("2006-01-02"))
+	if err := s.bookingRepository.ExpireWaitingEntries(today); err != nil {
+		log.Printf("waitlist: failed to expire past entries: %v", err)
+	}
+}
+
+// passOffer offers a slot whose holder let go of it to the next student,
+// or releases the hold on it when nobody else is waiting.
+func (s *Service) passOffer(scheduleID uint) {
+	if s.offerSlot(scheduleID) {
+		return
+	}
+	if err := s.serviceHttp.HoldSchedule(scheduleID, nil); err != nil {
+		log.Printf("waitlist: failed to release hold on schedule %d: %v", scheduleID, err)
+	}
+}
+
+// offerSlot holds a freed slot for the next waiting student, if any, and
+// reports whether it did. Slots that are not bookable (anymore) or already
+// held are left alone. The teacher service hides a held slot from searches.
+func (s *Service) offerSlot(scheduleID uint) bool {
+	schedule, err := s.serviceHttp.CheckScheduleAvailability(scheduleID)
+	if err != nil {
+		log.Printf("waitlist: schedule %d: %v", scheduleID, err)
+		return false
+	}
+	if schedule.Status != "available" {
+		return false
+	}
+	date := parseLessonDate(schedule.Date)
+	if date == nil {
+		return false
+	}
+
+	hold := s.waitlist.HoldDuration
+	if hold <= 0 {
+		hold = defaultWaitlistHold
+	}
+	now := time.Now()
+	entry, err := s.bookingRepository.OfferSlotToNext(scheduleID, schedule.TeacherID, *date, now, now.Add(hold))
+	if err != nil {
+		log.Printf("waitlist: failed to offer schedule %d: %v", scheduleID, err)
+		return false
+	}
+	if entry == nil {
+		return false
+	}
+	log.Printf("waitlist: schedule %d offered to user %d until %s", scheduleID, entry.UserID, entry.OfferExpiresAt.Format(time.RFC3339))
+	if err := s.serviceHttp.HoldSchedule(scheduleID, entry.OfferExpiresAt); err != nil {
+		log.Printf("waitlist: failed to hold schedule %d: %v", scheduleID, err)
+	}
+	go s.notifyWaitlistOffer(*entry, schedule)
+	return true
+}
+
+// notifyWaitlistOffer tells the student that a slot is held for them. It
+// has no email template, so it only goes to the notification center.
+func (s *Service) notifyWaitlistOffer(entry model.WaitlistEntry, slot *schedule.ScheduleResponse) {
+	description := fmt.Sprintf("A lesson on %s at %s is held for you until %s",
+		dateOnly(slot.Date), clockOnly(slot.StartTime), entry.OfferExpiresAt.Format("2006-01-02 15:04"))
+	notification := user.ActivityNotification{
+		Title:     "A waitlisted slot opened up",
+		Message:   description + ". Book it before the hold runs out.",
+		Link:      "/bookings",
+		Reference: fmt.Sprintf("waitlist_offer:%d", entry.ID),
+	}
+	if err := s.serviceUser.CreateActivityNotification(entry.UserID, "waitlist_offer", description, notification); err != nil {
+		log.Printf("waitlist: failed to notify user %d of entry %d: %v", entry.UserID, entry.ID, err)
+	}
+}
+
+func (s *Service) userWaitlistEntry(userID, id uint) (*model.WaitlistEntry, error) {
+	entry, err := s.bookingRepository.GetWaitlistEntry(id)
+	if err != nil {
+		if err.Error() == "waitlist entry not found" {
+			return nil, err
+		}
+		return nil, errors.New("failed to get waitlist entry")
+	}
+	if entry.UserID != userID {
+		return nil, errors.New("waitlist entry not found")
+	}
+	return entry, nil
+}
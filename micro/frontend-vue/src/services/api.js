@@ -259,6 +259,38 @@ export const userService = {
   async toggleFavoriteTeacher(payload) {
     const response = await apiClient.post(`${API_CONFIG.USER_SERVICE}/favorites`, payload)
     return response.data
+  },
+
+  /**
+   * List the user's in-app notifications, newest first, with the
+   * `unread_count`.
+   *
+   * @param {Object} params Query parameters, such as { unread: true, page: 1 }.
+   */
+  async getNotifications(params = {}) {
+    const response = await apiClient.get(`${API_CONFIG.USER_SERVICE}/notifications`, { params })
+    return response.data
+  },
+
+  async markNotificationRead(id) {
+    const response = await apiClient.post(`${API_CONFIG.USER_SERVICE}/notifications/${id}/read`)
+    return response.data
+  },
+
+  async markAllNotificationsRead() {
+    const response = await apiClient.post(`${API_CONFIG.USER_SERVICE}/notifications/read-all`)
+    return response.data
+  },
+
+  /**
+   * Open the live notification stream. EventSource cannot send the
+   * Authorization header, so a short-lived stream token is fetched first
+   * and passed in the URL.
+   */
+  async openNotificationStream() {
+    const response = await apiClient.post(`${API_CONFIG.USER_SERVICE}/notifications/stream-token`)
+    const token = encodeURIComponent(response.data.data.token)
+    return new EventSource(`${API_CONFIG.USER_SERVICE}/notifications/stream?token=${token}`)
   }
 
 }
@@ -0,0 +1,124 @@
+import { defineStore } from 'pinia'
+import { ref } from 'vue'
+import { userService } from '@/services/api'
+import { useUIStore } from '@/stores/ui'
+
+// Delay before reopening a dropped stream. Each attempt fetches a new
+// stream token, as the one in the old URL may have expired.
+const RECONNECT_DELAY = 5000
+
+// The notifications store keeps the user's in-app notifications and unread
+// count up to date over the user service's server-sent event stream, so
+// nothing needs to poll.
+export const useNotificationsStore = defineStore('notifications', () => {
+  const notifications = ref([])
+  const unreadCount = ref(0)
+
+  let stream = null
+  let reconnectTimer = null
+  let active = false
+
+  const fetchNotifications = async () => {
+    try {
+      const response = await userService.getNotifications()
+      notifications.value = response.data || []
+      unreadCount.value = response.unread_count || 0
+    } catch (error) {
+      console.error('Failed to fetch notifications:', error)
+    }
+  }
+
+  const scheduleReconnect = () => {
+    if (!active || reconnectTimer) return
+    reconnectTimer = setTimeout(() => {
+      reconnectTimer = null
+      openStream()
+    }, RECONNECT_DELAY)
+  }
+
+  const openStream = async () => {
+    try {
+      const source = await userService.openNotificationStream()
+      if (!active) {
+        source.close()
+        return
+      }
+      stream = source
+
+      source.addEventListener('ready', (event) => {
+        unreadCount.value = JSON.parse(event.data).unread_count
+        // Catch up on what was missed while disconnected.
+        fetchNotifications()
+      })
+      source.addEventListener('notification', (event) => {
+        const data = JSON.parse(event.data)
+        notifications.value.unshift(data.notification)
+        unreadCount.value = data.unread_count
+        useUIStore().showInfo(data.notification.title)
+      })
+      source.addEventListener('read', (event) => {
+        const data = JSON.parse(event.data)
+        const readAt = new Date().toISOString()
+        notifications.value.forEach((notification) => {
+          if (!data.notification_id || notification.id === data.notification_id) {
+            notification.read_at = notification.read_at || readAt
+          }
+        })
+        unreadCount.value = data.unread_count
+      })
+      // The browser would retry with the same, soon expired, token.
+      source.onerror = () => {
+        source.close()
+        if (stream === source) stream = null
+        scheduleReconnect()
+      }
+    } catch (error) {
+      console.error('Failed to open notification stream:', error)
+      scheduleReconnect()
+    }
+  }
+
+  const connect = () => {
+    if (active) return
+    active = true
+    openStream()
+  }
+
+  const disconnect = () => {
+    active = false
+    clearTimeout(reconnectTimer)
+    reconnectTimer = null
+    stream?.close()
+    stream = null
+    notifications.value = []
+    unreadCount.value = 0
+  }
+
+  const markRead = async (id) => {
+    try {
+      const response = await userService.markNotificationRead(id)
+      unreadCount.value = response.unread_count
+    } catch (error) {
+      console.error('Failed to mark notification read:', error)
+    }
+  }
+
+  const markAllRead = async () => {
+    try {
+      await userService.markAllNotificationsRead()
+      unreadCount.value = 0
+    } catch (error) {
+      console.error('Failed to mark notifications read:', error)
+    }
+  }
+
+  return {
+    notifications,
+    unreadCount,
+    connect,
+    disconnect,
+    fetchNotifications,
+    markRead,
+    markAllRead
+  }
+})
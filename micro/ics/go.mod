@@ -0,0 +1,3 @@
+module ics
+
+go 1.24.3
@@ -0,0 +1,121 @@
+// Package ics writes the iCalendar feeds the booking and teacher services
+// publish, so both render events the same way.
+package ics
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event statuses of an iCalendar VEVENT.
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+)
+
+const icsTimeFormat = "20060102T150405Z"
+
+// Event is a single VEVENT. Start, End and Updated are written in UTC so
+// the feed does not depend on the subscriber's time zone. Sequence must grow
+// whenever the event is moved or cancelled.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Updated     time.Time
+	Status      string
+	Sequence    int
+}
+
+// Write renders a VCALENDAR with the given events. product names the
+// service in PRODID. timezone is only a display hint (X-WR-TIMEZONE) for
+// clients; all times are in UTC.
+func Write(product, name, timezone string, events []Event) string {
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//booking-teacher-app//"+product+"//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:"+escapeICSText(name))
+	if timezone != "" {
+		writeICSLine(&b, "X-WR-TIMEZONE:"+timezone)
+	}
+
+	now := time.Now().UTC().Format(icsTimeFormat)
+	for _, event := range events {
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+event.UID)
+		writeICSLine(&b, "DTSTAMP:"+now)
+		writeICSLine(&b, "DTSTART:"+event.Start.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "DTEND:"+event.End.UTC().Format(icsTimeFormat))
+		if !event.Updated.IsZero() {
+			writeICSLine(&b, "LAST-MODIFIED:"+event.Updated.UTC().Format(icsTimeFormat))
+		}
+		writeICSLine(&b, fmt.Sprintf("SEQUENCE:%d", event.Sequence))
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(event.Summary))
+		if event.Description != "" {
+			writeICSLine(&b, "DESCRIPTION:"+escapeICSText(event.Description))
+		}
+		if event.Location != "" {
+			writeICSLine(&b, "LOCATION:"+escapeICSText(event.Location))
+		}
+		if event.URL != "" {
+			writeICSLine(&b, "URL:"+event.URL)
+		}
+		if event.Status != "" {
+			writeICSLine(&b, "STATUS:"+event.Status)
+		}
+		writeICSLine(&b, "END:VEVENT")
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// escapeICSText escapes a TEXT value as required by RFC 5545.
+func escapeICSText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeICSLine writes a content line folded at 75 octets, without splitting
+// multi-byte characters, and terminated by CRLF.
+func writeICSLine(b *strings.Builder, line string) {
+	// Continuation lines start with a space, which counts toward the limit.
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// NewToken returns a random token for a feed URL.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
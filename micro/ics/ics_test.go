@@ -0,0 +1,47 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestWriteFoldsAndEscapes(t *testing.T) {
+	start := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	out := Write("booking", "Lessons", "Asia/Jakarta", []Event{{
+		UID:         "booking-1@booking-teacher-app",
+		Summary:     "Japanese lesson; N5, kana",
+		Description: strings.Repeat("日本語", 20),
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Status:      StatusCancelled,
+	}})
+
+	for _, want := range []string{
+		"PRODID:-//booking-teacher-app//booking//EN\r\n",
+		"X-WR-TIMEZONE:Asia/Jakarta\r\n",
+		"DTSTART:20260301T020000Z\r\n",
+		`SUMMARY:Japanese lesson\; N5\, kana` + "\r\n",
+		"STATUS:CANCELLED\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "LOCATION:") {
+		t.Errorf("unexpected LOCATION for an event without one")
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a character: %q", line)
+		}
+	}
+	if !strings.Contains(strings.ReplaceAll(out, "\r\n ", ""), "DESCRIPTION:"+strings.Repeat("日本語", 20)) {
+		t.Errorf("folded description does not unfold to the original")
+	}
+}
@@ -26,10 +26,17 @@ func main() {
 		type (
 			Payment       = model.Payment
 			PaymentMethod = model.PaymentMethod
+			Refund        = model.Refund
 		)
-		if err := db.AutoMigrate(&Payment{}, &PaymentMethod{}); err != nil {
+		if err := db.AutoMigrate(&Payment{}, &PaymentMethod{}, &Refund{}); err != nil {
 			zerolog.Info().Err(err).Msg("failed to auto migrate payment service database")
 		}
+		// Refund references are unique per payment, not across payments.
+		if db.Migrator().HasIndex(&Refund{}, "idx_refunds_reference") {
+			if err := db.Migrator().DropIndex(&Refund{}, "idx_refunds_reference"); err != nil {
+				zerolog.Info().Err(err).Msg("failed to drop old refund reference index")
+			}
+		}
 	}
 	gin.SetMode(c.GIN_MODE)
 
@@ -80,6 +87,13 @@ func main() {
 	callback := r.Group("/api/v1")
 	callback.POST("/payments/callback", paymentHandler.HandleWebhook)
 
+	// Internal routes for service-to-service calls (e.g. the booking service
+	// refunding a cancelled lesson). They move money and are reachable on
+	// the public port, so they require the secret the services share.
+	internal := r.Group("/api/v1/internal")
+	internal.Use(middleware.InternalMiddleware(c.InternalSecret))
+	internal.POST("/payments/:id/refund", paymentHandler.RefundPaymentInternal)
+
 	// Payment method CRUD routes
 	crudMethods := r.Group("/api/v1/admin/payment-methods")
 	crudMethods.Use(middleware.AuthMiddleware(&c.JWT))
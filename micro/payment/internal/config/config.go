@@ -19,6 +19,7 @@ type Config struct {
 	AppPort           string
 	GIN_MODE          string
 	JWT               JWT
+	InternalSecret    string
 	ServiceBooking    Service
 	ServiceUser       Service
 	Midtrans          Midtrans
@@ -30,9 +31,16 @@ type JWT struct {
 	TokenDuration int
 }
 
+// InternalSecretHeader carries the secret the services share. Internal
+// routes that change bookings, move money or expose users require it.
+const InternalSecretHeader = "X-Internal-Secret"
+
 type Service struct {
 	Host string
 	Port string
+	// InternalSecret is sent in InternalSecretHeader to the internal routes
+	// of the service that require it.
+	InternalSecret string
 }
 
 type Midtrans struct {
@@ -47,6 +55,8 @@ func LoadConfig() *Config {
 		log.Println("No .env file found or failed to load")
 	}
 
+	internalSecret := os.Getenv("INTERNAL_SERVICE_SECRET")
+
 	return &Config{
 		MysqlDSN:          os.Getenv("MYSQL_DSN"),
 		AppPort:           os.Getenv("APP_PORT"),
@@ -58,13 +68,16 @@ func LoadConfig() *Config {
 			SecretKey:     os.Getenv("JWT_SECRET_KEY"),
 			TokenDuration: cast.ToInt(os.Getenv("JWT_TOKEN_DURATION")),
 		},
+		InternalSecret: internalSecret,
 		ServiceBooking: Service{
-			Host: os.Getenv("SERVICE_BOOKING_HOST"),
-			Port: os.Getenv("SERVICE_BOOKING_PORT"),
+			Host:           os.Getenv("SERVICE_BOOKING_HOST"),
+			Port:           os.Getenv("SERVICE_BOOKING_PORT"),
+			InternalSecret: internalSecret,
 		},
 		ServiceUser: Service{
-			Host: os.Getenv("SERVICE_USER_HOST"),
-			Port: os.Getenv("SERVICE_USER_PORT"),
+			Host:           os.Getenv("SERVICE_USER_HOST"),
+			Port:           os.Getenv("SERVICE_USER_PORT"),
+			InternalSecret: internalSecret,
 		},
 		Midtrans: Midtrans{
 			ServerKey:        os.Getenv("MIDTRANS_SERVER_KEY"),
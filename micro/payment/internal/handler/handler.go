@@ -3,6 +3,7 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"payment/internal/model"
 	"payment/internal/service"
 	"time"
 
@@ -24,7 +25,6 @@ func NewHandler(paymentService *service.Service) *Handler {
 func (c *Handler) CreatePayment(ctx *gin.Context) {
 	var req struct {
 		BookingID     uint   `json:"booking_id"`
-		Amount        int64  `json:"amount"`
 		PaymentMethod string `json:"payment_method"`
 	}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -34,9 +34,9 @@ func (c *Handler) CreatePayment(ctx *gin.Context) {
 
 	orderID := fmt.Sprintf("BOOK-%d-%d", req.BookingID, time.Now().Unix())
 
-	url, err := c.paymentService.CreatePayment(ctx, orderID, req.BookingID, req.Amount, req.PaymentMethod)
+	url, err := c.paymentService.CreatePayment(ctx, orderID, req.BookingID, req.PaymentMethod)
 	if err != nil {
-		if err.Error() == "booking not found" || err.Error() == "payment method not found or not active" {
+		if err.Error() == "booking not found" || err.Error() == "booking has nothing to pay" || err.Error() == "payment method not found or not active" {
 			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 			return
 		}
@@ -111,3 +111,30 @@ func (h *Handler) GetPayments(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, payments)
 }
+
+// RefundPaymentInternal refunds a settled payment on behalf of another
+// service (e.g. the booking service when a lesson is cancelled). It is
+// registered outside the authenticated group and must not be exposed
+// publicly.
+func (h *Handler) RefundPaymentInternal(ctx *gin.Context) {
+	var req model.RefundRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	payment, refund, err := h.paymentService.RefundPayment(cast.ToUint(ctx.Param("id")), req.Amount, req.Reason, req.Reference)
+	if err != nil {
+		switch err.Error() {
+		case "payment not found":
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "only settled payments can be refunded", "refund amount exceeds paid amount":
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"payment": payment, "refund": refund})
+}
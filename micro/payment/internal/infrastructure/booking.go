@@ -28,6 +28,7 @@ type InternalBookingResponse struct {
 		Status     string  `json:"status"`
 		PaymentID  *uint   `json:"payment_id"`
 		TotalPrice float64 `json:"total_price"`
+		AmountDue  float64 `json:"amount_due"`
 	} `json:"booking"`
 }
 
@@ -1,6 +1,7 @@
 package infrastructure
 
 import (
+	"fmt"
 	"os"
 	"payment/internal/config"
 
@@ -42,3 +43,32 @@ func (p *PaymentService) CreatePayment(orderID string, bookingID uint, amount in
 
 	return resp.RedirectURL, nil
 }
+
+// RefundPayment asks Midtrans to refund the given order. A zero amount
+// refunds the full gross amount of the transaction. Midtrans refunds a
+// refund key only once, so a retry with the same key is safe.
+func (p *PaymentService) RefundPayment(orderID, refundKey string, amount int64, reason string) error {
+
+	if cast.ToBool(os.Getenv("IS_NFT")) {
+		return nil
+	}
+
+	coreGateway := midtrans.CoreGateway{Client: *p.midtransClient}
+
+	req := &midtrans.RefundReq{
+		RefundKey: refundKey,
+		Amount:    amount,
+		Reason:    reason,
+	}
+
+	resp, err := coreGateway.Refund(orderID, req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != "200" && resp.StatusCode != "201" {
+		return fmt.Errorf("refund rejected: %s", resp.StatusMessage)
+	}
+
+	return nil
+}
@@ -4,29 +4,31 @@ import (
     "fmt"
     "log"
     "payment/internal/config"
+    "time"
 
     "github.com/go-resty/resty/v2"
 )
 
 // User provides methods to interact with the user service from the payment
-// service.  It allows creating activity logs on behalf of a user without
-// requiring authentication by calling the user service's internal
-// endpoints.
+// service.  It allows creating activity logs on behalf of a user by
+// calling the user service's internal endpoints, authenticated by the
+// secret the services share.
 type User struct {
     restyClient *resty.Client
     serviceUser config.Service
 }
 
 // NewUser constructs a new User client.  It receives a Resty client and
-// service configuration (host and port) for the user service.  The
+// service configuration (host, port and shared secret) for the user service.  The
 // Resty client is shared across services to reuse underlying HTTP
 // connections.
 func NewUser(restyClient *resty.Client, serviceUser config.Service) *User {
     return &User{
         restyClient: restyClient,
         serviceUser: config.Service{
-            Host: serviceUser.Host,
-            Port: serviceUser.Port,
+            Host:           serviceUser.Host,
+            Port:           serviceUser.Port,
+            InternalSecret: serviceUser.InternalSecret,
         },
     }
 }
@@ -38,22 +40,77 @@ func NewUser(restyClient *resty.Client, serviceUser config.Service) *User {
 // error containing the status.  Otherwise, it returns nil to indicate
 // success.
 func (u *User) CreateActivityLog(userID uint, action, description string) error {
+    return u.postActivity(map[string]interface{}{
+        "user_id":    userID,
+        "action":     action,
+        "description": description,
+    })
+}
+
+// ActivityNotification puts an activity into the user's in-app
+// notification center. The reference keeps it from showing up twice.
+type ActivityNotification struct {
+    Title     string `json:"title"`
+    Message   string `json:"message,omitempty"`
+    Link      string `json:"link,omitempty"`
+    Reference string `json:"reference,omitempty"`
+}
+
+// CreateActivityNotification records an activity like CreateActivityLog and
+// also notifies the user of it in the app.
+func (u *User) CreateActivityNotification(userID uint, action, description string, notification ActivityNotification) error {
+    return u.postActivity(map[string]interface{}{
+        "user_id":      userID,
+        "action":       action,
+        "description":  description,
+        "notification": notification,
+    })
+}
+
+func (u *User) postActivity(payload map[string]interface{}) error {
     url := fmt.Sprintf("%s:%s/api/v1/internal/activity", u.serviceUser.Host, u.serviceUser.Port)
     log.Printf("calling user activity endpoint %s", url)
+    resp, err := u.restyClient.R().
+        SetHeader("Content-Type", "application/json").
+        SetHeader(config.InternalSecretHeader, u.serviceUser.InternalSecret).
+        SetBody(payload).
+        Post(url)
+    if err != nil {
+        return err
+    }
+    if resp.IsError() {
+        return fmt.Errorf("failed to create activity log: %s", resp.Status())
+    }
+    return nil
+}
+// SendPaymentReceipt asks the user service to send the receipt of a settled
+// payment to the user, as far as their notification preferences allow.
+// The payment ID keeps the receipt from being sent twice.
+func (u *User) SendPaymentReceipt(userID, bookingID, paymentID uint, amount float64, method string, paidAt time.Time) error {
+    url := fmt.Sprintf("%s:%s/api/v1/internal/notifications/events", u.serviceUser.Host, u.serviceUser.Port)
     payload := map[string]interface{}{
         "user_id":    userID,
-        "action":     action,
-        "description": description,
+        "event_type": "payment_receipt",
+        "reference":  fmt.Sprintf("payment:%d", paymentID),
+        "role":       "student",
+        "data": map[string]string{
+            "booking_id":     fmt.Sprint(bookingID),
+            "payment_id":     fmt.Sprint(paymentID),
+            "amount":         fmt.Sprintf("Rp %.0f", amount),
+            "payment_method": method,
+            "paid_at":        paidAt.Format("2006-01-02 15:04"),
+        },
     }
     resp, err := u.restyClient.R().
         SetHeader("Content-Type", "application/json").
+        SetHeader(config.InternalSecretHeader, u.serviceUser.InternalSecret).
         SetBody(payload).
         Post(url)
     if err != nil {
         return err
     }
     if resp.IsError() {
-        return fmt.Errorf("failed to create activity log: %s", resp.Status())
+        return fmt.Errorf("failed to send payment receipt: %s", resp.Status())
     }
     return nil
-}
\ No newline at end of file
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"payment/internal/config"
 	"strings"
@@ -46,3 +47,18 @@ func AuthMiddleware(jwtConfig *config.JWT) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// InternalMiddleware admits only the other services, which send the secret
+// they share in config.InternalSecretHeader. Without a configured secret
+// every call is refused.
+func InternalMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := c.GetHeader(config.InternalSecretHeader)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
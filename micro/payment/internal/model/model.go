@@ -6,14 +6,28 @@ type Payment struct {
 	ID                    uint   `gorm:"primaryKey"`
 	MidtransTransactionID string `gorm:"size:100"`
 	Amount                float64
-	Status                string `gorm:"type:enum('pending','settlement','failed','cancel');default:'pending'"`
+	Status                string `gorm:"type:enum('pending','settlement','failed','cancel','refund');default:'pending'"`
 	PaymentMethod         string
 	BookingID             uint `gorm:"index"`
 	PaidAt                *time.Time
+	RefundAmount          float64
+	RefundedAt            *time.Time
 	CreatedAt             time.Time
 	UpdatedAt             time.Time
 }
 
+// Refund is one refund of a payment. Reference is chosen by the caller and
+// identifies the refund across retries, so asking twice for the same
+// reference refunds only once.
+type Refund struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PaymentID uint      `gorm:"uniqueIndex:idx_refund_payment_reference" json:"payment_id"`
+	Reference string    `gorm:"size:100;uniqueIndex:idx_refund_payment_reference" json:"reference"`
+	Amount    float64   `json:"amount"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type BookingResponse struct {
 	ID        uint      `json:"id"`
 	Status    string    `json:"status"`
@@ -29,6 +43,14 @@ type PaymentResponse struct {
 	PaymentMethod         string     `json:"payment_method"`
 	BookingID             uint       `json:"booking_id"`
 	PaidAt                *time.Time `json:"paid_at"`
+	RefundAmount          float64    `json:"refund_amount"`
+	RefundedAt            *time.Time `json:"refunded_at"`
 	CreatedAt             time.Time  `json:"created_at"`
 	UpdatedAt             time.Time  `json:"updated_at"`
 }
+
+type RefundRequest struct {
+	Amount    float64 `json:"amount"`
+	Reason    string  `json:"reason"`
+	Reference string  `json:"reference" binding:"max=100"`
+}
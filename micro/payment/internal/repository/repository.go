@@ -136,3 +136,22 @@ func (r *Repository) GetPaymentById(id uint) (*model.Payment, error) {
 	err := r.DB.Where("id = ?", id).First(&payment).Error
 	return &payment, err
 }
+
+// GetRefundByReference returns the refund of the payment recorded under
+// reference.
+func (r *Repository) GetRefundByReference(paymentID uint, reference string) (*model.Refund, error) {
+	var refund model.Refund
+	err := r.DB.Where("payment_id = ? AND reference = ?", paymentID, reference).First(&refund).Error
+	return &refund, err
+}
+
+// SaveRefund records a refund and the payment's new refunded amount
+// together.
+func (r *Repository) SaveRefund(payment *model.Payment, refund *model.Refund) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(payment).Error; err != nil {
+			return err
+		}
+		return tx.Create(refund).Error
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"math"
 	"payment/internal/infrastructure"
 	"payment/internal/model"
 	"payment/internal/pkg"
@@ -38,7 +39,7 @@ func NewService(
 	}
 }
 
-func (s *Service) CreatePayment(c *gin.Context, orderID string, bookingID uint, amount int64, paymentMethod string) (string, error) {
+func (s *Service) CreatePayment(c *gin.Context, orderID string, bookingID uint, paymentMethod string) (string, error) {
 
 	exist, err := s.serviceBooking.CheckBookingExist(c, cast.ToString(bookingID))
 	if err != nil {
@@ -48,6 +49,20 @@ func (s *Service) CreatePayment(c *gin.Context, orderID string, bookingID uint,
 		return "", errors.New("booking not found")
 	}
 
+	// The booking carries the price computed from its lesson type, and it is
+	// the only amount ever charged. The first booking of a series paid
+	// upfront is due the price of the whole series, and a paid booking moved
+	// to a dearer slot is due the difference.
+	bookingDetail, bookingErr := s.serviceBooking.GetBooking(bookingID)
+	if bookingErr != nil {
+		log.Println(bookingErr)
+		return "", errors.New("failed to get booking price")
+	}
+	amount := int64(math.Round(bookingDetail.Booking.AmountDue))
+	if amount <= 0 {
+		return "", errors.New("booking has nothing to pay")
+	}
+
 	method, err := s.repository.GetPaymentMethodByName(context.Background(), strings.ToLower(paymentMethod))
 
 	if err != nil || !method.IsActive {
@@ -76,24 +91,17 @@ func (s *Service) CreatePayment(c *gin.Context, orderID string, bookingID uint,
 	}
 
 	// After successfully creating the payment record, record a recent activity
-	// for the user associated with this booking.  We fetch the booking to
-	// determine the user ID from the booking service's internal endpoint.  If
-	// the booking cannot be retrieved or the user service call fails, we log
-	// the error but do not block the payment creation flow.
-	if s.serviceUser != nil && s.serviceBooking != nil {
-		// Retrieve booking details to get the user ID
-		if bookingDetail, err := s.serviceBooking.GetBooking(bookingID); err == nil {
-			userID := bookingDetail.Booking.UserID
-			// Compose a description in Indonesian indicating that the user created a payment
-			go func() {
-				description := fmt.Sprintf("Pengguna membuat pembayaran untuk pemesanan #%d", bookingID)
-				if err := s.serviceUser.CreateActivityLog(userID, "create_payment", description); err != nil {
-					log.Printf("failed to log activity for payment creation: %v", err)
-				}
-			}()
-		} else {
-			log.Printf("failed to fetch booking for activity log: %v", err)
-		}
+	// for the user associated with this booking. If the user service call
+	// fails, we log the error but do not block the payment creation flow.
+	if s.serviceUser != nil {
+		userID := bookingDetail.Booking.UserID
+		// Compose a description in Indonesian indicating that the user created a payment
+		go func() {
+			description := fmt.Sprintf("Pengguna membuat pembayaran untuk pemesanan #%d", bookingID)
+			if err := s.serviceUser.CreateActivityLog(userID, "create_payment", description); err != nil {
+				log.Printf("failed to log activity for payment creation: %v", err)
+			}
+		}()
 	}
 
 	return redirectUrl, nil
@@ -128,8 +136,9 @@ func (s *Service) Handle(trxId string, status string) (*model.Payment, error) {
 		}
 		// Record an activity log when payment is settled (paid) indicating the user
 		// has completed the payment and the booking is paid.  Fetch the user ID
-		// from the booking service and create the activity.  Errors from
-		// logging are recorded but do not interrupt the callback flow.
+		// from the booking service, create the activity and send the payment
+		// receipt.  Errors from logging are recorded but do not interrupt the
+		// callback flow.
 		if s.serviceUser != nil && s.serviceBooking != nil {
 			if bookingDetail, err2 := s.serviceBooking.GetBooking(payment.BookingID); err2 == nil {
 				userID := bookingDetail.Booking.UserID
@@ -138,6 +147,9 @@ func (s *Service) Handle(trxId string, status string) (*model.Payment, error) {
 					if err3 := s.serviceUser.CreateActivityLog(userID, "payment_success", description); err3 != nil {
 						log.Printf("failed to log activity for payment success: %v", err3)
 					}
+					if err3 := s.serviceUser.SendPaymentReceipt(userID, payment.BookingID, payment.ID, payment.Amount, payment.PaymentMethod, *payment.PaidAt); err3 != nil {
+						log.Printf("failed to send payment receipt: %v", err3)
+					}
 				}()
 			} else {
 				log.Printf("failed to fetch booking for payment success activity: %v", err2)
@@ -201,11 +213,91 @@ func (s *Service) GetPaymentById(id uint) (*model.PaymentResponse, error) {
 		PaymentMethod:         payment.PaymentMethod,
 		BookingID:             payment.BookingID,
 		PaidAt:                payment.PaidAt,
+		RefundAmount:          payment.RefundAmount,
+		RefundedAt:            payment.RefundedAt,
 		CreatedAt:             payment.CreatedAt,
 		UpdatedAt:             payment.UpdatedAt,
 	}, nil
 }
 
+// RefundPayment refunds a settled payment through Midtrans and marks it as
+// refunded. A zero amount refunds whatever has not been refunded yet; a
+// partial amount may be refunded several times until the payment is
+// exhausted. A refund of the payment asked for again with the same
+// reference is not repeated; the earlier refund is returned instead, so
+// callers can retry safely. The booking status is left to the caller (booking service).
+func (s *Service) RefundPayment(id uint, amount float64, reason, reference string) (*model.Payment, *model.Refund, error) {
+	payment, err := s.repository.GetPaymentById(id)
+	if err != nil {
+		return nil, nil, errors.New("payment not found")
+	}
+
+	if reference != "" {
+		if refund, err := s.repository.GetRefundByReference(payment.ID, reference); err == nil {
+			return payment, refund, nil
+		}
+	}
+
+	if payment.Status != "settlement" && payment.Status != "refund" {
+		return nil, nil, errors.New("only settled payments can be refunded")
+	}
+
+	remaining := payment.Amount - payment.RefundAmount
+	if amount <= 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		return nil, nil, errors.New("refund amount exceeds paid amount")
+	}
+
+	now := time.Now()
+	if reference == "" {
+		reference = fmt.Sprintf("payment:%d:%d", payment.ID, now.UnixNano())
+	}
+	refundKey := fmt.Sprintf("%s-%s", payment.MidtransTransactionID, reference)
+	if err := s.infra.RefundPayment(payment.MidtransTransactionID, refundKey, int64(amount), reason); err != nil {
+		log.Println(err)
+		return nil, nil, errors.New("failed to refund payment")
+	}
+
+	payment.RefundAmount += amount
+	payment.RefundedAt = &now
+	if payment.RefundAmount >= payment.Amount {
+		payment.Status = "refund"
+	}
+
+	refund := &model.Refund{
+		PaymentID: payment.ID,
+		Reference: reference,
+		Amount:    amount,
+		Reason:    reason,
+	}
+	if err := s.repository.SaveRefund(payment, refund); err != nil {
+		return nil, nil, err
+	}
+
+	if s.serviceUser != nil && s.serviceBooking != nil {
+		if bookingDetail, err := s.serviceBooking.GetBooking(payment.BookingID); err == nil {
+			userID := bookingDetail.Booking.UserID
+			go func() {
+				description := fmt.Sprintf("Dana sebesar %.0f untuk pemesanan #%d telah dikembalikan", amount, payment.BookingID)
+				// Every refund of a payment gets its own notification, once.
+				notification := infrastructure.ActivityNotification{
+					Title:     "Dana dikembalikan",
+					Message:   description,
+					Link:      "/bookings",
+					Reference: fmt.Sprintf("refund:%d", refund.ID),
+				}
+				if err := s.serviceUser.CreateActivityNotification(userID, "payment_refund", description, notification); err != nil {
+					log.Printf("failed to log activity for payment refund: %v", err)
+				}
+			}()
+		}
+	}
+
+	return payment, refund, nil
+}
+
 func (s *Service) GetPayments(pg int, limit int) (pkg.ResponsePaginate, error) {
 	return s.repository.GetPayments(pg, limit)
 }
@@ -81,6 +81,9 @@ DB_CONN_MAX_LIFETIME=30
 JWT_SECRET_KEY=your-secret-key-here
 JWT_TOKEN_DURATION=24
 
+# Internal service calls (same value in every service)
+INTERNAL_SERVICE_SECRET=your-internal-secret-here
+
 # SMTP Configuration
 SMTP_HOST=smtp.gmail.com
 SMTP_PORT=587
@@ -108,6 +111,9 @@ DB_CONN_MAX_LIFETIME=30
 JWT_SECRET_KEY=your-secret-key-here
 JWT_TOKEN_DURATION=24
 
+# Internal service calls (same value in every service)
+INTERNAL_SERVICE_SECRET=your-internal-secret-here
+
 # Supabase Configuration (for file uploads)
 CLIENT_ENDPOINT=https://your-project.supabase.co
 CLIENT_ACCESS_KEY=your-access-key
@@ -130,6 +136,9 @@ DB_CONN_MAX_LIFETIME=30
 JWT_SECRET_KEY=your-secret-key-here
 JWT_TOKEN_DURATION=24
 
+# Internal service calls (same value in every service)
+INTERNAL_SERVICE_SECRET=your-internal-secret-here
+
 # Teacher Service Configuration
 SERVICE_SCHEDULE_HOST=localhost
 SERVICE_SCHEDULE_PORT=8082
@@ -156,6 +165,9 @@ DB_CONN_MAX_LIFETIME=30
 JWT_SECRET_KEY=your-secret-key-here
 JWT_TOKEN_DURATION=24
 
+# Internal service calls (same value in every service)
+INTERNAL_SERVICE_SECRET=your-internal-secret-here
+
 # Midtrans Configuration
 MIDTRANS_SERVER_KEY=your-midtrans-server-key
 MIDTRANS_CLIENT_KEY=your-midtrans-client-key
@@ -7,6 +7,7 @@ import (
 	"teacher/internal/handler"
 	"teacher/internal/infrastructure/booking"
 	"teacher/internal/infrastructure/supabase"
+	"teacher/internal/infrastructure/user"
 	"teacher/internal/middleware"
 	"teacher/internal/models"
 	"teacher/internal/repository"
@@ -16,6 +17,7 @@ import (
 	"github.com/go-resty/resty/v2"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cast"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -26,12 +28,28 @@ func main() {
 	// and schedules exist when the service starts.
 	{
 		type (
-			Teacher  = models.Teacher
-			Schedule = models.Schedule
+			Teacher    = models.Teacher
+			Schedule   = models.Schedule
+			LessonType = models.LessonType
+			Review     = models.Review
+			Document   = models.TeacherDocument
+			History    = models.TeacherStatusHistory
+			Subject    = models.Subject
+			Language   = models.InstructionLanguage
+			TSubject   = models.TeacherSubject
+			TLanguage  = models.TeacherLanguage
+			Note       = models.TeacherStudentNote
+			Feed       = models.CalendarFeed
+			External   = models.ExternalCalendar
+			Busy       = models.BusyTime
 		)
-		if err := db.AutoMigrate(&Teacher{}, &Schedule{}); err != nil {
+		if err := db.AutoMigrate(&Teacher{}, &LessonType{}, &Schedule{}, &Review{}, &Document{}, &History{},
+			&Subject{}, &Language{}, &TSubject{}, &TLanguage{}, &Note{}, &Feed{}, &External{}, &Busy{}); err != nil {
 			log.Info().Err(err).Msg("failed to auto migrate teacher service database")
 		}
+		if err := restrictScheduleTeacherDelete(db); err != nil {
+			log.Info().Err(err).Msg("failed to migrate schedules teacher foreign key")
+		}
 	}
 	gin.SetMode(c.GIN_MODE)
 
@@ -48,6 +66,17 @@ func main() {
 
 	teacherRepo := repository.NewRepository(db)
 	scheduleRepo := repository.NewScheduleRepository(db)
+	lessonTypeRepo := repository.NewLessonTypeRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+	onboardingRepo := repository.NewOnboardingRepository(db)
+	catalogRepo := repository.NewCatalogRepository(db)
+	rosterRepo := repository.NewRosterRepository(db)
+	calendarRepo := repository.NewCalendarRepository(db)
+	externalCalendarRepo := repository.NewExternalCalendarRepository(db)
+
+	if err := catalogRepo.EnsureDefaults(models.DefaultSubjects, models.DefaultLanguages); err != nil {
+		log.Info().Err(err).Msg("failed to seed subject and language catalog")
+	}
 
 	restyInit := resty.New()
 	restyInit.SetDebug(cast.ToBool(os.Getenv("DEBUG")))
@@ -55,14 +84,38 @@ func main() {
 	supabaseService := supabase.InitUploadClient(&c.Client, restyInit)
 
 	bookingService := booking.NewBookingService(restyInit, &c.ServiceBooking)
+	userService := user.NewUserService(restyInit, &c.ServiceUser)
 
-	teacherService := service.NewService(teacherRepo)
-	scheduleService := service.NewScheduleService(scheduleRepo)
-	dashboardService := service.NewDashboardService(teacherRepo, bookingService)
+	teacherService := service.NewService(teacherRepo, lessonTypeRepo, reviewRepo, catalogRepo, userService)
+	scheduleService := service.NewScheduleService(scheduleRepo, lessonTypeRepo, bookingService)
+	lessonTypeService := service.NewLessonTypeService(lessonTypeRepo, scheduleRepo)
+	catalogService := service.NewCatalogService(catalogRepo, scheduleRepo)
+	reviewService := service.NewReviewService(reviewRepo, scheduleRepo, bookingService)
+	onboardingService := service.NewOnboardingService(onboardingRepo, supabaseService, userService)
+	rosterService := service.NewRosterService(rosterRepo, scheduleRepo, bookingService, userService)
+	dashboardService := service.NewDashboardService(teacherRepo, scheduleRepo, bookingService, rosterService)
+	rescheduleRequestService := service.NewRescheduleRequestService(scheduleRepo, bookingService)
+	calendarService := service.NewCalendarService(calendarRepo, scheduleRepo, c.Calendar)
+	externalCalendarService := service.NewExternalCalendarService(externalCalendarRepo, scheduleRepo, c.Calendar)
+
+	// Import busy times of the teachers' external calendars periodically.
+	go externalCalendarService.RunImporter()
+	// Cancel and refund group classes that miss their minimum enrolment.
+	go scheduleService.RunEnrolmentCutoff()
+	// Retry cancelling the bookings of cancelled slots that failed before.
+	go scheduleService.RunBookingCancellationRetry()
 
 	scheduleHandler := handler.NewScheduleHandler(scheduleService)
 	handlers := handler.NewHandler(teacherService)
 	dashboardHandler := handler.NewDashboardHandler(dashboardService)
+	rosterHandler := handler.NewRosterHandler(rosterService)
+	rescheduleRequestHandler := handler.NewRescheduleRequestHandler(rescheduleRequestService)
+	calendarHandler := handler.NewCalendarHandler(calendarService)
+	externalCalendarHandler := handler.NewExternalCalendarHandler(externalCalendarService)
+	lessonTypeHandler := handler.NewLessonTypeHandler(lessonTypeService)
+	catalogHandler := handler.NewCatalogHandler(catalogService)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+	onboardingHandler := handler.NewOnboardingHandler(onboardingService)
 
 	uploadHandler := handler.NewUploadHandler(supabaseService, &c.Client)
 
@@ -73,10 +126,54 @@ func main() {
 		auth := api.Group("")
 		auth.Use(middleware.AuthMiddleware(&c.JWT))
 		auth.GET("/teachers/me", handlers.GetMe)
-		api.POST("/teachers", handlers.CreateTeacher)
+
+		// Batch schedule operations, each returning a per-row report. Creating
+		// and shifting are all-or-nothing. Cancelling cancels all slots at
+		// once and then their bookings; bookings that fail are retried later.
+		auth.POST("/schedule/bulk", scheduleHandler.BulkCreateSchedules)
+		auth.POST("/schedule/bulk/csv", scheduleHandler.BulkCreateSchedulesCSV)
+		auth.PUT("/schedule/bulk/shift", scheduleHandler.BulkShiftSchedules)
+		auth.PUT("/schedule/bulk/cancel", scheduleHandler.BulkCancelSchedules)
+
+		// Lesson types define the durations and prices a teacher offers.
+		api.GET("/teachers/:id/lesson-types", lessonTypeHandler.GetLessonTypes)
+		auth.POST("/teachers/:id/lesson-types", lessonTypeHandler.CreateLessonType)
+		auth.PUT("/lesson-types/:id", lessonTypeHandler.UpdateLessonType)
+		auth.DELETE("/lesson-types/:id", lessonTypeHandler.DeleteLessonType)
+
+		// Subject and instruction language catalog and teacher assignments.
+		api.GET("/subjects", catalogHandler.GetSubjects)
+		api.GET("/languages", catalogHandler.GetLanguages)
+		auth.POST("/admin/subjects", catalogHandler.CreateSubject)
+		auth.PUT("/admin/subjects/:id", catalogHandler.UpdateSubject)
+		auth.POST("/admin/languages", catalogHandler.CreateLanguage)
+		auth.PUT("/teachers/:id/subjects", catalogHandler.SetTeacherSubjects)
+		auth.PUT("/teachers/:id/languages", catalogHandler.SetTeacherLanguages)
+
+		// Reviews of completed lessons and their moderation.
+		api.GET("/teachers/:id/reviews", reviewHandler.GetTeacherReviews)
+		auth.POST("/reviews", reviewHandler.CreateReview)
+		auth.PUT("/reviews/:id/reply", reviewHandler.ReplyToReview)
+		auth.GET("/admin/reviews", reviewHandler.GetReviewsForModeration)
+		auth.PUT("/admin/reviews/:id", reviewHandler.ModerateReview)
+
+		// Teacher applications. Only approved teachers are listed publicly.
+		auth.GET("/teachers/application", onboardingHandler.GetMyApplication)
+		auth.POST("/teachers/application", onboardingHandler.SubmitApplication)
+		auth.POST("/teachers/application/documents", onboardingHandler.UploadDocument)
+		auth.GET("/admin/teacher-applications", onboardingHandler.GetApplications)
+		auth.GET("/admin/teacher-applications/:id", onboardingHandler.GetApplication)
+		auth.PUT("/admin/teacher-applications/:id/approve", onboardingHandler.ApproveApplication)
+		auth.PUT("/admin/teacher-applications/:id/reject", onboardingHandler.RejectApplication)
+		auth.POST("/teachers", handlers.CreateTeacher)
 		api.GET("/teachers/:id", handlers.GetTeacher)
-		api.PUT("/teachers/:id", handlers.UpdateTeacher)
-		api.DELETE("/teachers/:id", handlers.DeleteTeacher)
+		auth.PUT("/teachers/:id", handlers.UpdateTeacher)
+
+		// Teachers and schedules are soft deleted; admins can bring them back.
+		auth.DELETE("/teachers/:id", handlers.DeleteTeacher)
+		auth.GET("/admin/teachers/deleted", handlers.GetDeletedTeachers)
+		auth.PUT("/admin/teachers/:id/restore", handlers.RestoreTeacher)
+		auth.PUT("/admin/schedules/:id/restore", scheduleHandler.RestoreSchedule)
 
 		api.GET("/total-teachers", handlers.CountTeachers)
 
@@ -92,12 +189,68 @@ func main() {
 		api.DELETE("/schedule/:id", scheduleHandler.DeleteSchedule)
 		api.POST("schedule/filter-by-teacher", scheduleHandler.FilterByTeacher)
 
-		api.GET("/teachers/dashboard/:teacher_id", dashboardHandler.GetTeacherDashboard)
+		auth.GET("/teachers/dashboard/:teacher_id", dashboardHandler.GetTeacherDashboard)
+		auth.GET("/teachers/dashboard/:teacher_id/analytics", dashboardHandler.GetTeacherAnalytics)
+		auth.GET("/teachers/dashboard/:teacher_id/students", rosterHandler.GetRoster)
+		auth.PUT("/teachers/dashboard/:teacher_id/students/:student_id/note", rosterHandler.SetNote)
+		// Ask a student to move a booked lesson to other slots.
+		auth.POST("/teachers/dashboard/:teacher_id/reschedule-requests", rescheduleRequestHandler.ProposeReschedule)
+		auth.GET("/teachers/dashboard/:teacher_id/reschedule-requests", rescheduleRequestHandler.GetRescheduleRequests)
+		auth.POST("/teachers/dashboard/:teacher_id/reschedule-requests/:id/withdraw", rescheduleRequestHandler.WithdrawRescheduleRequest)
+
+		// ICS feed of the teacher's lessons. The feed itself is authenticated
+		// by the secret token in its URL so calendar apps can subscribe.
+		auth.GET("/teachers/calendar/feed", calendarHandler.GetFeed)
+		auth.POST("/teachers/calendar/feed/rotate", calendarHandler.RotateFeed)
+		auth.DELETE("/teachers/calendar/feed", calendarHandler.RevokeFeed)
+		api.GET("/teachers/calendar/ics/:token", calendarHandler.GetTeacherCalendar)
+
+		// External calendars whose busy times block the teacher's slots.
+		auth.GET("/teachers/:id/external-calendars", externalCalendarHandler.GetCalendars)
+		auth.POST("/teachers/:id/external-calendars", externalCalendarHandler.AddCalendarURL)
+		auth.POST("/teachers/:id/external-calendars/upload", externalCalendarHandler.UploadCalendar)
+		auth.POST("/teachers/:id/external-calendars/:calendar_id/sync", externalCalendarHandler.SyncCalendar)
+		auth.DELETE("/teachers/:id/external-calendars/:calendar_id", externalCalendarHandler.DeleteCalendar)
 
 		api.POST("/upload-image", uploadHandler.UploadHandler)
 	}
 
+	// Slot lookup for recurring bookings of the booking service.
+	r.POST("/api/v1/internal/schedules/match", scheduleHandler.MatchSlotsInternal)
+	r.PUT("/api/v1/internal/schedules/:id/hold", scheduleHandler.HoldScheduleInternal)
+	r.POST("/api/v1/internal/schedules/reserve", scheduleHandler.ReserveSchedulesInternal)
+	r.POST("/api/v1/internal/schedules/release", scheduleHandler.ReleaseSchedulesInternal)
+
+	// Internal endpoints used by the user service to keep teacher profiles
+	// linked to user accounts. They bypass authentication.
+	r.POST("/api/v1/internal/teachers/provision", onboardingHandler.ProvisionTeacherInternal)
+	r.POST("/api/v1/internal/teachers/archive", onboardingHandler.ArchiveTeacherInternal)
+	r.PUT("/api/v1/internal/teachers/profile", onboardingHandler.SyncTeacherInternal)
+
 	log.Info().Msgf("Starting server on port %s", c.AppPort)
 
 	r.Run(fmt.Sprint(":", c.AppPort))
 }
+
+// restrictScheduleTeacherDelete replaces the cascading schedules -> teachers
+// foreign key of older databases. AutoMigrate does not alter existing
+// constraints, and a hard delete of a teacher must not wipe the schedules
+// that bookings point to.
+func restrictScheduleTeacherDelete(db *gorm.DB) error {
+	var names []string
+	if err := db.Raw(`SELECT CONSTRAINT_NAME FROM information_schema.REFERENTIAL_CONSTRAINTS
+		WHERE CONSTRAINT_SCHEMA = DATABASE() AND TABLE_NAME = 'schedules'
+		AND REFERENCED_TABLE_NAME = 'teachers' AND DELETE_RULE = 'CASCADE'`).Scan(&names).Error; err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	for _, name := range names {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE schedules DROP FOREIGN KEY `%s`", name)).Error; err != nil {
+			return err
+		}
+	}
+	return db.Migrator().CreateConstraint(&models.Schedule{}, "Teacher")
+}
@@ -48,4 +48,7 @@ require (
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/rs/zerolog v1.34.0
 	golang.org/x/text v0.21.0 // indirect
+	ics v0.0.0
 )
+
+replace ics => ../ics
@@ -21,6 +21,8 @@ type Config struct {
 	JWT               JWT
 	Client            Client
 	ServiceBooking    Service
+	ServiceUser       Service
+	Calendar          Calendar
 	IsNFT             bool
 }
 
@@ -29,17 +31,39 @@ type JWT struct {
 	TokenDuration int
 }
 
+// InternalSecretHeader carries the secret the services share. Internal
+// routes that change bookings, move money or expose users require it.
+const InternalSecretHeader = "X-Internal-Secret"
+
 type Service struct {
 	Host string
 	Port string
+	// InternalSecret is sent in InternalSecretHeader to the internal routes
+	// of the service that require it.
+	InternalSecret string
+}
+
+// Calendar configures the ICS feeds and the external calendar import.
+// Timezone is the zone schedule times are stored in and PublicURL the
+// externally reachable base URL of the service. ImportInterval is how often
+// external calendars are synced.
+type Calendar struct {
+	Timezone       string
+	PublicURL      string
+	ImportInterval time.Duration
 }
 
+// Client configures the object storage. BucketName is public; private
+// files such as qualification documents go to PrivateBucketName and are
+// only handed out as signed URLs valid for SignedURLExpiry.
 type Client struct {
-	Endpoint   string
-	AccessKey  string
-	SecretKey  string
-	Region     string
-	BucketName string
+	Endpoint          string
+	AccessKey         string
+	SecretKey         string
+	Region            string
+	BucketName        string
+	PrivateBucketName string
+	SignedURLExpiry   time.Duration
 }
 
 func LoadConfig() *Config {
@@ -48,6 +72,8 @@ func LoadConfig() *Config {
 		log.Println("No .env file found or failed to load")
 	}
 
+	internalSecret := os.Getenv("INTERNAL_SERVICE_SECRET")
+
 	return &Config{
 		MysqlDSN:          os.Getenv("MYSQL_DSN"),
 		AppPort:           os.Getenv("APP_PORT"),
@@ -60,14 +86,26 @@ func LoadConfig() *Config {
 			TokenDuration: cast.ToInt(os.Getenv("JWT_TOKEN_DURATION")),
 		},
 		Client: Client{
-			Endpoint:   os.Getenv("CLIENT_ENDPOINT"),
-			AccessKey:  os.Getenv("CLIENT_ACCESS_KEY"),
-			SecretKey:  os.Getenv("CLIENT_SECRET_KEY"),
-			Region:     os.Getenv("CLIENT_REGION"),
-			BucketName: os.Getenv("CLIENT_BUCKET_NAME"),
+			Endpoint:          os.Getenv("CLIENT_ENDPOINT"),
+			AccessKey:         os.Getenv("CLIENT_ACCESS_KEY"),
+			SecretKey:         os.Getenv("CLIENT_SECRET_KEY"),
+			Region:            os.Getenv("CLIENT_REGION"),
+			BucketName:        os.Getenv("CLIENT_BUCKET_NAME"),
+			PrivateBucketName: os.Getenv("CLIENT_PRIVATE_BUCKET_NAME"),
+			SignedURLExpiry:   cast.ToDuration(os.Getenv("CLIENT_SIGNED_URL_EXPIRY")),
 		},
 		ServiceBooking: Service{
-			Host: os.Getenv("SERVICE_BOOKING_HOST"),
+			Host:           os.Getenv("SERVICE_BOOKING_HOST"),
+			InternalSecret: internalSecret,
+		},
+		ServiceUser: Service{
+			Host:           os.Getenv("SERVICE_USER_HOST"),
+			InternalSecret: internalSecret,
+		},
+		Calendar: Calendar{
+			Timezone:       os.Getenv("CALENDAR_TIMEZONE"),
+			PublicURL:      os.Getenv("CALENDAR_PUBLIC_URL"),
+			ImportInterval: cast.ToDuration(os.Getenv("CALENDAR_IMPORT_INTERVAL")),
 		},
 		IsNFT: cast.ToBool(os.Getenv("IS_NFT")),
 	}
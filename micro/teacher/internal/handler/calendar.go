@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"teacher/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+type CalendarHandler struct {
+	calendarService *service.CalendarService
+}
+
+func NewCalendarHandler(calendarService *service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+// GetFeed - GET /api/v1/teachers/calendar/feed
+//
+// Returns the ICS feed URL of the authenticated teacher, creating the feed
+// on first use.
+func (h *CalendarHandler) GetFeed(c *gin.Context) {
+	feed, err := h.calendarService.GetFeed(cast.ToUint(c.MustGet("user_id")))
+	if err != nil {
+		respondCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": feed})
+}
+
+// RotateFeed - POST /api/v1/teachers/calendar/feed/rotate
+func (h *CalendarHandler) RotateFeed(c *gin.Context) {
+	feed, err := h.calendarService.RotateFeed(cast.ToUint(c.MustGet("user_id")))
+	if err != nil {
+		respondCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed rotated", "data": feed})
+}
+
+// RevokeFeed - DELETE /api/v1/teachers/calendar/feed
+func (h *CalendarHandler) RevokeFeed(c *gin.Context) {
+	if err := h.calendarService.RevokeFeed(cast.ToUint(c.MustGet("user_id"))); err != nil {
+		respondCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked"})
+}
+
+// GetTeacherCalendar - GET /api/v1/teachers/calendar/ics/:token
+//
+// Calendar apps cannot send a JWT, so the secret token in the URL is the
+// only credential.
+func (h *CalendarHandler) GetTeacherCalendar(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	calendar, err := h.calendarService.RenderTeacherCalendar(token)
+	if err != nil {
+		respondCalendarError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(calendar))
+}
+
+func respondCalendarError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
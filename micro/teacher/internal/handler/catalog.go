@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"teacher/internal/models"
+	"teacher/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+type CatalogHandler struct {
+	catalogService *service.CatalogService
+}
+
+func NewCatalogHandler(catalogService *service.CatalogService) *CatalogHandler {
+	return &CatalogHandler{
+		catalogService: catalogService,
+	}
+}
+
+// GetSubjects - GET /api/v1/subjects
+//
+// Only active subjects are listed unless include_inactive=true is passed.
+func (h *CatalogHandler) GetSubjects(c *gin.Context) {
+	activeOnly := !cast.ToBool(c.Query("include_inactive"))
+	subjects, err := h.catalogService.GetSubjects(activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": subjects})
+}
+
+// CreateSubject - POST /api/v1/admin/subjects
+func (h *CatalogHandler) CreateSubject(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req models.SubjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subject, err := h.catalogService.CreateSubject(req)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Subject created", "data": subject})
+}
+
+// UpdateSubject - PUT /api/v1/admin/subjects/:id
+func (h *CatalogHandler) UpdateSubject(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req models.SubjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subject, err := h.catalogService.UpdateSubject(cast.ToUint(c.Param("id")), req)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Subject updated", "data": subject})
+}
+
+// GetLanguages - GET /api/v1/languages
+func (h *CatalogHandler) GetLanguages(c *gin.Context) {
+	languages, err := h.catalogService.GetLanguages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": languages})
+}
+
+// CreateLanguage - POST /api/v1/admin/languages
+func (h *CatalogHandler) CreateLanguage(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req models.InstructionLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	language, err := h.catalogService.CreateLanguage(req)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Language created", "data": language})
+}
+
+// SetTeacherSubjects - PUT /api/v1/teachers/:id/subjects
+func (h *CatalogHandler) SetTeacherSubjects(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return
+	}
+
+	var req models.TeacherSubjectsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.authorize(c, teacherID) {
+		return
+	}
+
+	subjects, err := h.catalogService.SetTeacherSubjects(teacherID, req)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Subjects updated", "data": subjects})
+}
+
+// SetTeacherLanguages - PUT /api/v1/teachers/:id/languages
+func (h *CatalogHandler) SetTeacherLanguages(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return
+	}
+
+	var req models.TeacherLanguagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.authorize(c, teacherID) {
+		return
+	}
+
+	languages, err := h.catalogService.SetTeacherLanguages(teacherID, req)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Languages updated", "data": languages})
+}
+
+func (h *CatalogHandler) authorize(c *gin.Context, teacherID uint) bool {
+	userID := cast.ToUint(c.MustGet("user_id"))
+	role := c.GetString("user_role")
+	if !h.catalogService.CanManageTeacher(userID, role, teacherID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this teacher"})
+		return false
+	}
+	return true
+}
+
+func respondCatalogError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasSuffix(err.Error(), "already exists"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -3,9 +3,12 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"teacher/internal/models"
 	"teacher/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
 )
 
 type DashboardHandler struct {
@@ -31,6 +34,12 @@ func (h *DashboardHandler) GetTeacherDashboard(c *gin.Context) {
 		return
 	}
 
+	userID := cast.ToUint(c.MustGet("user_id"))
+	if !h.dashboardService.CanManageTeacher(userID, c.GetString("user_role"), uint(teacherID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to view the dashboard of this teacher"})
+		return
+	}
+
 	dashboardData, err := h.dashboardService.GetTeacherDashboard(uint(teacherID))
 	if err != nil {
 		if err.Error() == "teacher not found" {
@@ -43,3 +52,42 @@ func (h *DashboardHandler) GetTeacherDashboard(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dashboardData)
 }
+
+// GetTeacherAnalytics - GET /api/v1/teachers/dashboard/:teacher_id/analytics
+//
+// Query: from, to (YYYY-MM-DD, default the last 30 days) and interval (day,
+// week or month, default day). Only the teacher and admins can see it.
+func (h *DashboardHandler) GetTeacherAnalytics(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("teacher_id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher_id format"})
+		return
+	}
+
+	var filter models.AnalyticsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := cast.ToUint(c.MustGet("user_id"))
+	if !h.dashboardService.CanManageTeacher(userID, c.GetString("user_role"), teacherID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to view analytics of this teacher"})
+		return
+	}
+
+	analytics, err := h.dashboardService.GetTeacherAnalytics(teacherID, filter)
+	if err != nil {
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"teacher/internal/models"
+	"teacher/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+type ExternalCalendarHandler struct {
+	externalService *service.ExternalCalendarService
+}
+
+func NewExternalCalendarHandler(externalService *service.ExternalCalendarService) *ExternalCalendarHandler {
+	return &ExternalCalendarHandler{
+		externalService: externalService,
+	}
+}
+
+// GetCalendars - GET /api/v1/teachers/:id/external-calendars
+func (h *ExternalCalendarHandler) GetCalendars(c *gin.Context) {
+	teacherID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	calendars, err := h.externalService.GetCalendars(teacherID)
+	if err != nil {
+		respondExternalCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": calendars})
+}
+
+// AddCalendarURL - POST /api/v1/teachers/:id/external-calendars
+func (h *ExternalCalendarHandler) AddCalendarURL(c *gin.Context) {
+	teacherID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	var req models.ExternalCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	calendar, err := h.externalService.AddCalendarURL(teacherID, req)
+	if err != nil {
+		respondExternalCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Calendar added", "data": calendar})
+}
+
+// UploadCalendar - POST /api/v1/teachers/:id/external-calendars/upload
+//
+// Expects a multipart form with the .ics file in "file" and an optional
+// display name in "name".
+func (h *ExternalCalendarHandler) UploadCalendar(c *gin.Context) {
+	teacherID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get file"})
+		return
+	}
+	if fileHeader.Size > service.MaxCalendarFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "calendar file is too large"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, service.MaxCalendarFileSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+
+	name := c.PostForm("name")
+	if name == "" {
+		name = strings.TrimSuffix(fileHeader.Filename, ".ics")
+	}
+
+	calendar, err := h.externalService.UploadCalendar(teacherID, name, content)
+	if err != nil {
+		respondExternalCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Calendar imported", "data": calendar})
+}
+
+// SyncCalendar - POST /api/v1/teachers/:id/external-calendars/:calendar_id/sync
+func (h *ExternalCalendarHandler) SyncCalendar(c *gin.Context) {
+	teacherID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	calendar, err := h.externalService.SyncCalendar(teacherID, cast.ToUint(c.Param("calendar_id")))
+	if err != nil {
+		respondExternalCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar synced", "data": calendar})
+}
+
+// DeleteCalendar - DELETE /api/v1/teachers/:id/external-calendars/:calendar_id
+func (h *ExternalCalendarHandler) DeleteCalendar(c *gin.Context) {
+	teacherID, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	if err := h.externalService.DeleteCalendar(teacherID, cast.ToUint(c.Param("calendar_id"))); err != nil {
+		respondExternalCalendarError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar deleted"})
+}
+
+func (h *ExternalCalendarHandler) authorize(c *gin.Context) (uint, bool) {
+	teacherID := cast.ToUint(c.Param("id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return 0, false
+	}
+
+	userID := cast.ToUint(c.MustGet("user_id"))
+	role := c.GetString("user_role")
+	if !h.externalService.CanManageTeacher(userID, role, teacherID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this teacher"})
+		return 0, false
+	}
+	return teacherID, true
+}
+
+func respondExternalCalendarError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strings"
 	"teacher/internal/models"
 	"teacher/internal/pkg"
 	"teacher/internal/service"
@@ -32,12 +33,22 @@ func (h *Handler) GetTeachers(c *gin.Context) {
 		paginate.Limit = cast.ToInt(limit)
 	}
 
-	response, err := h.teacherService.GetTeachers(&paginate)
+	var filter models.TeacherFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.teacherService.GetTeachers(&paginate, filter)
 	if err != nil {
 		if err.Error() == "teacher not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if strings.HasPrefix(err.Error(), "invalid") || strings.Contains(err.Error(), "must") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -46,7 +57,13 @@ func (h *Handler) GetTeachers(c *gin.Context) {
 
 }
 
+// CreateTeacher lets an admin create an approved teacher profile directly.
+// Teachers go through the application flow instead.
 func (h *Handler) CreateTeacher(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
 	var teacher models.TeacherRequest
 	if err := c.ShouldBindJSON(&teacher); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -63,6 +80,9 @@ func (h *Handler) CreateTeacher(c *gin.Context) {
 }
 
 func (h *Handler) DeleteTeacher(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
 
 	var id string
 	id = c.Param("id")
@@ -84,6 +104,39 @@ func (h *Handler) DeleteTeacher(c *gin.Context) {
 	c.JSON(http.StatusOK, teacher)
 }
 
+// RestoreTeacher - PUT /api/v1/admin/teachers/:id/restore
+func (h *Handler) RestoreTeacher(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	teacher, err := h.teacherService.RestoreTeacher(cast.ToUint(c.Param("id")))
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Teacher restored", "data": teacher})
+}
+
+// GetDeletedTeachers - GET /api/v1/admin/teachers/deleted
+func (h *Handler) GetDeletedTeachers(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	paginate := pkg.Paginate{
+		Page:  cast.ToInt(c.Query("page")),
+		Limit: cast.ToInt(c.Query("limit")),
+	}
+
+	response, err := h.teacherService.GetDeletedTeachers(&paginate)
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 func (h *Handler) UpdateTeacher(c *gin.Context) {
 
 	params := c.Params.ByName("id")
@@ -92,6 +145,13 @@ func (h *Handler) UpdateTeacher(c *gin.Context) {
 		return
 	}
 
+	// Only the teacher themself or an admin may edit the profile, which is
+	// also synced to the linked user account.
+	if !h.teacherService.CanManageTeacher(cast.ToUint(c.MustGet("user_id")), c.GetString("user_role"), cast.ToUint(params)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage this teacher"})
+		return
+	}
+
 	var teacher models.TeacherRequest
 	if err := c.ShouldBindJSON(&teacher); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"teacher/internal/models"
+	"teacher/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+type LessonTypeHandler struct {
+	lessonTypeService *service.LessonTypeService
+}
+
+func NewLessonTypeHandler(lessonTypeService *service.LessonTypeService) *LessonTypeHandler {
+	return &LessonTypeHandler{
+		lessonTypeService: lessonTypeService,
+	}
+}
+
+// GetLessonTypes - GET /api/v1/teachers/:id/lesson-types
+//
+// Only active lesson types are listed unless include_inactive=true is passed.
+func (h *LessonTypeHandler) GetLessonTypes(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return
+	}
+
+	activeOnly := !cast.ToBool(c.Query("include_inactive"))
+	lessonTypes, err := h.lessonTypeService.GetLessonTypes(teacherID, activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": lessonTypes})
+}
+
+// CreateLessonType - POST /api/v1/teachers/:id/lesson-types
+func (h *LessonTypeHandler) CreateLessonType(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return
+	}
+
+	var req models.LessonTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.authorize(c, teacherID) {
+		return
+	}
+
+	lessonType, err := h.lessonTypeService.CreateLessonType(teacherID, req)
+	if err != nil {
+		respondLessonTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Lesson type created", "data": lessonType})
+}
+
+// UpdateLessonType - PUT /api/v1/lesson-types/:id
+func (h *LessonTypeHandler) UpdateLessonType(c *gin.Context) {
+	id := cast.ToUint(c.Param("id"))
+	if id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lesson type id"})
+		return
+	}
+
+	var req models.LessonTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, err := h.lessonTypeService.GetLessonType(id)
+	if err != nil {
+		respondLessonTypeError(c, err)
+		return
+	}
+	if !h.authorize(c, current.TeacherID) {
+		return
+	}
+
+	lessonType, err := h.lessonTypeService.UpdateLessonType(id, req)
+	if err != nil {
+		respondLessonTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Lesson type updated", "data": lessonType})
+}
+
+// DeleteLessonType - DELETE /api/v1/lesson-types/:id
+func (h *LessonTypeHandler) DeleteLessonType(c *gin.Context) {
+	id := cast.ToUint(c.Param("id"))
+	if id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lesson type id"})
+		return
+	}
+
+	current, err := h.lessonTypeService.GetLessonType(id)
+	if err != nil {
+		respondLessonTypeError(c, err)
+		return
+	}
+	if !h.authorize(c, current.TeacherID) {
+		return
+	}
+
+	if err := h.lessonTypeService.DeactivateLessonType(id); err != nil {
+		respondLessonTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Lesson type deleted"})
+}
+
+func (h *LessonTypeHandler) authorize(c *gin.Context, teacherID uint) bool {
+	userID := cast.ToUint(c.MustGet("user_id"))
+	role := c.GetString("user_role")
+	if !h.lessonTypeService.CanManageTeacher(userID, role, teacherID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage lesson types of this teacher"})
+		return false
+	}
+	return true
+}
+
+func respondLessonTypeError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+	"teacher/internal/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+type OnboardingHandler struct {
+	onboardingService *service.OnboardingService
+}
+
+func NewOnboardingHandler(onboardingService *service.OnboardingService) *OnboardingHandler {
+	return &OnboardingHandler{
+		onboardingService: onboardingService,
+	}
+}
+
+// SubmitApplication - POST /api/v1/teachers/application
+func (h *OnboardingHandler) SubmitApplication(c *gin.Context) {
+	var req models.TeacherApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := cast.ToUint(c.MustGet("user_id"))
+	teacher, err := h.onboardingService.SubmitApplication(userID, c.GetString("user_role"), req)
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Application submitted", "data": teacher})
+}
+
+// UploadDocument - POST /api/v1/teachers/application/documents
+//
+// Expects a multipart form with the document in "file" and an optional
+// display name in "name".
+func (h *OnboardingHandler) UploadDocument(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get file"})
+		return
+	}
+
+	safeFilename := sanitizeFilename(fileHeader.Filename)
+	tempPath := filepath.Join(os.TempDir(), fmt.Sprintf("%d_%s", time.Now().UnixNano(), safeFilename))
+	if err := c.SaveUploadedFile(fileHeader, tempPath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+		return
+	}
+	defer os.Remove(tempPath)
+
+	userID := cast.ToUint(c.MustGet("user_id"))
+	document, err := h.onboardingService.UploadDocument(userID, c.PostForm("name"), tempPath, safeFilename)
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Document uploaded", "data": document})
+}
+
+// GetMyApplication - GET /api/v1/teachers/application
+func (h *OnboardingHandler) GetMyApplication(c *gin.Context) {
+	userID := cast.ToUint(c.MustGet("user_id"))
+	application, err := h.onboardingService.GetMyApplication(userID)
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, application)
+}
+
+// GetApplications - GET /api/v1/admin/teacher-applications
+func (h *OnboardingHandler) GetApplications(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	paginate := pkg.Paginate{
+		Page:  cast.ToInt(c.Query("page")),
+		Limit: cast.ToInt(c.Query("limit")),
+	}
+
+	response, err := h.onboardingService.GetApplications(c.Query("status"), &paginate)
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetApplication - GET /api/v1/admin/teacher-applications/:id
+func (h *OnboardingHandler) GetApplication(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	application, err := h.onboardingService.GetApplication(cast.ToUint(c.Param("id")))
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, application)
+}
+
+// ApproveApplication - PUT /api/v1/admin/teacher-applications/:id/approve
+func (h *OnboardingHandler) ApproveApplication(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	adminID := cast.ToUint(c.MustGet("user_id"))
+	teacher, err := h.onboardingService.ApproveApplication(adminID, cast.ToUint(c.Param("id")))
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Application approved", "data": teacher})
+}
+
+// RejectApplication - PUT /api/v1/admin/teacher-applications/:id/reject
+func (h *OnboardingHandler) RejectApplication(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req models.TeacherRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID := cast.ToUint(c.MustGet("user_id"))
+	teacher, err := h.onboardingService.RejectApplication(adminID, cast.ToUint(c.Param("id")), req.Reason)
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Application rejected", "data": teacher})
+}
+
+// ProvisionTeacherInternal - POST /api/v1/internal/teachers/provision
+func (h *OnboardingHandler) ProvisionTeacherInternal(c *gin.Context) {
+	var req models.TeacherProvisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	teacher, err := h.onboardingService.ProvisionTeacher(req)
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Teacher provisioned", "data": teacher})
+}
+
+// ArchiveTeacherInternal - POST /api/v1/internal/teachers/archive
+func (h *OnboardingHandler) ArchiveTeacherInternal(c *gin.Context) {
+	var req struct {
+		UserID    uint   `json:"user_id" binding:"required"`
+		ChangedBy uint   `json:"changed_by"`
+		Reason    string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.onboardingService.ArchiveTeacher(req.UserID, req.ChangedBy, req.Reason); err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Teacher archived"})
+}
+
+// SyncTeacherInternal - PUT /api/v1/internal/teachers/profile
+func (h *OnboardingHandler) SyncTeacherInternal(c *gin.Context) {
+	var req models.TeacherProvisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	teacher, err := h.onboardingService.SyncFromUser(req)
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Teacher updated", "data": teacher})
+}
+
+// requireAdmin aborts with 403 unless the authenticated user is an admin.
+func requireAdmin(c *gin.Context) bool {
+	if c.GetString("user_role") != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+		return false
+	}
+	return true
+}
+
+func respondOnboardingError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "only users with"):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
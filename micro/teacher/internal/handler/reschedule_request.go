@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"teacher/internal/infrastructure/booking"
+	"teacher/internal/models"
+	"teacher/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+type RescheduleRequestHandler struct {
+	rescheduleService *service.RescheduleRequestService
+}
+
+func NewRescheduleRequestHandler(rescheduleService *service.RescheduleRequestService) *RescheduleRequestHandler {
+	return &RescheduleRequestHandler{
+		rescheduleService: rescheduleService,
+	}
+}
+
+// ProposeReschedule - POST /api/v1/teachers/dashboard/:teacher_id/reschedule-requests
+//
+// Body: booking_id, schedule_ids (up to 5 of the teacher's open slots) and
+// an optional reason.
+func (h *RescheduleRequestHandler) ProposeReschedule(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("teacher_id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return
+	}
+
+	var req models.RescheduleProposal
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.authorize(c, teacherID) {
+		return
+	}
+
+	request, err := h.rescheduleService.ProposeReschedule(teacherID, req)
+	if err != nil {
+		respondRescheduleRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Reschedule request sent", "data": request})
+}
+
+// GetRescheduleRequests - GET /api/v1/teachers/dashboard/:teacher_id/reschedule-requests
+//
+// Query: status.
+func (h *RescheduleRequestHandler) GetRescheduleRequests(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("teacher_id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return
+	}
+	if !h.authorize(c, teacherID) {
+		return
+	}
+
+	requests, err := h.rescheduleService.GetRescheduleRequests(teacherID, c.Query("status"))
+	if err != nil {
+		respondRescheduleRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+// WithdrawRescheduleRequest - POST /api/v1/teachers/dashboard/:teacher_id/reschedule-requests/:id/withdraw
+func (h *RescheduleRequestHandler) WithdrawRescheduleRequest(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("teacher_id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return
+	}
+	if !h.authorize(c, teacherID) {
+		return
+	}
+
+	request, err := h.rescheduleService.WithdrawRescheduleRequest(teacherID, cast.ToUint(c.Param("id")))
+	if err != nil {
+		respondRescheduleRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reschedule request withdrawn", "data": request})
+}
+
+func (h *RescheduleRequestHandler) authorize(c *gin.Context, teacherID uint) bool {
+	userID := cast.ToUint(c.MustGet("user_id"))
+	role := c.GetString("user_role")
+	if !h.rescheduleService.CanManageTeacher(userID, role, teacherID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to reschedule lessons of this teacher"})
+		return false
+	}
+	return true
+}
+
+// respondRescheduleRequestError keeps the status the booking service
+// answered with for errors it reported.
+func respondRescheduleRequestError(c *gin.Context, err error) {
+	var requestErr *booking.RequestError
+	switch {
+	case errors.As(err, &requestErr):
+		c.JSON(requestErr.Status, gin.H{"error": requestErr.Message})
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+	"teacher/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+type ReviewHandler struct {
+	reviewService *service.ReviewService
+}
+
+func NewReviewHandler(reviewService *service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+	}
+}
+
+// CreateReview - POST /api/v1/reviews
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	var req models.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := cast.ToUint(c.MustGet("user_id"))
+	review, err := h.reviewService.CreateReview(userID, req)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Review created", "data": review})
+}
+
+// GetTeacherReviews - GET /api/v1/teachers/:id/reviews
+func (h *ReviewHandler) GetTeacherReviews(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return
+	}
+
+	paginate := pkg.Paginate{
+		Page:  cast.ToInt(c.Query("page")),
+		Limit: cast.ToInt(c.Query("limit")),
+	}
+
+	response, err := h.reviewService.GetTeacherReviews(teacherID, &paginate)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ReplyToReview - PUT /api/v1/reviews/:id/reply
+func (h *ReviewHandler) ReplyToReview(c *gin.Context) {
+	id := cast.ToUint(c.Param("id"))
+	if id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review id"})
+		return
+	}
+
+	var req models.ReviewReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := cast.ToUint(c.MustGet("user_id"))
+	review, err := h.reviewService.ReplyToReview(userID, c.GetString("user_role"), id, req.Reply)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reply saved", "data": review})
+}
+
+// GetReviewsForModeration - GET /api/v1/admin/reviews
+func (h *ReviewHandler) GetReviewsForModeration(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	paginate := pkg.Paginate{
+		Page:  cast.ToInt(c.Query("page")),
+		Limit: cast.ToInt(c.Query("limit")),
+	}
+
+	response, err := h.reviewService.GetReviewsForModeration(cast.ToBool(c.Query("flagged")), &paginate)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ModerateReview - PUT /api/v1/admin/reviews/:id
+func (h *ReviewHandler) ModerateReview(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := cast.ToUint(c.Param("id"))
+	if id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review id"})
+		return
+	}
+
+	var req models.ReviewModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.reviewService.ModerateReview(id, req)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Review updated", "data": review})
+}
+
+func respondReviewError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "only the reviewed teacher"):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
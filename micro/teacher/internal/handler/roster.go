@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+	"teacher/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+type RosterHandler struct {
+	rosterService *service.RosterService
+}
+
+func NewRosterHandler(rosterService *service.RosterService) *RosterHandler {
+	return &RosterHandler{
+		rosterService: rosterService,
+	}
+}
+
+// GetRoster - GET /api/v1/teachers/dashboard/:teacher_id/students
+//
+// Query: page, limit and sort (recent or upcoming).
+func (h *RosterHandler) GetRoster(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("teacher_id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return
+	}
+	if !h.authorize(c, teacherID) {
+		return
+	}
+
+	paginate := pkg.Paginate{
+		Page:  cast.ToInt(c.Query("page")),
+		Limit: cast.ToInt(c.Query("limit")),
+	}
+
+	response, err := h.rosterService.GetRoster(teacherID, c.Query("sort"), &paginate)
+	if err != nil {
+		respondRosterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetNote - PUT /api/v1/teachers/dashboard/:teacher_id/students/:student_id/note
+func (h *RosterHandler) SetNote(c *gin.Context) {
+	teacherID := cast.ToUint(c.Param("teacher_id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid teacher id"})
+		return
+	}
+
+	var req models.StudentNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.authorize(c, teacherID) {
+		return
+	}
+
+	note, err := h.rosterService.SetNote(teacherID, cast.ToUint(c.Param("student_id")), req.Note)
+	if err != nil {
+		respondRosterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Note saved", "data": note})
+}
+
+func (h *RosterHandler) authorize(c *gin.Context, teacherID uint) bool {
+	userID := cast.ToUint(c.MustGet("user_id"))
+	role := c.GetString("user_role")
+	if !h.rosterService.CanManageTeacher(userID, role, teacherID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to view students of this teacher"})
+		return false
+	}
+	return true
+}
+
+func respondRosterError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
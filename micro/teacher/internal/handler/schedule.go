@@ -3,6 +3,7 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"teacher/internal/models"
 	"teacher/internal/pkg"
 	"teacher/internal/service"
@@ -122,6 +123,20 @@ func (s *ScheduleHandler) DeleteSchedule(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted"})
 }
 
+// RestoreSchedule - PUT /api/v1/admin/schedules/:id/restore
+func (s *ScheduleHandler) RestoreSchedule(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	schedule, err := s.scheduleService.RestoreScheduleService(cast.ToUint(c.Param("id")))
+	if err != nil {
+		respondOnboardingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule restored", "data": schedule})
+}
+
 func (s *ScheduleHandler) CreateSchedule(c *gin.Context) {
 	var req models.ScheduleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -140,10 +155,16 @@ func (s *ScheduleHandler) CreateSchedule(c *gin.Context) {
 	endTime := parseTime.EndTime
 
 	schedule := models.Schedule{
-		TeacherID: req.TeacherID,
-		Date:      date,
-		StartTime: pkg.NormalizeTime(startTime),
-		EndTime:   pkg.NormalizeTime(endTime),
+		TeacherID:    req.TeacherID,
+		Date:         date,
+		StartTime:    pkg.NormalizeTime(startTime),
+		EndTime:      pkg.NormalizeTime(endTime),
+		LessonTypeID: req.LessonTypeID,
+		SubjectID:    req.SubjectID,
+		Capacity:     req.Capacity,
+		SeatPrice:    req.SeatPrice,
+		MinSeats:     req.MinSeats,
+		CutoffHours:  req.CutoffHours,
 	}
 
 	err = s.scheduleService.CreateScheduleService(&schedule)
@@ -168,6 +189,10 @@ func (s *ScheduleHandler) UpdateScheduleStatus(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if err.Error() == "schedule is not available" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -195,6 +220,92 @@ func (s *ScheduleHandler) GetBatchScheduleDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, schedules)
 }
 
+// MatchSlotsInternal finds the teacher's slots with given times on given
+// dates. The booking service uses it to validate recurring bookings.
+func (s *ScheduleHandler) MatchSlotsInternal(c *gin.Context) {
+	var req models.ScheduleMatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedules, err := s.scheduleService.MatchSlots(req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+// ReserveSchedulesInternal books several slots at once, or none of them.
+// The booking service uses it for recurring bookings.
+func (s *ScheduleHandler) ReserveSchedulesInternal(c *gin.Context) {
+	var req models.ScheduleBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.scheduleService.ReserveSchedules(req); err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		case strings.HasSuffix(err.Error(), "is not available"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "schedules reserved"})
+}
+
+// ReleaseSchedulesInternal undoes ReserveSchedulesInternal.
+func (s *ScheduleHandler) ReleaseSchedulesInternal(c *gin.Context) {
+	var req models.ScheduleBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.scheduleService.ReleaseSchedules(req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "schedules released"})
+}
+
+// HoldScheduleInternal holds a slot for a waitlisted student. The booking
+// service calls it when it offers the slot.
+func (s *ScheduleHandler) HoldScheduleInternal(c *gin.Context) {
+	id := cast.ToUint(c.Param("id"))
+	if id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule id"})
+		return
+	}
+
+	var req models.ScheduleHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.scheduleService.HoldSchedule(id, req); err != nil {
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "schedule hold updated"})
+}
+
 func (s *ScheduleHandler) FilterByTeacher(c *gin.Context) {
 	var req models.ScheduleFilterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -253,11 +364,17 @@ func (s *ScheduleHandler) UpdateSchedule(c *gin.Context) {
 	endTime := parseTime.EndTime
 
 	schedule := models.Schedule{
-		ID:        cast.ToUint(id),
-		Date:      date,
-		StartTime: pkg.NormalizeTime(startTime),
-		EndTime:   pkg.NormalizeTime(endTime),
-		Status:    req.Status,
+		ID:           cast.ToUint(id),
+		Date:         date,
+		StartTime:    pkg.NormalizeTime(startTime),
+		EndTime:      pkg.NormalizeTime(endTime),
+		Status:       req.Status,
+		LessonTypeID: req.LessonTypeID,
+		SubjectID:    req.SubjectID,
+		Capacity:     req.Capacity,
+		SeatPrice:    req.SeatPrice,
+		MinSeats:     req.MinSeats,
+		CutoffHours:  req.CutoffHours,
 	}
 
 	err = s.scheduleService.Update(&schedule)
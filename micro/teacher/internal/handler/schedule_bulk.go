@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"teacher/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// BulkCreateSchedules - POST /api/v1/schedule/bulk
+func (s *ScheduleHandler) BulkCreateSchedules(c *gin.Context) {
+	var req models.BulkScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !s.authorizeTeacher(c, req.TeacherID) {
+		return
+	}
+
+	report, err := s.scheduleService.BulkCreateSchedules(req.TeacherID, req.Schedules)
+	s.respondBulk(c, report, err, "Schedules created")
+}
+
+// BulkCreateSchedulesCSV - POST /api/v1/schedule/bulk/csv
+//
+// Expects a multipart form with a teacher_id field and a CSV file in "file"
+// with the columns date,start_time,end_time. A header row is optional.
+func (s *ScheduleHandler) BulkCreateSchedulesCSV(c *gin.Context) {
+	teacherID := cast.ToUint(c.PostForm("teacher_id"))
+	if teacherID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "teacher_id is required"})
+		return
+	}
+
+	if !s.authorizeTeacher(c, teacherID) {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get file"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	rows, err := parseScheduleCSV(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := s.scheduleService.BulkCreateSchedules(teacherID, rows)
+	s.respondBulk(c, report, err, "Schedules created")
+}
+
+// BulkShiftSchedules - PUT /api/v1/schedule/bulk/shift
+func (s *ScheduleHandler) BulkShiftSchedules(c *gin.Context) {
+	var req models.BulkShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !s.authorizeTeacher(c, req.TeacherID) {
+		return
+	}
+
+	report, err := s.scheduleService.BulkShiftSchedules(req)
+	s.respondBulk(c, report, err, "Schedules shifted")
+}
+
+// BulkCancelSchedules - PUT /api/v1/schedule/bulk/cancel
+func (s *ScheduleHandler) BulkCancelSchedules(c *gin.Context) {
+	var req models.BulkCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !s.authorizeTeacher(c, req.TeacherID) {
+		return
+	}
+
+	report, err := s.scheduleService.BulkCancelSchedules(req)
+	s.respondBulk(c, report, err, "Schedules cancelled")
+}
+
+func (s *ScheduleHandler) respondBulk(c *gin.Context, report *models.BulkScheduleResponse, err error, message string) {
+	if err != nil {
+		switch {
+		case err.Error() == "bulk validation failed":
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report": report})
+		case err.Error() == "teacher not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "report": report})
+}
+
+// authorizeTeacher aborts with 403 unless the authenticated user is an admin
+// or the owner of the teacher profile.
+func (s *ScheduleHandler) authorizeTeacher(c *gin.Context, teacherID uint) bool {
+	userID := cast.ToUint(c.MustGet("user_id"))
+	role := c.GetString("user_role")
+	if !s.scheduleService.CanManageTeacher(userID, role, teacherID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to manage schedules of this teacher"})
+		return false
+	}
+	return true
+}
+
+func parseScheduleCSV(r io.Reader) ([]models.ScheduleRequest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []models.ScheduleRequest
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid csv file")
+		}
+		if line == 1 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+
+		// Keep malformed rows so they show up in the validation report with
+		// their position instead of being dropped.
+		for len(record) < 3 {
+			record = append(record, "")
+		}
+		rows = append(rows, models.ScheduleRequest{
+			Date:      strings.TrimSpace(record[0]),
+			StartTime: strings.TrimSpace(record[1]),
+			EndTime:   strings.TrimSpace(record[2]),
+		})
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("csv file has no rows")
+	}
+
+	return rows, nil
+}
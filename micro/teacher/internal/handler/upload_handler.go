@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -49,11 +48,7 @@ func (h *UploadHandler) UploadHandler(c *gin.Context) {
 	}
 
 	// Public URL jika bucket diatur "public"
-	fileURL := fmt.Sprintf("%s/storage/v1/object/public/%s/%s",
-		h.cfg.Endpoint,
-		h.cfg.BucketName,
-		objectName,
-	)
+	fileURL := h.uploadService.PublicURL(objectName)
 
 	c.JSON(http.StatusOK, gin.H{"message": "File uploaded successfully", "file_url": fileURL})
 }
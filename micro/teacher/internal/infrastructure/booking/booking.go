@@ -1,9 +1,11 @@
 package booking
 
 import (
+	"encoding/json"
 	"fmt"
 	"teacher/internal/config"
 	"teacher/internal/models"
+	"teacher/internal/pkg"
 
 	"github.com/go-resty/resty/v2"
 )
@@ -45,3 +47,233 @@ func (s *BookingService) GetBookingByTeacherId(teacherId uint) ([]models.Teacher
 	return bookings.Bookings, nil
 
 }
+
+// CancelBookingsBySchedules asks the booking service to cancel, and refund
+// when already paid, every booking holding one of the given schedules. The
+// bookings it could not cancel are returned; asking again retries them.
+func (s *BookingService) CancelBookingsBySchedules(scheduleIDs []uint, reason string) ([]models.BookingCancelFailure, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/bookings/cancel-by-schedules", s.Cfg.Host)
+
+	var result struct {
+		Failed []models.BookingCancelFailure `json:"failed"`
+	}
+	resp, err := s.Client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader(config.InternalSecretHeader, s.Cfg.InternalSecret).
+		SetBody(map[string]interface{}{
+			"schedule_ids": scheduleIDs,
+			"reason":       reason,
+		}).
+		SetResult(&result).
+		Post(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("booking service returned status: %d", resp.StatusCode())
+	}
+
+	return result.Failed, nil
+}
+
+// NotifySlotsPublished tells the booking service that the given schedules
+// became bookable, so they can be offered to waitlisted students.
+func (s *BookingService) NotifySlotsPublished(scheduleIDs []uint) error {
+	url := fmt.Sprintf("%s/api/v1/internal/waitlist/slots-published", s.Cfg.Host)
+
+	resp, err := s.Client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]interface{}{
+			"schedule_ids": scheduleIDs,
+		}).
+		Post(url)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != 200 && resp.StatusCode() != 202 {
+		return fmt.Errorf("booking service returned status: %d", resp.StatusCode())
+	}
+
+	return nil
+}
+
+// GetBooking fetches a single booking from the booking service's internal
+// endpoint.
+func (s *BookingService) GetBooking(bookingID uint) (*models.BookingDetail, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/bookings/%d", s.Cfg.Host, bookingID)
+
+	var result struct {
+		Booking models.BookingDetail `json:"booking"`
+	}
+
+	resp, err := s.Client.R().SetResult(&result).Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() == 404 {
+		return nil, fmt.Errorf("booking not found")
+	}
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("booking service returned status: %d", resp.StatusCode())
+	}
+
+	return &result.Booking, nil
+}
+
+// GetTeacherAnalytics fetches the aggregated booking figures of a teacher
+// between from and to (inclusive, YYYY-MM-DD).
+func (s *BookingService) GetTeacherAnalytics(teacherID uint, from, to, interval string) (*models.BookingAnalytics, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/bookings/teacher/%d/analytics", s.Cfg.Host, teacherID)
+
+	var analytics models.BookingAnalytics
+	resp, err := s.Client.R().
+		SetQueryParams(map[string]string{
+			"from":     from,
+			"to":       to,
+			"interval": interval,
+		}).
+		SetResult(&analytics).
+		Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("booking service returned status: %d", resp.StatusCode())
+	}
+
+	return &analytics, nil
+}
+
+// GetTeacherStudents fetches one page of the teacher's student roster with
+// the booking figures filled in.
+func (s *BookingService) GetTeacherStudents(teacherID uint, sort string, paginate pkg.Paginate) ([]models.RosterStudent, pkg.PaginationPage, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/bookings/teacher/%d/students", s.Cfg.Host, teacherID)
+
+	var result struct {
+		Data       []models.RosterStudent `json:"data"`
+		Pagination pkg.PaginationPage     `json:"pagination"`
+	}
+	resp, err := s.Client.R().
+		SetQueryParams(map[string]string{
+			"page":  fmt.Sprint(paginate.Page),
+			"limit": fmt.Sprint(paginate.Limit),
+			"sort":  sort,
+		}).
+		SetResult(&result).
+		Get(url)
+	if err != nil {
+		return nil, pkg.PaginationPage{}, err
+	}
+
+	if resp.StatusCode() != 200 {
+		return nil, pkg.PaginationPage{}, fmt.Errorf("booking service returned status: %d", resp.StatusCode())
+	}
+
+	return result.Data, result.Pagination, nil
+}
+
+// ProposeReschedule asks the booking service to send a reschedule request
+// to the student of the booking. Errors the booking service reports for the
+// request itself are returned with their message.
+func (s *BookingService) ProposeReschedule(teacherID, teacherUserID uint, proposal models.RescheduleProposal) (*models.RescheduleRequest, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/reschedule-requests", s.Cfg.Host)
+
+	var result struct {
+		Data models.RescheduleRequest `json:"data"`
+	}
+	resp, err := s.Client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]interface{}{
+			"teacher_id":      teacherID,
+			"teacher_user_id": teacherUserID,
+			"booking_id":      proposal.BookingID,
+			"schedule_ids":    proposal.ScheduleIDs,
+			"reason":          proposal.Reason,
+		}).
+		SetResult(&result).
+		Post(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() != 201 {
+		return nil, bookingError(resp)
+	}
+
+	return &result.Data, nil
+}
+
+// GetRescheduleRequests lists the reschedule requests of a teacher. An
+// empty status lists all of them.
+func (s *BookingService) GetRescheduleRequests(teacherID uint, status string) ([]models.RescheduleRequest, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/reschedule-requests/teacher/%d", s.Cfg.Host, teacherID)
+
+	var result struct {
+		Data []models.RescheduleRequest `json:"data"`
+	}
+	resp, err := s.Client.R().
+		SetQueryParam("status", status).
+		SetResult(&result).
+		Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("booking service returned status: %d", resp.StatusCode())
+	}
+
+	return result.Data, nil
+}
+
+// WithdrawRescheduleRequest takes back a pending reschedule request of the
+// teacher.
+func (s *BookingService) WithdrawRescheduleRequest(teacherID, id uint) (*models.RescheduleRequest, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/reschedule-requests/%d/withdraw", s.Cfg.Host, id)
+
+	var result struct {
+		Data models.RescheduleRequest `json:"data"`
+	}
+	resp, err := s.Client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]interface{}{"teacher_id": teacherID}).
+		SetResult(&result).
+		Post(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() != 200 {
+		return nil, bookingError(resp)
+	}
+
+	return &result.Data, nil
+}
+
+// RequestError is an error the booking service reported for the request
+// itself, as opposed to a failure to reach it.
+type RequestError struct {
+	Status  int
+	Message string
+}
+
+func (e *RequestError) Error() string {
+	return e.Message
+}
+
+// bookingError turns a 4xx response carrying an error message into a
+// RequestError. Anything else is reported by status.
+func bookingError(resp *resty.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if resp.StatusCode() >= 400 && resp.StatusCode() < 500 &&
+		json.Unmarshal(resp.Body(), &body) == nil && body.Error != "" {
+		return &RequestError{Status: resp.StatusCode(), Message: body.Error}
+	}
+	return fmt.Errorf("booking service returned status: %d", resp.StatusCode())
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"log"
 	"teacher/internal/config"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
+// defaultSignedURLExpiry applies when CLIENT_SIGNED_URL_EXPIRY is not set.
+const defaultSignedURLExpiry = 5 * time.Minute
+
 type Client struct {
 	Client *resty.Client
 	Cfg    *config.Client
@@ -18,10 +22,23 @@ func InitUploadClient(cfg *config.Client, restyClient *resty.Client) *Client {
 	return &Client{Client: restyClient, Cfg: cfg}
 }
 func (u *Client) UploadToSupabase(objectName, filePath string) error {
+	return u.upload(u.Cfg.BucketName, objectName, filePath)
+}
+
+// UploadPrivate uploads a file to the private bucket. It can only be read
+// through a SignedURL.
+func (u *Client) UploadPrivate(objectName, filePath string) error {
+	if u.Cfg.PrivateBucketName == "" {
+		return fmt.Errorf("private bucket is not configured")
+	}
+	return u.upload(u.Cfg.PrivateBucketName, objectName, filePath)
+}
+
+func (u *Client) upload(bucket, objectName, filePath string) error {
 
 	url := fmt.Sprintf("%s/storage/v1/object/%s/%s",
 		u.Cfg.Endpoint,
-		u.Cfg.BucketName,
+		bucket,
 		objectName,
 	)
 
@@ -46,3 +63,44 @@ func (u *Client) UploadToSupabase(objectName, filePath string) error {
 
 	return nil
 }
+
+// PublicURL returns the public URL of an uploaded object. It only resolves
+// when the bucket is configured as public.
+func (u *Client) PublicURL(objectName string) string {
+	return fmt.Sprintf("%s/storage/v1/object/public/%s/%s",
+		u.Cfg.Endpoint,
+		u.Cfg.BucketName,
+		objectName,
+	)
+}
+
+// SignedURL returns a short-lived URL to an object of the private bucket.
+func (u *Client) SignedURL(objectName string) (string, error) {
+	expiry := u.Cfg.SignedURLExpiry
+	if expiry <= 0 {
+		expiry = defaultSignedURLExpiry
+	}
+
+	url := fmt.Sprintf("%s/storage/v1/object/sign/%s/%s",
+		u.Cfg.Endpoint,
+		u.Cfg.PrivateBucketName,
+		objectName,
+	)
+
+	var result struct {
+		SignedURL string `json:"signedURL"`
+	}
+	resp, err := u.Client.R().
+		SetHeader("Authorization", "Bearer "+u.Cfg.AccessKey).
+		SetBody(map[string]interface{}{"expiresIn": int(expiry.Seconds())}).
+		SetResult(&result).
+		Post(url)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode() >= 300 || result.SignedURL == "" {
+		return "", fmt.Errorf("signing failed: %s", resp.String())
+	}
+
+	return u.Cfg.Endpoint + "/storage/v1" + result.SignedURL, nil
+}
@@ -0,0 +1,83 @@
+package user
+
+import (
+	"fmt"
+	"teacher/internal/config"
+
+	"github.com/go-resty/resty/v2"
+)
+
+type UserService struct {
+	Client *resty.Client
+	Cfg    *config.Service
+}
+
+func NewUserService(initRestyClient *resty.Client, cfg *config.Service) *UserService {
+	return &UserService{
+		Client: initRestyClient,
+		Cfg:    cfg,
+	}
+}
+
+// SyncProfile pushes a teacher's name and avatar to the linked user account
+// through the user service's internal endpoint, authenticated by the shared
+// secret.
+func (s *UserService) SyncProfile(userID uint, name, profileImage string) error {
+	url := fmt.Sprintf("%s/api/v1/internal/users/%d/profile", s.Cfg.Host, userID)
+
+	resp, err := s.Client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader(config.InternalSecretHeader, s.Cfg.InternalSecret).
+		SetBody(map[string]interface{}{
+			"name":          name,
+			"profile_image": profileImage,
+		}).
+		Put(url)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("user service returned status: %d", resp.StatusCode())
+	}
+
+	return nil
+}
+
+// UserResponse holds the contact details returned by the user service.
+type UserResponse struct {
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	ProfileImage string `json:"profile_image"`
+}
+
+// GetUsers looks up several users with one call to the user service's
+// internal batch endpoint, authenticated by the shared secret. Unknown ids
+// are missing from the result.
+func (s *UserService) GetUsers(userIDs []uint) (map[uint]UserResponse, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/users/batch", s.Cfg.Host)
+
+	var result struct {
+		Users []UserResponse `json:"users"`
+	}
+	resp, err := s.Client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader(config.InternalSecretHeader, s.Cfg.InternalSecret).
+		SetBody(map[string]interface{}{"ids": userIDs}).
+		SetResult(&result).
+		Post(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("user service returned status: %d", resp.StatusCode())
+	}
+
+	users := make(map[uint]UserResponse, len(result.Users))
+	for _, user := range result.Users {
+		users[user.ID] = user
+	}
+	return users, nil
+}
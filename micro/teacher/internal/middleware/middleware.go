@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/cast"
 )
 
 func AuthMiddleware(jwtConfig *config.JWT) gin.HandlerFunc {
@@ -40,9 +41,10 @@ func AuthMiddleware(jwtConfig *config.JWT) gin.HandlerFunc {
 			return
 		}
 
-		// Simpan ke context
-		c.Set("user_id", uint(claims["user_id"].(float64)))
-		c.Set("user_role", claims["role"].(string))
+		// Simpan ke context. The user service signs user_id as a string, so
+		// cast instead of asserting a numeric claim.
+		c.Set("user_id", cast.ToUint(claims["user_id"]))
+		c.Set("user_role", cast.ToString(claims["role"]))
 
 		c.Next()
 	}
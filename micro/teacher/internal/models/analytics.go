@@ -0,0 +1,70 @@
+package models
+
+// Intervals the teacher analytics can be grouped by.
+const (
+	AnalyticsIntervalDay   = "day"
+	AnalyticsIntervalWeek  = "week"
+	AnalyticsIntervalMonth = "month"
+)
+
+// AnalyticsFilter is the query of the teacher analytics endpoint. From and
+// To are inclusive dates (YYYY-MM-DD).
+type AnalyticsFilter struct {
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Interval string `form:"interval"`
+}
+
+// AnalyticsPeriod holds the figures of one period. Period is YYYY-MM-DD,
+// YYYY-Www (ISO week) or YYYY-MM depending on the interval. Occupancy is the
+// share of offered hours that got booked.
+type AnalyticsPeriod struct {
+	Period        string  `json:"period"`
+	Bookings      int     `json:"bookings"`
+	Cancellations int     `json:"cancellations"`
+	Lessons       int     `json:"lessons"`
+	Earnings      float64 `json:"earnings"`
+	NewStudents   int     `json:"new_students"`
+	BookedHours   float64 `json:"booked_hours"`
+	OfferedHours  float64 `json:"offered_hours"`
+	Occupancy     float64 `json:"occupancy"`
+}
+
+// BookingAnalytics is the part of the analytics computed by the booking
+// service.
+type BookingAnalytics struct {
+	Summary AnalyticsSummary  `json:"summary"`
+	Series  []AnalyticsPeriod `json:"series"`
+}
+
+// ScheduleHours is the booked and still available time of the teacher's
+// slots in one period, in minutes.
+type ScheduleHours struct {
+	Period           string
+	BookedMinutes    float64
+	AvailableMinutes float64
+}
+
+type AnalyticsSummary struct {
+	Bookings          int     `json:"bookings"`
+	Cancellations     int     `json:"cancellations"`
+	CancellationRate  float64 `json:"cancellation_rate"`
+	Lessons           int     `json:"lessons"`
+	Earnings          float64 `json:"earnings"`
+	NewStudents       int     `json:"new_students"`
+	Students          int     `json:"students"`
+	RepeatStudents    int     `json:"repeat_students"`
+	RepeatStudentRate float64 `json:"repeat_student_rate"`
+	BookedHours       float64 `json:"booked_hours"`
+	OfferedHours      float64 `json:"offered_hours"`
+	Occupancy         float64 `json:"occupancy"`
+}
+
+type TeacherAnalyticsResponse struct {
+	TeacherID uint              `json:"teacher_id"`
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	Interval  string            `json:"interval"`
+	Summary   AnalyticsSummary  `json:"summary"`
+	Series    []AnalyticsPeriod `json:"series"`
+}
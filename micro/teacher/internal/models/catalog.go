@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// Subject is an entry of the catalog of things that can be taught, e.g.
+// JLPT N3 preparation or business Japanese. Subjects are never deleted,
+// only deactivated, because teachers keep referencing them.
+type Subject struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Code        string    `gorm:"size:50;uniqueIndex;not null" json:"code"`
+	Name        string    `gorm:"size:100;not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	IsActive    bool      `gorm:"not null" json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// InstructionLanguage is a language a teacher can explain lessons in.
+type InstructionLanguage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Code      string    `gorm:"size:10;uniqueIndex;not null" json:"code"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TeacherSubject assigns a subject to a teacher. PricePerHour overrides the
+// teacher's hourly rate for that subject when set.
+type TeacherSubject struct {
+	TeacherID    uint      `gorm:"primaryKey;type:int unsigned" json:"teacher_id"`
+	SubjectID    uint      `gorm:"primaryKey;index" json:"subject_id"`
+	Subject      *Subject  `gorm:"foreignKey:SubjectID" json:"subject,omitempty"`
+	PricePerHour *float64  `json:"price_per_hour"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TeacherLanguage assigns an instruction language to a teacher.
+type TeacherLanguage struct {
+	TeacherID  uint                 `gorm:"primaryKey;type:int unsigned" json:"teacher_id"`
+	LanguageID uint                 `gorm:"primaryKey;index" json:"language_id"`
+	Language   *InstructionLanguage `gorm:"foreignKey:LanguageID" json:"language,omitempty"`
+}
+
+// DefaultSubjects and DefaultLanguages are created on startup when missing.
+var (
+	DefaultSubjects = []Subject{
+		{Code: "jlpt-n5", Name: "JLPT N5 Preparation"},
+		{Code: "jlpt-n4", Name: "JLPT N4 Preparation"},
+		{Code: "jlpt-n3", Name: "JLPT N3 Preparation"},
+		{Code: "jlpt-n2", Name: "JLPT N2 Preparation"},
+		{Code: "jlpt-n1", Name: "JLPT N1 Preparation"},
+		{Code: "conversation", Name: "Conversation"},
+		{Code: "business", Name: "Business Japanese"},
+		{Code: "kanji", Name: "Kanji"},
+	}
+	DefaultLanguages = []InstructionLanguage{
+		{Code: "id", Name: "Indonesian"},
+		{Code: "en", Name: "English"},
+		{Code: "ja", Name: "Japanese"},
+	}
+)
+
+type SubjectRequest struct {
+	Code        string `json:"code" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+type InstructionLanguageRequest struct {
+	Code string `json:"code" binding:"required"`
+	Name string `json:"name" binding:"required"`
+}
+
+type TeacherSubjectItem struct {
+	SubjectID    uint     `json:"subject_id" binding:"required"`
+	PricePerHour *float64 `json:"price_per_hour"`
+}
+
+// TeacherSubjectsRequest replaces all subjects of a teacher.
+type TeacherSubjectsRequest struct {
+	Subjects []TeacherSubjectItem `json:"subjects" binding:"dive"`
+}
+
+// TeacherLanguagesRequest replaces all instruction languages of a teacher.
+type TeacherLanguagesRequest struct {
+	LanguageIDs []uint `json:"language_ids"`
+}
@@ -8,6 +8,7 @@ type TeacherDashboardResponse struct {
 	UpcomingBookings []BookingInfo   `json:"upcoming_bookings"`
 	RecentStudents   []StudentInfo   `json:"recent_students"`
 	CompletedLessons []BookingInfo   `json:"completed_lessons"`
+	GroupClasses     []GroupClass    `json:"group_classes"`
 }
 
 type DashboardStats struct {
@@ -17,6 +18,18 @@ type DashboardStats struct {
 	TotalEarnings    float64 `json:"total_earnings"`
 }
 
+// GroupClass is the enrolment of an upcoming group class.
+type GroupClass struct {
+	ScheduleID uint   `json:"schedule_id"`
+	Date       string `json:"date"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	Status     string `json:"status"`
+	Capacity   int    `json:"capacity"`
+	SeatsTaken int    `json:"seats_taken"`
+	MinSeats   int    `json:"min_seats"`
+}
+
 type BookingInfo struct {
 	ID          uint    `json:"id"`
 	StudentID   uint    `json:"student_id"`
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// External calendar sources.
+const (
+	ExternalCalendarSourceURL    = "url"
+	ExternalCalendarSourceUpload = "upload"
+)
+
+// ExternalCalendar is a teacher's personal calendar whose busy times block
+// their slots. URL calendars are fetched on every sync, uploaded files are
+// kept in Content and re-expanded as the sync window moves.
+type ExternalCalendar struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	TeacherID    uint       `gorm:"index;not null" json:"teacher_id"`
+	Name         string     `gorm:"size:100" json:"name"`
+	Source       string     `gorm:"type:enum('url','upload');not null" json:"source"`
+	URL          string     `gorm:"size:2048" json:"url,omitempty"`
+	Content      string     `gorm:"type:mediumtext" json:"-"`
+	BusyCount    int        `json:"busy_count"`
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	LastError    string     `gorm:"size:500" json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// BusyTime is one imported occurrence of an external calendar event.
+type BusyTime struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CalendarID uint      `gorm:"index;not null" json:"calendar_id"`
+	TeacherID  uint      `gorm:"index:idx_busy_times_teacher_range,priority:1;not null" json:"teacher_id"`
+	StartAt    time.Time `gorm:"index:idx_busy_times_teacher_range,priority:2;not null" json:"start_at"`
+	EndAt      time.Time `gorm:"not null" json:"end_at"`
+}
+
+type ExternalCalendarRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	URL  string `json:"url" binding:"required"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// AllowedLessonDurations lists the lesson lengths (in minutes) a teacher can
+// offer.
+var AllowedLessonDurations = []int{30, 45, 60, 90}
+
+// LessonType is a priced lesson offering of a teacher, e.g. a discounted
+// 90-minute lesson or a trial lesson for students who never booked the
+// teacher before. Schedules reference a lesson type to get their price.
+type LessonType struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	TeacherID       uint      `gorm:"index;type:int unsigned;not null" json:"teacher_id"`
+	Name            string    `gorm:"size:100;not null" json:"name"`
+	DurationMinutes int       `gorm:"not null" json:"duration_minutes"`
+	Price           float64   `gorm:"not null" json:"price"`
+	IsTrial         bool      `gorm:"default:false" json:"is_trial"`
+	IsActive        bool      `gorm:"not null" json:"is_active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type LessonTypeRequest struct {
+	Name            string  `json:"name" binding:"required"`
+	DurationMinutes int     `json:"duration_minutes" binding:"required"`
+	Price           float64 `json:"price"`
+	IsTrial         bool    `json:"is_trial"`
+	IsActive        *bool   `json:"is_active"`
+}
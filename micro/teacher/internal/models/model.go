@@ -1,47 +1,82 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Teacher struct {
-	UserID             uint       `gorm:"index" json:"user_id"`
-	ID                 uint       `gorm:"primaryKey" json:"id"`
-	Bio                string     `json:"bio"`
-	Name               string     `json:"name"`
-	LanguageLevel      string     `gorm:"size:100" json:"language_level"`
-	PricePerHour       float64    `json:"price_per_hour"`
-	AvailableStartTime string     `gorm:"type:VARCHAR(8)" json:"available_start_time"` // format: "HH:mm"
-	AvailableEndTime   string     `gorm:"type:VARCHAR(8)" json:"available_end_time"`
-	ProfileImage       string     `json:"profile_image"`
-	Schedules          []Schedule `gorm:"foreignKey:TeacherID" json:"schedules,omitempty"`
-	CreatedAt          time.Time  `json:"created_at"`
-	UpdatedAt          time.Time  `json:"updated_at"`
-	DeletedAt          *time.Time `json:"deleted_at"`
+	UserID             uint           `gorm:"index" json:"user_id"`
+	ID                 uint           `gorm:"primaryKey" json:"id"`
+	Bio                string         `json:"bio"`
+	Name               string         `json:"name"`
+	LanguageLevel      string         `gorm:"size:100;index" json:"language_level"`
+	PricePerHour       float64        `gorm:"index" json:"price_per_hour"`
+	RatingAverage      float64        `gorm:"index" json:"rating_average"`
+	RatingCount        int            `json:"rating_count"`
+	AvailableStartTime string         `gorm:"type:VARCHAR(8)" json:"available_start_time"` // format: "HH:mm"
+	AvailableEndTime   string         `gorm:"type:VARCHAR(8)" json:"available_end_time"`
+	ProfileImage       string         `json:"profile_image"`
+	Status             string         `gorm:"type:enum('pending','approved','rejected','archived');default:'approved';index" json:"status"`
+	DemoVideoURL       string         `gorm:"size:500" json:"demo_video_url"`
+	RejectionReason    string         `gorm:"type:text" json:"rejection_reason,omitempty"`
+	Schedules          []Schedule     `gorm:"foreignKey:TeacherID" json:"schedules,omitempty"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
 type Schedule struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	TeacherID uint      `gorm:"index;type:int unsigned;not null" json:"teacher_id"`
-	Teacher   *Teacher  `gorm:"foreignKey:TeacherID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"teacher"`
-	Date      time.Time `gorm:"type:date" json:"date"`
-	StartTime string    `gorm:"type:VARCHAR(8)" json:"start_time"`
-	EndTime   string    `gorm:"type:VARCHAR(8)" json:"end_time"`
-	Status    string    `gorm:"type:enum('available','booked','cancelled');default:'available'" json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	TeacherID    uint           `gorm:"index;index:idx_schedules_availability,priority:1;type:int unsigned;not null" json:"teacher_id"`
+	Teacher      *Teacher       `gorm:"foreignKey:TeacherID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"teacher"`
+	Date         time.Time      `gorm:"type:date;index:idx_schedules_availability,priority:3" json:"date"`
+	StartTime    string         `gorm:"type:VARCHAR(8);index:idx_schedules_availability,priority:4" json:"start_time"`
+	EndTime      string         `gorm:"type:VARCHAR(8)" json:"end_time"`
+	Status       string         `gorm:"type:enum('available','booked','cancelled');default:'available';index:idx_schedules_availability,priority:2" json:"status"`
+	LessonTypeID *uint          `gorm:"index" json:"lesson_type_id"`
+	LessonType   *LessonType    `gorm:"foreignKey:LessonTypeID" json:"lesson_type,omitempty"`
+	SubjectID    *uint          `gorm:"index" json:"subject_id"`                // prices a slot without lesson type at the teacher's rate for the subject
+	Sequence     int            `gorm:"not null;default:0" json:"sequence"`     // bumped when the slot is moved or cancelled
+	Blocked      bool           `gorm:"not null;default:false" json:"blocked"`  // overlaps a busy time of an external calendar
+	Capacity     int            `gorm:"not null;default:1" json:"capacity"`     // a capacity above 1 makes a group class
+	SeatsTaken   int            `gorm:"not null;default:0" json:"seats_taken"`  // only tracked for group classes
+	MinSeats     int            `gorm:"not null;default:0" json:"min_seats"`    // group classes below this at the cutoff are cancelled
+	CutoffHours  int            `gorm:"not null;default:0" json:"cutoff_hours"` // hours before the start enrolment is checked
+	SeatPrice    *float64       `json:"seat_price"`                             // per-seat price of a group class
+	CancelRetry  bool           `gorm:"not null;default:false" json:"-"`        // cancelled, but its bookings are not cancelled yet
+	Freed        bool           `gorm:"not null;default:false" json:"-"`        // its lesson was cancelled and the slot reopened; calendar feeds show it cancelled
+	HeldUntil    *time.Time     `json:"held_until,omitempty"`                   // held for a waitlisted student until then
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// IsGroup reports whether the schedule is a group class with several seats.
+func (s Schedule) IsGroup() bool {
+	return s.Capacity > 1
 }
 
 type TeacherResponse struct {
-	ID             uint       `json:"id"`
-	Name           string     `json:"name"`
-	Bio            string     `json:"bio"`
-	LanguageLevel  string     `json:"language_level"`
-	PricePerHour   float64    `json:"price_per_hour"`
-	AvailableStart string     `json:"available_start_time"`
-	AvailableEnd   string     `json:"available_end_time"`
-	ProfileImage   string     `json:"profile_image"`
-	CreatedAt      string     `json:"created_at"`
-	UpdatedAt      string     `json:"updated_at"`
-	Schedules      []Schedule `json:"schedules"`
+	ID             uint              `json:"id"`
+	UserID         uint              `json:"user_id,omitempty"`
+	Name           string            `json:"name"`
+	Bio            string            `json:"bio"`
+	LanguageLevel  string            `json:"language_level"`
+	PricePerHour   float64           `json:"price_per_hour"`
+	RatingAverage  float64           `json:"rating_average"`
+	RatingCount    int               `json:"rating_count"`
+	AvailableStart string            `json:"available_start_time"`
+	AvailableEnd   string            `json:"available_end_time"`
+	ProfileImage   string            `json:"profile_image"`
+	CreatedAt      string            `json:"created_at"`
+	UpdatedAt      string            `json:"updated_at"`
+	Schedules      []Schedule        `json:"schedules,omitempty"`
+	LessonTypes    []LessonType      `json:"lesson_types,omitempty"`
+	RecentReviews  []Review          `json:"recent_reviews,omitempty"`
+	Subjects       []TeacherSubject  `json:"subjects,omitempty"`
+	Languages      []TeacherLanguage `json:"languages,omitempty"`
 }
 
 type TeacherRequest struct {
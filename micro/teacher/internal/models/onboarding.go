@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Verification statuses of a teacher profile. Only approved teachers are
+// listed publicly. Archived profiles belong to users that were demoted or
+// deleted in the user service.
+const (
+	TeacherStatusPending  = "pending"
+	TeacherStatusApproved = "approved"
+	TeacherStatusRejected = "rejected"
+	TeacherStatusArchived = "archived"
+)
+
+// TeacherDocument is a qualification document uploaded with an application.
+// The file lives in the private bucket under ObjectName; FileURL is a
+// short-lived signed URL made for the applicant or an admin when the
+// document is read.
+type TeacherDocument struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	TeacherID  uint      `gorm:"index;type:int unsigned;not null" json:"teacher_id"`
+	Name       string    `gorm:"size:255;not null" json:"name"`
+	ObjectName string    `gorm:"size:500;not null" json:"-"`
+	FileURL    string    `gorm:"-" json:"file_url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TeacherStatusHistory records every verification status change so reviews
+// of applications can be audited. Rows are only ever inserted.
+type TeacherStatusHistory struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	TeacherID  uint      `gorm:"index;type:int unsigned;not null" json:"teacher_id"`
+	FromStatus string    `gorm:"size:20" json:"from_status"`
+	ToStatus   string    `gorm:"size:20;not null" json:"to_status"`
+	Reason     string    `gorm:"type:text" json:"reason,omitempty"`
+	ChangedBy  uint      `json:"changed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type TeacherApplicationRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	Bio            string  `json:"bio"`
+	LanguageLevel  string  `json:"language_level"`
+	PricePerHour   float64 `json:"price_per_hour"`
+	AvailableStart string  `json:"available_start_time" binding:"required"`
+	AvailableEnd   string  `json:"available_end_time" binding:"required"`
+	ProfileImage   string  `json:"profile_image"`
+	DemoVideoURL   string  `json:"demo_video_url" binding:"required"`
+}
+
+type TeacherRejectRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// TeacherApplicationResponse is the full application as seen by the
+// applicant and by admins.
+type TeacherApplicationResponse struct {
+	Teacher   Teacher                `json:"teacher"`
+	Documents []TeacherDocument      `json:"documents"`
+	History   []TeacherStatusHistory `json:"history"`
+}
+
+// TeacherProvisionRequest is sent by the user service when a user becomes a
+// teacher or their name or avatar changes. ChangedBy is the user that
+// triggered the change and ends up in the status history.
+type TeacherProvisionRequest struct {
+	UserID       uint   `json:"user_id" binding:"required"`
+	Name         string `json:"name"`
+	ProfileImage string `json:"profile_image"`
+	ChangedBy    uint   `json:"changed_by"`
+}
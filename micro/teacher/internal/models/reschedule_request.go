@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// RescheduleProposal asks the student of a booking to move it to one of
+// the teacher's other slots.
+type RescheduleProposal struct {
+	BookingID   uint   `json:"booking_id" binding:"required"`
+	ScheduleIDs []uint `json:"schedule_ids" binding:"required"`
+	Reason      string `json:"reason"`
+}
+
+// RescheduleRequest is a reschedule request as stored by the booking
+// service.
+type RescheduleRequest struct {
+	ID           uint               `json:"id"`
+	BookingID    uint               `json:"booking_id"`
+	UserID       uint               `json:"user_id"`
+	TeacherID    uint               `json:"teacher_id"`
+	Reason       string             `json:"reason"`
+	Status       string             `json:"status"`
+	Policy       string             `json:"policy"`
+	Deadline     time.Time          `json:"deadline"`
+	Outcome      string             `json:"outcome,omitempty"`
+	NewBookingID *uint              `json:"new_booking_id,omitempty"`
+	ResolvedAt   *time.Time         `json:"resolved_at,omitempty"`
+	Options      []RescheduleOption `json:"options"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+type RescheduleOption struct {
+	ScheduleID uint   `json:"schedule_id"`
+	Date       string `json:"date"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+}
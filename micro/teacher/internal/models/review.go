@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Review is a student's rating of a completed lesson. There is at most one
+// review per booking. Hidden reviews are kept for moderation but excluded
+// from listings and from the teacher's aggregate rating.
+type Review struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	BookingID  uint       `gorm:"uniqueIndex;not null" json:"booking_id"`
+	TeacherID  uint       `gorm:"index:idx_reviews_teacher_visible,priority:1;type:int unsigned;not null" json:"teacher_id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Rating     int        `gorm:"not null" json:"rating"`
+	Comment    string     `gorm:"type:text" json:"comment"`
+	Reply      string     `gorm:"type:text" json:"reply,omitempty"`
+	RepliedAt  *time.Time `json:"replied_at,omitempty"`
+	IsHidden   bool       `gorm:"not null;index:idx_reviews_teacher_visible,priority:2" json:"is_hidden"`
+	IsFlagged  bool       `gorm:"not null;index" json:"is_flagged"`
+	FlagReason string     `gorm:"size:255" json:"flag_reason,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type ReviewRequest struct {
+	BookingID uint   `json:"booking_id" binding:"required"`
+	Rating    int    `json:"rating" binding:"required"`
+	Comment   string `json:"comment"`
+}
+
+type ReviewReplyRequest struct {
+	Reply string `json:"reply" binding:"required"`
+}
+
+// ReviewModerationRequest changes the moderation flags of a review. Fields
+// left out are not changed.
+type ReviewModerationRequest struct {
+	IsHidden   *bool  `json:"is_hidden"`
+	IsFlagged  *bool  `json:"is_flagged"`
+	FlagReason string `json:"flag_reason"`
+}
+
+// BookingDetail is the booking as returned by the booking service's internal
+// endpoint.
+type BookingDetail struct {
+	ID         uint    `json:"id"`
+	UserID     uint    `json:"user_id"`
+	ScheduleID uint    `json:"schedule_id"`
+	TeacherID  uint    `json:"teacher_id"`
+	Status     string  `json:"status"`
+	PaymentID  *uint   `json:"payment_id"`
+	TotalPrice float64 `json:"total_price"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Sort orders of the student roster: by last lesson (recent, the default)
+// or by next lesson (upcoming).
+const (
+	RosterSortRecent   = "recent"
+	RosterSortUpcoming = "upcoming"
+)
+
+// TeacherStudentNote is a private note a teacher keeps about a student. It
+// is only shown to the teacher and admins.
+type TeacherStudentNote struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TeacherID uint      `gorm:"uniqueIndex:idx_teacher_student_note;type:int unsigned;not null" json:"teacher_id"`
+	StudentID uint      `gorm:"uniqueIndex:idx_teacher_student_note;not null" json:"student_id"`
+	Note      string    `gorm:"type:text" json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type StudentNoteRequest struct {
+	Note string `json:"note"`
+}
+
+// RosterStudent is one row of the teacher's student roster. The booking
+// figures come from the booking service, contact details from the user
+// service. Lesson dates are YYYY-MM-DD.
+type RosterStudent struct {
+	StudentID      uint    `json:"student_id"`
+	Name           string  `json:"name"`
+	Email          string  `json:"email"`
+	ProfileImage   string  `json:"profile_image"`
+	TotalLessons   int     `json:"total_lessons"`
+	TotalBookings  int     `json:"total_bookings"`
+	TotalSpent     float64 `json:"total_spent"`
+	LastLessonDate *string `json:"last_lesson_date"`
+	NextLessonDate *string `json:"next_lesson_date"`
+	Note           string  `json:"note"`
+}
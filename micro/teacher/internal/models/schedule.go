@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type ScheduleResponse struct {
 	ID         uint    `json:"id"`
 	Status     string  `json:"status"`
@@ -9,6 +11,25 @@ type ScheduleResponse struct {
 	EndTime    string  `json:"end_time"`
 	TotalPrice float64 `json:"total_price"`
 	Teacher    TeacherResponse
+
+	LessonTypeID    *uint  `json:"lesson_type_id"`
+	LessonTypeName  string `json:"lesson_type_name,omitempty"`
+	SubjectID       *uint  `json:"subject_id,omitempty"`
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+	IsTrial         bool   `json:"is_trial"`
+
+	// Capacity is 1 for private lessons; group classes stay bookable until
+	// every seat is taken.
+	Capacity   int `json:"capacity"`
+	SeatsTaken int `json:"seats_taken"`
+
+	// Blocked slots overlap a busy time of an external calendar and cannot
+	// be booked.
+	Blocked bool `json:"blocked"`
+
+	// IsDeleted marks schedules that were removed after being booked. They
+	// are still returned so historical bookings can be shown.
+	IsDeleted bool `json:"is_deleted"`
 }
 
 type ScheduleRequest struct {
@@ -17,6 +38,40 @@ type ScheduleRequest struct {
 	StartTime string `json:"start_time"`
 	EndTime   string `json:"end_time"`
 	Status    string `json:"status"`
+
+	LessonTypeID *uint `json:"lesson_type_id"`
+	// SubjectID is one of the teacher's subjects. A slot without lesson type
+	// is priced at the teacher's rate for it.
+	SubjectID *uint `json:"subject_id"`
+
+	// Group class settings, see Schedule. A capacity of 0 or 1 creates a
+	// private lesson.
+	Capacity    int      `json:"capacity"`
+	SeatPrice   *float64 `json:"seat_price"`
+	MinSeats    int      `json:"min_seats"`
+	CutoffHours int      `json:"cutoff_hours"`
+}
+
+// ScheduleMatchRequest looks up the teacher's slots with the given times on
+// each of the dates (YYYY-MM-DD), e.g. the occurrences of a recurring
+// booking.
+type ScheduleMatchRequest struct {
+	TeacherID uint     `json:"teacher_id" binding:"required"`
+	StartTime string   `json:"start_time" binding:"required"`
+	EndTime   string   `json:"end_time" binding:"required"`
+	Dates     []string `json:"dates" binding:"required"`
+}
+
+// ScheduleBatchRequest names the slots to reserve or release together, e.g.
+// the occurrences of a recurring booking.
+type ScheduleBatchRequest struct {
+	ScheduleIDs []uint `json:"schedule_ids" binding:"required"`
+}
+
+// ScheduleHoldRequest holds a slot for a waitlisted student until HeldUntil.
+// A missing HeldUntil releases the hold.
+type ScheduleHoldRequest struct {
+	HeldUntil *time.Time `json:"held_until"`
 }
 
 type ScheduleFilterRequest struct {
@@ -27,3 +82,50 @@ type ScheduleFilterRequest struct {
 type ScheduleFilterResponse struct {
 	ValidScheduleIDs []string `json:"valid_schedule_ids"`
 }
+
+type BulkScheduleRequest struct {
+	TeacherID uint              `json:"teacher_id" binding:"required"`
+	Schedules []ScheduleRequest `json:"schedules" binding:"required"`
+}
+
+type BulkShiftRequest struct {
+	TeacherID    uint   `json:"teacher_id" binding:"required"`
+	ScheduleIDs  []uint `json:"schedule_ids" binding:"required"`
+	ShiftDays    int    `json:"shift_days"`
+	ShiftMinutes int    `json:"shift_minutes"`
+}
+
+type BulkCancelRequest struct {
+	TeacherID uint   `json:"teacher_id" binding:"required"`
+	StartDate string `json:"start_date" binding:"required"`
+	EndDate   string `json:"end_date" binding:"required"`
+	Reason    string `json:"reason"`
+}
+
+// BulkScheduleRow reports the outcome of a single row of a batch operation.
+// Row is 1-based and follows the order of the request (or the CSV data rows).
+type BulkScheduleRow struct {
+	Row        int      `json:"row"`
+	ScheduleID uint     `json:"schedule_id,omitempty"`
+	Date       string   `json:"date"`
+	StartTime  string   `json:"start_time"`
+	EndTime    string   `json:"end_time"`
+	Booked     bool     `json:"booked,omitempty"`
+	Valid      bool     `json:"valid"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// BookingCancelFailure is a booking the booking service could not cancel
+// together with its schedule.
+type BookingCancelFailure struct {
+	BookingID  uint   `json:"booking_id"`
+	ScheduleID uint   `json:"schedule_id"`
+	Error      string `json:"error"`
+}
+
+type BulkScheduleResponse struct {
+	Total   int               `json:"total"`
+	Invalid int               `json:"invalid"`
+	Applied bool              `json:"applied"`
+	Rows    []BulkScheduleRow `json:"rows"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// Sort orders supported by the teacher search.
+const (
+	TeacherSortPriceAsc     = "price_asc"
+	TeacherSortPriceDesc    = "price_desc"
+	TeacherSortRating       = "rating"
+	TeacherSortAvailability = "availability"
+)
+
+// TeacherFilter holds the optional search criteria of GET /teachers. Subject
+// and Language are catalog codes; with a subject the price range applies to
+// the teacher's price for that subject. The availability fields select teachers that have at least one available slot
+// between AvailableFrom and AvailableTo (dates, YYYY-MM-DD) lying inside the
+// TimeFrom-TimeTo window of the day (HH:MM).
+type TeacherFilter struct {
+	Query         string   `form:"q"`
+	LanguageLevel string   `form:"language_level"`
+	Subject       string   `form:"subject"`
+	Language      string   `form:"language"`
+	MinPrice      *float64 `form:"min_price"`
+	MaxPrice      *float64 `form:"max_price"`
+	MinRating     *float64 `form:"min_rating"`
+	AvailableFrom string   `form:"available_from"`
+	AvailableTo   string   `form:"available_to"`
+	TimeFrom      string   `form:"time_from"`
+	TimeTo        string   `form:"time_to"`
+	Sort          string   `form:"sort"`
+}
+
+// HasAvailability reports whether any availability criterion is set.
+func (f TeacherFilter) HasAvailability() bool {
+	return f.AvailableFrom != "" || f.AvailableTo != "" || f.TimeFrom != "" || f.TimeTo != ""
+}
@@ -0,0 +1,629 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ics"
+)
+
+// maxRecurrencePeriods bounds RRULE expansion so a rule starting far in the
+// past or without an end cannot loop forever.
+const maxRecurrencePeriods = 50000
+
+// BusyInterval is one occurrence of an event that blocks the calendar owner.
+type BusyInterval struct {
+	UID   string
+	Start time.Time
+	End   time.Time
+}
+
+type icsVEvent struct {
+	uid          string
+	start        time.Time
+	end          time.Time
+	allDay       bool
+	duration     time.Duration
+	hasEnd       bool
+	rrule        string
+	rdates       []time.Time
+	exdates      map[int64]bool
+	recurrenceID time.Time
+	transparent  bool
+	cancelled    bool
+}
+
+// ParseICSBusyTimes parses an iCalendar document and returns the busy
+// occurrences that overlap [from, to), sorted by start. Recurring events are
+// expanded from their RRULE and RDATE, minus EXDATE and instances replaced
+// by a RECURRENCE-ID override. Transparent and cancelled events are not
+// busy. Floating times and unknown TZIDs are read in loc.
+func ParseICSBusyTimes(data []byte, from, to time.Time, loc *time.Location) ([]BusyInterval, error) {
+	events, err := parseICSEvents(string(data), loc)
+	if err != nil {
+		return nil, err
+	}
+
+	overridden := make(map[string]bool)
+	for _, event := range events {
+		if !event.recurrenceID.IsZero() {
+			overridden[overrideKey(event.uid, event.recurrenceID)] = true
+		}
+	}
+
+	var busy []BusyInterval
+	for _, event := range events {
+		if event.transparent || event.cancelled || event.start.IsZero() {
+			continue
+		}
+		for _, start := range event.occurrences(from, to) {
+			if event.recurrenceID.IsZero() && overridden[overrideKey(event.uid, start)] {
+				continue
+			}
+			end := start.Add(event.length())
+			if !end.After(start) || !end.After(from) || !start.Before(to) {
+				continue
+			}
+			busy = append(busy, BusyInterval{UID: event.uid, Start: start, End: end})
+		}
+	}
+
+	sort.Slice(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+	return busy, nil
+}
+
+func overrideKey(uid string, start time.Time) string {
+	return fmt.Sprintf("%s/%d", uid, start.Unix())
+}
+
+// length returns the duration of every occurrence. All-day events without
+// an end last one day.
+func (e icsVEvent) length() time.Duration {
+	switch {
+	case e.hasEnd:
+		return e.end.Sub(e.start)
+	case e.duration > 0:
+		return e.duration
+	case e.allDay:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// occurrences returns the start of every instance before to. An event
+// whose rule cannot be expanded only yields its first instance.
+func (e icsVEvent) occurrences(from, to time.Time) []time.Time {
+	starts := []time.Time{e.start}
+	if e.rrule != "" && e.recurrenceID.IsZero() {
+		if rule, err := parseRRule(e.rrule, e.start.Location()); err == nil {
+			starts = rule.expand(e.start, from.Add(-e.length()), to)
+		}
+	}
+	starts = append(starts, e.rdates...)
+
+	result := make([]time.Time, 0, len(starts))
+	seen := make(map[int64]bool, len(starts))
+	for _, start := range starts {
+		if e.exdates[start.Unix()] || seen[start.Unix()] {
+			continue
+		}
+		seen[start.Unix()] = true
+		result = append(result, start)
+	}
+	return result
+}
+
+func parseICSEvents(data string, loc *time.Location) ([]icsVEvent, error) {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\n ", "")
+	data = strings.ReplaceAll(data, "\n\t", "")
+	if !strings.Contains(data, "BEGIN:VCALENDAR") {
+		return nil, errors.New("not an iCalendar file")
+	}
+
+	var (
+		events  []icsVEvent
+		current *icsVEvent
+		nested  []string
+	)
+	for _, raw := range strings.Split(data, "\n") {
+		line := strings.TrimRight(raw, "\r")
+		if line == "" {
+			continue
+		}
+		name, params, value := splitICSLine(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT" && current == nil:
+			current = &icsVEvent{exdates: make(map[int64]bool)}
+			continue
+		case name == "END" && value == "VEVENT" && len(nested) == 0 && current != nil:
+			events = append(events, *current)
+			current = nil
+			continue
+		case current == nil:
+			continue
+		case name == "BEGIN":
+			// Sub-components such as VALARM have their own properties.
+			nested = append(nested, value)
+			continue
+		case name == "END" && len(nested) > 0:
+			nested = nested[:len(nested)-1]
+			continue
+		case len(nested) > 0:
+			continue
+		}
+
+		if err := current.set(name, params, value, loc); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", name, value, err)
+		}
+	}
+	return events, nil
+}
+
+func (e *icsVEvent) set(name string, params map[string]string, value string, loc *time.Location) error {
+	switch name {
+	case "UID":
+		e.uid = value
+	case "DTSTART":
+		start, allDay, err := parseICSTime(value, params, loc)
+		if err != nil {
+			return err
+		}
+		e.start, e.allDay = start, allDay
+	case "DTEND":
+		end, _, err := parseICSTime(value, params, loc)
+		if err != nil {
+			return err
+		}
+		e.end, e.hasEnd = end, true
+	case "DURATION":
+		duration, err := parseICSDuration(value)
+		if err != nil {
+			return err
+		}
+		e.duration = duration
+	case "RRULE":
+		e.rrule = value
+	case "RDATE", "EXDATE":
+		for _, item := range strings.Split(value, ",") {
+			// RDATE may also hold periods (start/end); only the start is used.
+			item, _, _ = strings.Cut(item, "/")
+			t, _, err := parseICSTime(item, params, loc)
+			if err != nil {
+				return err
+			}
+			if name == "RDATE" {
+				e.rdates = append(e.rdates, t)
+			} else {
+				e.exdates[t.Unix()] = true
+			}
+		}
+	case "RECURRENCE-ID":
+		t, _, err := parseICSTime(value, params, loc)
+		if err != nil {
+			return err
+		}
+		e.recurrenceID = t
+	case "TRANSP":
+		e.transparent = strings.EqualFold(value, "TRANSPARENT")
+	case "STATUS":
+		e.cancelled = strings.EqualFold(value, ics.StatusCancelled)
+	}
+	return nil
+}
+
+// splitICSLine splits a content line into its upper-cased name, parameters
+// and value. Colons inside quoted parameter values are not separators.
+func splitICSLine(line string) (string, map[string]string, string) {
+	inQuotes := false
+	sep := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+
+	parts := strings.Split(line[:sep], ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		if key, value, ok := strings.Cut(part, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[sep+1:]
+}
+
+// parseICSTime parses a DATE or DATE-TIME value. UTC values end in Z, others
+// are read in their TZID or, when it is missing or unknown, in loc.
+func parseICSTime(value string, params map[string]string, loc *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if tzid := params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// parseICSDuration parses durations such as PT1H30M, P1D or -PT15M.
+func parseICSDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = -1
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") {
+		return 0, errors.New("duration must start with P")
+	}
+
+	var total time.Duration
+	number := ""
+	inTime := false
+	for _, r := range value[1:] {
+		if r >= '0' && r <= '9' {
+			number += string(r)
+			continue
+		}
+		if r == 'T' {
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, errors.New("missing number in duration")
+		}
+		number = ""
+		switch {
+		case r == 'W':
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case r == 'D':
+			total += time.Duration(n) * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += time.Duration(n) * time.Hour
+		case r == 'M' && inTime:
+			total += time.Duration(n) * time.Minute
+		case r == 'S' && inTime:
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("unknown duration unit %q", r)
+		}
+	}
+	if number != "" {
+		return 0, errors.New("duration ends without a unit")
+	}
+	return sign * total, nil
+}
+
+type rruleByDay struct {
+	ordinal int
+	weekday time.Weekday
+}
+
+type rrule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []rruleByDay
+	byMonthDay []int
+	byMonth    map[time.Month]bool
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRRule supports the rule parts calendar apps commonly emit: FREQ
+// DAILY to YEARLY with INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY and
+// BYMONTH. Other parts are rejected rather than expanded incorrectly.
+func parseRRule(value string, loc *time.Location) (*rrule, error) {
+	rule := &rrule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			rule.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", val)
+			}
+			rule.count = n
+		case "UNTIL":
+			until, allDay, err := parseICSTime(val, nil, loc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL %q", val)
+			}
+			if allDay {
+				// A date-only UNTIL includes the whole day.
+				until = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+			rule.until = until
+		case "BYDAY":
+			for _, item := range strings.Split(val, ",") {
+				item = strings.ToUpper(strings.TrimSpace(item))
+				if len(item) < 2 {
+					return nil, fmt.Errorf("invalid BYDAY %q", item)
+				}
+				weekday, ok := icsWeekdays[item[len(item)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY %q", item)
+				}
+				ordinal := 0
+				if prefix := item[:len(item)-2]; prefix != "" {
+					n, err := strconv.Atoi(prefix)
+					if err != nil || n == 0 {
+						return nil, fmt.Errorf("invalid BYDAY %q", item)
+					}
+					ordinal = n
+				}
+				rule.byDay = append(rule.byDay, rruleByDay{ordinal: ordinal, weekday: weekday})
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(item)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %q", item)
+				}
+				rule.byMonthDay = append(rule.byMonthDay, n)
+			}
+		case "BYMONTH":
+			rule.byMonth = make(map[time.Month]bool)
+			for _, item := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(item)
+				if err != nil || n < 1 || n > 12 {
+					return nil, fmt.Errorf("invalid BYMONTH %q", item)
+				}
+				rule.byMonth[time.Month(n)] = true
+			}
+		case "WKST":
+			// Only affects weekly rules with INTERVAL > 1 and BYDAY; weeks
+			// are assumed to start on Monday.
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	switch rule.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported FREQ %q", rule.freq)
+	}
+	if rule.freq == "YEARLY" && rule.byMonth == nil && len(rule.byDay) > 0 {
+		return nil, errors.New("yearly BYDAY is only supported together with BYMONTH")
+	}
+	for _, day := range rule.byDay {
+		if day.ordinal != 0 && rule.freq != "MONTHLY" && !(rule.freq == "YEARLY" && rule.byMonth != nil) {
+			return nil, errors.New("ordinal BYDAY is only supported for monthly rules")
+		}
+	}
+	return rule, nil
+}
+
+// expand returns the instance starts of the rule beginning at dtstart that
+// start before to, skipping those before from. COUNT is applied from
+// dtstart so skipped instances still count.
+func (r *rrule) expand(dtstart, from, to time.Time) []time.Time {
+	var starts []time.Time
+	emitted := 0
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		candidates := r.period(dtstart, period)
+		if len(candidates) == 0 && r.periodStart(dtstart, period).After(to) {
+			break
+		}
+		for _, candidate := range candidates {
+			if candidate.Before(dtstart) {
+				continue
+			}
+			emitted++
+			if r.count > 0 && emitted > r.count {
+				return starts
+			}
+			if !r.until.IsZero() && candidate.After(r.until) {
+				return starts
+			}
+			if !candidate.Before(to) {
+				return starts
+			}
+			if candidate.Before(from) {
+				continue
+			}
+			starts = append(starts, candidate)
+		}
+	}
+	return starts
+}
+
+// periodStart returns the first day of the n-th period of the rule.
+func (r *rrule) periodStart(dtstart time.Time, n int) time.Time {
+	step := n * r.interval
+	switch r.freq {
+	case "DAILY":
+		return dtstart.AddDate(0, 0, step)
+	case "WEEKLY":
+		return weekStart(dtstart).AddDate(0, 0, 7*step)
+	case "MONTHLY":
+		return time.Date(dtstart.Year(), dtstart.Month()+time.Month(step), 1, 0, 0, 0, 0, dtstart.Location())
+	default:
+		return time.Date(dtstart.Year()+step, time.January, 1, 0, 0, 0, 0, dtstart.Location())
+	}
+}
+
+// period returns the sorted instance starts within the n-th period.
+func (r *rrule) period(dtstart time.Time, n int) []time.Time {
+	start := r.periodStart(dtstart, n)
+	var days []time.Time
+
+	switch r.freq {
+	case "DAILY":
+		days = []time.Time{start}
+	case "WEEKLY":
+		if len(r.byDay) == 0 {
+			days = []time.Time{start.AddDate(0, 0, mondayOffset(dtstart.Weekday()))}
+		}
+		for _, day := range r.byDay {
+			days = append(days, start.AddDate(0, 0, mondayOffset(day.weekday)))
+		}
+	case "MONTHLY":
+		days = r.monthDays(start.Year(), start.Month(), dtstart)
+	case "YEARLY":
+		if r.byMonth == nil {
+			days = r.monthDays(start.Year(), dtstart.Month(), dtstart)
+			break
+		}
+		for month := time.January; month <= time.December; month++ {
+			if r.byMonth[month] {
+				days = append(days, r.monthDays(start.Year(), month, dtstart)...)
+			}
+		}
+	}
+
+	result := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		if r.byMonth != nil && !r.byMonth[day.Month()] {
+			continue
+		}
+		if r.freq == "DAILY" && !r.matchesDay(day) {
+			continue
+		}
+		result = append(result, time.Date(day.Year(), day.Month(), day.Day(),
+			dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location()))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+// matchesDay applies BYDAY and BYMONTHDAY as filters of a daily rule.
+func (r *rrule) matchesDay(day time.Time) bool {
+	if len(r.byDay) > 0 {
+		found := false
+		for _, byDay := range r.byDay {
+			if byDay.weekday == day.Weekday() {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.byMonthDay) > 0 {
+		last := daysIn(day.Year(), day.Month())
+		for _, monthDay := range r.byMonthDay {
+			if monthDay == day.Day() || (monthDay < 0 && last+monthDay+1 == day.Day()) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// monthDays returns the days of the month selected by BYMONTHDAY and BYDAY,
+// or the day of month of dtstart when neither is set. Days that do not
+// exist in the month are skipped.
+func (r *rrule) monthDays(year int, month time.Month, dtstart time.Time) []time.Time {
+	loc := dtstart.Location()
+	last := daysIn(year, month)
+	date := func(day int) time.Time { return time.Date(year, month, day, 0, 0, 0, 0, loc) }
+
+	var byMonthDay map[int]bool
+	if len(r.byMonthDay) > 0 {
+		byMonthDay = make(map[int]bool)
+		for _, day := range r.byMonthDay {
+			if day < 0 {
+				day = last + day + 1
+			}
+			if day >= 1 && day <= last {
+				byMonthDay[day] = true
+			}
+		}
+	}
+
+	if len(r.byDay) == 0 {
+		if byMonthDay == nil {
+			if dtstart.Day() > last {
+				return nil
+			}
+			return []time.Time{date(dtstart.Day())}
+		}
+		days := make([]time.Time, 0, len(byMonthDay))
+		for day := range byMonthDay {
+			days = append(days, date(day))
+		}
+		return days
+	}
+
+	var days []time.Time
+	for _, byDay := range r.byDay {
+		var matches []int
+		for day := 1; day <= last; day++ {
+			if date(day).Weekday() == byDay.weekday {
+				matches = append(matches, day)
+			}
+		}
+		if byDay.ordinal > 0 && byDay.ordinal <= len(matches) {
+			matches = matches[byDay.ordinal-1 : byDay.ordinal]
+		} else if byDay.ordinal < 0 && -byDay.ordinal <= len(matches) {
+			i := len(matches) + byDay.ordinal
+			matches = matches[i : i+1]
+		} else if byDay.ordinal != 0 {
+			matches = nil
+		}
+		for _, day := range matches {
+			if byMonthDay == nil || byMonthDay[day] {
+				days = append(days, date(day))
+			}
+		}
+	}
+	return days
+}
+
+func weekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -mondayOffset(t.Weekday()))
+}
+
+// mondayOffset returns the number of days from Monday to weekday.
+func mondayOffset(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,107 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func parseFixture(t *testing.T, name string) []BusyInterval {
+	t.Helper()
+
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	from := time.Date(2025, time.March, 1, 0, 0, 0, 0, loc)
+	to := time.Date(2025, time.April, 1, 0, 0, 0, 0, loc)
+	busy, err := ParseICSBusyTimes(data, from, to, loc)
+	if err != nil {
+		t.Fatalf("ParseICSBusyTimes: %v", err)
+	}
+	return busy
+}
+
+func assertBusy(t *testing.T, got []BusyInterval, want [][2]string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		for _, b := range got {
+			t.Logf("got %s %s - %s", b.UID, b.Start.UTC().Format(time.RFC3339), b.End.UTC().Format(time.RFC3339))
+		}
+		t.Fatalf("got %d busy intervals, want %d", len(got), len(want))
+	}
+	for i, b := range got {
+		start := b.Start.UTC().Format(time.RFC3339)
+		end := b.End.UTC().Format(time.RFC3339)
+		if start != want[i][0] || end != want[i][1] {
+			t.Errorf("interval %d (%s) = %s - %s, want %s - %s", i, b.UID, start, end, want[i][0], want[i][1])
+		}
+	}
+}
+
+func TestParseICSBusyTimesSingleEvents(t *testing.T) {
+	assertBusy(t, parseFixture(t, "busy_single.ics"), [][2]string{
+		{"2025-03-03T01:00:00Z", "2025-03-03T02:00:00Z"}, // TZID Asia/Tokyo
+		{"2025-03-04T02:00:00Z", "2025-03-04T03:00:00Z"}, // UTC, VALARM ignored
+		{"2025-03-04T17:00:00Z", "2025-03-05T17:00:00Z"}, // all-day in the default zone
+		{"2025-03-06T02:00:00Z", "2025-03-06T03:30:00Z"}, // floating time with DURATION
+	})
+}
+
+func TestParseICSBusyTimesRecurringEvents(t *testing.T) {
+	assertBusy(t, parseFixture(t, "busy_recurring.ics"), [][2]string{
+		{"2025-03-01T13:00:00Z", "2025-03-01T14:00:00Z"},
+		{"2025-03-03T02:00:00Z", "2025-03-03T03:00:00Z"},
+		{"2025-03-03T13:00:00Z", "2025-03-03T14:00:00Z"},
+		{"2025-03-05T13:00:00Z", "2025-03-05T14:00:00Z"},
+		{"2025-03-07T13:00:00Z", "2025-03-07T14:00:00Z"},
+		{"2025-03-10T07:00:00Z", "2025-03-10T08:00:00Z"}, // RECURRENCE-ID override
+		{"2025-03-12T02:00:00Z", "2025-03-12T03:00:00Z"},
+		{"2025-03-17T02:00:00Z", "2025-03-17T03:00:00Z"},
+		{"2025-03-19T02:00:00Z", "2025-03-19T03:00:00Z"},
+		{"2025-03-20T08:00:00Z", "2025-03-20T09:00:00Z"}, // unsupported rule, first instance only
+		{"2025-03-28T10:00:00Z", "2025-03-28T11:00:00Z"}, // last Friday of the month
+	})
+}
+
+func TestParseICSBusyTimesRejectsOtherFiles(t *testing.T) {
+	_, err := ParseICSBusyTimes([]byte("not a calendar"), time.Now(), time.Now().Add(time.Hour), time.UTC)
+	if err == nil {
+		t.Fatal("expected an error for a non-iCalendar file")
+	}
+}
+
+func TestParseICSDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"PT1H30M":   90 * time.Minute,
+		"P1D":       24 * time.Hour,
+		"P1W":       7 * 24 * time.Hour,
+		"P1DT2H":    26 * time.Hour,
+		"-PT15M":    -15 * time.Minute,
+		"PT45S":     45 * time.Second,
+		"P0DT0H30M": 30 * time.Minute,
+	}
+	for input, want := range tests {
+		got, err := parseICSDuration(input)
+		if err != nil {
+			t.Errorf("parseICSDuration(%q): %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseICSDuration(%q) = %v, want %v", input, got, want)
+		}
+	}
+
+	for _, input := range []string{"1H", "PT1X", "PT5"} {
+		if _, err := parseICSDuration(input); err == nil {
+			t.Errorf("parseICSDuration(%q) succeeded, want error", input)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"errors"
+	"teacher/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Calendar struct {
+	DB *gorm.DB
+}
+
+func NewCalendarRepository(db *gorm.DB) *Calendar {
+	return &Calendar{
+		DB: db,
+	}
+}
+
+func (c *Calendar) GetFeedByUserID(userID uint) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := c.DB.Where("user_id = ?", userID).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("calendar feed not found")
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (c *Calendar) GetFeedByToken(token string) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := c.DB.Where("token = ?", token).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("calendar feed not found")
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (c *Calendar) SaveFeed(feed *models.CalendarFeed) error {
+	return c.DB.Save(feed).Error
+}
+
+func (c *Calendar) DeleteFeed(userID uint) error {
+	return c.DB.Where("user_id = ?", userID).Delete(&models.CalendarFeed{}).Error
+}
+
+// GetCalendarSchedules returns the teacher's booked and cancelled slots,
+// group classes with at least one seat taken and slots whose lesson was
+// cancelled, on or after since. Cancelled lessons are kept so subscribers
+// can remove them.
+func (c *Calendar) GetCalendarSchedules(teacherID uint, since time.Time) ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	err := c.DB.Preload("LessonType").
+		Where("teacher_id = ? AND date >= ?", teacherID, since.Format("2006-01-02")).
+		Where("status IN ? OR seats_taken > 0 OR freed = ?", []string{"booked", "cancelled"}, true).
+		Order("date, start_time").
+		Find(&schedules).Error
+	return schedules, err
+}
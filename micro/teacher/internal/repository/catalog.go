@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"errors"
+	"teacher/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type Catalog struct {
+	DB *gorm.DB
+}
+
+func NewCatalogRepository(db *gorm.DB) *Catalog {
+	return &Catalog{
+		DB: db,
+	}
+}
+
+// EnsureDefaults creates the default subjects and languages that do not
+// exist yet. Existing rows, including deactivated ones, are left alone.
+func (c *Catalog) EnsureDefaults(subjects []models.Subject, languages []models.InstructionLanguage) error {
+	for _, subject := range subjects {
+		subject.IsActive = true
+		if err := c.DB.Where("code = ?", subject.Code).FirstOrCreate(&subject).Error; err != nil {
+			return err
+		}
+	}
+	for _, language := range languages {
+		if err := c.DB.Where("code = ?", language.Code).FirstOrCreate(&language).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) GetSubjects(activeOnly bool) ([]models.Subject, error) {
+	var subjects []models.Subject
+	q := c.DB.Model(&models.Subject{})
+	if activeOnly {
+		q = q.Where("is_active = ?", true)
+	}
+	if err := q.Order("name").Find(&subjects).Error; err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+func (c *Catalog) GetSubjectByID(id uint) (*models.Subject, error) {
+	var subject models.Subject
+	if err := c.DB.First(&subject, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("subject not found")
+		}
+		return nil, err
+	}
+	return &subject, nil
+}
+
+func (c *Catalog) GetSubjectsByIDs(ids []uint) ([]models.Subject, error) {
+	var subjects []models.Subject
+	if err := c.DB.Where("id IN ?", ids).Find(&subjects).Error; err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+func (c *Catalog) SubjectCodeExists(code string, excludeID uint) (bool, error) {
+	var count int64
+	err := c.DB.Model(&models.Subject{}).Where("code = ? AND id <> ?", code, excludeID).Count(&count).Error
+	return count > 0, err
+}
+
+func (c *Catalog) SaveSubject(subject *models.Subject) error {
+	return c.DB.Save(subject).Error
+}
+
+func (c *Catalog) GetLanguages() ([]models.InstructionLanguage, error) {
+	var languages []models.InstructionLanguage
+	if err := c.DB.Order("name").Find(&languages).Error; err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
+
+func (c *Catalog) GetLanguagesByIDs(ids []uint) ([]models.InstructionLanguage, error) {
+	var languages []models.InstructionLanguage
+	if err := c.DB.Where("id IN ?", ids).Find(&languages).Error; err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
+
+func (c *Catalog) LanguageCodeExists(code string) (bool, error) {
+	var count int64
+	err := c.DB.Model(&models.InstructionLanguage{}).Where("code = ?", code).Count(&count).Error
+	return count > 0, err
+}
+
+func (c *Catalog) CreateLanguage(language *models.InstructionLanguage) error {
+	return c.DB.Create(language).Error
+}
+
+func (c *Catalog) GetTeacherSubjects(teacherID uint) ([]models.TeacherSubject, error) {
+	var subjects []models.TeacherSubject
+	if err := c.DB.Preload("Subject").Where("teacher_id = ?", teacherID).
+		Order("subject_id").Find(&subjects).Error; err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+func (c *Catalog) GetTeacherLanguages(teacherID uint) ([]models.TeacherLanguage, error) {
+	var languages []models.TeacherLanguage
+	if err := c.DB.Preload("Language").Where("teacher_id = ?", teacherID).
+		Order("language_id").Find(&languages).Error; err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
+
+// ReplaceTeacherSubjects swaps the subject assignments of a teacher in one
+// transaction.
+func (c *Catalog) ReplaceTeacherSubjects(teacherID uint, subjects []models.TeacherSubject) error {
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("teacher_id = ?", teacherID).Delete(&models.TeacherSubject{}).Error; err != nil {
+			return err
+		}
+		if len(subjects) == 0 {
+			return nil
+		}
+		return tx.Create(&subjects).Error
+	})
+}
+
+// ReplaceTeacherLanguages swaps the instruction languages of a teacher in
+// one transaction.
+func (c *Catalog) ReplaceTeacherLanguages(teacherID uint, languages []models.TeacherLanguage) error {
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("teacher_id = ?", teacherID).Delete(&models.TeacherLanguage{}).Error; err != nil {
+			return err
+		}
+		if len(languages) == 0 {
+			return nil
+		}
+		return tx.Create(&languages).Error
+	})
+}
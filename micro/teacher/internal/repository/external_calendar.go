@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+	"teacher/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExternalCalendar struct {
+	DB *gorm.DB
+}
+
+func NewExternalCalendarRepository(db *gorm.DB) *ExternalCalendar {
+	return &ExternalCalendar{
+		DB: db,
+	}
+}
+
+func (e *ExternalCalendar) CreateCalendar(calendar *models.ExternalCalendar) error {
+	return e.DB.Create(calendar).Error
+}
+
+func (e *ExternalCalendar) GetCalendarByID(id uint) (*models.ExternalCalendar, error) {
+	var calendar models.ExternalCalendar
+	if err := e.DB.First(&calendar, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("calendar not found")
+		}
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+func (e *ExternalCalendar) GetTeacherCalendars(teacherID uint) ([]models.ExternalCalendar, error) {
+	var calendars []models.ExternalCalendar
+	if err := e.DB.Where("teacher_id = ?", teacherID).Order("id").Find(&calendars).Error; err != nil {
+		return nil, err
+	}
+	return calendars, nil
+}
+
+func (e *ExternalCalendar) GetAllCalendars() ([]models.ExternalCalendar, error) {
+	var calendars []models.ExternalCalendar
+	if err := e.DB.Order("teacher_id, id").Find(&calendars).Error; err != nil {
+		return nil, err
+	}
+	return calendars, nil
+}
+
+// DeleteCalendar removes the calendar together with its busy times.
+func (e *ExternalCalendar) DeleteCalendar(id uint) error {
+	return e.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", id).Delete(&models.BusyTime{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ExternalCalendar{}, id).Error
+	})
+}
+
+// ReplaceBusyTimes swaps the busy times of a calendar and records the sync
+// result in one transaction.
+func (e *ExternalCalendar) ReplaceBusyTimes(calendar *models.ExternalCalendar, busy []models.BusyTime) error {
+	return e.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", calendar.ID).Delete(&models.BusyTime{}).Error; err != nil {
+			return err
+		}
+		if len(busy) > 0 {
+			if err := tx.CreateInBatches(&busy, 200).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.ExternalCalendar{}).Where("id = ?", calendar.ID).
+			Updates(map[string]interface{}{
+				"busy_count":     calendar.BusyCount,
+				"last_synced_at": calendar.LastSyncedAt,
+				"last_error":     calendar.LastError,
+			}).Error
+	})
+}
+
+// SetSyncError records a failed sync. Busy times of the previous successful
+// sync are kept so a temporarily unreachable calendar keeps blocking.
+func (e *ExternalCalendar) SetSyncError(id uint, syncedAt time.Time, message string) error {
+	return e.DB.Model(&models.ExternalCalendar{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_synced_at": syncedAt, "last_error": message}).Error
+}
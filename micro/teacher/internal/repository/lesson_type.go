@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+	"teacher/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type LessonType struct {
+	DB *gorm.DB
+}
+
+func NewLessonTypeRepository(db *gorm.DB) *LessonType {
+	return &LessonType{
+		DB: db,
+	}
+}
+
+func (l *LessonType) CreateLessonType(lessonType *models.LessonType) error {
+	return l.DB.Create(lessonType).Error
+}
+
+func (l *LessonType) UpdateLessonType(lessonType *models.LessonType) error {
+	return l.DB.Save(lessonType).Error
+}
+
+func (l *LessonType) GetLessonTypeByID(id uint) (*models.LessonType, error) {
+	var lessonType models.LessonType
+	if err := l.DB.First(&lessonType, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("lesson type not found")
+		}
+		return nil, err
+	}
+	return &lessonType, nil
+}
+
+func (l *LessonType) GetLessonTypesByTeacher(teacherID uint, activeOnly bool) ([]models.LessonType, error) {
+	var lessonTypes []models.LessonType
+	q := l.DB.Where("teacher_id = ?", teacherID)
+	if activeOnly {
+		q = q.Where("is_active = ?", true)
+	}
+	if err := q.Order("duration_minutes, price").Find(&lessonTypes).Error; err != nil {
+		return nil, err
+	}
+	return lessonTypes, nil
+}
+
+// CountSchedules counts the schedules of the lesson type that are not
+// cancelled.
+func (l *LessonType) CountSchedules(id uint) (int64, error) {
+	var count int64
+	err := l.DB.Model(&models.Schedule{}).
+		Where("lesson_type_id = ? AND status <> ?", id, "cancelled").
+		Count(&count).Error
+	return count, err
+}
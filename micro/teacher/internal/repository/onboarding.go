@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"errors"
+	"math"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+
+	"gorm.io/gorm"
+)
+
+type Onboarding struct {
+	DB *gorm.DB
+}
+
+func NewOnboardingRepository(db *gorm.DB) *Onboarding {
+	return &Onboarding{
+		DB: db,
+	}
+}
+
+// SaveApplication creates or updates the teacher profile of an application
+// and appends the status change, if any, in the same transaction.
+func (o *Onboarding) SaveApplication(teacher *models.Teacher, history *models.TeacherStatusHistory) error {
+	return o.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(teacher).Error; err != nil {
+			return err
+		}
+		if history == nil {
+			return nil
+		}
+		history.TeacherID = teacher.ID
+		return tx.Create(history).Error
+	})
+}
+
+func (o *Onboarding) GetTeacherByID(id uint) (*models.Teacher, error) {
+	var teacher models.Teacher
+	if err := o.DB.First(&teacher, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("teacher not found")
+		}
+		return nil, err
+	}
+	return &teacher, nil
+}
+
+func (o *Onboarding) GetTeacherByUserID(userID uint) (*models.Teacher, error) {
+	var teacher models.Teacher
+	if err := o.DB.Where("user_id = ?", userID).First(&teacher).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("application not found")
+		}
+		return nil, err
+	}
+	return &teacher, nil
+}
+
+func (o *Onboarding) CreateDocument(document *models.TeacherDocument) error {
+	return o.DB.Create(document).Error
+}
+
+func (o *Onboarding) GetDocuments(teacherID uint) ([]models.TeacherDocument, error) {
+	var documents []models.TeacherDocument
+	if err := o.DB.Where("teacher_id = ?", teacherID).Order("created_at").Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func (o *Onboarding) GetStatusHistory(teacherID uint) ([]models.TeacherStatusHistory, error) {
+	var history []models.TeacherStatusHistory
+	if err := o.DB.Where("teacher_id = ?", teacherID).Order("created_at, id").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetApplications lists teacher profiles by verification status, oldest
+// first so the review queue is worked in order.
+func (o *Onboarding) GetApplications(status string, paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+	if paginate.Page < 1 {
+		paginate.Page = 1
+	}
+	if paginate.Limit <= 0 || paginate.Limit > 100 {
+		paginate.Limit = 10
+	}
+
+	q := o.DB.Model(&models.Teacher{})
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	var teachers []models.Teacher
+	offset := (paginate.Page - 1) * paginate.Limit
+	if err := q.Order("updated_at ASC").Offset(offset).Limit(paginate.Limit).Find(&teachers).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	return pkg.ResponsePaginate{
+		Data: teachers,
+		Pagination: pkg.PaginationPage{
+			CurrentPage: paginate.Page,
+			TotalPage:   int(math.Ceil(float64(total) / float64(paginate.Limit))),
+			TotalData:   int(total),
+			Limit:       paginate.Limit,
+		},
+	}, nil
+}
+
+// ArchiveTeacher hides the teacher profile and cancels its future slots that
+// nobody has booked yet. Booked slots are left for the booking flow.
+func (o *Onboarding) ArchiveTeacher(teacher *models.Teacher, history *models.TeacherStatusHistory) error {
+	return o.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(teacher).Error; err != nil {
+			return err
+		}
+		history.TeacherID = teacher.ID
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Schedule{}).
+			Where("teacher_id = ? AND status = ? AND date >= CURDATE()", teacher.ID, "available").
+			Update("status", "cancelled").Error
+	})
+}
+
+// GetStatusBeforeArchive returns the status the teacher had when it was last
+// archived, or an empty string when it never was.
+func (o *Onboarding) GetStatusBeforeArchive(teacherID uint) (string, error) {
+	var history models.TeacherStatusHistory
+	err := o.DB.Where("teacher_id = ? AND to_status = ?", teacherID, models.TeacherStatusArchived).
+		Order("created_at DESC, id DESC").First(&history).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return history.FromStatus, nil
+}
@@ -5,8 +5,10 @@ import (
 	"math"
 	"teacher/internal/models"
 	"teacher/internal/pkg"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository struct {
@@ -23,7 +25,9 @@ func (r *Repository) CreateTeacher(teacher *models.Teacher) error {
 	return r.db.Create(teacher).Error
 }
 
-func (r *Repository) GetTeachers(paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+// GetTeachers lists teachers matching the filter. Schedules are not
+// preloaded here; list views only need the profile fields.
+func (r *Repository) GetTeachers(paginate *pkg.Paginate, filter models.TeacherFilter) (pkg.ResponsePaginate, error) {
 	var teachers []models.Teacher
 
 	if paginate.Page < 1 {
@@ -34,11 +38,30 @@ func (r *Repository) GetTeachers(paginate *pkg.Paginate) (pkg.ResponsePaginate,
 		paginate.Limit = 10
 	}
 
+	query := r.filterTeachers(r.db.Model(&models.Teacher{}), filter)
+
 	var total int64
-	r.db.Model(&models.Teacher{}).Count(&total)
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	switch filter.Sort {
+	case models.TeacherSortPriceAsc:
+		query = query.Order(r.teacherPrice(filter, false))
+	case models.TeacherSortPriceDesc:
+		query = query.Order(r.teacherPrice(filter, true))
+	case models.TeacherSortRating:
+		query = query.Order("teachers.rating_average DESC").Order("teachers.rating_count DESC")
+	case models.TeacherSortAvailability:
+		// Teachers without any matching slot go last.
+		next := r.availableSlots(filter).Select("MIN(TIMESTAMP(schedules.date, schedules.start_time))")
+		query = query.Select("teachers.*, (?) AS next_available_at", next).
+			Order("next_available_at IS NULL").
+			Order("next_available_at ASC")
+	}
 
 	offset := (paginate.Page - 1) * paginate.Limit
-	err := r.db.Preload("Schedules").Offset(offset).Limit(paginate.Limit).Find(&teachers).Error
+	err := query.Order("teachers.id ASC").Offset(offset).Limit(paginate.Limit).Find(&teachers).Error
 
 	if err != nil {
 		return pkg.ResponsePaginate{}, err
@@ -55,12 +78,198 @@ func (r *Repository) GetTeachers(paginate *pkg.Paginate) (pkg.ResponsePaginate,
 	}, nil
 }
 
+func (r *Repository) filterTeachers(query *gorm.DB, filter models.TeacherFilter) *gorm.DB {
+	query = query.Where("teachers.status = ?", models.TeacherStatusApproved)
+	if filter.Query != "" {
+		like := "%" + filter.Query + "%"
+		query = query.Where("(teachers.name LIKE ? OR teachers.bio LIKE ?)", like, like)
+	}
+	if filter.LanguageLevel != "" {
+		query = query.Where("teachers.language_level = ?", filter.LanguageLevel)
+	}
+	if filter.Subject != "" {
+		query = query.Where("EXISTS (?)", r.teacherSubject(filter))
+	} else {
+		if filter.MinPrice != nil {
+			query = query.Where("teachers.price_per_hour >= ?", *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			query = query.Where("teachers.price_per_hour <= ?", *filter.MaxPrice)
+		}
+	}
+	if filter.Language != "" {
+		sub := r.db.Table("teacher_languages").
+			Joins("JOIN instruction_languages ON instruction_languages.id = teacher_languages.language_id").
+			Where("teacher_languages.teacher_id = teachers.id").
+			Where("instruction_languages.code = ?", filter.Language).
+			Select("1")
+		query = query.Where("EXISTS (?)", sub)
+	}
+	if filter.MinRating != nil {
+		query = query.Where("teachers.rating_average >= ?", *filter.MinRating)
+	}
+	if filter.HasAvailability() {
+		query = query.Where("EXISTS (?)", r.availableSlots(filter).Select("1"))
+	}
+	return query
+}
+
+// teacherSubject matches the outer teacher row when it offers the active
+// subject of the filter within the price range. A subject without its own
+// price costs the teacher's hourly rate.
+func (r *Repository) teacherSubject(filter models.TeacherFilter) *gorm.DB {
+	price := "COALESCE(teacher_subjects.price_per_hour, teachers.price_per_hour)"
+	sub := r.db.Table("teacher_subjects").
+		Joins("JOIN subjects ON subjects.id = teacher_subjects.subject_id").
+		Where("teacher_subjects.teacher_id = teachers.id").
+		Where("subjects.code = ? AND subjects.is_active = ?", filter.Subject, true)
+	if filter.MinPrice != nil {
+		sub = sub.Where(price+" >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		sub = sub.Where(price+" <= ?", *filter.MaxPrice)
+	}
+	return sub.Select("1")
+}
+
+// teacherPrice orders teachers by their hourly rate, or by their rate for
+// the subject of the filter when there is one.
+func (r *Repository) teacherPrice(filter models.TeacherFilter, desc bool) clause.OrderBy {
+	if filter.Subject == "" {
+		return clause.OrderBy{Columns: []clause.OrderByColumn{{
+			Column: clause.Column{Table: "teachers", Name: "price_per_hour"},
+			Desc:   desc,
+		}}}
+	}
+
+	price := r.db.Table("teacher_subjects").
+		Joins("JOIN subjects ON subjects.id = teacher_subjects.subject_id").
+		Where("teacher_subjects.teacher_id = teachers.id AND subjects.code = ?", filter.Subject).
+		Select("COALESCE(teacher_subjects.price_per_hour, teachers.price_per_hour)")
+	sql := "(?) ASC"
+	if desc {
+		sql = "(?) DESC"
+	}
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: []interface{}{price}}}
+}
+
+// availableSlots selects the future available schedules of the outer
+// teacher row that match the availability part of the filter. Slots must lie
+// entirely inside the time-of-day window. Slots held for a waitlisted
+// student do not count.
+func (r *Repository) availableSlots(filter models.TeacherFilter) *gorm.DB {
+	from := filter.AvailableFrom
+	today := time.Now().Format("2006-01-02")
+	if from == "" || from < today {
+		from = today
+	}
+
+	sub := r.db.Table("schedules").
+		Where("schedules.teacher_id = teachers.id").
+		Where("schedules.deleted_at IS NULL").
+		Where("schedules.status = ?", "available").
+		Where("schedules.blocked = ?", false).
+		Where("schedules.held_until IS NULL OR schedules.held_until <= ?", time.Now()).
+		Where("schedules.date >= ?", from)
+	if filter.AvailableTo != "" {
+		sub = sub.Where("schedules.date <= ?", filter.AvailableTo)
+	}
+	if filter.TimeFrom != "" {
+		sub = sub.Where("schedules.start_time >= ?", filter.TimeFrom)
+	}
+	if filter.TimeTo != "" {
+		sub = sub.Where("schedules.end_time <= ?", filter.TimeTo)
+	}
+	return sub
+}
+
 func (r *Repository) UpdateTeacher(teacher *models.Teacher) error {
 	return r.db.Save(teacher).Error
 }
 
+// DeleteTeacher soft deletes the teacher together with its future slots
+// that are still open. Booked and past slots stay so bookings keep resolving.
+// Both get the same deletion time, which RestoreTeacher relies on.
 func (r *Repository) DeleteTeacher(teacher *models.Teacher) error {
-	return r.db.Delete(teacher).Error
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Teacher{}).Where("id = ?", teacher.ID).UpdateColumn("deleted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("teacher not found")
+		}
+		return tx.Model(&models.Schedule{}).
+			Where("teacher_id = ? AND status = ? AND date >= ?", teacher.ID, "available", now.Format("2006-01-02")).
+			UpdateColumn("deleted_at", now).Error
+	})
+}
+
+// RestoreTeacher undoes DeleteTeacher, including the slots that were
+// removed with the teacher.
+func (r *Repository) RestoreTeacher(id uint) (*models.Teacher, error) {
+	var teacher models.Teacher
+	if err := r.db.Unscoped().First(&teacher, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("teacher not found")
+		}
+		return nil, err
+	}
+	if !teacher.DeletedAt.Valid {
+		return nil, errors.New("teacher is not deleted")
+	}
+
+	deletedAt := teacher.DeletedAt.Time
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Teacher{}).Where("id = ?", id).
+			UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Schedule{}).
+			Where("teacher_id = ? AND deleted_at = ?", id, deletedAt).
+			UpdateColumn("deleted_at", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	teacher.DeletedAt = gorm.DeletedAt{}
+	return &teacher, nil
+}
+
+// GetDeletedTeachers lists soft deleted teachers, most recently deleted
+// first.
+func (r *Repository) GetDeletedTeachers(paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+	if paginate.Page < 1 {
+		paginate.Page = 1
+	}
+	if paginate.Limit <= 0 || paginate.Limit > 100 {
+		paginate.Limit = 10
+	}
+
+	query := r.db.Unscoped().Model(&models.Teacher{}).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	var teachers []models.Teacher
+	offset := (paginate.Page - 1) * paginate.Limit
+	if err := query.Order("deleted_at DESC").Offset(offset).Limit(paginate.Limit).Find(&teachers).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	return pkg.ResponsePaginate{
+		Data: teachers,
+		Pagination: pkg.PaginationPage{
+			CurrentPage: paginate.Page,
+			TotalPage:   int(math.Ceil(float64(total) / float64(paginate.Limit))),
+			TotalData:   int(total),
+			Limit:       paginate.Limit,
+		},
+	}, nil
 }
 func (r *Repository) GetTeacherByID(id uint) (*models.Teacher, error) {
 	var teacher models.Teacher
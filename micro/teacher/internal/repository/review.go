@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"errors"
+	"math"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+
+	"gorm.io/gorm"
+)
+
+type Review struct {
+	DB *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) *Review {
+	return &Review{
+		DB: db,
+	}
+}
+
+// CreateReview stores the review and refreshes the teacher's aggregate
+// rating in the same transaction.
+func (r *Review) CreateReview(review *models.Review) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		return refreshTeacherRating(tx, review.TeacherID)
+	})
+}
+
+func (r *Review) UpdateReview(review *models.Review) error {
+	return r.DB.Save(review).Error
+}
+
+// ModerateReview saves the moderation flags and refreshes the teacher's
+// aggregate rating, since hidden reviews do not count.
+func (r *Review) ModerateReview(review *models.Review) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(review).Error; err != nil {
+			return err
+		}
+		return refreshTeacherRating(tx, review.TeacherID)
+	})
+}
+
+func (r *Review) GetReviewByID(id uint) (*models.Review, error) {
+	var review models.Review
+	if err := r.DB.First(&review, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("review not found")
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *Review) ExistsForBooking(bookingID uint) (bool, error) {
+	var count int64
+	if err := r.DB.Model(&models.Review{}).Where("booking_id = ?", bookingID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetTeacherReviews lists the visible reviews of a teacher, newest first.
+func (r *Review) GetTeacherReviews(teacherID uint, paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+	return r.paginate(r.DB.Model(&models.Review{}).
+		Where("teacher_id = ? AND is_hidden = ?", teacherID, false), paginate)
+}
+
+// GetReviewsForModeration lists reviews for admins, optionally only the
+// flagged ones. Hidden reviews are included.
+func (r *Review) GetReviewsForModeration(flaggedOnly bool, paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+	q := r.DB.Model(&models.Review{})
+	if flaggedOnly {
+		q = q.Where("is_flagged = ?", true)
+	}
+	return r.paginate(q, paginate)
+}
+
+func (r *Review) GetRecentReviews(teacherID uint, limit int) ([]models.Review, error) {
+	var reviews []models.Review
+	if err := r.DB.Where("teacher_id = ? AND is_hidden = ?", teacherID, false).
+		Order("created_at DESC").Limit(limit).Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *Review) paginate(q *gorm.DB, paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+	if paginate.Page < 1 {
+		paginate.Page = 1
+	}
+	if paginate.Limit <= 0 || paginate.Limit > 100 {
+		paginate.Limit = 10
+	}
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	var reviews []models.Review
+	offset := (paginate.Page - 1) * paginate.Limit
+	if err := q.Order("created_at DESC").Offset(offset).Limit(paginate.Limit).Find(&reviews).Error; err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	return pkg.ResponsePaginate{
+		Data: reviews,
+		Pagination: pkg.PaginationPage{
+			CurrentPage: paginate.Page,
+			TotalPage:   int(math.Ceil(float64(total) / float64(paginate.Limit))),
+			TotalData:   int(total),
+			Limit:       paginate.Limit,
+		},
+	}, nil
+}
+
+// refreshTeacherRating recomputes the denormalized rating columns used for
+// teacher search from the visible reviews.
+func refreshTeacherRating(tx *gorm.DB, teacherID uint) error {
+	var aggregate struct {
+		Average float64
+		Count   int
+	}
+	if err := tx.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("teacher_id = ? AND is_hidden = ?", teacherID, false).
+		Scan(&aggregate).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Teacher{}).Where("id = ?", teacherID).Updates(map[string]interface{}{
+		"rating_average": math.Round(aggregate.Average*100) / 100,
+		"rating_count":   aggregate.Count,
+	}).Error
+}
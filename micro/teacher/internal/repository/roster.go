@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"teacher/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Roster struct {
+	DB *gorm.DB
+}
+
+func NewRosterRepository(db *gorm.DB) *Roster {
+	return &Roster{
+		DB: db,
+	}
+}
+
+// GetNotes returns the teacher's notes about the given students, keyed by
+// student id.
+func (r *Roster) GetNotes(teacherID uint, studentIDs []uint) (map[uint]string, error) {
+	notes := make(map[uint]string)
+	if len(studentIDs) == 0 {
+		return notes, nil
+	}
+
+	var rows []models.TeacherStudentNote
+	if err := r.DB.Where("teacher_id = ? AND student_id IN ?", teacherID, studentIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		notes[row.StudentID] = row.Note
+	}
+	return notes, nil
+}
+
+// SaveNote creates or replaces the teacher's note about a student.
+func (r *Roster) SaveNote(note *models.TeacherStudentNote) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "teacher_id"}, {Name: "student_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"note", "updated_at"}),
+	}).Create(note).Error
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"math"
 	"teacher/internal/models"
@@ -28,14 +30,40 @@ func (s *Schedule) GetTeacherByID(id uint) (*models.Teacher, error) {
 	return &teacher, nil
 }
 
+// HasScheduleConflict reports whether the time overlaps a booked slot of the
+// teacher, a group class that is not cancelled (it may fill up any time) or
+// a busy time imported from one of their external calendars.
 func (s *Schedule) HasScheduleConflict(teacherID uint, date time.Time, start, end string) (bool, error) {
 	var count int64
 	err := s.DB.Model(&models.Schedule{}).
-		Where("teacher_id = ? AND date = ? AND start_time < ? AND end_time > ? AND status = ?", teacherID, date, end, start, "booked").
+		Where("teacher_id = ? AND date = ? AND start_time < ? AND end_time > ?", teacherID, date, end, start).
+		Where("status = ? OR seats_taken > 0 OR (capacity > 1 AND status <> ?)", "booked", "cancelled").
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	day := date.Format("2006-01-02")
+	err = s.DB.Model(&models.BusyTime{}).
+		Where("teacher_id = ? AND start_at < TIMESTAMP(?, ?) AND end_at > TIMESTAMP(?, ?)", teacherID, day, end, day, start).
 		Count(&count).Error
 	return count > 0, err
 }
 
+// RefreshBlockedSchedules marks the teacher's open slots from the given day
+// on as blocked when they overlap an imported busy time, and unblocks the
+// others. Booked slots are left alone.
+func (s *Schedule) RefreshBlockedSchedules(teacherID uint, from time.Time) error {
+	overlapping := s.DB.Model(&models.BusyTime{}).Select("1").
+		Where("busy_times.teacher_id = schedules.teacher_id").
+		Where("busy_times.start_at < TIMESTAMP(schedules.date, schedules.end_time)").
+		Where("busy_times.end_at > TIMESTAMP(schedules.date, schedules.start_time)")
+
+	return s.DB.Model(&models.Schedule{}).
+		Where("teacher_id = ? AND status = ? AND date >= ?", teacherID, "available", from.Format("2006-01-02")).
+		UpdateColumn("blocked", gorm.Expr("EXISTS (?)", overlapping)).Error
+}
+
 func (s *Schedule) CreateSchedule(schedule *models.Schedule) error {
 	return s.DB.Create(schedule).Error
 }
@@ -53,7 +81,8 @@ func (s *Schedule) GetAvailableSchedules(teacherID uint, paginate *pkg.Paginate)
 
 	var count int64
 	err := s.DB.Model(&models.Schedule{}).
-		Where("teacher_id = ? AND status = ? AND date >= ?", teacherID, "available", time.Now().Format("2006-01-02")).
+		Where("teacher_id = ? AND status = ? AND blocked = ? AND date >= ?", teacherID, "available", false, time.Now().Format("2006-01-02")).
+		Where("held_until IS NULL OR held_until <= ?", time.Now()).
 		Count(&count).Error
 	if err != nil {
 		return pkg.ResponsePaginate{}, err
@@ -61,7 +90,8 @@ func (s *Schedule) GetAvailableSchedules(teacherID uint, paginate *pkg.Paginate)
 
 	offset := (paginate.Page - 1) * paginate.Limit
 	err = s.DB.Offset(offset).Limit(paginate.Limit).
-		Where("teacher_id = ? AND status = ? AND date >= ?", teacherID, "available", time.Now().Format("2006-01-02")).
+		Where("teacher_id = ? AND status = ? AND blocked = ? AND date >= ?", teacherID, "available", false, time.Now().Format("2006-01-02")).
+		Where("held_until IS NULL OR held_until <= ?", time.Now()).
 		Find(&schedules).Error
 
 	if err != nil {
@@ -82,12 +112,15 @@ func (s *Schedule) GetAvailableSchedules(teacherID uint, paginate *pkg.Paginate)
 func (s *Schedule) CancelSchedule(id uint) error {
 	return s.DB.Model(&models.Schedule{}).
 		Where("id = ?", id).
-		Update("status", "cancelled").Error
+		Updates(map[string]interface{}{
+			"status":   "cancelled",
+			"sequence": gorm.Expr("sequence + 1"),
+		}).Error
 }
 
 func (s *Schedule) GetSchedulesById(id uint) (models.Schedule, error) {
 	var schedule models.Schedule
-	if err := s.DB.Preload("Teacher").First(&schedule, id).Error; err != nil {
+	if err := s.DB.Preload("Teacher").Preload("LessonType").First(&schedule, id).Error; err != nil {
 		return models.Schedule{}, err
 	}
 	return schedule, nil
@@ -97,6 +130,28 @@ func (s *Schedule) DeleteSchedule(id uint) error {
 	return s.DB.Delete(&models.Schedule{}, id).Error
 }
 
+// RestoreSchedule undoes the soft delete of a schedule.
+func (s *Schedule) RestoreSchedule(id uint) (*models.Schedule, error) {
+	var schedule models.Schedule
+	if err := s.DB.Unscoped().First(&schedule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("schedule not found")
+		}
+		return nil, err
+	}
+	if !schedule.DeletedAt.Valid {
+		return nil, errors.New("schedule is not deleted")
+	}
+
+	if err := s.DB.Unscoped().Model(&models.Schedule{}).Where("id = ?", id).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+
+	schedule.DeletedAt = gorm.DeletedAt{}
+	return &schedule, nil
+}
+
 func (s *Schedule) UpdateScheduleStatus(id uint, status string) error {
 	log.Println(status, id)
 	return s.DB.Model(&models.Schedule{}).
@@ -104,9 +159,169 @@ func (s *Schedule) UpdateScheduleStatus(id uint, status string) error {
 		Update("status", status).Error
 }
 
+// ReserveSlot books an open private slot in a single statement, so two
+// bookings cannot both take it. It reports false when the slot was not open.
+// A slot freed by a cancellation gets a new sequence, so calendar feeds
+// show the lesson again.
+func (s *Schedule) ReserveSlot(id uint) (bool, error) {
+	result := s.DB.Exec(`UPDATE schedules
+		SET status = 'booked',
+			sequence = CASE WHEN freed THEN sequence + 1 ELSE sequence END,
+			freed = false,
+			held_until = NULL,
+			updated_at = ?
+		WHERE id = ? AND status = 'available' AND blocked = false AND deleted_at IS NULL`,
+		time.Now(), id)
+	return result.RowsAffected > 0, result.Error
+}
+
+// errSlotUnavailable rolls back ReserveSlots.
+var errSlotUnavailable = errors.New("schedule is not available")
+
+// ReserveSlots books all given slots, taking a seat of group classes, in one
+// transaction. If one of them is not open nothing is booked and its id is
+// returned.
+func (s *Schedule) ReserveSlots(ids []uint) (uint, error) {
+	var unavailable uint
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var schedules []models.Schedule
+		if err := tx.Where("id IN ?", ids).Find(&schedules).Error; err != nil {
+			return err
+		}
+		capacity := make(map[uint]int, len(schedules))
+		for _, schedule := range schedules {
+			capacity[schedule.ID] = schedule.Capacity
+		}
+
+		repo := &Schedule{DB: tx}
+		for _, id := range ids {
+			reserve := repo.ReserveSlot
+			if capacity[id] > 1 {
+				reserve = repo.ReserveSeat
+			}
+			ok, err := reserve(id)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				unavailable = id
+				return errSlotUnavailable
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errSlotUnavailable) {
+		return unavailable, nil
+	}
+	return 0, err
+}
+
+// ReleaseSlots undoes ReserveSlots: private slots reopen and group classes
+// get a seat back. Unlike FreeSlot no lesson took place in them, so they do
+// not show up as cancelled in calendar feeds.
+func (s *Schedule) ReleaseSlots(ids []uint) error {
+	return s.DB.Exec(`UPDATE schedules
+		SET status = CASE WHEN status = 'booked' THEN 'available' ELSE status END,
+			seats_taken = CASE WHEN capacity > 1 THEN seats_taken - 1 ELSE seats_taken END,
+			updated_at = ?
+		WHERE id IN ? AND ((capacity > 1 AND seats_taken > 0) OR (capacity <= 1 AND status = 'booked'))`,
+		time.Now(), ids).Error
+}
+
+// FreeSlot reopens a booked private slot whose lesson was cancelled. The
+// slot stays in calendar feeds as a cancelled event.
+func (s *Schedule) FreeSlot(id uint) error {
+	return s.DB.Exec(`UPDATE schedules
+		SET status = 'available',
+			freed = true,
+			sequence = sequence + 1,
+			updated_at = ?
+		WHERE id = ? AND status = 'booked'`,
+		time.Now(), id).Error
+}
+
+// HoldSlot holds an open slot for a waitlisted student until the given
+// time, or releases the hold when until is nil.
+func (s *Schedule) HoldSlot(id uint, until *time.Time) error {
+	return s.DB.Model(&models.Schedule{}).
+		Where("id = ?", id).
+		UpdateColumn("held_until", until).Error
+}
+
+// ReserveSeat takes a seat of an open group class in a single statement, so
+// concurrent bookings cannot oversell it. The class is marked booked when
+// its last seat is taken. It reports false when no seat was left.
+func (s *Schedule) ReserveSeat(id uint) (bool, error) {
+	result := s.DB.Exec(`UPDATE schedules
+		SET status = CASE WHEN seats_taken + 1 >= capacity THEN 'booked' ELSE status END,
+			seats_taken = seats_taken + 1,
+			sequence = CASE WHEN freed THEN sequence + 1 ELSE sequence END,
+			freed = false,
+			held_until = NULL,
+			updated_at = ?
+		WHERE id = ? AND status = 'available' AND blocked = false AND seats_taken < capacity AND deleted_at IS NULL`,
+		time.Now(), id)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReleaseSeat gives a seat of a group class back and reopens a full class.
+// Cancelled classes stay cancelled. A class whose last student left is kept
+// in calendar feeds as cancelled; MySQL assigns left to right, so freed and
+// sequence see the new seats_taken.
+func (s *Schedule) ReleaseSeat(id uint) error {
+	return s.DB.Exec(`UPDATE schedules
+		SET status = CASE WHEN status = 'booked' THEN 'available' ELSE status END,
+			seats_taken = seats_taken - 1,
+			freed = seats_taken = 0,
+			sequence = CASE WHEN freed THEN sequence + 1 ELSE sequence END,
+			updated_at = ?
+		WHERE id = ? AND seats_taken > 0`,
+		time.Now(), id).Error
+}
+
+// FindTeacherSlots returns the teacher's slots that are not cancelled and
+// run from start to end on one of the dates.
+func (s *Schedule) FindTeacherSlots(teacherID uint, dates []string, start, end string) ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	err := s.DB.
+		Where("teacher_id = ? AND date IN ? AND start_time = ? AND end_time = ? AND status <> ?", teacherID, dates, start, end, "cancelled").
+		Order("date, id").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// GetUnderfilledClasses returns group classes that have not started yet, are
+// past their enrolment cutoff and still lack the minimum number of seats.
+func (s *Schedule) GetUnderfilledClasses(now time.Time) ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	err := s.DB.
+		Where("capacity > 1 AND min_seats > 0 AND seats_taken < min_seats AND status <> ?", "cancelled").
+		Where("TIMESTAMP(date, start_time) > ?", now).
+		Where("TIMESTAMP(date, start_time) - INTERVAL cutoff_hours HOUR <= ?", now).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// GetUpcomingGroupClasses returns the teacher's group classes starting from
+// now on, earliest first.
+func (s *Schedule) GetUpcomingGroupClasses(teacherID uint, now time.Time, limit int) ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	err := s.DB.
+		Where("teacher_id = ? AND capacity > 1 AND status <> ?", teacherID, "cancelled").
+		Where("TIMESTAMP(date, start_time) >= ?", now).
+		Order("date, start_time").
+		Limit(limit).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// GetBatchScheduleDetail also returns soft deleted schedules and teachers,
+// since callers resolve schedules of existing bookings.
 func (s *Schedule) GetBatchScheduleDetail(ids []uint) ([]models.Schedule, error) {
 	var schedules []models.Schedule
-	if err := s.DB.Preload("Teacher").
+	if err := s.DB.Unscoped().
+		Preload("Teacher", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("LessonType").
 		Where("id IN ?", ids).Find(&schedules).Error; err != nil {
 		return nil, err
 	}
@@ -158,7 +373,174 @@ func (s *Schedule) GetSchedules(paginate pkg.Paginate) (pkg.ResponsePaginate, er
 }
 
 func (s *Schedule) UpdateSchedule(id uint, schedule *models.Schedule) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Schedule{}).
+			Where("id = ?", id).
+			Omit("sequence").
+			Updates(schedule).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Schedule{}).
+			Where("id = ?", id).
+			UpdateColumn("sequence", gorm.Expr("sequence + 1")).Error
+	})
+}
+
+// GetTeacherSubject returns the assignment of the subject to the teacher.
+func (s *Schedule) GetTeacherSubject(teacherID, subjectID uint) (*models.TeacherSubject, error) {
+	var subject models.TeacherSubject
+	if err := s.DB.Where("teacher_id = ? AND subject_id = ?", teacherID, subjectID).First(&subject).Error; err != nil {
+		return nil, err
+	}
+	return &subject, nil
+}
+
+// GetTeacherSubjects returns the subject assignments of any of the teachers
+// to any of the subjects.
+func (s *Schedule) GetTeacherSubjects(teacherIDs, subjectIDs []uint) ([]models.TeacherSubject, error) {
+	var subjects []models.TeacherSubject
+	err := s.DB.Where("teacher_id IN ? AND subject_id IN ?", teacherIDs, subjectIDs).Find(&subjects).Error
+	return subjects, err
+}
+
+func (s *Schedule) GetTeacherByUserID(userID uint) (*models.Teacher, error) {
+	var teacher models.Teacher
+	if err := s.DB.Where("user_id = ?", userID).First(&teacher).Error; err != nil {
+		return nil, err
+	}
+	return &teacher, nil
+}
+
+// CreateSchedules inserts all schedules in a single transaction so a batch is
+// either fully created or not at all.
+func (s *Schedule) CreateSchedules(schedules []models.Schedule) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&schedules).Error
+	})
+}
+
+// UpdateScheduleTimes moves every schedule to its new date and time in a
+// single transaction.
+func (s *Schedule) UpdateScheduleTimes(schedules []models.Schedule) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, schedule := range schedules {
+			if err := tx.Model(&models.Schedule{}).
+				Where("id = ?", schedule.ID).
+				Updates(map[string]interface{}{
+					"date":       schedule.Date,
+					"start_time": schedule.StartTime,
+					"end_time":   schedule.EndTime,
+					"sequence":   gorm.Expr("sequence + 1"),
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetTeacherSchedulesInRange returns the teacher's schedules between start and
+// end (inclusive) that are not cancelled yet.
+func (s *Schedule) GetTeacherSchedulesInRange(teacherID uint, start, end time.Time) ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	if err := s.DB.
+		Where("teacher_id = ? AND date BETWEEN ? AND ? AND status <> ?", teacherID, start.Format("2006-01-02"), end.Format("2006-01-02"), "cancelled").
+		Order("date, start_time").
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// CancelSchedules marks the schedules that are not cancelled yet cancelled
+// and returns the ones that have bookings to cancel.
+func (s *Schedule) CancelSchedules(ids []uint) ([]models.Schedule, error) {
+	_, booked, err := s.cancelSchedules(ids, "")
+	return booked, err
+}
+
+// CancelUnderfilledClass cancels the group class if it still lacks its
+// minimum seats. It reports whether it did, and returns the class when it
+// has bookings to cancel, with the seats taken when it was cancelled.
+func (s *Schedule) CancelUnderfilledClass(id uint) (bool, *models.Schedule, error) {
+	cancelled, booked, err := s.cancelSchedules([]uint{id}, " AND seats_taken < min_seats")
+	if err != nil || len(booked) == 0 {
+		return cancelled > 0, nil, err
+	}
+	return cancelled > 0, &booked[0], nil
+}
+
+// cancelSchedules cancels the schedules matching condition and returns how
+// many it cancelled and the cancelled ones that have bookings to cancel.
+// Those are flagged until their bookings are cancelled too, see
+// ClearCancelRetry. The flag is set in the same statement, so a booking
+// made just before is not missed; MySQL assigns left to right, so
+// cancel_retry sees the status from before.
+func (s *Schedule) cancelSchedules(ids []uint, condition string) (int64, []models.Schedule, error) {
+	var cancelled int64
+	var booked []models.Schedule
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`UPDATE schedules
+			SET cancel_retry = cancel_retry OR status = 'booked' OR seats_taken > 0,
+				status = 'cancelled',
+				sequence = sequence + 1,
+				updated_at = ?
+			WHERE id IN ? AND status <> 'cancelled' AND deleted_at IS NULL`+condition,
+			time.Now(), ids)
+		if result.Error != nil {
+			return result.Error
+		}
+		cancelled = result.RowsAffected
+		return tx.Where("id IN ? AND status = ? AND cancel_retry = ?", ids, "cancelled", true).
+			Find(&booked).Error
+	})
+	return cancelled, booked, err
+}
+
+// GetCancelRetries returns the cancelled schedules whose bookings still have
+// to be cancelled.
+func (s *Schedule) GetCancelRetries() ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	err := s.DB.Where("cancel_retry = ?", true).Find(&schedules).Error
+	return schedules, err
+}
+
+// ClearCancelRetry marks the bookings of the schedules as cancelled.
+func (s *Schedule) ClearCancelRetry(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
 	return s.DB.Model(&models.Schedule{}).
-		Where("id = ?", id).
-		Updates(schedule).Error
+		Where("id IN ?", ids).
+		Update("cancel_retry", false).Error
+}
+
+// analyticsPeriodFormats maps an analytics interval to the MySQL date format
+// of its period key. The booking service groups by the same keys.
+var analyticsPeriodFormats = map[string]string{
+	models.AnalyticsIntervalDay:   "%Y-%m-%d",
+	models.AnalyticsIntervalWeek:  "%x-W%v",
+	models.AnalyticsIntervalMonth: "%Y-%m",
+}
+
+// GetScheduleHours sums the length of the teacher's booked and available
+// slots between from and to (inclusive) per period. Cancelled slots are not
+// counted as offered time.
+func (s *Schedule) GetScheduleHours(teacherID uint, from, to, interval string) ([]models.ScheduleHours, error) {
+	format, ok := analyticsPeriodFormats[interval]
+	if !ok {
+		return nil, fmt.Errorf("unknown interval %q", interval)
+	}
+
+	length := "TIME_TO_SEC(TIMEDIFF(end_time, start_time)) / 60"
+	var rows []models.ScheduleHours
+	err := s.DB.Model(&models.Schedule{}).
+		Select(fmt.Sprintf("DATE_FORMAT(date, '%s') AS period, ", format)+
+			"COALESCE(SUM(CASE WHEN status = 'booked' THEN "+length+" END), 0) AS booked_minutes, "+
+			"COALESCE(SUM(CASE WHEN status = 'available' THEN "+length+" END), 0) AS available_minutes").
+		Where("teacher_id = ? AND date BETWEEN ? AND ?", teacherID, from, to).
+		Group("period").
+		Order("period").
+		Scan(&rows).Error
+	return rows, err
 }
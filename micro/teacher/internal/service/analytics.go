@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"teacher/internal/models"
+	"time"
+)
+
+const (
+	defaultAnalyticsDays = 30
+	// maxAnalyticsDays bounds the range so a daily series stays readable and
+	// the aggregation queries stay cheap.
+	maxAnalyticsDays      = 366
+	maxAnalyticsDaysTotal = 3 * 366
+)
+
+// GetTeacherAnalytics returns the teacher's earnings, lessons, students and
+// occupancy per period. Booking figures are aggregated by the booking
+// service; offered and booked hours come from the teacher's own slots.
+func (s *DashboardService) GetTeacherAnalytics(teacherID uint, filter models.AnalyticsFilter) (*models.TeacherAnalyticsResponse, error) {
+	from, to, err := validateAnalyticsFilter(&filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.teacherRepo.GetTeacherByID(teacherID); err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	bookingStats, err := s.serviceBooking.GetTeacherAnalytics(teacherID, filter.From, filter.To, filter.Interval)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to get booking analytics")
+	}
+	hours, err := s.scheduleRepo.GetScheduleHours(teacherID, filter.From, filter.To, filter.Interval)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to get schedule hours")
+	}
+
+	byPeriod := make(map[string]*models.AnalyticsPeriod)
+	series := make([]models.AnalyticsPeriod, 0)
+	for _, period := range analyticsPeriods(from, to, filter.Interval) {
+		series = append(series, models.AnalyticsPeriod{Period: period})
+	}
+	for i := range series {
+		byPeriod[series[i].Period] = &series[i]
+	}
+
+	for _, stat := range bookingStats.Series {
+		if period, ok := byPeriod[stat.Period]; ok {
+			period.Bookings = stat.Bookings
+			period.Cancellations = stat.Cancellations
+			period.Lessons = stat.Lessons
+			period.Earnings = stat.Earnings
+			period.NewStudents = stat.NewStudents
+		}
+	}
+
+	summary := bookingStats.Summary
+	for _, row := range hours {
+		period, ok := byPeriod[row.Period]
+		if !ok {
+			continue
+		}
+		period.BookedHours = row.BookedMinutes / 60
+		period.OfferedHours = (row.BookedMinutes + row.AvailableMinutes) / 60
+		period.Occupancy = share(period.BookedHours, period.OfferedHours)
+
+		summary.BookedHours += period.BookedHours
+		summary.OfferedHours += period.OfferedHours
+	}
+	summary.Occupancy = share(summary.BookedHours, summary.OfferedHours)
+
+	return &models.TeacherAnalyticsResponse{
+		TeacherID: teacherID,
+		From:      filter.From,
+		To:        filter.To,
+		Interval:  filter.Interval,
+		Summary:   summary,
+		Series:    series,
+	}, nil
+}
+
+func (s *DashboardService) CanManageTeacher(userID uint, role string, teacherID uint) bool {
+	return canManageTeacher(s.scheduleRepo, userID, role, teacherID)
+}
+
+// validateAnalyticsFilter fills in the defaults (daily, last 30 days) and
+// checks the range.
+func validateAnalyticsFilter(filter *models.AnalyticsFilter) (time.Time, time.Time, error) {
+	switch filter.Interval {
+	case "":
+		filter.Interval = models.AnalyticsIntervalDay
+	case models.AnalyticsIntervalDay, models.AnalyticsIntervalWeek, models.AnalyticsIntervalMonth:
+	default:
+		return time.Time{}, time.Time{}, errors.New("invalid interval, use day, week or month")
+	}
+
+	to, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	if filter.To != "" {
+		parsed, err := time.Parse("2006-01-02", filter.To)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid date format, should be 2006-01-02")
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -(defaultAnalyticsDays - 1))
+	if filter.From != "" {
+		parsed, err := time.Parse("2006-01-02", filter.From)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid date format, should be 2006-01-02")
+		}
+		from = parsed
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, errors.New("to must not be before from")
+	}
+	days := int(to.Sub(from).Hours()/24) + 1
+	limit := maxAnalyticsDaysTotal
+	if filter.Interval == models.AnalyticsIntervalDay {
+		limit = maxAnalyticsDays
+	}
+	if days > limit {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not be longer than %d days for interval %s", limit, filter.Interval)
+	}
+
+	filter.From = from.Format("2006-01-02")
+	filter.To = to.Format("2006-01-02")
+	return from, to, nil
+}
+
+// analyticsPeriods lists the period keys between from and to in order, in
+// the same format the aggregation queries group by.
+func analyticsPeriods(from, to time.Time, interval string) []string {
+	var periods []string
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		var key string
+		switch interval {
+		case models.AnalyticsIntervalWeek:
+			year, week := day.ISOWeek()
+			key = fmt.Sprintf("%d-W%02d", year, week)
+		case models.AnalyticsIntervalMonth:
+			key = day.Format("2006-01")
+		default:
+			key = day.Format("2006-01-02")
+		}
+		if len(periods) == 0 || periods[len(periods)-1] != key {
+			periods = append(periods, key)
+		}
+	}
+	return periods
+}
+
+func share(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total
+}
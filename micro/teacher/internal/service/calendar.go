@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"teacher/internal/config"
+	"teacher/internal/models"
+	"teacher/internal/repository"
+	"time"
+
+	"ics"
+)
+
+// calendarPastDays keeps recent lessons in the feed so a cancellation that
+// happened shortly before still reaches subscribers.
+const calendarPastDays = 30
+
+type CalendarService struct {
+	calendarRepo *repository.Calendar
+	scheduleRepo *repository.Schedule
+	calendar     config.Calendar
+}
+
+func NewCalendarService(calendarRepo *repository.Calendar, scheduleRepo *repository.Schedule, calendar config.Calendar) *CalendarService {
+	return &CalendarService{
+		calendarRepo: calendarRepo,
+		scheduleRepo: scheduleRepo,
+		calendar:     calendar,
+	}
+}
+
+// GetFeed returns the teacher's feed, creating it on first use.
+func (s *CalendarService) GetFeed(userID uint) (*models.CalendarFeedResponse, error) {
+	if _, err := s.scheduleRepo.GetTeacherByUserID(userID); err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	feed, err := s.calendarRepo.GetFeedByUserID(userID)
+	if err != nil && err.Error() != "calendar feed not found" {
+		log.Println(err)
+		return nil, errors.New("failed to get calendar feed")
+	}
+	if feed == nil {
+		return s.RotateFeed(userID)
+	}
+	return s.feedResponse(feed), nil
+}
+
+// RotateFeed replaces the feed token. Subscriptions using the old URL stop
+// working.
+func (s *CalendarService) RotateFeed(userID uint) (*models.CalendarFeedResponse, error) {
+	if _, err := s.scheduleRepo.GetTeacherByUserID(userID); err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	token, err := ics.NewToken()
+	if err != nil {
+		return nil, errors.New("failed to generate calendar token")
+	}
+
+	feed, err := s.calendarRepo.GetFeedByUserID(userID)
+	if err != nil {
+		feed = &models.CalendarFeed{UserID: userID}
+	}
+	feed.Token = token
+	feed.CreatedAt = time.Now()
+
+	if err := s.calendarRepo.SaveFeed(feed); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to save calendar feed")
+	}
+	return s.feedResponse(feed), nil
+}
+
+// RevokeFeed deletes the feed so its URL stops working.
+func (s *CalendarService) RevokeFeed(userID uint) error {
+	if err := s.calendarRepo.DeleteFeed(userID); err != nil {
+		log.Println(err)
+		return errors.New("failed to revoke calendar feed")
+	}
+	return nil
+}
+
+// RenderTeacherCalendar renders the ICS feed of the teacher owning token.
+func (s *CalendarService) RenderTeacherCalendar(token string) (string, error) {
+	feed, err := s.calendarRepo.GetFeedByToken(token)
+	if err != nil {
+		return "", errors.New("calendar feed not found")
+	}
+	teacher, err := s.scheduleRepo.GetTeacherByUserID(feed.UserID)
+	if err != nil {
+		return "", errors.New("calendar feed not found")
+	}
+
+	loc := s.location()
+	today, _ := time.ParseInLocation("2006-01-02", time.Now().In(loc).Format("2006-01-02"), loc)
+	schedules, err := s.calendarRepo.GetCalendarSchedules(teacher.ID, today.AddDate(0, 0, -calendarPastDays))
+	if err != nil {
+		log.Println(err)
+		return "", errors.New("failed to get schedules")
+	}
+
+	events := make([]ics.Event, 0, len(schedules))
+	for _, schedule := range schedules {
+		event, err := scheduleEvent(schedule, loc)
+		if err != nil {
+			log.Printf("skipping schedule %d in calendar: %v", schedule.ID, err)
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return ics.Write("teacher", "Teaching schedule", loc.String(), events), nil
+}
+
+func scheduleEvent(schedule models.Schedule, loc *time.Location) (ics.Event, error) {
+	start, err := lessonTime(schedule.Date, schedule.StartTime, loc)
+	if err != nil {
+		return ics.Event{}, err
+	}
+	end, err := lessonTime(schedule.Date, schedule.EndTime, loc)
+	if err != nil {
+		return ics.Event{}, err
+	}
+
+	summary := "Japanese lesson"
+	if schedule.LessonType != nil && schedule.LessonType.Name != "" {
+		summary += ": " + schedule.LessonType.Name
+	}
+
+	status := ics.StatusConfirmed
+	if schedule.Status == "cancelled" || schedule.Freed {
+		status = ics.StatusCancelled
+	}
+
+	return ics.Event{
+		UID:         fmt.Sprintf("schedule-%d@booking-teacher-app", schedule.ID),
+		Summary:     summary,
+		Description: fmt.Sprintf("Schedule #%d", schedule.ID),
+		Start:       start,
+		End:         end,
+		Updated:     schedule.UpdatedAt,
+		Status:      status,
+		Sequence:    schedule.Sequence,
+	}, nil
+}
+
+// lessonTime combines a schedule date with a HH:MM or HH:MM:SS time in loc.
+func lessonTime(date time.Time, clock string, loc *time.Location) (time.Time, error) {
+	if strings.Count(clock, ":") == 1 {
+		clock += ":00"
+	}
+	return time.ParseInLocation("2006-01-02 15:04:05", date.Format("2006-01-02")+" "+clock, loc)
+}
+
+func (s *CalendarService) location() *time.Location {
+	return calendarLocation(s.calendar)
+}
+
+// calendarLocation returns the zone schedule times are stored in.
+func calendarLocation(calendar config.Calendar) *time.Location {
+	loc, err := time.LoadLocation(calendar.Timezone)
+	if err != nil || calendar.Timezone == "" {
+		return time.Local
+	}
+	return loc
+}
+
+func (s *CalendarService) feedResponse(feed *models.CalendarFeed) *models.CalendarFeedResponse {
+	return &models.CalendarFeedResponse{
+		Token:     feed.Token,
+		URL:       fmt.Sprintf("%s/api/v1/teachers/calendar/ics/%s.ics", strings.TrimRight(s.calendar.PublicURL, "/"), feed.Token),
+		CreatedAt: feed.CreatedAt,
+	}
+}
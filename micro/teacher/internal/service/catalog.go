@@ -0,0 +1,224 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"teacher/internal/models"
+	"teacher/internal/repository"
+	"time"
+)
+
+var catalogCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+type CatalogService struct {
+	catalogRepo  *repository.Catalog
+	scheduleRepo *repository.Schedule
+}
+
+func NewCatalogService(catalogRepo *repository.Catalog, scheduleRepo *repository.Schedule) *CatalogService {
+	return &CatalogService{
+		catalogRepo:  catalogRepo,
+		scheduleRepo: scheduleRepo,
+	}
+}
+
+func (s *CatalogService) GetSubjects(activeOnly bool) ([]models.Subject, error) {
+	subjects, err := s.catalogRepo.GetSubjects(activeOnly)
+	if err != nil {
+		return nil, errors.New("failed to get subjects")
+	}
+	return subjects, nil
+}
+
+func (s *CatalogService) CreateSubject(req models.SubjectRequest) (*models.Subject, error) {
+	subject := &models.Subject{IsActive: true}
+	if err := s.applySubjectRequest(subject, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.catalogRepo.SaveSubject(subject); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to create subject")
+	}
+	return subject, nil
+}
+
+// UpdateSubject renames or deactivates a subject. Deactivated subjects stay
+// assigned to teachers but are no longer offered in the catalog or search.
+func (s *CatalogService) UpdateSubject(id uint, req models.SubjectRequest) (*models.Subject, error) {
+	subject, err := s.catalogRepo.GetSubjectByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applySubjectRequest(subject, req); err != nil {
+		return nil, err
+	}
+	subject.UpdatedAt = time.Now()
+
+	if err := s.catalogRepo.SaveSubject(subject); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to update subject")
+	}
+	return subject, nil
+}
+
+func (s *CatalogService) applySubjectRequest(subject *models.Subject, req models.SubjectRequest) error {
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	if !catalogCodePattern.MatchString(code) {
+		return errors.New("code must only contain lowercase letters, digits and dashes")
+	}
+
+	exists, err := s.catalogRepo.SubjectCodeExists(code, subject.ID)
+	if err != nil {
+		return errors.New("failed to check subject code")
+	}
+	if exists {
+		return fmt.Errorf("subject %s already exists", code)
+	}
+
+	subject.Code = code
+	subject.Name = strings.TrimSpace(req.Name)
+	subject.Description = req.Description
+	if req.IsActive != nil {
+		subject.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+func (s *CatalogService) GetLanguages() ([]models.InstructionLanguage, error) {
+	languages, err := s.catalogRepo.GetLanguages()
+	if err != nil {
+		return nil, errors.New("failed to get languages")
+	}
+	return languages, nil
+}
+
+func (s *CatalogService) CreateLanguage(req models.InstructionLanguageRequest) (*models.InstructionLanguage, error) {
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	if !catalogCodePattern.MatchString(code) {
+		return nil, errors.New("code must only contain lowercase letters, digits and dashes")
+	}
+
+	exists, err := s.catalogRepo.LanguageCodeExists(code)
+	if err != nil {
+		return nil, errors.New("failed to check language code")
+	}
+	if exists {
+		return nil, fmt.Errorf("language %s already exists", code)
+	}
+
+	language := &models.InstructionLanguage{Code: code, Name: strings.TrimSpace(req.Name)}
+	if err := s.catalogRepo.CreateLanguage(language); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to create language")
+	}
+	return language, nil
+}
+
+// SetTeacherSubjects replaces the subjects a teacher offers. Only active
+// subjects can be assigned.
+func (s *CatalogService) SetTeacherSubjects(teacherID uint, req models.TeacherSubjectsRequest) ([]models.TeacherSubject, error) {
+	if _, err := s.scheduleRepo.GetTeacherByID(teacherID); err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	ids := make([]uint, 0, len(req.Subjects))
+	seen := make(map[uint]bool)
+	for _, item := range req.Subjects {
+		if seen[item.SubjectID] {
+			return nil, fmt.Errorf("subject %d is listed more than once", item.SubjectID)
+		}
+		if item.PricePerHour != nil && *item.PricePerHour < 0 {
+			return nil, errors.New("price_per_hour must not be negative")
+		}
+		seen[item.SubjectID] = true
+		ids = append(ids, item.SubjectID)
+	}
+
+	if len(ids) > 0 {
+		subjects, err := s.catalogRepo.GetSubjectsByIDs(ids)
+		if err != nil {
+			return nil, errors.New("failed to get subjects")
+		}
+		active := make(map[uint]bool)
+		for _, subject := range subjects {
+			active[subject.ID] = subject.IsActive
+		}
+		for _, id := range ids {
+			if !active[id] {
+				return nil, fmt.Errorf("subject %d is not offered", id)
+			}
+		}
+	}
+
+	assignments := make([]models.TeacherSubject, 0, len(req.Subjects))
+	for _, item := range req.Subjects {
+		assignments = append(assignments, models.TeacherSubject{
+			TeacherID:    teacherID,
+			SubjectID:    item.SubjectID,
+			PricePerHour: item.PricePerHour,
+		})
+	}
+
+	if err := s.catalogRepo.ReplaceTeacherSubjects(teacherID, assignments); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to update teacher subjects")
+	}
+	return s.GetTeacherSubjects(teacherID)
+}
+
+// SetTeacherLanguages replaces the instruction languages of a teacher.
+func (s *CatalogService) SetTeacherLanguages(teacherID uint, req models.TeacherLanguagesRequest) ([]models.TeacherLanguage, error) {
+	if _, err := s.scheduleRepo.GetTeacherByID(teacherID); err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	seen := make(map[uint]bool)
+	for _, id := range req.LanguageIDs {
+		if seen[id] {
+			return nil, fmt.Errorf("language %d is listed more than once", id)
+		}
+		seen[id] = true
+	}
+
+	if len(req.LanguageIDs) > 0 {
+		languages, err := s.catalogRepo.GetLanguagesByIDs(req.LanguageIDs)
+		if err != nil {
+			return nil, errors.New("failed to get languages")
+		}
+		if len(languages) != len(req.LanguageIDs) {
+			return nil, errors.New("language not found")
+		}
+	}
+
+	assignments := make([]models.TeacherLanguage, 0, len(req.LanguageIDs))
+	for _, id := range req.LanguageIDs {
+		assignments = append(assignments, models.TeacherLanguage{TeacherID: teacherID, LanguageID: id})
+	}
+
+	if err := s.catalogRepo.ReplaceTeacherLanguages(teacherID, assignments); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to update teacher languages")
+	}
+
+	languages, err := s.catalogRepo.GetTeacherLanguages(teacherID)
+	if err != nil {
+		return nil, errors.New("failed to get teacher languages")
+	}
+	return languages, nil
+}
+
+func (s *CatalogService) GetTeacherSubjects(teacherID uint) ([]models.TeacherSubject, error) {
+	subjects, err := s.catalogRepo.GetTeacherSubjects(teacherID)
+	if err != nil {
+		return nil, errors.New("failed to get teacher subjects")
+	}
+	return subjects, nil
+}
+
+func (s *CatalogService) CanManageTeacher(userID uint, role string, teacherID uint) bool {
+	return canManageTeacher(s.scheduleRepo, userID, role, teacherID)
+}
@@ -4,18 +4,24 @@ import (
 	"fmt"
 	"teacher/internal/infrastructure/booking"
 	"teacher/internal/models"
+	"teacher/internal/pkg"
 	"teacher/internal/repository"
+	"time"
 )
 
 type DashboardService struct {
 	teacherRepo    *repository.Repository
+	scheduleRepo   *repository.Schedule
 	serviceBooking *booking.BookingService
+	rosterService  *RosterService
 }
 
-func NewDashboardService(teacherRepo *repository.Repository, serviceBooking *booking.BookingService) *DashboardService {
+func NewDashboardService(teacherRepo *repository.Repository, scheduleRepo *repository.Schedule, serviceBooking *booking.BookingService, rosterService *RosterService) *DashboardService {
 	return &DashboardService{
 		teacherRepo:    teacherRepo,
+		scheduleRepo:   scheduleRepo,
 		serviceBooking: serviceBooking,
+		rosterService:  rosterService,
 	}
 }
 
@@ -41,8 +47,17 @@ func (s *DashboardService) GetTeacherDashboard(teacherID uint) (*models.TeacherD
 	// Get completed lessons
 	completedLessons := s.getRealCompletedLessons(bookings)
 
-	// Get unique students
-	recentStudents := s.getRealStudents(bookings)
+	// Students with the most recent lessons, with contact details
+	recentStudents, err := s.getRecentStudents(teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enrolment of the next group classes
+	groupClasses, err := s.getGroupClasses(teacherID)
+	if err != nil {
+		return nil, err
+	}
 
 	// Build response
 	response := &models.TeacherDashboardResponse{
@@ -51,6 +66,7 @@ func (s *DashboardService) GetTeacherDashboard(teacherID uint) (*models.TeacherD
 		UpcomingBookings: upcomingBookings,
 		RecentStudents:   recentStudents,
 		CompletedLessons: completedLessons,
+		GroupClasses:     groupClasses,
 	}
 
 	return response, nil
@@ -141,37 +157,48 @@ func (s *DashboardService) getRealCompletedLessons(bookings []models.BookingInfo
 	return completed
 }
 
-func (s *DashboardService) getRealStudents(bookings []models.BookingInfo) []models.StudentInfo {
-	studentMap := make(map[uint]*models.StudentInfo)
-
-	for _, booking := range bookings {
-		if _, exists := studentMap[booking.StudentID]; !exists {
-			studentMap[booking.StudentID] = &models.StudentInfo{
-				ID:           booking.StudentID,
-				Name:         booking.StudentName,
-				Email:        "", // Would need to fetch from user service
-				TotalLessons: 0,
-				TotalSpent:   0,
-			}
-		}
-
-		student := studentMap[booking.StudentID]
-		student.TotalLessons++
-		if booking.Status == "completed" {
-			student.TotalSpent += booking.Price
-		}
+// getRecentStudents returns the five students with the most recent lessons
+// from the roster.
+func (s *DashboardService) getRecentStudents(teacherID uint) ([]models.StudentInfo, error) {
+	roster, _, err := s.rosterService.roster(teacherID, models.RosterSortRecent, pkg.Paginate{Page: 1, Limit: 5})
+	if err != nil {
+		return nil, err
 	}
 
-	var students []models.StudentInfo
-	for _, student := range studentMap {
-		students = append(students, *student)
+	students := make([]models.StudentInfo, 0, len(roster))
+	for _, student := range roster {
+		students = append(students, models.StudentInfo{
+			ID:           student.StudentID,
+			Name:         student.Name,
+			Email:        student.Email,
+			TotalLessons: student.TotalLessons,
+			TotalSpent:   student.TotalSpent,
+		})
 	}
+	return students, nil
+}
 
-	// Limit to 5 recent students
-	if len(students) > 5 {
-		return students[:5]
+// getGroupClasses returns the enrolment of the teacher's next group classes.
+func (s *DashboardService) getGroupClasses(teacherID uint) ([]models.GroupClass, error) {
+	schedules, err := s.scheduleRepo.GetUpcomingGroupClasses(teacherID, time.Now(), upcomingGroupClasses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group classes: %w", err)
+	}
+
+	classes := make([]models.GroupClass, 0, len(schedules))
+	for _, schedule := range schedules {
+		classes = append(classes, models.GroupClass{
+			ScheduleID: schedule.ID,
+			Date:       schedule.Date.Format("2006-01-02"),
+			StartTime:  schedule.StartTime,
+			EndTime:    schedule.EndTime,
+			Status:     schedule.Status,
+			Capacity:   schedule.Capacity,
+			SeatsTaken: schedule.SeatsTaken,
+			MinSeats:   schedule.MinSeats,
+		})
 	}
-	return students
+	return classes, nil
 }
 
 func (s *DashboardService) mapToTeacherResponse(teacher *models.Teacher) models.TeacherResponse {
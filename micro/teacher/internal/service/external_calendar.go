@@ -0,0 +1,317 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"teacher/internal/config"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+	"teacher/internal/repository"
+	"time"
+)
+
+const (
+	// calendarImportDays is how far ahead busy times are imported.
+	calendarImportDays = 90
+	// MaxCalendarFileSize limits uploaded and fetched calendars.
+	MaxCalendarFileSize = 2 << 20
+	// calendarFetchTimeout bounds fetching one subscribed calendar.
+	calendarFetchTimeout = 15 * time.Second
+
+	defaultCalendarImportInterval = 30 * time.Minute
+)
+
+type ExternalCalendarService struct {
+	externalRepo *repository.ExternalCalendar
+	scheduleRepo *repository.Schedule
+	client       *http.Client
+	calendar     config.Calendar
+}
+
+func NewExternalCalendarService(externalRepo *repository.ExternalCalendar, scheduleRepo *repository.Schedule, calendar config.Calendar) *ExternalCalendarService {
+	return &ExternalCalendarService{
+		externalRepo: externalRepo,
+		scheduleRepo: scheduleRepo,
+		client:       pkg.NewPublicHTTPClient(calendarFetchTimeout),
+		calendar:     calendar,
+	}
+}
+
+func (s *ExternalCalendarService) GetCalendars(teacherID uint) ([]models.ExternalCalendar, error) {
+	calendars, err := s.externalRepo.GetTeacherCalendars(teacherID)
+	if err != nil {
+		return nil, errors.New("failed to get calendars")
+	}
+	return calendars, nil
+}
+
+// AddCalendarURL registers an ICS subscription URL and syncs it right away.
+// A failed first sync is reported on the calendar, not as an error, so the
+// teacher can see what went wrong and retry.
+func (s *ExternalCalendarService) AddCalendarURL(teacherID uint, req models.ExternalCalendarRequest) (*models.ExternalCalendar, error) {
+	if _, err := s.scheduleRepo.GetTeacherByID(teacherID); err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	calendarURL, err := normalizeCalendarURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &models.ExternalCalendar{
+		TeacherID: teacherID,
+		Name:      strings.TrimSpace(req.Name),
+		Source:    models.ExternalCalendarSourceURL,
+		URL:       calendarURL,
+	}
+	if err := s.externalRepo.CreateCalendar(calendar); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to save calendar")
+	}
+
+	s.syncCalendar(calendar)
+	return s.refresh(calendar), nil
+}
+
+// UploadCalendar stores an uploaded .ics file and imports its busy times.
+func (s *ExternalCalendarService) UploadCalendar(teacherID uint, name string, content []byte) (*models.ExternalCalendar, error) {
+	if _, err := s.scheduleRepo.GetTeacherByID(teacherID); err != nil {
+		return nil, errors.New("teacher not found")
+	}
+	if len(content) > MaxCalendarFileSize {
+		return nil, fmt.Errorf("calendar file must not be larger than %d MB", MaxCalendarFileSize>>20)
+	}
+	from, to := s.importWindow()
+	if _, err := pkg.ParseICSBusyTimes(content, from, to, s.location()); err != nil {
+		return nil, fmt.Errorf("invalid calendar file: %v", err)
+	}
+
+	calendar := &models.ExternalCalendar{
+		TeacherID: teacherID,
+		Name:      strings.TrimSpace(name),
+		Source:    models.ExternalCalendarSourceUpload,
+		Content:   string(content),
+	}
+	if err := s.externalRepo.CreateCalendar(calendar); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to save calendar")
+	}
+
+	s.syncCalendar(calendar)
+	return s.refresh(calendar), nil
+}
+
+// SyncCalendar re-imports a single calendar of the teacher on demand.
+func (s *ExternalCalendarService) SyncCalendar(teacherID, id uint) (*models.ExternalCalendar, error) {
+	calendar, err := s.teacherCalendar(teacherID, id)
+	if err != nil {
+		return nil, err
+	}
+	s.syncCalendar(calendar)
+	return s.refresh(calendar), nil
+}
+
+// DeleteCalendar removes the calendar and unblocks the slots it blocked.
+func (s *ExternalCalendarService) DeleteCalendar(teacherID, id uint) error {
+	calendar, err := s.teacherCalendar(teacherID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.externalRepo.DeleteCalendar(calendar.ID); err != nil {
+		log.Println(err)
+		return errors.New("failed to delete calendar")
+	}
+
+	from, _ := s.importWindow()
+	if err := s.scheduleRepo.RefreshBlockedSchedules(teacherID, from); err != nil {
+		log.Println(err)
+		return errors.New("failed to update blocked schedules")
+	}
+	return nil
+}
+
+// SyncAll re-imports every external calendar.
+func (s *ExternalCalendarService) SyncAll() {
+	calendars, err := s.externalRepo.GetAllCalendars()
+	if err != nil {
+		log.Printf("calendar import: failed to get calendars: %v", err)
+		return
+	}
+	for i := range calendars {
+		s.syncCalendar(&calendars[i])
+	}
+}
+
+// RunImporter syncs all external calendars every import interval. It blocks
+// and is meant to run in its own goroutine.
+func (s *ExternalCalendarService) RunImporter() {
+	interval := s.calendar.ImportInterval
+	if interval <= 0 {
+		interval = defaultCalendarImportInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.SyncAll()
+		<-ticker.C
+	}
+}
+
+// syncCalendar replaces the busy times of the calendar with those in the
+// import window and re-evaluates the teacher's blocked slots. Errors are
+// stored on the calendar.
+func (s *ExternalCalendarService) syncCalendar(calendar *models.ExternalCalendar) {
+	now := time.Now()
+	from, to := s.importWindow()
+
+	busy, err := s.readBusyTimes(calendar, from, to)
+	if err != nil {
+		log.Printf("calendar import: calendar %d: %v", calendar.ID, err)
+		if err := s.externalRepo.SetSyncError(calendar.ID, now, err.Error()); err != nil {
+			log.Println(err)
+		}
+		return
+	}
+
+	calendar.BusyCount = len(busy)
+	calendar.LastSyncedAt = &now
+	calendar.LastError = ""
+	if err := s.externalRepo.ReplaceBusyTimes(calendar, busy); err != nil {
+		log.Printf("calendar import: calendar %d: %v", calendar.ID, err)
+		return
+	}
+	if err := s.scheduleRepo.RefreshBlockedSchedules(calendar.TeacherID, from); err != nil {
+		log.Printf("calendar import: teacher %d: %v", calendar.TeacherID, err)
+	}
+}
+
+func (s *ExternalCalendarService) readBusyTimes(calendar *models.ExternalCalendar, from, to time.Time) ([]models.BusyTime, error) {
+	data := []byte(calendar.Content)
+	if calendar.Source == models.ExternalCalendarSourceURL {
+		fetched, err := s.fetchCalendar(calendar.URL)
+		if err != nil {
+			return nil, err
+		}
+		data = fetched
+	}
+
+	intervals, err := pkg.ParseICSBusyTimes(data, from, to, s.location())
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar: %v", err)
+	}
+
+	busy := make([]models.BusyTime, 0, len(intervals))
+	for _, interval := range intervals {
+		busy = append(busy, models.BusyTime{
+			CalendarID: calendar.ID,
+			TeacherID:  calendar.TeacherID,
+			StartAt:    interval.Start,
+			EndAt:      interval.End,
+		})
+	}
+	return busy, nil
+}
+
+// fetchCalendar downloads a subscribed calendar. The URL comes from the
+// teacher, so the client refuses internal addresses.
+func (s *ExternalCalendarService) fetchCalendar(calendarURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, calendarURL, nil)
+	if err != nil {
+		return nil, errors.New("invalid calendar url")
+	}
+	req.Header.Set("Accept", "text/calendar")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if errors.Is(err, pkg.ErrNonPublicAddress) {
+			return nil, errors.New("calendar url must point to a public address")
+		}
+		return nil, fmt.Errorf("failed to fetch calendar: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch calendar: status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxCalendarFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch calendar: %v", err)
+	}
+	if len(body) > MaxCalendarFileSize {
+		return nil, errors.New("calendar is too large")
+	}
+	return body, nil
+}
+
+// importWindow returns the range busy times are imported for, starting at
+// the beginning of today.
+func (s *ExternalCalendarService) importWindow() (time.Time, time.Time) {
+	loc := s.location()
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	return from, from.AddDate(0, 0, calendarImportDays)
+}
+
+func (s *ExternalCalendarService) location() *time.Location {
+	return calendarLocation(s.calendar)
+}
+
+func (s *ExternalCalendarService) teacherCalendar(teacherID, id uint) (*models.ExternalCalendar, error) {
+	calendar, err := s.externalRepo.GetCalendarByID(id)
+	if err != nil {
+		if err.Error() == "calendar not found" {
+			return nil, err
+		}
+		return nil, errors.New("failed to get calendar")
+	}
+	if calendar.TeacherID != teacherID {
+		return nil, errors.New("calendar not found")
+	}
+	return calendar, nil
+}
+
+// refresh reloads the calendar to return the stored sync result.
+func (s *ExternalCalendarService) refresh(calendar *models.ExternalCalendar) *models.ExternalCalendar {
+	reloaded, err := s.externalRepo.GetCalendarByID(calendar.ID)
+	if err != nil {
+		return calendar
+	}
+	return reloaded
+}
+
+// normalizeCalendarURL accepts http(s) and webcal subscription URLs. Hosts
+// given as an internal IP address or localhost are refused here already;
+// names resolving to one are refused by the fetching client.
+func normalizeCalendarURL(raw string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" {
+		return "", errors.New("invalid calendar url")
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+	case "webcal", "webcals":
+		parsed.Scheme = "https"
+	default:
+		return "", errors.New("calendar url must use http, https or webcal")
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return "", errors.New("calendar url must point to a public address")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !pkg.IsPublicAddr(addr) {
+		return "", errors.New("calendar url must point to a public address")
+	}
+	return parsed.String(), nil
+}
+
+func (s *ExternalCalendarService) CanManageTeacher(userID uint, role string, teacherID uint) bool {
+	return canManageTeacher(s.scheduleRepo, userID, role, teacherID)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"teacher/internal/models"
+	"time"
+)
+
+const (
+	// maxGroupCapacity bounds the seats of a group class.
+	maxGroupCapacity = 20
+	// defaultCutoffHours applies when a minimum enrolment is set without a
+	// cutoff.
+	defaultCutoffHours = 24
+
+	enrolmentCheckInterval = 5 * time.Minute
+	upcomingGroupClasses   = 10
+)
+
+// validateGroupClass checks the group class settings of a schedule and fills
+// in their defaults. seatsTaken is the number of seats already sold.
+func validateGroupClass(schedule *models.Schedule, seatsTaken int) error {
+	if schedule.Capacity == 0 {
+		schedule.Capacity = 1
+	}
+	if schedule.Capacity < 1 || schedule.Capacity > maxGroupCapacity {
+		return fmt.Errorf("capacity must be between 1 and %d", maxGroupCapacity)
+	}
+	if schedule.Capacity < seatsTaken {
+		return errors.New("capacity must not be below the seats already taken")
+	}
+
+	if !schedule.IsGroup() {
+		if schedule.MinSeats != 0 || schedule.CutoffHours != 0 || schedule.SeatPrice != nil {
+			return errors.New("min_seats, cutoff_hours and seat_price are only allowed for group classes")
+		}
+		return nil
+	}
+
+	if schedule.MinSeats < 0 || schedule.MinSeats > schedule.Capacity {
+		return errors.New("min_seats must be between 0 and the capacity")
+	}
+	if schedule.CutoffHours < 0 {
+		return errors.New("cutoff_hours must not be negative")
+	}
+	if schedule.MinSeats > 0 && schedule.CutoffHours == 0 {
+		schedule.CutoffHours = defaultCutoffHours
+	}
+	if schedule.SeatPrice != nil && *schedule.SeatPrice < 0 {
+		return errors.New("seat_price must not be negative")
+	}
+	return nil
+}
+
+// updateGroupSeats books or frees a seat of a group class. Any other status
+// change applies to the whole class.
+func (s *ScheduleService) updateGroupSeats(schedule models.Schedule, status string) error {
+	switch status {
+	case "booked":
+		ok, err := s.scheduleRepo.ReserveSeat(schedule.ID)
+		if err != nil {
+			log.Println(err)
+			return errors.New("failed to update schedule")
+		}
+		if !ok {
+			return errors.New("schedule is not available")
+		}
+	case "available":
+		if err := s.scheduleRepo.ReleaseSeat(schedule.ID); err != nil {
+			log.Println(err)
+			return errors.New("failed to update schedule")
+		}
+	default:
+		if err := s.scheduleRepo.UpdateScheduleStatus(schedule.ID, status); err != nil {
+			return errors.New("failed to update schedule")
+		}
+	}
+	return nil
+}
+
+// CancelUnderfilledClasses cancels group classes that did not reach their
+// minimum enrolment by the cutoff. Their bookings are then cancelled and
+// refunded through the booking service; the ones that fail are retried by
+// RetryBookingCancellations.
+func (s *ScheduleService) CancelUnderfilledClasses() {
+	classes, err := s.scheduleRepo.GetUnderfilledClasses(time.Now())
+	if err != nil {
+		log.Printf("enrolment cutoff: failed to get classes: %v", err)
+		return
+	}
+
+	for _, class := range classes {
+		// Seats may have been taken since the class was listed, so the
+		// minimum is checked again when cancelling.
+		cancelled, booked, err := s.scheduleRepo.CancelUnderfilledClass(class.ID)
+		if err != nil {
+			log.Printf("enrolment cutoff: failed to cancel schedule %d: %v", class.ID, err)
+			continue
+		}
+		if !cancelled {
+			continue
+		}
+		seats := 0
+		if booked != nil {
+			seats = booked.SeatsTaken
+		}
+		log.Printf("enrolment cutoff: cancelled schedule %d with %d/%d seats", class.ID, seats, class.MinSeats)
+		if booked == nil {
+			continue
+		}
+
+		reason := fmt.Sprintf("Group class cancelled: %d of the minimum %d seats were taken", seats, class.MinSeats)
+		failed, err := s.cancelBookings([]uint{class.ID}, reason)
+		if err != nil {
+			continue
+		}
+		for _, failure := range failed {
+			log.Printf("enrolment cutoff: booking %d of schedule %d: %s", failure.BookingID, class.ID, failure.Error)
+		}
+	}
+}
+
+// RunEnrolmentCutoff checks group classes for their minimum enrolment every
+// few minutes. It blocks and is meant to run in its own goroutine.
+func (s *ScheduleService) RunEnrolmentCutoff() {
+	ticker := time.NewTicker(enrolmentCheckInterval)
+	defer ticker.Stop()
+	for {
+		s.CancelUnderfilledClasses()
+		<-ticker.C
+	}
+}
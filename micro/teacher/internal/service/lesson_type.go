@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+	"teacher/internal/repository"
+	"time"
+)
+
+type LessonTypeService struct {
+	lessonTypeRepo *repository.LessonType
+	scheduleRepo   *repository.Schedule
+}
+
+func NewLessonTypeService(lessonTypeRepo *repository.LessonType, scheduleRepo *repository.Schedule) *LessonTypeService {
+	return &LessonTypeService{
+		lessonTypeRepo: lessonTypeRepo,
+		scheduleRepo:   scheduleRepo,
+	}
+}
+
+func (s *LessonTypeService) GetLessonTypes(teacherID uint, activeOnly bool) ([]models.LessonType, error) {
+	lessonTypes, err := s.lessonTypeRepo.GetLessonTypesByTeacher(teacherID, activeOnly)
+	if err != nil {
+		return nil, errors.New("failed to get lesson types")
+	}
+	return lessonTypes, nil
+}
+
+func (s *LessonTypeService) GetLessonType(id uint) (*models.LessonType, error) {
+	return s.lessonTypeRepo.GetLessonTypeByID(id)
+}
+
+func (s *LessonTypeService) CreateLessonType(teacherID uint, req models.LessonTypeRequest) (*models.LessonType, error) {
+	if _, err := s.scheduleRepo.GetTeacherByID(teacherID); err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	if err := validateLessonTypeRequest(req); err != nil {
+		return nil, err
+	}
+
+	lessonType := &models.LessonType{
+		TeacherID:       teacherID,
+		Name:            req.Name,
+		DurationMinutes: req.DurationMinutes,
+		Price:           req.Price,
+		IsTrial:         req.IsTrial,
+		IsActive:        req.IsActive == nil || *req.IsActive,
+	}
+
+	if err := s.lessonTypeRepo.CreateLessonType(lessonType); err != nil {
+		return nil, errors.New("failed to create lesson type")
+	}
+	return lessonType, nil
+}
+
+// UpdateLessonType changes a lesson type in place. Bookings keep the price
+// they were charged, so only future bookings see the new price. The
+// duration is fixed once slots use the lesson type, as their length and
+// price were set for it; a new lesson type has to be created instead.
+func (s *LessonTypeService) UpdateLessonType(id uint, req models.LessonTypeRequest) (*models.LessonType, error) {
+	lessonType, err := s.lessonTypeRepo.GetLessonTypeByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateLessonTypeRequest(req); err != nil {
+		return nil, err
+	}
+
+	if req.DurationMinutes != lessonType.DurationMinutes {
+		used, err := s.lessonTypeRepo.CountSchedules(id)
+		if err != nil {
+			return nil, errors.New("failed to check schedules of lesson type")
+		}
+		if used > 0 {
+			return nil, errors.New("duration cannot change while schedules use this lesson type, create a new lesson type instead")
+		}
+	}
+
+	lessonType.Name = req.Name
+	lessonType.DurationMinutes = req.DurationMinutes
+	lessonType.Price = req.Price
+	lessonType.IsTrial = req.IsTrial
+	if req.IsActive != nil {
+		lessonType.IsActive = *req.IsActive
+	}
+	lessonType.UpdatedAt = time.Now()
+
+	if err := s.lessonTypeRepo.UpdateLessonType(lessonType); err != nil {
+		return nil, errors.New("failed to update lesson type")
+	}
+	return lessonType, nil
+}
+
+// DeactivateLessonType hides a lesson type from new schedules. It is not
+// deleted because existing schedules and bookings still reference it.
+func (s *LessonTypeService) DeactivateLessonType(id uint) error {
+	lessonType, err := s.lessonTypeRepo.GetLessonTypeByID(id)
+	if err != nil {
+		return err
+	}
+
+	lessonType.IsActive = false
+	lessonType.UpdatedAt = time.Now()
+	if err := s.lessonTypeRepo.UpdateLessonType(lessonType); err != nil {
+		return errors.New("failed to delete lesson type")
+	}
+	return nil
+}
+
+func (s *LessonTypeService) CanManageTeacher(userID uint, role string, teacherID uint) bool {
+	return canManageTeacher(s.scheduleRepo, userID, role, teacherID)
+}
+
+func validateLessonTypeRequest(req models.LessonTypeRequest) error {
+	if !slices.Contains(models.AllowedLessonDurations, req.DurationMinutes) {
+		return fmt.Errorf("duration_minutes must be one of %v", models.AllowedLessonDurations)
+	}
+	if req.Price < 0 {
+		return errors.New("price must not be negative")
+	}
+	return nil
+}
+
+// validateScheduleLessonType checks that the lesson type belongs to the
+// teacher, is still offered and matches the length of the slot.
+func validateScheduleLessonType(lessonType *models.LessonType, teacherID uint, startTime, endTime string) error {
+	if lessonType.TeacherID != teacherID {
+		return errors.New("lesson type does not belong to this teacher")
+	}
+	if !lessonType.IsActive {
+		return errors.New("lesson type is no longer offered")
+	}
+
+	duration, err := pkg.CalculateDuration(startTime, endTime)
+	if err != nil {
+		return err
+	}
+	if int(duration*60) != lessonType.DurationMinutes {
+		return fmt.Errorf("slot length must be %d minutes for this lesson type", lessonType.DurationMinutes)
+	}
+	return nil
+}
+
+// calculateLessonPrice is the single place a slot's price is derived. Group
+// classes with a seat price charge it per seat. Slots with a lesson type use
+// its explicit price; older slots without one fall back to an hourly rate
+// times the slot length. The rate is subjectRate, the teacher's rate for
+// the slot's subject, when there is one, and the teacher's general rate
+// otherwise.
+func calculateLessonPrice(schedule models.Schedule, subjectRate *float64) (float64, error) {
+	if schedule.IsGroup() && schedule.SeatPrice != nil {
+		return *schedule.SeatPrice, nil
+	}
+	if schedule.LessonType != nil {
+		return schedule.LessonType.Price, nil
+	}
+
+	if schedule.Teacher == nil {
+		return 0, errors.New("teacher not found")
+	}
+
+	duration, err := pkg.CalculateDuration(schedule.StartTime, schedule.EndTime)
+	if err != nil {
+		return 0, err
+	}
+	if subjectRate != nil {
+		return duration * *subjectRate, nil
+	}
+	return duration * schedule.Teacher.PricePerHour, nil
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"teacher/internal/infrastructure/supabase"
+	"teacher/internal/infrastructure/user"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+	"teacher/internal/repository"
+	"time"
+)
+
+type OnboardingService struct {
+	onboardingRepo *repository.Onboarding
+	uploadClient   *supabase.Client
+	serviceUser    *user.UserService
+}
+
+func NewOnboardingService(onboardingRepo *repository.Onboarding, uploadClient *supabase.Client, serviceUser *user.UserService) *OnboardingService {
+	return &OnboardingService{
+		onboardingRepo: onboardingRepo,
+		uploadClient:   uploadClient,
+		serviceUser:    serviceUser,
+	}
+}
+
+// SubmitApplication creates the caller's teacher profile in pending state,
+// or updates it while it is still under review. A rejected application goes
+// back to pending when it is resubmitted.
+func (s *OnboardingService) SubmitApplication(userID uint, role string, req models.TeacherApplicationRequest) (*models.Teacher, error) {
+	if role != "teacher" {
+		return nil, errors.New("only users with the teacher role can apply")
+	}
+
+	availableStart, err := pkg.ParseTime(req.AvailableStart)
+	if err != nil {
+		return nil, err
+	}
+	availableEnd, err := pkg.ParseTime(req.AvailableEnd)
+	if err != nil {
+		return nil, err
+	}
+	if !availableEnd.After(availableStart) {
+		return nil, errors.New("available end time must be after start time")
+	}
+	if !strings.HasPrefix(req.DemoVideoURL, "https://") && !strings.HasPrefix(req.DemoVideoURL, "http://") {
+		return nil, errors.New("demo video url must be an http(s) link")
+	}
+
+	teacher, err := s.onboardingRepo.GetTeacherByUserID(userID)
+	if err != nil && err.Error() != "application not found" {
+		log.Println(err)
+		return nil, errors.New("failed to get application")
+	}
+	if teacher == nil {
+		teacher = &models.Teacher{UserID: userID, CreatedAt: time.Now()}
+	}
+	if teacher.Status == models.TeacherStatusApproved {
+		return nil, errors.New("teacher profile is already approved")
+	}
+
+	var history *models.TeacherStatusHistory
+	if teacher.Status != models.TeacherStatusPending {
+		history = &models.TeacherStatusHistory{
+			FromStatus: teacher.Status,
+			ToStatus:   models.TeacherStatusPending,
+			ChangedBy:  userID,
+		}
+	}
+
+	teacher.Name = req.Name
+	teacher.Bio = req.Bio
+	teacher.LanguageLevel = req.LanguageLevel
+	teacher.PricePerHour = req.PricePerHour
+	teacher.AvailableStartTime = pkg.NormalizeTime(availableStart)
+	teacher.AvailableEndTime = pkg.NormalizeTime(availableEnd)
+	teacher.ProfileImage = req.ProfileImage
+	teacher.DemoVideoURL = req.DemoVideoURL
+	teacher.Status = models.TeacherStatusPending
+	teacher.RejectionReason = ""
+	teacher.UpdatedAt = time.Now()
+
+	if err := s.onboardingRepo.SaveApplication(teacher, history); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to save application")
+	}
+
+	syncUserProfile(s.serviceUser, teacher)
+	return teacher, nil
+}
+
+// UploadDocument stores a qualification document of the caller's
+// application in the private bucket. filePath is a local copy of the
+// uploaded file.
+func (s *OnboardingService) UploadDocument(userID uint, name, filePath, fileName string) (*models.TeacherDocument, error) {
+	teacher, err := s.onboardingRepo.GetTeacherByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if teacher.Status == models.TeacherStatusApproved {
+		return nil, errors.New("teacher profile is already approved")
+	}
+
+	objectName := fmt.Sprintf("teacher-documents/%d/%d_%s", teacher.ID, time.Now().Unix(), fileName)
+	if err := s.uploadClient.UploadPrivate(objectName, filePath); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to upload document")
+	}
+
+	if name == "" {
+		name = fileName
+	}
+	document := &models.TeacherDocument{
+		TeacherID:  teacher.ID,
+		Name:       name,
+		ObjectName: objectName,
+	}
+	if err := s.onboardingRepo.CreateDocument(document); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to save document")
+	}
+	documents := []models.TeacherDocument{*document}
+	s.signDocuments(documents)
+	return &documents[0], nil
+}
+
+func (s *OnboardingService) GetMyApplication(userID uint) (*models.TeacherApplicationResponse, error) {
+	teacher, err := s.onboardingRepo.GetTeacherByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildApplication(teacher)
+}
+
+func (s *OnboardingService) GetApplication(teacherID uint) (*models.TeacherApplicationResponse, error) {
+	teacher, err := s.onboardingRepo.GetTeacherByID(teacherID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildApplication(teacher)
+}
+
+func (s *OnboardingService) GetApplications(status string, paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+	switch status {
+	case "", models.TeacherStatusPending, models.TeacherStatusApproved, models.TeacherStatusRejected, models.TeacherStatusArchived:
+	default:
+		return pkg.ResponsePaginate{}, errors.New("invalid status")
+	}
+
+	response, err := s.onboardingRepo.GetApplications(status, paginate)
+	if err != nil {
+		return pkg.ResponsePaginate{}, errors.New("failed to get applications")
+	}
+	return response, nil
+}
+
+// ApproveApplication publishes the teacher profile.
+func (s *OnboardingService) ApproveApplication(adminID, teacherID uint) (*models.Teacher, error) {
+	return s.changeStatus(adminID, teacherID, models.TeacherStatusApproved, "")
+}
+
+// RejectApplication hides the teacher profile. Approved teachers can be
+// rejected too, which takes them out of the public listing.
+func (s *OnboardingService) RejectApplication(adminID, teacherID uint, reason string) (*models.Teacher, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	return s.changeStatus(adminID, teacherID, models.TeacherStatusRejected, reason)
+}
+
+func (s *OnboardingService) changeStatus(adminID, teacherID uint, status, reason string) (*models.Teacher, error) {
+	teacher, err := s.onboardingRepo.GetTeacherByID(teacherID)
+	if err != nil {
+		return nil, err
+	}
+	if teacher.Status == status {
+		return nil, fmt.Errorf("teacher is already %s", status)
+	}
+	if teacher.Status == models.TeacherStatusArchived {
+		return nil, errors.New("archived teacher profiles cannot be reviewed")
+	}
+
+	history := &models.TeacherStatusHistory{
+		FromStatus: teacher.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		ChangedBy:  adminID,
+	}
+
+	teacher.Status = status
+	teacher.RejectionReason = reason
+	teacher.UpdatedAt = time.Now()
+
+	if err := s.onboardingRepo.SaveApplication(teacher, history); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to update application")
+	}
+	return teacher, nil
+}
+
+func (s *OnboardingService) buildApplication(teacher *models.Teacher) (*models.TeacherApplicationResponse, error) {
+	documents, err := s.onboardingRepo.GetDocuments(teacher.ID)
+	if err != nil {
+		return nil, errors.New("failed to get documents")
+	}
+	s.signDocuments(documents)
+	history, err := s.onboardingRepo.GetStatusHistory(teacher.ID)
+	if err != nil {
+		return nil, errors.New("failed to get status history")
+	}
+
+	return &models.TeacherApplicationResponse{
+		Teacher:   *teacher,
+		Documents: documents,
+		History:   history,
+	}, nil
+}
+
+// signDocuments fills in the signed URLs of the documents. Only the
+// applicant and admins get to see an application, so only they get the
+// URLs. A document that cannot be signed is listed without one.
+func (s *OnboardingService) signDocuments(documents []models.TeacherDocument) {
+	for i := range documents {
+		url, err := s.uploadClient.SignedURL(documents[i].ObjectName)
+		if err != nil {
+			log.Printf("failed to sign document %d: %v", documents[i].ID, err)
+			continue
+		}
+		documents[i].FileURL = url
+	}
+}
+
+// ProvisionTeacher makes sure the user has a teacher profile. New profiles
+// start as pending applications; archived ones get back the status they had
+// before they were archived. Calling it again only refreshes name and avatar.
+func (s *OnboardingService) ProvisionTeacher(req models.TeacherProvisionRequest) (*models.Teacher, error) {
+	teacher, err := s.onboardingRepo.GetTeacherByUserID(req.UserID)
+	if err != nil && err.Error() != "application not found" {
+		log.Println(err)
+		return nil, errors.New("failed to get teacher")
+	}
+
+	var history *models.TeacherStatusHistory
+	switch {
+	case teacher == nil:
+		teacher = &models.Teacher{
+			UserID:    req.UserID,
+			Status:    models.TeacherStatusPending,
+			CreatedAt: time.Now(),
+		}
+		history = &models.TeacherStatusHistory{
+			ToStatus:  models.TeacherStatusPending,
+			Reason:    "profile provisioned for teacher account",
+			ChangedBy: req.ChangedBy,
+		}
+	case teacher.Status == models.TeacherStatusArchived:
+		previous, err := s.onboardingRepo.GetStatusBeforeArchive(teacher.ID)
+		if err != nil {
+			log.Println(err)
+			return nil, errors.New("failed to get status history")
+		}
+		if previous == "" || previous == models.TeacherStatusArchived {
+			previous = models.TeacherStatusPending
+		}
+		history = &models.TeacherStatusHistory{
+			FromStatus: teacher.Status,
+			ToStatus:   previous,
+			Reason:     "teacher account restored",
+			ChangedBy:  req.ChangedBy,
+		}
+		teacher.Status = previous
+	}
+
+	applyUserProfile(teacher, req)
+	teacher.UpdatedAt = time.Now()
+
+	if err := s.onboardingRepo.SaveApplication(teacher, history); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to provision teacher")
+	}
+	return teacher, nil
+}
+
+// ArchiveTeacher hides the profile of a user that is no longer a teacher.
+// Users without a profile are ignored.
+func (s *OnboardingService) ArchiveTeacher(userID, changedBy uint, reason string) error {
+	teacher, err := s.onboardingRepo.GetTeacherByUserID(userID)
+	if err != nil {
+		if err.Error() == "application not found" {
+			return nil
+		}
+		log.Println(err)
+		return errors.New("failed to get teacher")
+	}
+	if teacher.Status == models.TeacherStatusArchived {
+		return nil
+	}
+
+	history := &models.TeacherStatusHistory{
+		FromStatus: teacher.Status,
+		ToStatus:   models.TeacherStatusArchived,
+		Reason:     reason,
+		ChangedBy:  changedBy,
+	}
+	teacher.Status = models.TeacherStatusArchived
+	teacher.UpdatedAt = time.Now()
+
+	if err := s.onboardingRepo.ArchiveTeacher(teacher, history); err != nil {
+		log.Println(err)
+		return errors.New("failed to archive teacher")
+	}
+	return nil
+}
+
+// SyncFromUser copies the user's name and avatar onto the linked profile.
+func (s *OnboardingService) SyncFromUser(req models.TeacherProvisionRequest) (*models.Teacher, error) {
+	teacher, err := s.onboardingRepo.GetTeacherByUserID(req.UserID)
+	if err != nil {
+		if err.Error() == "application not found" {
+			return nil, errors.New("teacher not found")
+		}
+		log.Println(err)
+		return nil, errors.New("failed to get teacher")
+	}
+
+	applyUserProfile(teacher, req)
+	teacher.UpdatedAt = time.Now()
+
+	if err := s.onboardingRepo.SaveApplication(teacher, nil); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to update teacher")
+	}
+	return teacher, nil
+}
+
+func applyUserProfile(teacher *models.Teacher, req models.TeacherProvisionRequest) {
+	if req.Name != "" {
+		teacher.Name = req.Name
+	}
+	if req.ProfileImage != "" {
+		teacher.ProfileImage = req.ProfileImage
+	}
+}
+
+// syncUserProfile pushes the teacher's name and avatar to the linked user
+// account. It runs in the background; a failure only leaves the user record
+// stale until the next change.
+func syncUserProfile(serviceUser *user.UserService, teacher *models.Teacher) {
+	if serviceUser == nil || teacher.UserID == 0 {
+		return
+	}
+	userID, name, profileImage := teacher.UserID, teacher.Name, teacher.ProfileImage
+	go func() {
+		if err := serviceUser.SyncProfile(userID, name, profileImage); err != nil {
+			log.Printf("failed to sync profile of user %d: %v", userID, err)
+		}
+	}()
+}
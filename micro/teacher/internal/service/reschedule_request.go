@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"teacher/internal/infrastructure/booking"
+	"teacher/internal/models"
+	"teacher/internal/repository"
+)
+
+// RescheduleRequestService lets teachers ask students to move a booked
+// lesson. The requests are kept and answered in the booking service.
+type RescheduleRequestService struct {
+	scheduleRepo   *repository.Schedule
+	serviceBooking *booking.BookingService
+}
+
+func NewRescheduleRequestService(scheduleRepo *repository.Schedule, serviceBooking *booking.BookingService) *RescheduleRequestService {
+	return &RescheduleRequestService{
+		scheduleRepo:   scheduleRepo,
+		serviceBooking: serviceBooking,
+	}
+}
+
+// ProposeReschedule sends the student of a booking the teacher's
+// alternative slots.
+func (s *RescheduleRequestService) ProposeReschedule(teacherID uint, proposal models.RescheduleProposal) (*models.RescheduleRequest, error) {
+	teacher, err := s.scheduleRepo.GetTeacherByID(teacherID)
+	if err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	request, err := s.serviceBooking.ProposeReschedule(teacher.ID, teacher.UserID, proposal)
+	if err != nil {
+		return nil, bookingRequestError(err, "failed to send reschedule request")
+	}
+	return request, nil
+}
+
+// GetRescheduleRequests lists the teacher's reschedule requests.
+func (s *RescheduleRequestService) GetRescheduleRequests(teacherID uint, status string) ([]models.RescheduleRequest, error) {
+	requests, err := s.serviceBooking.GetRescheduleRequests(teacherID, status)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to get reschedule requests")
+	}
+	if requests == nil {
+		requests = []models.RescheduleRequest{}
+	}
+	return requests, nil
+}
+
+// WithdrawRescheduleRequest takes back a request the student has not
+// answered yet.
+func (s *RescheduleRequestService) WithdrawRescheduleRequest(teacherID, id uint) (*models.RescheduleRequest, error) {
+	request, err := s.serviceBooking.WithdrawRescheduleRequest(teacherID, id)
+	if err != nil {
+		return nil, bookingRequestError(err, "failed to withdraw reschedule request")
+	}
+	return request, nil
+}
+
+func (s *RescheduleRequestService) CanManageTeacher(userID uint, role string, teacherID uint) bool {
+	return canManageTeacher(s.scheduleRepo, userID, role, teacherID)
+}
+
+// bookingRequestError passes errors the booking service reported for the
+// request on and replaces failures to reach it with fallback.
+func bookingRequestError(err error, fallback string) error {
+	var requestErr *booking.RequestError
+	if errors.As(err, &requestErr) {
+		return requestErr
+	}
+	log.Println(err)
+	return errors.New(fallback)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"teacher/internal/infrastructure/booking"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+	"teacher/internal/repository"
+	"time"
+)
+
+const recentReviewsLimit = 5
+
+type ReviewService struct {
+	reviewRepo     *repository.Review
+	scheduleRepo   *repository.Schedule
+	serviceBooking *booking.BookingService
+}
+
+func NewReviewService(reviewRepo *repository.Review, scheduleRepo *repository.Schedule, serviceBooking *booking.BookingService) *ReviewService {
+	return &ReviewService{
+		reviewRepo:     reviewRepo,
+		scheduleRepo:   scheduleRepo,
+		serviceBooking: serviceBooking,
+	}
+}
+
+// CreateReview lets the student of a completed booking rate the lesson. The
+// teacher is resolved from the booked schedule rather than taken from the
+// request.
+func (s *ReviewService) CreateReview(userID uint, req models.ReviewRequest) (*models.Review, error) {
+	if req.Rating < 1 || req.Rating > 5 {
+		return nil, errors.New("rating must be between 1 and 5")
+	}
+
+	bookingDetail, err := s.serviceBooking.GetBooking(req.BookingID)
+	if err != nil {
+		if err.Error() == "booking not found" {
+			return nil, err
+		}
+		log.Println(err)
+		return nil, errors.New("failed to get booking")
+	}
+	if bookingDetail.UserID != userID {
+		return nil, errors.New("booking not found")
+	}
+	if bookingDetail.Status != "completed" {
+		return nil, errors.New("only completed lessons can be reviewed")
+	}
+
+	exists, err := s.reviewRepo.ExistsForBooking(req.BookingID)
+	if err != nil {
+		return nil, errors.New("failed to check review")
+	}
+	if exists {
+		return nil, errors.New("booking has already been reviewed")
+	}
+
+	// The slot or the teacher may have been deleted since the lesson took
+	// place, so look the schedule up including deleted rows.
+	schedules, err := s.scheduleRepo.GetBatchScheduleDetail([]uint{bookingDetail.ScheduleID})
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to get schedule")
+	}
+	if len(schedules) == 0 {
+		return nil, errors.New("schedule not found")
+	}
+	schedule := schedules[0]
+
+	review := &models.Review{
+		BookingID: req.BookingID,
+		TeacherID: schedule.TeacherID,
+		UserID:    userID,
+		Rating:    req.Rating,
+		Comment:   strings.TrimSpace(req.Comment),
+	}
+
+	if err := s.reviewRepo.CreateReview(review); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to create review")
+	}
+	return review, nil
+}
+
+// ReplyToReview stores the teacher's answer. A review can only be answered
+// once.
+func (s *ReviewService) ReplyToReview(userID uint, role string, reviewID uint, reply string) (*models.Review, error) {
+	review, err := s.reviewRepo.GetReviewByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != "teacher" || !canManageTeacher(s.scheduleRepo, userID, role, review.TeacherID) {
+		return nil, errors.New("only the reviewed teacher can reply")
+	}
+	if review.RepliedAt != nil {
+		return nil, errors.New("review has already been replied to")
+	}
+
+	reply = strings.TrimSpace(reply)
+	if reply == "" {
+		return nil, errors.New("reply must not be empty")
+	}
+
+	now := time.Now()
+	review.Reply = reply
+	review.RepliedAt = &now
+
+	if err := s.reviewRepo.UpdateReview(review); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to reply to review")
+	}
+	return review, nil
+}
+
+// ModerateReview hides or flags a review. Hiding or unhiding changes the
+// teacher's aggregate rating.
+func (s *ReviewService) ModerateReview(reviewID uint, req models.ReviewModerationRequest) (*models.Review, error) {
+	review, err := s.reviewRepo.GetReviewByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IsHidden != nil {
+		review.IsHidden = *req.IsHidden
+	}
+	if req.IsFlagged != nil {
+		review.IsFlagged = *req.IsFlagged
+		if !review.IsFlagged {
+			review.FlagReason = ""
+		}
+	}
+	if req.FlagReason != "" {
+		review.FlagReason = req.FlagReason
+	}
+
+	if err := s.reviewRepo.ModerateReview(review); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to moderate review")
+	}
+	return review, nil
+}
+
+func (s *ReviewService) GetTeacherReviews(teacherID uint, paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+	if _, err := s.scheduleRepo.GetTeacherByID(teacherID); err != nil {
+		return pkg.ResponsePaginate{}, errors.New("teacher not found")
+	}
+
+	response, err := s.reviewRepo.GetTeacherReviews(teacherID, paginate)
+	if err != nil {
+		return pkg.ResponsePaginate{}, errors.New("failed to get reviews")
+	}
+	return response, nil
+}
+
+func (s *ReviewService) GetReviewsForModeration(flaggedOnly bool, paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+	response, err := s.reviewRepo.GetReviewsForModeration(flaggedOnly, paginate)
+	if err != nil {
+		return pkg.ResponsePaginate{}, errors.New("failed to get reviews")
+	}
+	return response, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"teacher/internal/infrastructure/booking"
+	"teacher/internal/infrastructure/user"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+	"teacher/internal/repository"
+	"time"
+)
+
+type RosterService struct {
+	rosterRepo     *repository.Roster
+	scheduleRepo   *repository.Schedule
+	serviceBooking *booking.BookingService
+	serviceUser    *user.UserService
+}
+
+func NewRosterService(rosterRepo *repository.Roster, scheduleRepo *repository.Schedule, serviceBooking *booking.BookingService, serviceUser *user.UserService) *RosterService {
+	return &RosterService{
+		rosterRepo:     rosterRepo,
+		scheduleRepo:   scheduleRepo,
+		serviceBooking: serviceBooking,
+		serviceUser:    serviceUser,
+	}
+}
+
+// GetRoster returns one page of the teacher's students with their booking
+// figures, contact details and the teacher's private notes. Contact details
+// are best effort: when the user service is down the roster is returned
+// without them.
+func (s *RosterService) GetRoster(teacherID uint, sort string, paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+	switch sort {
+	case "", models.RosterSortRecent, models.RosterSortUpcoming:
+	default:
+		return pkg.ResponsePaginate{}, errors.New("invalid sort, use recent or upcoming")
+	}
+	if paginate.Page < 1 {
+		paginate.Page = 1
+	}
+	if paginate.Limit <= 0 || paginate.Limit > 100 {
+		paginate.Limit = 10
+	}
+
+	if _, err := s.scheduleRepo.GetTeacherByID(teacherID); err != nil {
+		return pkg.ResponsePaginate{}, errors.New("teacher not found")
+	}
+
+	students, pagination, err := s.roster(teacherID, sort, *paginate)
+	if err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	ids := make([]uint, 0, len(students))
+	for _, student := range students {
+		ids = append(ids, student.StudentID)
+	}
+	notes, err := s.rosterRepo.GetNotes(teacherID, ids)
+	if err != nil {
+		log.Println(err)
+		return pkg.ResponsePaginate{}, errors.New("failed to get student notes")
+	}
+	for i := range students {
+		students[i].Note = notes[students[i].StudentID]
+	}
+
+	return pkg.ResponsePaginate{Data: students, Pagination: pagination}, nil
+}
+
+// SetNote stores the teacher's private note about a student. An empty note
+// clears it.
+func (s *RosterService) SetNote(teacherID, studentID uint, note string) (*models.TeacherStudentNote, error) {
+	if studentID == 0 {
+		return nil, errors.New("invalid student id")
+	}
+	if _, err := s.scheduleRepo.GetTeacherByID(teacherID); err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	studentNote := &models.TeacherStudentNote{
+		TeacherID: teacherID,
+		StudentID: studentID,
+		Note:      strings.TrimSpace(note),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.rosterRepo.SaveNote(studentNote); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to save student note")
+	}
+	return studentNote, nil
+}
+
+func (s *RosterService) CanManageTeacher(userID uint, role string, teacherID uint) bool {
+	return canManageTeacher(s.scheduleRepo, userID, role, teacherID)
+}
+
+// roster fetches a page of students from the booking service and fills in
+// their contact details with a single user service call.
+func (s *RosterService) roster(teacherID uint, sort string, paginate pkg.Paginate) ([]models.RosterStudent, pkg.PaginationPage, error) {
+	students, pagination, err := s.serviceBooking.GetTeacherStudents(teacherID, sort, paginate)
+	if err != nil {
+		log.Println(err)
+		return nil, pkg.PaginationPage{}, errors.New("failed to get students")
+	}
+	if students == nil {
+		students = []models.RosterStudent{}
+	}
+	if len(students) == 0 {
+		return students, pagination, nil
+	}
+
+	ids := make([]uint, 0, len(students))
+	for _, student := range students {
+		ids = append(ids, student.StudentID)
+	}
+	users, err := s.serviceUser.GetUsers(ids)
+	if err != nil {
+		log.Printf("failed to get contact details of students: %v", err)
+		return students, pagination, nil
+	}
+	for i := range students {
+		if u, ok := users[students[i].StudentID]; ok {
+			students[i].Name = u.Name
+			students[i].Email = u.Email
+			students[i].ProfileImage = u.ProfileImage
+		}
+	}
+	return students, pagination, nil
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"teacher/internal/infrastructure/booking"
 	"teacher/internal/models"
 	"teacher/internal/pkg"
 	"teacher/internal/repository"
@@ -11,30 +13,94 @@ import (
 )
 
 type ScheduleService struct {
-	scheduleRepo *repository.Schedule
+	scheduleRepo   *repository.Schedule
+	lessonTypeRepo *repository.LessonType
+	serviceBooking *booking.BookingService
 }
 
-func NewScheduleService(scheduleRepo *repository.Schedule) *ScheduleService {
+func NewScheduleService(scheduleRepo *repository.Schedule, lessonTypeRepo *repository.LessonType, serviceBooking *booking.BookingService) *ScheduleService {
 	return &ScheduleService{
-		scheduleRepo: scheduleRepo,
+		scheduleRepo:   scheduleRepo,
+		lessonTypeRepo: lessonTypeRepo,
+		serviceBooking: serviceBooking,
 	}
 }
 
-func (s *ScheduleService) BookScheduleService(input models.Schedule) (*models.Schedule, error) {
-	// 1. Validasi teacher exist
-	teacher, err := s.scheduleRepo.GetTeacherByID(input.TeacherID)
+// teacherLookup finds the teacher profile of a user. Both the schedule and
+// the teacher repository can.
+type teacherLookup interface {
+	GetTeacherByUserID(userID uint) (*models.Teacher, error)
+}
+
+// canManageTeacher reports whether the caller may manage the given teacher:
+// admins always can, teachers only their own profile.
+func canManageTeacher(scheduleRepo teacherLookup, userID uint, role string, teacherID uint) bool {
+	if role == "admin" {
+		return true
+	}
+	if role != "teacher" {
+		return false
+	}
+	teacher, err := scheduleRepo.GetTeacherByUserID(userID)
 	if err != nil {
-		return nil, errors.New("teacher not found")
+		return false
+	}
+	return teacher.ID == teacherID
+}
+
+// checkLessonType validates the optional lesson type of a slot.
+func (s *ScheduleService) checkLessonType(lessonTypeID *uint, teacherID uint, startTime, endTime string) error {
+	if lessonTypeID == nil {
+		return nil
 	}
+	lessonType, err := s.lessonTypeRepo.GetLessonTypeByID(*lessonTypeID)
+	if err != nil {
+		return err
+	}
+	return validateScheduleLessonType(lessonType, teacherID, startTime, endTime)
+}
 
-	isValidStart, err := pkg.IsWithinRange(teacher.AvailableStartTime, teacher.AvailableEndTime, input.StartTime)
+// checkSubject validates the optional subject of a slot: the teacher has to
+// teach it.
+func (s *ScheduleService) checkSubject(subjectID *uint, teacherID uint) error {
+	if subjectID == nil {
+		return nil
+	}
+	if _, err := s.scheduleRepo.GetTeacherSubject(teacherID, *subjectID); err != nil {
+		return errors.New("subject is not taught by this teacher")
+	}
+	return nil
+}
+
+// validateScheduleWindow checks that the teacher is approved and that both
+// ends of a slot fall inside the teacher's available hours.
+func validateScheduleWindow(teacher *models.Teacher, startTime, endTime string) error {
+	if teacher.Status != models.TeacherStatusApproved {
+		return errors.New("teacher is not approved yet")
+	}
+
+	isValidStart, err := pkg.IsWithinRange(teacher.AvailableStartTime, teacher.AvailableEndTime, startTime)
 	if err != nil || !isValidStart {
-		return nil, errors.New("start time outside teacher availability")
+		return errors.New("start time outside teacher availability")
 	}
 
-	isValidEnd, err := pkg.IsWithinRange(teacher.AvailableStartTime, teacher.AvailableEndTime, input.EndTime)
+	isValidEnd, err := pkg.IsWithinRange(teacher.AvailableStartTime, teacher.AvailableEndTime, endTime)
 	if err != nil || !isValidEnd {
-		return nil, errors.New("end time outside teacher availability")
+		return errors.New("end time outside teacher availability")
+	}
+
+	return nil
+}
+
+func (s *ScheduleService) BookScheduleService(input models.Schedule) (*models.Schedule, error) {
+	// 1. Validasi teacher exist
+	teacher, err := s.scheduleRepo.GetTeacherByID(input.TeacherID)
+	if err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	if err := validateScheduleWindow(teacher, input.StartTime, input.EndTime); err != nil {
+		return nil, err
 	}
 
 	// 3. Validasi bentrok jadwal
@@ -90,6 +156,33 @@ func (s *ScheduleService) DeleteScheduleService(id uint) error {
 	return nil
 }
 
+// RestoreScheduleService brings back a deleted schedule. Slots of a deleted
+// teacher can only come back together with the teacher.
+func (s *ScheduleService) RestoreScheduleService(id uint) (*models.Schedule, error) {
+	schedules, err := s.scheduleRepo.GetBatchScheduleDetail([]uint{id})
+	if err != nil {
+		return nil, errors.New("failed to get schedule")
+	}
+	if len(schedules) == 0 {
+		return nil, errors.New("schedule not found")
+	}
+	if schedules[0].Teacher.DeletedAt.Valid {
+		return nil, errors.New("teacher of the schedule is deleted, restore the teacher first")
+	}
+
+	schedule, err := s.scheduleRepo.RestoreSchedule(id)
+	if err != nil {
+		if err.Error() == "schedule not found" || err.Error() == "schedule is not deleted" {
+			return nil, err
+		}
+		return nil, errors.New("failed to restore schedule")
+	}
+	if schedule.Status == "available" {
+		s.publishSlots([]uint{schedule.ID})
+	}
+	return schedule, nil
+}
+
 func (s *ScheduleService) CreateScheduleService(schedule *models.Schedule) error {
 
 	teacher, err := s.scheduleRepo.GetTeacherByID(schedule.TeacherID)
@@ -98,14 +191,20 @@ func (s *ScheduleService) CreateScheduleService(schedule *models.Schedule) error
 		return errors.New("teacher not found")
 	}
 
-	isValidStart, err := pkg.IsWithinRange(teacher.AvailableStartTime, teacher.AvailableEndTime, schedule.StartTime)
-	if err != nil || !isValidStart {
-		return errors.New("start time outside teacher availability")
+	if err := validateScheduleWindow(teacher, schedule.StartTime, schedule.EndTime); err != nil {
+		return err
 	}
 
-	isValidEnd, err := pkg.IsWithinRange(teacher.AvailableStartTime, teacher.AvailableEndTime, schedule.EndTime)
-	if err != nil || !isValidEnd {
-		return errors.New("end time outside teacher availability")
+	if err := s.checkLessonType(schedule.LessonTypeID, schedule.TeacherID, schedule.StartTime, schedule.EndTime); err != nil {
+		return err
+	}
+
+	if err := s.checkSubject(schedule.SubjectID, schedule.TeacherID); err != nil {
+		return err
+	}
+
+	if err := validateGroupClass(schedule, 0); err != nil {
+		return err
 	}
 
 	conflict, err := s.scheduleRepo.HasScheduleConflict(schedule.TeacherID, schedule.Date, schedule.StartTime, schedule.EndTime)
@@ -119,57 +218,233 @@ func (s *ScheduleService) CreateScheduleService(schedule *models.Schedule) error
 	if err := s.scheduleRepo.CreateSchedule(schedule); err != nil {
 		return errors.New("failed to create schedule")
 	}
+	if schedule.Status == "" || schedule.Status == "available" {
+		s.publishSlots([]uint{schedule.ID})
+	}
 	return nil
 }
 
+// publishSlots lets the booking service offer new slots to its waitlist.
+// The schedule change itself already succeeded, so failures are only logged.
+func (s *ScheduleService) publishSlots(ids []uint) {
+	if len(ids) == 0 {
+		return
+	}
+	go func() {
+		if err := s.serviceBooking.NotifySlotsPublished(ids); err != nil {
+			log.Printf("failed to notify booking service of new slots: %v", err)
+		}
+	}()
+}
+
 func (s *ScheduleService) UpdateScheduleService(id uint, status string) error {
 
-	_, err := s.scheduleRepo.GetSchedulesById(id)
+	schedule, err := s.scheduleRepo.GetSchedulesById(id)
 	if err != nil {
 		return errors.New("schedule not found")
 	}
 
+	// The teacher is busy in an external calendar at this time.
+	if status == "booked" && schedule.Blocked {
+		return errors.New("schedule is not available")
+	}
+
+	if schedule.IsGroup() {
+		return s.updateGroupSeats(schedule, status)
+	}
+
+	if status == "booked" {
+		ok, err := s.scheduleRepo.ReserveSlot(id)
+		if err != nil {
+			return errors.New("failed to update schedule")
+		}
+		if !ok {
+			return errors.New("schedule is not available")
+		}
+		return nil
+	}
+
+	// A booked slot reopens because its lesson was cancelled.
+	if status == "available" && schedule.Status == "booked" {
+		if err := s.scheduleRepo.FreeSlot(id); err != nil {
+			return errors.New("failed to update schedule")
+		}
+		return nil
+	}
+
 	if err := s.scheduleRepo.UpdateScheduleStatus(id, status); err != nil {
 		return errors.New("failed to update schedule")
 	}
 	return nil
 }
 
+// ReserveSchedules books all slots of req or none of them.
+func (s *ScheduleService) ReserveSchedules(req models.ScheduleBatchRequest) error {
+	if len(req.ScheduleIDs) == 0 {
+		return errors.New("schedule_ids is required")
+	}
+	if len(req.ScheduleIDs) > maxMatchDates {
+		return fmt.Errorf("too many schedules, maximum is %d", maxMatchDates)
+	}
+
+	unavailable, err := s.scheduleRepo.ReserveSlots(req.ScheduleIDs)
+	if err != nil {
+		log.Println(err)
+		return errors.New("failed to reserve schedules")
+	}
+	if unavailable != 0 {
+		return fmt.Errorf("schedule %d is not available", unavailable)
+	}
+	return nil
+}
+
+// ReleaseSchedules gives back slots reserved by ReserveSchedules whose
+// bookings could not be stored.
+func (s *ScheduleService) ReleaseSchedules(req models.ScheduleBatchRequest) error {
+	if len(req.ScheduleIDs) == 0 {
+		return nil
+	}
+	if err := s.scheduleRepo.ReleaseSlots(req.ScheduleIDs); err != nil {
+		log.Println(err)
+		return errors.New("failed to release schedules")
+	}
+	return nil
+}
+
+// HoldSchedule holds an open slot for the waitlisted student it is offered
+// to, so searches do not list it meanwhile.
+func (s *ScheduleService) HoldSchedule(id uint, req models.ScheduleHoldRequest) error {
+	if _, err := s.scheduleRepo.GetSchedulesById(id); err != nil {
+		return errors.New("schedule not found")
+	}
+	if err := s.scheduleRepo.HoldSlot(id, req.HeldUntil); err != nil {
+		return errors.New("failed to hold schedule")
+	}
+	return nil
+}
+
 func (s *ScheduleService) GetBatchScheduleDetailService(ids []uint) ([]models.ScheduleResponse, error) {
 	schedules, err := s.scheduleRepo.GetBatchScheduleDetail(ids)
 	if err != nil {
 		return nil, errors.New("failed to get batch schedule detail")
 	}
 
+	rates, err := s.subjectRates(schedules)
+	if err != nil {
+		return nil, errors.New("failed to calculate price")
+	}
+
 	var schedulesResponse []models.ScheduleResponse
 	for _, schedule := range schedules {
 
-		totalDuration, err := pkg.CalculateDuration(schedule.StartTime, schedule.EndTime)
+		var subjectRate *float64
+		if schedule.SubjectID != nil {
+			if rate, ok := rates[subjectRateKey{schedule.TeacherID, *schedule.SubjectID}]; ok {
+				subjectRate = &rate
+			}
+		}
+		totalPrice, err := calculateLessonPrice(schedule, subjectRate)
 		if err != nil {
-			return nil, errors.New("failed to calculate duration")
+			return nil, errors.New("failed to calculate price")
 		}
 
-		schedulesResponse = append(schedulesResponse, models.ScheduleResponse{
-			ID:         schedule.ID,
-			Status:     schedule.Status,
-			TeacherID:  schedule.TeacherID,
-			Date:       schedule.Date.Format("2006-01-02"),
-			StartTime:  schedule.StartTime,
-			EndTime:    schedule.EndTime,
-			TotalPrice: totalDuration * float64(schedule.Teacher.PricePerHour),
+		response := models.ScheduleResponse{
+			ID:           schedule.ID,
+			Status:       schedule.Status,
+			TeacherID:    schedule.TeacherID,
+			Date:         schedule.Date.Format("2006-01-02"),
+			StartTime:    schedule.StartTime,
+			EndTime:      schedule.EndTime,
+			TotalPrice:   totalPrice,
+			LessonTypeID: schedule.LessonTypeID,
+			SubjectID:    schedule.SubjectID,
+			Capacity:     schedule.Capacity,
+			SeatsTaken:   schedule.SeatsTaken,
+			Blocked:      schedule.Blocked,
+			IsDeleted:    schedule.DeletedAt.Valid || schedule.Teacher.DeletedAt.Valid,
 			Teacher: models.TeacherResponse{
 				ID:           schedule.Teacher.ID,
+				UserID:       schedule.Teacher.UserID,
 				Name:         schedule.Teacher.Name,
 				Bio:          schedule.Teacher.Bio,
 				PricePerHour: schedule.Teacher.PricePerHour,
 				ProfileImage: schedule.Teacher.ProfileImage,
 			},
-		})
+		}
+		if schedule.LessonType != nil {
+			response.LessonTypeName = schedule.LessonType.Name
+			response.DurationMinutes = schedule.LessonType.DurationMinutes
+			response.IsTrial = schedule.LessonType.IsTrial
+		}
+
+		schedulesResponse = append(schedulesResponse, response)
 	}
 
 	return schedulesResponse, nil
 }
 
+// subjectRateKey identifies a subject of a teacher.
+type subjectRateKey struct {
+	TeacherID uint
+	SubjectID uint
+}
+
+// subjectRates returns the hourly rates the teachers of the schedules set
+// for the schedules' subjects. Subjects without their own rate are left out.
+func (s *ScheduleService) subjectRates(schedules []models.Schedule) (map[subjectRateKey]float64, error) {
+	var teacherIDs, subjectIDs []uint
+	for _, schedule := range schedules {
+		if schedule.SubjectID != nil && schedule.LessonType == nil {
+			teacherIDs = append(teacherIDs, schedule.TeacherID)
+			subjectIDs = append(subjectIDs, *schedule.SubjectID)
+		}
+	}
+	rates := make(map[subjectRateKey]float64)
+	if len(subjectIDs) == 0 {
+		return rates, nil
+	}
+
+	subjects, err := s.scheduleRepo.GetTeacherSubjects(teacherIDs, subjectIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, subject := range subjects {
+		if subject.PricePerHour != nil {
+			rates[subjectRateKey{subject.TeacherID, subject.SubjectID}] = *subject.PricePerHour
+		}
+	}
+	return rates, nil
+}
+
+// maxMatchDates bounds the dates of a single slot lookup.
+const maxMatchDates = 52
+
+// MatchSlots returns the details of the teacher's slots with the requested
+// times on the requested dates. Dates without such a slot are left out.
+func (s *ScheduleService) MatchSlots(req models.ScheduleMatchRequest) ([]models.ScheduleResponse, error) {
+	if len(req.Dates) == 0 {
+		return []models.ScheduleResponse{}, nil
+	}
+	if len(req.Dates) > maxMatchDates {
+		return nil, fmt.Errorf("too many dates, maximum is %d", maxMatchDates)
+	}
+
+	schedules, err := s.scheduleRepo.FindTeacherSlots(req.TeacherID, req.Dates, req.StartTime, req.EndTime)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to find schedules")
+	}
+	if len(schedules) == 0 {
+		return []models.ScheduleResponse{}, nil
+	}
+
+	ids := make([]uint, 0, len(schedules))
+	for _, schedule := range schedules {
+		ids = append(ids, schedule.ID)
+	}
+	return s.GetBatchScheduleDetailService(ids)
+}
+
 func (s *ScheduleService) FetchTeacherIdAndIds(teacherID string, ids []string) ([]string, error) {
 
 	idsInt := make([]uint, len(ids))
@@ -201,11 +476,38 @@ func (s *ScheduleService) Schedules(paginate *pkg.Paginate) (pkg.ResponsePaginat
 
 func (s *ScheduleService) Update(schedule *models.Schedule) error {
 
-	_, err := s.scheduleRepo.GetSchedulesById(schedule.ID)
+	existing, err := s.scheduleRepo.GetSchedulesById(schedule.ID)
 	if err != nil {
 		return errors.New("schedule not found")
 	}
 
+	if err := s.checkLessonType(schedule.LessonTypeID, existing.TeacherID, schedule.StartTime, schedule.EndTime); err != nil {
+		return err
+	}
+
+	if err := s.checkSubject(schedule.SubjectID, existing.TeacherID); err != nil {
+		return err
+	}
+
+	// Group settings left out of the request keep their current value.
+	if schedule.Capacity == 0 {
+		schedule.Capacity = existing.Capacity
+	}
+	if schedule.IsGroup() {
+		if schedule.MinSeats == 0 {
+			schedule.MinSeats = existing.MinSeats
+		}
+		if schedule.CutoffHours == 0 {
+			schedule.CutoffHours = existing.CutoffHours
+		}
+		if schedule.SeatPrice == nil {
+			schedule.SeatPrice = existing.SeatPrice
+		}
+	}
+	if err := validateGroupClass(schedule, existing.SeatsTaken); err != nil {
+		return err
+	}
+
 	if err := s.scheduleRepo.UpdateSchedule(schedule.ID, schedule); err != nil {
 		return errors.New("failed to update schedule")
 	}
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"teacher/internal/models"
+	"teacher/internal/pkg"
+	"time"
+)
+
+const maxBulkScheduleRows = 500
+
+// bookingCancellationRetryInterval is how often the bookings of cancelled
+// slots that could not be cancelled yet are tried again.
+const bookingCancellationRetryInterval = 5 * time.Minute
+
+var errBulkValidation = errors.New("bulk validation failed")
+
+// BulkCreateSchedules validates every row against the same rules as
+// CreateScheduleService and only creates the slots when all rows pass. The
+// returned report always lists every row so the caller can fix the invalid
+// ones and resubmit the whole batch.
+func (s *ScheduleService) BulkCreateSchedules(teacherID uint, rows []models.ScheduleRequest) (*models.BulkScheduleResponse, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("no schedules to create")
+	}
+	if len(rows) > maxBulkScheduleRows {
+		return nil, fmt.Errorf("too many schedules, maximum is %d per batch", maxBulkScheduleRows)
+	}
+
+	teacher, err := s.scheduleRepo.GetTeacherByID(teacherID)
+	if err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	report := &models.BulkScheduleResponse{Total: len(rows)}
+	schedules := make([]models.Schedule, 0, len(rows))
+
+	for i, row := range rows {
+		result := models.BulkScheduleRow{
+			Row:       i + 1,
+			Date:      row.Date,
+			StartTime: row.StartTime,
+			EndTime:   row.EndTime,
+		}
+
+		schedule, errs := s.validateBulkSlot(teacher, row.Date, row.StartTime, row.EndTime, row.LessonTypeID, row.SubjectID)
+		if len(errs) == 0 {
+			if other := findOverlap(schedules, schedule); other >= 0 {
+				errs = append(errs, fmt.Sprintf("overlaps with row %d", other+1))
+			}
+		}
+
+		result.Errors = errs
+		result.Valid = len(errs) == 0
+		if !result.Valid {
+			report.Invalid++
+		}
+		report.Rows = append(report.Rows, result)
+
+		// Keep the parsed slot even when invalid so row indexes stay aligned
+		// for the overlap check above.
+		schedules = append(schedules, schedule)
+	}
+
+	if report.Invalid > 0 {
+		return report, errBulkValidation
+	}
+
+	if err := s.scheduleRepo.CreateSchedules(schedules); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to create schedules")
+	}
+
+	ids := make([]uint, 0, len(schedules))
+	for i := range schedules {
+		report.Rows[i].ScheduleID = schedules[i].ID
+		ids = append(ids, schedules[i].ID)
+	}
+	report.Applied = true
+	s.publishSlots(ids)
+
+	return report, nil
+}
+
+// BulkShiftSchedules moves a set of the teacher's slots by the given number
+// of days and minutes. Booked slots are rejected: moving them would silently
+// change a student's lesson, which has to go through a reschedule instead.
+func (s *ScheduleService) BulkShiftSchedules(req models.BulkShiftRequest) (*models.BulkScheduleResponse, error) {
+	if len(req.ScheduleIDs) == 0 {
+		return nil, errors.New("no schedules to shift")
+	}
+	if len(req.ScheduleIDs) > maxBulkScheduleRows {
+		return nil, fmt.Errorf("too many schedules, maximum is %d per batch", maxBulkScheduleRows)
+	}
+	if req.ShiftDays == 0 && req.ShiftMinutes == 0 {
+		return nil, errors.New("shift_days or shift_minutes is required")
+	}
+
+	teacher, err := s.scheduleRepo.GetTeacherByID(req.TeacherID)
+	if err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	existing, err := s.scheduleRepo.FindByTeacherAndIDs(req.TeacherID, req.ScheduleIDs)
+	if err != nil {
+		return nil, errors.New("failed to get schedules")
+	}
+	byID := make(map[uint]models.Schedule, len(existing))
+	for _, schedule := range existing {
+		byID[schedule.ID] = schedule
+	}
+
+	report := &models.BulkScheduleResponse{Total: len(req.ScheduleIDs)}
+	shifted := make([]models.Schedule, 0, len(req.ScheduleIDs))
+
+	for i, id := range req.ScheduleIDs {
+		result := models.BulkScheduleRow{Row: i + 1, ScheduleID: id}
+		var errs []string
+		var moved models.Schedule
+
+		current, ok := byID[id]
+		switch {
+		case !ok:
+			errs = append(errs, "schedule not found for this teacher")
+		case current.Status == "booked" || current.SeatsTaken > 0:
+			result.Booked = true
+			errs = append(errs, "booked schedules cannot be shifted, reschedule the booking instead")
+		case current.Status == "cancelled":
+			errs = append(errs, "cancelled schedules cannot be shifted")
+		default:
+			date, start, end, err := shiftSlot(current, req.ShiftDays, req.ShiftMinutes)
+			if err != nil {
+				errs = append(errs, err.Error())
+				break
+			}
+			result.Date, result.StartTime, result.EndTime = date, start, end
+			moved, errs = s.validateBulkSlot(teacher, date, start, end, current.LessonTypeID, current.SubjectID)
+			moved.ID = current.ID
+			if len(errs) == 0 {
+				if other := findOverlap(shifted, moved); other >= 0 {
+					errs = append(errs, fmt.Sprintf("overlaps with row %d", other+1))
+				}
+			}
+		}
+
+		result.Errors = errs
+		result.Valid = len(errs) == 0
+		if !result.Valid {
+			report.Invalid++
+		}
+		report.Rows = append(report.Rows, result)
+		shifted = append(shifted, moved)
+	}
+
+	if report.Invalid > 0 {
+		return report, errBulkValidation
+	}
+
+	if err := s.scheduleRepo.UpdateScheduleTimes(shifted); err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to shift schedules")
+	}
+	report.Applied = true
+
+	return report, nil
+}
+
+// BulkCancelSchedules cancels every non-cancelled slot of the teacher in the
+// given date range. Bookings holding any of those slots are then cancelled
+// and refunded through the booking service one by one. The rows of slots
+// whose bookings could not be cancelled say so; those are retried in the
+// background (see RetryBookingCancellations).
+func (s *ScheduleService) BulkCancelSchedules(req models.BulkCancelRequest) (*models.BulkScheduleResponse, error) {
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid start date format, should be 2006-01-02")
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return nil, errors.New("invalid end date format, should be 2006-01-02")
+	}
+	if end.Before(start) {
+		return nil, errors.New("end date must not be before start date")
+	}
+
+	if _, err := s.scheduleRepo.GetTeacherByID(req.TeacherID); err != nil {
+		return nil, errors.New("teacher not found")
+	}
+
+	schedules, err := s.scheduleRepo.GetTeacherSchedulesInRange(req.TeacherID, start, end)
+	if err != nil {
+		return nil, errors.New("failed to get schedules")
+	}
+
+	report := &models.BulkScheduleResponse{Total: len(schedules)}
+	if len(schedules) == 0 {
+		report.Applied = true
+		return report, nil
+	}
+
+	ids := make([]uint, 0, len(schedules))
+	for _, schedule := range schedules {
+		ids = append(ids, schedule.ID)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "Lesson cancelled by teacher"
+	}
+
+	// Whether a slot was booked is taken from the cancellation itself, as
+	// slots may be booked after they were listed.
+	booked, err := s.scheduleRepo.CancelSchedules(ids)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("failed to cancel schedules")
+	}
+	report.Applied = true
+
+	isBooked := make(map[uint]bool, len(booked))
+	bookedIDs := make([]uint, 0, len(booked))
+	for _, schedule := range booked {
+		isBooked[schedule.ID] = true
+		bookedIDs = append(bookedIDs, schedule.ID)
+	}
+	for i, schedule := range schedules {
+		report.Rows = append(report.Rows, models.BulkScheduleRow{
+			Row:        i + 1,
+			ScheduleID: schedule.ID,
+			Date:       schedule.Date.Format("2006-01-02"),
+			StartTime:  schedule.StartTime,
+			EndTime:    schedule.EndTime,
+			Booked:     isBooked[schedule.ID],
+			Valid:      true,
+		})
+	}
+
+	if len(bookedIDs) == 0 {
+		return report, nil
+	}
+	failed, err := s.cancelBookings(bookedIDs, reason)
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		if !row.Booked {
+			continue
+		}
+		if err != nil {
+			row.Errors = append(row.Errors, "bookings not cancelled yet, they will be retried")
+			continue
+		}
+		for _, failure := range failed {
+			if failure.ScheduleID == row.ScheduleID {
+				row.Errors = append(row.Errors, fmt.Sprintf("booking %d not cancelled yet, it will be retried: %s", failure.BookingID, failure.Error))
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// cancelBookings cancels the bookings of cancelled slots through the booking
+// service and clears the retry flag of the slots that have none left.
+func (s *ScheduleService) cancelBookings(scheduleIDs []uint, reason string) ([]models.BookingCancelFailure, error) {
+	failed, err := s.serviceBooking.CancelBookingsBySchedules(scheduleIDs, reason)
+	if err != nil {
+		log.Printf("failed to cancel bookings of schedules %v: %v", scheduleIDs, err)
+		return nil, err
+	}
+
+	pending := make(map[uint]bool, len(failed))
+	for _, failure := range failed {
+		pending[failure.ScheduleID] = true
+	}
+	done := make([]uint, 0, len(scheduleIDs))
+	for _, id := range scheduleIDs {
+		if !pending[id] {
+			done = append(done, id)
+		}
+	}
+	if err := s.scheduleRepo.ClearCancelRetry(done); err != nil {
+		log.Printf("failed to clear cancel retry of schedules %v: %v", done, err)
+	}
+	return failed, nil
+}
+
+// RunBookingCancellationRetry retries the booking cancellations of
+// cancelled slots every few minutes. It blocks and is meant to run in its
+// own goroutine.
+func (s *ScheduleService) RunBookingCancellationRetry() {
+	ticker := time.NewTicker(bookingCancellationRetryInterval)
+	defer ticker.Stop()
+	for {
+		s.RetryBookingCancellations()
+		<-ticker.C
+	}
+}
+
+// RetryBookingCancellations cancels the bookings left over from slots
+// cancelled earlier, e.g. because the booking service was down or a refund
+// failed. The booking service skips bookings already cancelled and refunds
+// each booking only once, so retrying is safe.
+func (s *ScheduleService) RetryBookingCancellations() {
+	schedules, err := s.scheduleRepo.GetCancelRetries()
+	if err != nil {
+		log.Printf("booking cancellation retry: failed to get schedules: %v", err)
+		return
+	}
+	if len(schedules) == 0 {
+		return
+	}
+
+	ids := make([]uint, 0, len(schedules))
+	for _, schedule := range schedules {
+		ids = append(ids, schedule.ID)
+	}
+	failed, err := s.cancelBookings(ids, "Lesson cancelled by teacher")
+	if err != nil {
+		return
+	}
+	for _, failure := range failed {
+		log.Printf("booking cancellation retry: booking %d of schedule %d: %s", failure.BookingID, failure.ScheduleID, failure.Error)
+	}
+}
+
+// validateBulkSlot parses a row with pkg.ParseTimeSchedule and applies the
+// same availability and conflict rules as a single schedule creation. All
+// problems of the row are collected instead of stopping at the first one.
+func (s *ScheduleService) validateBulkSlot(teacher *models.Teacher, dateRaw, startRaw, endRaw string, lessonTypeID, subjectID *uint) (models.Schedule, []string) {
+	var errs []string
+
+	parsed, err := pkg.ParseTimeSchedule(dateRaw, startRaw, endRaw)
+	if err != nil {
+		return models.Schedule{}, []string{err.Error()}
+	}
+
+	schedule := models.Schedule{
+		TeacherID:    teacher.ID,
+		Date:         parsed.Date,
+		StartTime:    pkg.NormalizeTime(parsed.StartTime),
+		EndTime:      pkg.NormalizeTime(parsed.EndTime),
+		Status:       "available",
+		LessonTypeID: lessonTypeID,
+		SubjectID:    subjectID,
+	}
+
+	if !parsed.EndTime.After(parsed.StartTime) {
+		errs = append(errs, "end time must be after start time")
+	}
+
+	if err := validateScheduleWindow(teacher, schedule.StartTime, schedule.EndTime); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) == 0 {
+		if err := s.checkLessonType(lessonTypeID, teacher.ID, schedule.StartTime, schedule.EndTime); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if err := s.checkSubject(subjectID, teacher.ID); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	conflict, err := s.scheduleRepo.HasScheduleConflict(teacher.ID, schedule.Date, schedule.StartTime, schedule.EndTime)
+	if err != nil {
+		errs = append(errs, "failed to check schedule")
+	} else if conflict {
+		errs = append(errs, "schedule conflict, please choose another time")
+	}
+
+	return schedule, errs
+}
+
+// shiftSlot returns the new date, start and end of a slot moved by the given
+// offset. Slots may not be pushed across midnight.
+func shiftSlot(schedule models.Schedule, days, minutes int) (string, string, string, error) {
+	start, err := pkg.ParseHHMM(schedule.StartTime)
+	if err != nil {
+		return "", "", "", err
+	}
+	end, err := pkg.ParseHHMM(schedule.EndTime)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	offset := time.Duration(minutes) * time.Minute
+	newStart := start.Add(offset)
+	newEnd := end.Add(offset)
+	if newStart.Day() != start.Day() || newEnd.Day() != end.Day() {
+		return "", "", "", errors.New("shifted slot crosses midnight")
+	}
+
+	date := schedule.Date.AddDate(0, 0, days)
+	return date.Format("2006-01-02"), pkg.NormalizeTime(newStart), pkg.NormalizeTime(newEnd), nil
+}
+
+// findOverlap returns the index of the first slot in batch that overlaps with
+// candidate on the same date, or -1 when there is none.
+func findOverlap(batch []models.Schedule, candidate models.Schedule) int {
+	for i, other := range batch {
+		if other.StartTime == "" || !other.Date.Equal(candidate.Date) {
+			continue
+		}
+		if other.StartTime < candidate.EndTime && other.EndTime > candidate.StartTime {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *ScheduleService) CanManageTeacher(userID uint, role string, teacherID uint) bool {
+	return canManageTeacher(s.scheduleRepo, userID, role, teacherID)
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"errors"
+	"log"
+	"teacher/internal/infrastructure/user"
 	"teacher/internal/models"
 	"teacher/internal/pkg"
 	"teacher/internal/repository"
@@ -9,17 +11,29 @@ import (
 )
 
 type Service struct {
-	teacherRepo *repository.Repository
+	teacherRepo    *repository.Repository
+	lessonTypeRepo *repository.LessonType
+	reviewRepo     *repository.Review
+	catalogRepo    *repository.Catalog
+	serviceUser    *user.UserService
 }
 
-func NewService(repo *repository.Repository) *Service {
+func NewService(repo *repository.Repository, lessonTypeRepo *repository.LessonType, reviewRepo *repository.Review, catalogRepo *repository.Catalog, serviceUser *user.UserService) *Service {
 	return &Service{
-		teacherRepo: repo,
+		teacherRepo:    repo,
+		lessonTypeRepo: lessonTypeRepo,
+		reviewRepo:     reviewRepo,
+		catalogRepo:    catalogRepo,
+		serviceUser:    serviceUser,
 	}
 }
 
-func (s *Service) GetTeachers(paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
-	response, err := s.teacherRepo.GetTeachers(paginate)
+func (s *Service) GetTeachers(paginate *pkg.Paginate, filter models.TeacherFilter) (pkg.ResponsePaginate, error) {
+	if err := validateTeacherFilter(&filter); err != nil {
+		return pkg.ResponsePaginate{}, err
+	}
+
+	response, err := s.teacherRepo.GetTeachers(paginate, filter)
 	if err != nil {
 		return pkg.ResponsePaginate{}, err
 	}
@@ -38,12 +52,13 @@ func (s *Service) GetTeachers(paginate *pkg.Paginate) (pkg.ResponsePaginate, err
 			Bio:            teacher.Bio,
 			LanguageLevel:  teacher.LanguageLevel,
 			PricePerHour:   teacher.PricePerHour,
+			RatingAverage:  teacher.RatingAverage,
+			RatingCount:    teacher.RatingCount,
 			AvailableStart: teacher.AvailableStartTime,
 			AvailableEnd:   teacher.AvailableEndTime,
 			ProfileImage:   teacher.ProfileImage,
 			CreatedAt:      teacher.CreatedAt.String(),
 			UpdatedAt:      teacher.UpdatedAt.String(),
-			Schedules:      teacher.Schedules,
 		}
 		teacherResponses = append(teacherResponses, teacherResponse)
 	}
@@ -72,8 +87,10 @@ func (s *Service) CreateTeacher(teacher models.TeacherRequest) error {
 		AvailableEndTime:   pkg.NormalizeTime(availableEnd),
 		// AvailableDays:      teacher.AvailableDays,
 		ProfileImage: teacher.ProfileImage,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		// Profiles created by an admin skip the application review.
+		Status:    models.TeacherStatusApproved,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
 	}
 
 	return s.teacherRepo.CreateTeacher(&teacherReq)
@@ -86,12 +103,17 @@ func (s *Service) GetTeacherByID(id uint) (*models.TeacherResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	if data.Status != models.TeacherStatusApproved {
+		return nil, errors.New("teacher not found")
+	}
 
 	teacher.ID = data.ID
 	teacher.Bio = data.Bio
 	teacher.Name = data.Name
 	teacher.LanguageLevel = data.LanguageLevel
 	teacher.PricePerHour = data.PricePerHour
+	teacher.RatingAverage = data.RatingAverage
+	teacher.RatingCount = data.RatingCount
 	teacher.AvailableStart = data.AvailableStartTime
 	teacher.AvailableEnd = data.AvailableEndTime
 	// teacher.AvailableDays = data.AvailableDays
@@ -99,9 +121,38 @@ func (s *Service) GetTeacherByID(id uint) (*models.TeacherResponse, error) {
 	teacher.CreatedAt = data.CreatedAt.String()
 	teacher.UpdatedAt = data.UpdatedAt.String()
 
+	lessonTypes, err := s.lessonTypeRepo.GetLessonTypesByTeacher(data.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	teacher.LessonTypes = lessonTypes
+
+	recentReviews, err := s.reviewRepo.GetRecentReviews(data.ID, recentReviewsLimit)
+	if err != nil {
+		return nil, err
+	}
+	teacher.RecentReviews = recentReviews
+
+	subjects, err := s.catalogRepo.GetTeacherSubjects(data.ID)
+	if err != nil {
+		return nil, err
+	}
+	teacher.Subjects = subjects
+
+	languages, err := s.catalogRepo.GetTeacherLanguages(data.ID)
+	if err != nil {
+		return nil, err
+	}
+	teacher.Languages = languages
+
 	return &teacher, nil
 }
 
+// CanManageTeacher reports whether the user may edit the teacher profile.
+func (s *Service) CanManageTeacher(userID uint, role string, teacherID uint) bool {
+	return canManageTeacher(s.teacherRepo, userID, role, teacherID)
+}
+
 func (s *Service) UpdateTeacher(teacher models.TeacherRequest) error {
 
 	availableStart, err := pkg.ParseTime(teacher.AvailableStart)
@@ -122,19 +173,23 @@ func (s *Service) UpdateTeacher(teacher models.TeacherRequest) error {
 		return errors.New("teacher not found")
 	}
 
-	return s.teacherRepo.UpdateTeacher(&models.Teacher{
-		ID:                 teacher.ID,
-		Name:               teacher.Name,
-		Bio:                teacher.Bio,
-		LanguageLevel:      teacher.LanguageLevel,
-		PricePerHour:       teacher.PricePerHour,
-		AvailableStartTime: pkg.NormalizeTime(availableStart),
-		AvailableEndTime:   pkg.NormalizeTime(availableEnd),
-		// AvailableDays:      teacher.AvailableDays,
-		ProfileImage: teacher.ProfileImage,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
-	})
+	// Update the loaded row so fields that are not part of the request, such
+	// as the owner, rating and verification status, are kept.
+	dataTeacher.Name = teacher.Name
+	dataTeacher.Bio = teacher.Bio
+	dataTeacher.LanguageLevel = teacher.LanguageLevel
+	dataTeacher.PricePerHour = teacher.PricePerHour
+	dataTeacher.AvailableStartTime = pkg.NormalizeTime(availableStart)
+	dataTeacher.AvailableEndTime = pkg.NormalizeTime(availableEnd)
+	dataTeacher.ProfileImage = teacher.ProfileImage
+	dataTeacher.UpdatedAt = time.Now()
+
+	if err := s.teacherRepo.UpdateTeacher(dataTeacher); err != nil {
+		return err
+	}
+
+	syncUserProfile(s.serviceUser, dataTeacher)
+	return nil
 }
 
 func (s *Service) DeleteTeacher(teacher models.TeacherRequest) error {
@@ -143,6 +198,26 @@ func (s *Service) DeleteTeacher(teacher models.TeacherRequest) error {
 	})
 }
 
+func (s *Service) RestoreTeacher(id uint) (*models.Teacher, error) {
+	teacher, err := s.teacherRepo.RestoreTeacher(id)
+	if err != nil {
+		if err.Error() == "teacher not found" || err.Error() == "teacher is not deleted" {
+			return nil, err
+		}
+		log.Println(err)
+		return nil, errors.New("failed to restore teacher")
+	}
+	return teacher, nil
+}
+
+func (s *Service) GetDeletedTeachers(paginate *pkg.Paginate) (pkg.ResponsePaginate, error) {
+	response, err := s.teacherRepo.GetDeletedTeachers(paginate)
+	if err != nil {
+		return pkg.ResponsePaginate{}, errors.New("failed to get deleted teachers")
+	}
+	return response, nil
+}
+
 func (s *Service) CountTeachers() (int64, error) {
 	return s.teacherRepo.CountTeachers()
 }
@@ -151,3 +226,48 @@ func (s *Service) CountTeachers() (int64, error) {
 func (s *Service) GetTeacherByUserID(userID uint) (*models.Teacher, error) {
 	return s.teacherRepo.GetTeacherByUserID(userID)
 }
+
+// validateTeacherFilter checks the search parameters and normalizes the
+// time-of-day bounds to HH:MM so they compare correctly with stored slots.
+func validateTeacherFilter(filter *models.TeacherFilter) error {
+	switch filter.Sort {
+	case "", models.TeacherSortPriceAsc, models.TeacherSortPriceDesc, models.TeacherSortRating, models.TeacherSortAvailability:
+	default:
+		return errors.New("invalid sort, use price_asc, price_desc, rating or availability")
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return errors.New("min_price must not be greater than max_price")
+	}
+	if filter.MinRating != nil && (*filter.MinRating < 0 || *filter.MinRating > 5) {
+		return errors.New("min_rating must be between 0 and 5")
+	}
+
+	for _, date := range []string{filter.AvailableFrom, filter.AvailableTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return errors.New("invalid date format, should be 2006-01-02")
+		}
+	}
+	if filter.AvailableFrom != "" && filter.AvailableTo != "" && filter.AvailableTo < filter.AvailableFrom {
+		return errors.New("available_to must not be before available_from")
+	}
+
+	for _, t := range []*string{&filter.TimeFrom, &filter.TimeTo} {
+		if *t == "" {
+			continue
+		}
+		parsed, err := pkg.ParseHHMM(*t)
+		if err != nil {
+			return errors.New("invalid time format, should be HH:MM")
+		}
+		*t = pkg.NormalizeTime(parsed)
+	}
+	if filter.TimeFrom != "" && filter.TimeTo != "" && filter.TimeTo <= filter.TimeFrom {
+		return errors.New("time_to must be after time_from")
+	}
+
+	return nil
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"auth/internal/config"
 	"auth/internal/handler"
+	"auth/internal/infrastructure/booking"
 	"auth/internal/infrastructure/statistic"
 	"auth/internal/infrastructure/supabase"
+	"auth/internal/infrastructure/teacher"
 	"auth/internal/middleware"
 	"auth/internal/models"
 	"auth/internal/repository"
@@ -36,8 +38,16 @@ func main() {
 			User            = models.User
 			ActivityLog     = models.ActivityLog
 			FavoriteTeacher = models.FavoriteTeacher
+			StudentProfile  = models.StudentProfile
+			LevelHistory    = models.LevelHistory
+			SkillAssessment = models.SkillAssessment
+
+			NotificationPreference = models.NotificationPreference
+			NotificationLog        = models.NotificationLog
+			OutboxEmail            = models.OutboxEmail
+			Notification           = models.Notification
 		)
-		if err := db.AutoMigrate(&User{}, &ActivityLog{}, &FavoriteTeacher{}); err != nil {
+		if err := db.AutoMigrate(&User{}, &ActivityLog{}, &FavoriteTeacher{}, &StudentProfile{}, &LevelHistory{}, &SkillAssessment{}, &NotificationPreference{}, &NotificationLog{}, &OutboxEmail{}, &Notification{}); err != nil {
 			zerolog.Info().Err(err).Msg("failed to auto migrate user service database")
 		}
 	}
@@ -61,6 +71,13 @@ func main() {
 
 	authService := service.NewJWTConfig(c.JWT.SecretKey, time.Duration(c.JWT.TokenDuration)*time.Hour)
 
+	outboxRepo := repository.NewOutboxEmailRepository(db)
+	emailTemplates, err := service.LoadEmailTemplates(c.SMTP.TemplateDir)
+	if err != nil {
+		// Emails fail until the templates are fixed; the rest of the
+		// service keeps working.
+		zerolog.Error().Err(err).Msg("failed to load email templates")
+	}
 	emailService := service.NewEmailService(
 		c.SMTP.Host,
 		c.SMTP.Port,
@@ -68,16 +85,23 @@ func main() {
 		c.SMTP.Username,
 		c.SMTP.Password,
 		c.SMTP.FromEmail,
-		c.SMTP.TemplatePath,
+		emailTemplates,
 		c.SMTP.TemplateLogoURL,
 		c.SMTP.InsecureSkipVerify,
 		c.SMTP.UseTLS,
+		outboxRepo,
+		c.SMTP.OutboxMaxAttempts,
+		c.SMTP.OutboxRetryBase,
 	)
+	go emailService.RunOutbox()
+	outboxHandler := handler.NewOutboxEmailHandler(emailService)
 
 	restyInit := resty.New()
 	restyInit.SetDebug(cast.ToBool(os.Getenv("DEBUG")))
 
 	statistic := statistic.NewStatistic(&c.ServiceBooking, &c.ServiceTeacher, restyInit)
+	teacherClient := teacher.NewTeacherClient(&c.ServiceTeacher, restyInit)
+	bookingClient := booking.NewBookingClient(&c.ServiceBooking, restyInit)
 
 	// Initialize repositories for activity logs and favorites
 	activityRepo := repository.NewActivityLogRepository(db)
@@ -86,8 +110,11 @@ func main() {
 	// logging of recent actions and managing favorite teachers. Passing the
 	// extra dependency ensures CreateActivityLog, GetRecentActivity, and
 	// favorite teacher methods work as expected.
-	userService := service.NewUserService(userRepo, emailService, c.JWT.TokenDuration, authService, statistic, activityRepo, favoriteRepo)
-	userHandler := handler.NewHandler(userService, userRepo)
+	notificationRepo := repository.NewNotificationRepository(db)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, emailService)
+
+	userService := service.NewUserService(userRepo, emailService, c.JWT.TokenDuration, authService, statistic, activityRepo, favoriteRepo, teacherClient)
+	userHandler := handler.NewHandler(userService, userRepo, notificationService)
 
 	initSupabase := supabase.InitUploadClient(&c.Client, restyInit)
 
@@ -96,6 +123,12 @@ func main() {
 	adminHandler := handler.NewAdminHandler(imageRepo)
 	userAdminHandler := handler.NewUserAdminHandler(userService, userRepo)
 
+	progressRepo := repository.NewProgressRepository(db)
+	progressService := service.NewProgressService(progressRepo, userRepo, bookingClient)
+	progressHandler := handler.NewProgressHandler(progressService)
+
+	notificationHandler := handler.NewNotificationHandler(notificationService, authService)
+
 	v1 := r.Group("/api/v1")
 	v1.POST("/register", userHandler.RegisterUser)
 	v1.POST("/login", userHandler.LoginUser)
@@ -123,6 +156,14 @@ func main() {
 	admin.PUT("/users/:id", userAdminHandler.UpdateUser)
 	admin.DELETE("/users/:id", userAdminHandler.DeleteUser)
 
+	// Delivery log of all notifications, filterable by status.
+	admin.GET("/notifications", notificationHandler.GetLogsAdmin)
+
+	// Outgoing emails, dead ones by default, and a manual retry for emails
+	// that ran out of attempts.
+	admin.GET("/emails", outboxHandler.GetEmails)
+	admin.POST("/emails/:id/retry", outboxHandler.RetryEmail)
+
 	auth := r.Group("/api/v1")
 	auth.Use(middleware.AuthMiddleware(authService))
 	auth.GET("/me", userHandler.GetMe)
@@ -139,12 +180,53 @@ func main() {
 	auth.POST("/favorites", userHandler.ToggleFavorite)
 	auth.GET("/favorites", userHandler.GetFavorites)
 
-	// Internal endpoint for service-to-service activity logging.  This
-	// endpoint bypasses authentication and should only be used by other
-	// services (e.g. payment service) to record activities on behalf of
-	// users.  It accepts a JSON payload with user_id, action and
-	// description.
-	r.POST("/api/v1/internal/activity", userHandler.LogActivityInternal)
+	// Student learning profile, teacher assessments after completed lessons
+	// and the progress built from both. Admins and the student's teachers
+	// can view the progress of a student.
+	auth.GET("/students/me/profile", progressHandler.GetStudentProfile)
+	auth.PUT("/students/me/profile", progressHandler.UpdateStudentProfile)
+	auth.GET("/students/me/progress", progressHandler.GetMyProgress)
+	auth.GET("/students/:id/progress", progressHandler.GetStudentProgress)
+	auth.POST("/students/assessments", progressHandler.SubmitAssessment)
+
+	// Notification settings per event and channel, and the user's own
+	// notification history.
+	auth.GET("/notifications/preferences", notificationHandler.GetPreferences)
+	auth.PUT("/notifications/preferences", notificationHandler.UpdatePreferences)
+	auth.GET("/notifications/history", notificationHandler.GetHistory)
+
+	// In-app notification center. New notifications and read state are
+	// pushed over the SSE stream, so clients need not poll the list. The
+	// stream is opened with a stream token in the URL, as EventSource
+	// cannot send the Authorization header.
+	auth.GET("/notifications", notificationHandler.GetNotifications)
+	auth.GET("/notifications/unread-count", notificationHandler.GetUnreadCount)
+	auth.POST("/notifications/stream-token", notificationHandler.CreateStreamToken)
+	r.GET("/api/v1/notifications/stream", middleware.StreamAuthMiddleware(authService), notificationHandler.StreamNotifications)
+	auth.POST("/notifications/read-all", notificationHandler.MarkAllRead)
+	auth.POST("/notifications/:id/read", notificationHandler.MarkRead)
+
+	// Internal endpoints for the other services, which authenticate with
+	// the secret they share.
+	internal := r.Group("/api/v1/internal")
+	internal.Use(middleware.InternalMiddleware(c.InternalSecret))
+
+	// Records activities on behalf of users, e.g. for the payment service.
+	// It accepts a JSON payload with user_id, action and description, plus
+	// an optional notification for the user's notification center.
+	internal.POST("/activity", userHandler.LogActivityInternal)
+
+	// Used by the teacher service to keep the name and avatar of a user in
+	// sync with the linked teacher profile.
+	internal.PUT("/users/:id/profile", userHandler.SyncProfileInternal)
+
+	// Looks up several users at once, e.g. for the teacher's student
+	// roster.
+	internal.POST("/users/batch", userHandler.GetUsersBatchInternal)
+
+	// Booking and payment events, rendered from the email templates in the
+	// user's language and sent per the user's preferences.
+	internal.POST("/notifications/events", notificationHandler.SendEventInternal)
 
 	zerolog.Info().Msg("Starting server on port " + fmt.Sprint(c.AppPort))
 
@@ -21,11 +21,16 @@ type Config struct {
 	SMTP              SMTP
 	Client            Client
 	JWT               JWT
+	InternalSecret    string
 	ServiceBooking    Service
 	ServiceTeacher    Service
 	IsNFT             bool
 }
 
+// InternalSecretHeader carries the secret the services share. Internal
+// routes that change bookings, move money or expose users require it.
+const InternalSecretHeader = "X-Internal-Secret"
+
 type Service struct {
 	Host string
 }
@@ -36,11 +41,13 @@ type SMTP struct {
 	Username           string
 	Password           string
 	FromEmail          string
-	TemplatePath       string
+	TemplateDir        string // layouts, partials and a folder per locale
 	TemplateLogoURL    string
 	TimeoutDuration    int
 	InsecureSkipVerify bool
 	UseTLS             bool
+	OutboxMaxAttempts  int
+	OutboxRetryBase    time.Duration // wait before the first retry, doubled after each
 }
 
 type Client struct {
@@ -73,17 +80,20 @@ func LoadConfig() *Config {
 			SecretKey:     os.Getenv("JWT_SECRET_KEY"),
 			TokenDuration: cast.ToInt(os.Getenv("JWT_TOKEN_DURATION")),
 		},
+		InternalSecret: os.Getenv("INTERNAL_SERVICE_SECRET"),
 		SMTP: SMTP{
 			Host:               os.Getenv("SMTP_HOST"),
 			Port:               cast.ToInt(os.Getenv("SMTP_PORT")),
 			Username:           os.Getenv("SMTP_USERNAME"),
 			Password:           os.Getenv("SMTP_PASSWORD"),
 			FromEmail:          os.Getenv("SMTP_FROM_EMAIL"),
-			TemplatePath:       os.Getenv("SMTP_TEMPLATE_PATH"),
+			TemplateDir:        os.Getenv("SMTP_TEMPLATE_DIR"),
 			TemplateLogoURL:    os.Getenv("SMTP_TEMPLATE_LOGO_URL"),
 			TimeoutDuration:    cast.ToInt(os.Getenv("SMTP_TIMEOUT_DURATION")),
 			InsecureSkipVerify: cast.ToBool(os.Getenv("SMTP_INSECURE_SKIP_VERIFY")),
 			UseTLS:             cast.ToBool(os.Getenv("SMTP_USE_TLS")),
+			OutboxMaxAttempts:  cast.ToInt(os.Getenv("SMTP_OUTBOX_MAX_ATTEMPTS")),
+			OutboxRetryBase:    cast.ToDuration(os.Getenv("SMTP_OUTBOX_RETRY_BASE")),
 		},
 		Client: Client{
 			Endpoint:   os.Getenv("CLIENT_ENDPOINT"),
@@ -6,6 +6,7 @@ import (
 	"auth/internal/service"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"strconv"
@@ -15,18 +16,20 @@ import (
 )
 
 type Handler struct {
-	userService *service.UserService
-	repoUser    *repository.UserRepository
+	userService   *service.UserService
+	repoUser      *repository.UserRepository
+	notifications *service.NotificationService
 	// adminHandler *handler.AdminHandler // Removed due to missing import
 }
 
-func NewHandler(userService *service.UserService, repoUser *repository.UserRepository) *Handler {
+func NewHandler(userService *service.UserService, repoUser *repository.UserRepository, notifications *service.NotificationService) *Handler {
 	// uploadDir := filepath.Join(".", "uploads", "hero")
 	// adminHandler := handler.NewAdminHandler(uploadDir)
 
 	return &Handler{
-		userService: userService,
-		repoUser:    repoUser,
+		userService:   userService,
+		repoUser:      repoUser,
+		notifications: notifications,
 		// adminHandler: adminHandler,
 	}
 }
@@ -61,16 +64,22 @@ func (h *Handler) LogActivity(c *gin.Context) {
 }
 
 // LogActivityInternal handles service-to-service requests to record an activity log
-// for a user.  This endpoint does not require authentication and expects
+// for a user.  The route requires the secret the services share and expects
 // a JSON payload with fields "user_id", "action", and "description".  It
 // calls the user service's CreateActivityLog method and returns a success
 // response when the activity is recorded.  In case of invalid input or
 // errors from the service layer, it returns an appropriate HTTP error.
+// An optional "notification" object with title, message, link and
+// reference also shows the activity in the user's notification center.
+// Only the activities of service.CheckServiceActivity are accepted.
 func (h *Handler) LogActivityInternal(c *gin.Context) {
 	var req struct {
 		UserID      uint   `json:"user_id"`
 		Action      string `json:"action"`
 		Description string `json:"description"`
+		// Notification, if given, also puts the activity into the user's
+		// notification center.
+		Notification *models.ActivityNotification `json:"notification"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
@@ -80,13 +89,89 @@ func (h *Handler) LogActivityInternal(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id and action are required"})
 		return
 	}
+	if err := service.CheckServiceActivity(req.Action, req.Notification); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err := h.userService.CreateActivityLog(c, req.UserID, req.Action, req.Description); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Notification != nil {
+		notification := &models.Notification{
+			UserID:  req.UserID,
+			Type:    req.Action,
+			Title:   req.Notification.Title,
+			Message: req.Notification.Message,
+			Link:    req.Notification.Link,
+		}
+		if notification.Message == "" {
+			notification.Message = req.Description
+		}
+		if req.Notification.Reference != "" {
+			notification.Reference = &req.Notification.Reference
+		}
+		if err := h.notifications.CreateNotification(c.Request.Context(), notification); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Activity recorded successfully"})
 }
 
+// SyncProfileInternal handles service-to-service updates of a user's name
+// and avatar, sent by the teacher service when a linked teacher profile
+// changes. The route requires the secret the services share.
+func (h *Handler) SyncProfileInternal(c *gin.Context) {
+	var req struct {
+		Name         string `json:"name"`
+		ProfileImage string `json:"profile_image"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	userID := cast.ToUint(c.Param("id"))
+	if userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	if err := h.userService.SyncProfileFromTeacher(c, userID, req.Name, req.ProfileImage); err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Profile synchronized successfully"})
+}
+
+// GetUsersBatchInternal returns the users with the given ids in one call,
+// so other services (e.g. the teacher roster) do not look them up one by
+// one. The route requires the secret the services share, as the users
+// include their email.
+func (h *Handler) GetUsersBatchInternal(c *gin.Context) {
+	var req struct {
+		IDs []uint `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	users, err := h.userService.GetUsersByIDs(c, req.IDs)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "at most") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
 // GetRecentActivity handles GET requests to fetch recent activity logs
 // for the authenticated user. An optional `limit` query parameter
 // controls how many records are returned. If the parameter is not
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"auth/internal/models"
+	"auth/internal/service"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// notificationHeartbeat keeps idle notification streams from being closed
+// by proxies.
+const notificationHeartbeat = 25 * time.Second
+
+// NotificationHandler serves notification preferences, the delivery log,
+// the in-app notification center with its live stream and the internal
+// endpoint other services report events to.
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	jwtConfig           *service.JWTConfig
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService, jwtConfig *service.JWTConfig) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService, jwtConfig: jwtConfig}
+}
+
+// GetPreferences - GET /api/v1/notifications/preferences
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	preferences, err := h.notificationService.GetPreferences(c.Request.Context(), cast.ToUint(c.GetString("user_id")))
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": preferences})
+}
+
+// UpdatePreferences - PUT /api/v1/notifications/preferences
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	var req models.NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(c.Request.Context(), cast.ToUint(c.GetString("user_id")), req)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification preferences updated successfully", "data": preferences})
+}
+
+// GetHistory - GET /api/v1/notifications/history
+func (h *NotificationHandler) GetHistory(c *gin.Context) {
+	h.respondLogs(c, cast.ToUint(c.GetString("user_id")))
+}
+
+// GetLogsAdmin - GET /api/admin/notifications. Query: user_id, status,
+// event_type, page and limit.
+func (h *NotificationHandler) GetLogsAdmin(c *gin.Context) {
+	if !c.GetBool("isAdmin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	h.respondLogs(c, cast.ToUint(c.Query("user_id")))
+}
+
+// SendEventInternal - POST /api/v1/internal/notifications/events
+func (h *NotificationHandler) SendEventInternal(c *gin.Context) {
+	var req models.NotificationEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := h.notificationService.SendEvent(c.Request.Context(), req); err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Notification queued"})
+}
+
+func (h *NotificationHandler) respondLogs(c *gin.Context, userID uint) {
+	page := cast.ToInt(c.DefaultQuery("page", "1"))
+	limit := cast.ToInt(c.DefaultQuery("limit", "20"))
+
+	logs, total, err := h.notificationService.GetLogs(c.Request.Context(), userID, c.Query("status"), c.Query("event_type"), page, limit)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": logs,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func respondNotificationError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.HasSuffix(msg, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+	}
+}
+
+// GetNotifications - GET /api/v1/notifications. Query: unread (true for
+// unread only), page and limit.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	page := cast.ToInt(c.DefaultQuery("page", "1"))
+	limit := cast.ToInt(c.DefaultQuery("limit", "20"))
+
+	notifications, total, unread, err := h.notificationService.GetNotifications(c.Request.Context(), cast.ToUint(c.GetString("user_id")), cast.ToBool(c.Query("unread")), page, limit)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":         notifications,
+		"unread_count": unread,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// GetUnreadCount - GET /api/v1/notifications/unread-count
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	unread, err := h.notificationService.GetUnreadNotificationCount(c.Request.Context(), cast.ToUint(c.GetString("user_id")))
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+// MarkRead - POST /api/v1/notifications/:id/read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id := cast.ToUint(c.Param("id"))
+	if id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id"})
+		return
+	}
+
+	unread, err := h.notificationService.MarkNotificationRead(c.Request.Context(), cast.ToUint(c.GetString("user_id")), id)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read", "unread_count": unread})
+}
+
+// MarkAllRead - POST /api/v1/notifications/read-all
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	marked, err := h.notificationService.MarkAllNotificationsRead(c.Request.Context(), cast.ToUint(c.GetString("user_id")))
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read", "marked": marked, "unread_count": 0})
+}
+
+// CreateStreamToken - POST /api/v1/notifications/stream-token. Issues a
+// short-lived token to open the stream with, as EventSource cannot send
+// the Authorization header.
+func (h *NotificationHandler) CreateStreamToken(c *gin.Context) {
+	token, expiresAt, err := h.jwtConfig.GenerateStreamToken(c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create stream token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"token": token, "expires_at": expiresAt}})
+}
+
+// StreamNotifications - GET /api/v1/notifications/stream?token=. Pushes
+// the user's new notifications and read state as server-sent events until
+// the client disconnects, in place of polling the list.
+func (h *NotificationHandler) StreamNotifications(c *gin.Context) {
+	userID := cast.ToUint(c.GetString("user_id"))
+	unread, err := h.notificationService.GetUnreadNotificationCount(c.Request.Context(), userID)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	events, unsubscribe := h.notificationService.SubscribeNotifications(userID)
+	defer unsubscribe()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("ready", gin.H{"unread_count": unread})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(notificationHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"auth/internal/models"
+	"auth/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// OutboxEmailHandler gives admins a view of outgoing emails and lets them
+// retry the ones that ran out of attempts.
+type OutboxEmailHandler struct {
+	emailService *service.EmailService
+}
+
+func NewOutboxEmailHandler(emailService *service.EmailService) *OutboxEmailHandler {
+	return &OutboxEmailHandler{emailService: emailService}
+}
+
+// GetEmails - GET /api/admin/emails. Query: status (dead by default, "all"
+// for every email), page and limit.
+func (h *OutboxEmailHandler) GetEmails(c *gin.Context) {
+	if !c.GetBool("isAdmin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+
+	status := c.DefaultQuery("status", models.OutboxDead)
+	switch status {
+	case "all":
+		status = ""
+	case models.OutboxQueued, models.OutboxSending, models.OutboxSent, models.OutboxDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+	page := cast.ToInt(c.DefaultQuery("page", "1"))
+	limit := cast.ToInt(c.DefaultQuery("limit", "20"))
+
+	emails, total, err := h.emailService.GetOutbox(c.Request.Context(), status, page, limit)
+	if err != nil {
+		respondOutboxError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": emails,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// RetryEmail - POST /api/admin/emails/:id/retry
+func (h *OutboxEmailHandler) RetryEmail(c *gin.Context) {
+	if !c.GetBool("isAdmin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+
+	id := cast.ToUint(c.Param("id"))
+	if id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email id"})
+		return
+	}
+	if err := h.emailService.RetryEmail(c.Request.Context(), id); err != nil {
+		respondOutboxError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email queued for retry"})
+}
+
+func respondOutboxError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.HasSuffix(msg, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "only dead"):
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"auth/internal/models"
+	"auth/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// ProgressHandler serves the student profile, skill assessment and
+// progress endpoints.
+type ProgressHandler struct {
+	progressService *service.ProgressService
+}
+
+func NewProgressHandler(progressService *service.ProgressService) *ProgressHandler {
+	return &ProgressHandler{progressService: progressService}
+}
+
+// GetStudentProfile - GET /api/v1/students/me/profile
+func (h *ProgressHandler) GetStudentProfile(c *gin.Context) {
+	profile, err := h.progressService.GetStudentProfile(c.Request.Context(), cast.ToUint(c.GetString("user_id")))
+	if err != nil {
+		respondProgressError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+// UpdateStudentProfile - PUT /api/v1/students/me/profile
+func (h *ProgressHandler) UpdateStudentProfile(c *gin.Context) {
+	var req models.StudentProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.progressService.UpdateStudentProfile(c.Request.Context(), cast.ToUint(c.GetString("user_id")), req)
+	if err != nil {
+		respondProgressError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Student profile updated successfully", "data": profile})
+}
+
+// GetMyProgress - GET /api/v1/students/me/progress
+func (h *ProgressHandler) GetMyProgress(c *gin.Context) {
+	progress, err := h.progressService.GetProgress(c.Request.Context(), cast.ToUint(c.GetString("user_id")))
+	if err != nil {
+		respondProgressError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": progress})
+}
+
+// GetStudentProgress - GET /api/v1/students/:id/progress
+func (h *ProgressHandler) GetStudentProgress(c *gin.Context) {
+	studentID := cast.ToUint(c.Param("id"))
+	if studentID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student id"})
+		return
+	}
+
+	progress, err := h.progressService.GetStudentProgress(c.Request.Context(), cast.ToUint(c.GetString("user_id")), studentID)
+	if err != nil {
+		respondProgressError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": progress})
+}
+
+// SubmitAssessment - POST /api/v1/students/assessments
+func (h *ProgressHandler) SubmitAssessment(c *gin.Context) {
+	var req models.SkillAssessmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assessment, err := h.progressService.SubmitAssessment(c.Request.Context(), cast.ToUint(c.GetString("user_id")), req)
+	if err != nil {
+		respondProgressError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Assessment saved successfully", "data": assessment})
+}
+
+func respondProgressError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.HasSuffix(msg, "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "only teachers"), strings.HasPrefix(msg, "you can only"):
+		c.JSON(http.StatusForbidden, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "failed to"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+	}
+}
@@ -55,7 +55,7 @@ func (h *UserAdminHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.CreateUser(c.Request.Context(), req)
+	user, err := h.userService.CreateUser(c.Request.Context(), cast.ToUint(c.GetString("user_id")), req)
 	if err != nil {
 		if err.Error() == "user already exists" {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
@@ -85,7 +85,7 @@ func (h *UserAdminHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.UpdateUser(c.Request.Context(), uint(userID), req)
+	user, err := h.userService.UpdateUser(c.Request.Context(), cast.ToUint(c.GetString("user_id")), uint(userID), req)
 	if err != nil {
 		if err.Error() == "user not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -109,7 +109,7 @@ func (h *UserAdminHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.DeleteUser(c.Request.Context(), uint(userID)); err != nil {
+	if err := h.userService.DeleteUser(c.Request.Context(), cast.ToUint(c.GetString("user_id")), uint(userID)); err != nil {
 		if err.Error() == "user not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
@@ -0,0 +1,49 @@
+package booking
+
+import (
+	"auth/internal/config"
+	"fmt"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// CompletedLesson is a completed lesson of a student as reported by the
+// booking service. LessonDate is YYYY-MM-DD.
+type CompletedLesson struct {
+	BookingID       uint   `json:"booking_id"`
+	TeacherID       uint   `json:"teacher_id"`
+	TeacherUserID   uint   `json:"teacher_user_id"`
+	LessonDate      string `json:"lesson_date"`
+	StartTime       string `json:"start_time"`
+	EndTime         string `json:"end_time"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+// Client calls the booking service's internal endpoints.
+type Client struct {
+	Client *resty.Client
+	Cfg    *config.Service
+}
+
+func NewBookingClient(cfg *config.Service, restyClient *resty.Client) *Client {
+	return &Client{Client: restyClient, Cfg: cfg}
+}
+
+// GetCompletedLessons returns the completed lessons of the student in
+// lesson order.
+func (c *Client) GetCompletedLessons(userID uint) ([]CompletedLesson, error) {
+	var res struct {
+		Data []CompletedLesson `json:"data"`
+	}
+	resp, err := c.Client.R().
+		SetResult(&res).
+		Get(fmt.Sprintf("%s/api/v1/internal/bookings/user/%d/completed-lessons", c.Cfg.Host, userID))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("booking service returned status: %d", resp.StatusCode())
+	}
+	return res.Data, nil
+}
@@ -0,0 +1,64 @@
+package teacher
+
+import (
+	"auth/internal/config"
+	"fmt"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Client calls the teacher service's internal endpoints that keep teacher
+// profiles linked to user accounts.
+type Client struct {
+	Client *resty.Client
+	Cfg    *config.Service
+}
+
+func NewTeacherClient(cfg *config.Service, restyClient *resty.Client) *Client {
+	return &Client{Client: restyClient, Cfg: cfg}
+}
+
+// ProvisionTeacher creates the teacher profile of the user, or restores it
+// when it was archived. It is safe to call repeatedly.
+func (c *Client) ProvisionTeacher(userID uint, name, profileImage string, changedBy uint) error {
+	return c.send(http.MethodPost, "/api/v1/internal/teachers/provision", map[string]interface{}{
+		"user_id":       userID,
+		"name":          name,
+		"profile_image": profileImage,
+		"changed_by":    changedBy,
+	})
+}
+
+// ArchiveTeacher hides the teacher profile of the user, if there is one.
+func (c *Client) ArchiveTeacher(userID, changedBy uint, reason string) error {
+	return c.send(http.MethodPost, "/api/v1/internal/teachers/archive", map[string]interface{}{
+		"user_id":    userID,
+		"changed_by": changedBy,
+		"reason":     reason,
+	})
+}
+
+// SyncTeacherProfile copies the user's name and avatar to the teacher
+// profile.
+func (c *Client) SyncTeacherProfile(userID uint, name, profileImage string) error {
+	return c.send(http.MethodPut, "/api/v1/internal/teachers/profile", map[string]interface{}{
+		"user_id":       userID,
+		"name":          name,
+		"profile_image": profileImage,
+	})
+}
+
+func (c *Client) send(method, path string, body map[string]interface{}) error {
+	resp, err := c.Client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		Execute(method, fmt.Sprintf("%s%s", c.Cfg.Host, path))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("teacher service returned status: %d", resp.StatusCode())
+	}
+	return nil
+}
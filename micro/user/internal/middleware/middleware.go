@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"auth/internal/config"
 	"auth/internal/service"
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
@@ -52,6 +54,16 @@ func AuthMiddleware(jwtConfig *service.JWTConfig) gin.HandlerFunc {
 			return
 		}
 
+		// Stream tokens only open streams.
+		if service.IsStreamToken(claims) {
+			log.Warn().Msg("Stream token used as access token")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid token",
+			})
+			c.Abort()
+			return
+		}
+
 		userID, ok := claims["user_id"].(string)
 		if !ok {
 			log.Warn().Msg("User ID not found in token")
@@ -62,9 +74,67 @@ func AuthMiddleware(jwtConfig *service.JWTConfig) gin.HandlerFunc {
 			return
 		}
 
+		role, _ := claims["role"].(string)
 		c.Set("user_id", userID)
-		c.Set("isAdmin", claims["role"] == "admin")
+		c.Set("role", role)
+		c.Set("isAdmin", role == "admin")
 		c.Set("token", tokenString)
 		c.Next()
 	}
 }
+
+// StreamAuthMiddleware authenticates an event stream by the stream token
+// in the token query parameter, or like AuthMiddleware by the
+// Authorization header for clients that can set it.
+func StreamAuthMiddleware(jwtConfig *service.JWTConfig) gin.HandlerFunc {
+	headerAuth := AuthMiddleware(jwtConfig)
+	return func(c *gin.Context) {
+		tokenString := c.Query("token")
+		if tokenString == "" {
+			headerAuth(c)
+			return
+		}
+
+		claims, err := jwtConfig.ValidateStreamToken(tokenString)
+		if err != nil {
+			log.Warn().Err(err).Msg("Invalid stream token")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid token",
+			})
+			c.Abort()
+			return
+		}
+
+		userID, ok := claims["user_id"].(string)
+		if !ok {
+			log.Warn().Msg("User ID not found in stream token")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "User ID not found in token",
+			})
+			c.Abort()
+			return
+		}
+
+		role, _ := claims["role"].(string)
+		c.Set("user_id", userID)
+		c.Set("role", role)
+		c.Set("isAdmin", role == "admin")
+		c.Next()
+	}
+}
+
+// InternalMiddleware admits only the other services, which send the secret
+// they share in config.InternalSecretHeader. Without a configured secret
+// every call is refused.
+func InternalMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := c.GetHeader(config.InternalSecretHeader)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+			log.Warn().Str("path", c.FullPath()).Msg("Internal call without a valid secret")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"auth/internal/config"
+	"auth/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testJWT = service.NewJWTConfig("test-secret", time.Hour)
+
+func serve(handler gin.HandlerFunc, target, authorization string) (int, string) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/stream", handler, func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id")+":"+c.GetString("role"))
+	})
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, w.Body.String()
+}
+
+func TestStreamAuthMiddleware(t *testing.T) {
+	streamToken, _, err := testJWT.GenerateStreamToken("7", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	access, err := testJWT.GenerateToken("7", "student", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "7", "role": "user", "purpose": "stream", "exp": time.Now().Add(-time.Minute).Unix(),
+	}).SignedString([]byte(testJWT.SecretKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		target        string
+		authorization string
+		wantCode      int
+		wantBody      string
+	}{
+		{name: "stream token", target: "/stream?token=" + streamToken, wantCode: http.StatusOK, wantBody: "7:user"},
+		{name: "access token in the header", target: "/stream", authorization: "Bearer " + access, wantCode: http.StatusOK, wantBody: "7:user"},
+		{name: "no token", target: "/stream", wantCode: http.StatusUnauthorized},
+		{name: "access token in the query", target: "/stream?token=" + access, wantCode: http.StatusUnauthorized},
+		{name: "expired stream token", target: "/stream?token=" + expired, wantCode: http.StatusUnauthorized},
+		{name: "malformed token", target: "/stream?token=abc", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := serve(StreamAuthMiddleware(testJWT), tt.target, tt.authorization)
+			if code != tt.wantCode || (tt.wantBody != "" && body != tt.wantBody) {
+				t.Errorf("got %d %q, want %d %q", code, body, tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareRejectsStreamTokens(t *testing.T) {
+	streamToken, _, err := testJWT.GenerateStreamToken("7", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := serve(AuthMiddleware(testJWT), "/stream", "Bearer "+streamToken); code != http.StatusUnauthorized {
+		t.Errorf("got %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestInternalMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		secret   string
+		given    string
+		wantCode int
+	}{
+		{name: "matching secret", secret: "s3cret", given: "s3cret", wantCode: http.StatusOK},
+		{name: "wrong secret", secret: "s3cret", given: "guess", wantCode: http.StatusUnauthorized},
+		{name: "no secret sent", secret: "s3cret", wantCode: http.StatusUnauthorized},
+		{name: "no secret configured", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.PUT("/internal", InternalMiddleware(tt.secret), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodPut, "/internal", nil)
+			if tt.given != "" {
+				req.Header.Set(config.InternalSecretHeader, tt.given)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
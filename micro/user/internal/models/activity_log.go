@@ -21,6 +21,22 @@ type ActivityLog struct {
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+// Activities the booking and payment services record for a user. The
+// internal activity route accepts no others.
+const (
+	ActivityCreatePayment  = "create_payment"
+	ActivityPaymentSuccess = "payment_success"
+	ActivityPaymentRefund  = "payment_refund"
+	ActivityWaitlistOffer  = "waitlist_offer"
+)
+
+var ServiceActivities = []string{
+	ActivityCreatePayment,
+	ActivityPaymentSuccess,
+	ActivityPaymentRefund,
+	ActivityWaitlistOffer,
+}
+
 type FavoriteTeacher struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	UserID    uint      `gorm:"index;not null" json:"user_id"`
@@ -2,6 +2,12 @@ package models
 
 import "time"
 
+// Locales emails are written in. Users pick one in their profile; without a
+// preference they get DefaultLocale.
+const DefaultLocale = "en"
+
+var Locales = []string{"id", "en", "ja"}
+
 type User struct {
 	ID              uint   `gorm:"primaryKey"`
 	Name            string `gorm:"size:100;not null"`
@@ -9,6 +15,7 @@ type User struct {
 	PasswordHash    string `gorm:"type:text;not null"`
 	Role            string `gorm:"type:enum('user','admin','teacher');not null;default:'user'"`
 	ProfileImage    string
+	Locale          string `gorm:"size:5;not null;default:'en'"`
 	ResetToken      string
 	ResetExpiration *time.Time
 	CreatedAt       time.Time
@@ -21,6 +28,7 @@ type UserResponse struct {
 	Email        string    `json:"email"`
 	Role         string    `json:"role"`
 	ProfileImage string    `json:"profile_image"`
+	Locale       string    `json:"locale"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -34,6 +42,7 @@ type UpdateProfileRequest struct {
 	Name         string `json:"name" binding:"required,min=2"`
 	Email        string `json:"email" binding:"required,email"`
 	ProfileImage string `json:"profile_image"`
+	Locale       string `json:"locale" binding:"omitempty,oneof=id en ja"`
 }
 
 type ChangePasswordRequest struct {
@@ -0,0 +1,141 @@
+package models
+
+import "time"
+
+// Notification events sent by the booking and payment services.
+const (
+	EventBookingConfirmed   = "booking_confirmed"
+	EventPaymentReceipt     = "payment_receipt"
+	EventLessonReminder24h  = "lesson_reminder_24h"
+	EventLessonReminder1h   = "lesson_reminder_1h"
+	EventBookingCancelled   = "booking_cancelled"
+	EventBookingRescheduled = "booking_rescheduled"
+
+	// Events of a teacher's request to move a lesson. Accepting it or
+	// letting it move or cancel the lesson is notified as
+	// booking_rescheduled or booking_cancelled.
+	EventRescheduleRequested = "reschedule_requested"
+	EventRescheduleWithdrawn = "reschedule_withdrawn"
+	EventRescheduleExpired   = "reschedule_expired"
+
+	EventHomeworkAssigned  = "homework_assigned"
+	EventHomeworkSubmitted = "homework_submitted"
+	EventHomeworkReviewed  = "homework_reviewed"
+)
+
+// NotificationEvents lists the events users can configure, in display
+// order.
+var NotificationEvents = []string{
+	EventBookingConfirmed,
+	EventPaymentReceipt,
+	EventLessonReminder24h,
+	EventLessonReminder1h,
+	EventBookingCancelled,
+	EventBookingRescheduled,
+	EventRescheduleRequested,
+	EventRescheduleWithdrawn,
+	EventRescheduleExpired,
+	EventHomeworkAssigned,
+	EventHomeworkSubmitted,
+	EventHomeworkReviewed,
+}
+
+// Notification channels.
+const (
+	ChannelEmail = "email"
+	ChannelInApp = "in_app"
+)
+
+var NotificationChannels = []string{ChannelEmail, ChannelInApp}
+
+// Delivery statuses of a notification.
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+	DeliverySkipped = "skipped"
+)
+
+// NotificationPreference turns one event on or off for one channel. Events
+// without a preference are enabled.
+type NotificationPreference struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"uniqueIndex:idx_notification_preference;not null" json:"-"`
+	EventType string    `gorm:"uniqueIndex:idx_notification_preference;size:50;not null" json:"event_type"`
+	Channel   string    `gorm:"uniqueIndex:idx_notification_preference;size:20;not null" json:"channel"`
+	Enabled   bool      `gorm:"not null" json:"enabled"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+type NotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences" binding:"required,dive"`
+}
+
+// NotificationLog records every notification per channel with its delivery
+// status. Reference identifies the subject of the event, e.g. "booking:12",
+// so the same event is delivered once.
+type NotificationLog struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"uniqueIndex:idx_notification_delivery;not null" json:"user_id"`
+	EventType string     `gorm:"uniqueIndex:idx_notification_delivery;size:50;not null" json:"event_type"`
+	Channel   string     `gorm:"uniqueIndex:idx_notification_delivery;size:20;not null" json:"channel"`
+	Reference string     `gorm:"uniqueIndex:idx_notification_delivery;size:100;not null" json:"reference"`
+	Subject   string     `gorm:"size:255" json:"subject"`
+	Body      string     `gorm:"type:text" json:"body"`
+	Status    string     `gorm:"size:20;index;not null" json:"status"`
+	Error     string     `gorm:"type:text" json:"error,omitempty"`
+	SentAt    *time.Time `json:"sent_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// NotificationEventRequest is posted by other services for an event of a
+// user. Data fills the event's template; Role tells the template whether
+// the user is the student or the teacher of the lesson.
+type NotificationEventRequest struct {
+	UserID    uint              `json:"user_id" binding:"required"`
+	EventType string            `json:"event_type" binding:"required"`
+	Reference string            `json:"reference" binding:"required,max=100"`
+	Role      string            `json:"role"`
+	Data      map[string]string `json:"data"`
+}
+
+// Notification is an entry in the user's in-app notification center. Type
+// is the event or activity it came from. Reference, when set, keeps the
+// same event from showing up twice.
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"uniqueIndex:idx_notification_reference;index:idx_notification_unread;not null" json:"-"`
+	Type      string     `gorm:"uniqueIndex:idx_notification_reference;size:50;not null" json:"type"`
+	Reference *string    `gorm:"uniqueIndex:idx_notification_reference;size:100" json:"reference,omitempty"`
+	Title     string     `gorm:"size:255;not null" json:"title"`
+	Message   string     `gorm:"type:text" json:"message"`
+	Link      string     `gorm:"size:255" json:"link,omitempty"`
+	ReadAt    *time.Time `gorm:"index:idx_notification_unread" json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ActivityNotification can be sent along with an activity by another
+// service to also show the activity in the user's notification center.
+// Message defaults to the activity's description.
+type ActivityNotification struct {
+	Title     string `json:"title" binding:"required,max=255"`
+	Message   string `json:"message"`
+	Link      string `json:"link" binding:"max=255"`
+	Reference string `json:"reference" binding:"max=100"`
+}
+
+// Notification stream event types.
+const (
+	NotificationStreamCreated = "notification"
+	NotificationStreamRead    = "read"
+)
+
+// NotificationStreamEvent is pushed to the user's open notification
+// streams. A read event without NotificationID means all were read.
+type NotificationStreamEvent struct {
+	Type           string        `json:"type"`
+	Notification   *Notification `json:"notification,omitempty"`
+	NotificationID uint          `json:"notification_id,omitempty"`
+	UnreadCount    int64         `json:"unread_count"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Outbox email statuses. Queued emails wait for their next attempt,
+// sending ones are held by a worker until LockedUntil, and dead ones ran
+// out of attempts.
+const (
+	OutboxQueued  = "queued"
+	OutboxSending = "sending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead"
+)
+
+// OutboxEmail is an email waiting to be sent, or the record of one that was
+// sent or gave up. NotificationLogID links the delivery log entry of a
+// notification email, which follows the outcome.
+type OutboxEmail struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	ToEmail           string     `gorm:"size:255;not null" json:"to_email"`
+	Subject           string     `gorm:"size:255;not null" json:"subject"`
+	ContentType       string     `gorm:"size:100;not null" json:"content_type"`
+	Body              string     `gorm:"type:mediumtext" json:"-"`
+	Status            string     `gorm:"size:20;not null;index:idx_outbox_due" json:"status"`
+	Attempts          int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt     time.Time  `gorm:"index:idx_outbox_due" json:"next_attempt_at"`
+	LockedUntil       *time.Time `json:"-"`
+	LastError         string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt            *time.Time `json:"sent_at"`
+	NotificationLogID *uint      `json:"notification_log_id,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,111 @@
+package models
+
+import "time"
+
+// JLPT levels from the easiest to the hardest.
+var JLPTLevels = []string{"N5", "N4", "N3", "N2", "N1"}
+
+const (
+	LevelSourceSelf       = "self"
+	LevelSourceAssessment = "assessment"
+)
+
+// StudentProfile holds the learning profile of a student. JLPTLevel is the
+// level the student currently holds or studies at, TargetLevel the one
+// they aim for.
+type StudentProfile struct {
+	UserID           uint       `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	JLPTLevel        string     `gorm:"size:2" json:"jlpt_level"`
+	TargetLevel      string     `gorm:"size:2" json:"target_level"`
+	TargetDate       *time.Time `gorm:"type:date" json:"target_date"`
+	WeeklyLessonGoal int        `gorm:"not null;default:0" json:"weekly_lesson_goal"`
+	Goals            string     `gorm:"type:text" json:"goals"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type StudentProfileRequest struct {
+	JLPTLevel        string `json:"jlpt_level"`
+	TargetLevel      string `json:"target_level"`
+	TargetDate       string `json:"target_date"`
+	WeeklyLessonGoal int    `json:"weekly_lesson_goal" binding:"min=0,max=21"`
+	Goals            string `json:"goals" binding:"max=2000"`
+}
+
+// LevelHistory records every change of a student's level, either set by
+// the student or estimated by a teacher in an assessment.
+type LevelHistory struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"index;not null" json:"user_id"`
+	Level        string    `gorm:"size:2;not null" json:"level"`
+	Source       string    `gorm:"size:20;not null" json:"source"`
+	AssessmentID *uint     `json:"assessment_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SkillAssessment is the teacher's assessment of a student after a
+// completed lesson. Skills are scored from 1 to 5, zero meaning not
+// assessed.
+type SkillAssessment struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	BookingID      uint      `gorm:"uniqueIndex;not null" json:"booking_id"`
+	UserID         uint      `gorm:"index;not null" json:"user_id"`
+	TeacherID      uint      `gorm:"not null" json:"teacher_id"`
+	TeacherUserID  uint      `gorm:"not null" json:"-"`
+	LessonDate     string    `gorm:"size:10" json:"lesson_date"`
+	Vocabulary     int       `json:"vocabulary"`
+	Grammar        int       `json:"grammar"`
+	Listening      int       `json:"listening"`
+	Speaking       int       `json:"speaking"`
+	Reading        int       `json:"reading"`
+	EstimatedLevel string    `gorm:"size:2" json:"estimated_level"`
+	Comment        string    `gorm:"type:text" json:"comment"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type SkillAssessmentRequest struct {
+	UserID         uint   `json:"user_id" binding:"required"`
+	BookingID      uint   `json:"booking_id" binding:"required"`
+	Vocabulary     int    `json:"vocabulary" binding:"min=0,max=5"`
+	Grammar        int    `json:"grammar" binding:"min=0,max=5"`
+	Listening      int    `json:"listening" binding:"min=0,max=5"`
+	Speaking       int    `json:"speaking" binding:"min=0,max=5"`
+	Reading        int    `json:"reading" binding:"min=0,max=5"`
+	EstimatedLevel string `json:"estimated_level"`
+	Comment        string `json:"comment" binding:"max=2000"`
+}
+
+// SkillAverages are the average scores over all assessments that scored
+// the skill.
+type SkillAverages struct {
+	Vocabulary float64 `json:"vocabulary"`
+	Grammar    float64 `json:"grammar"`
+	Listening  float64 `json:"listening"`
+	Speaking   float64 `json:"speaking"`
+	Reading    float64 `json:"reading"`
+}
+
+// ProgressResponse aggregates the learning progress of a student. Streaks
+// count consecutive ISO weeks with at least one completed lesson; the
+// current streak is still alive when the last such week is this or the
+// previous one.
+type ProgressResponse struct {
+	UserID            uint              `json:"user_id"`
+	LessonsCompleted  int               `json:"lessons_completed"`
+	HoursStudied      float64           `json:"hours_studied"`
+	CurrentStreak     int               `json:"current_streak_weeks"`
+	LongestStreak     int               `json:"longest_streak_weeks"`
+	LessonsThisWeek   int               `json:"lessons_this_week"`
+	WeeklyLessonGoal  int               `json:"weekly_lesson_goal"`
+	FirstLessonDate   string            `json:"first_lesson_date,omitempty"`
+	LastLessonDate    string            `json:"last_lesson_date,omitempty"`
+	CurrentLevel      string            `json:"current_level"`
+	EstimatedLevel    string            `json:"estimated_level"`
+	TargetLevel       string            `json:"target_level"`
+	TargetDate        *time.Time        `json:"target_date"`
+	LevelHistory      []LevelHistory    `json:"level_history"`
+	SkillAverages     SkillAverages     `json:"skill_averages"`
+	AssessmentCount   int               `json:"assessment_count"`
+	RecentAssessments []SkillAssessment `json:"recent_assessments"`
+}
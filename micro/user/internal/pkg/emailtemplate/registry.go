@@ -0,0 +1,213 @@
+// Package emailtemplate renders emails from a directory of named templates,
+// each with a plain-text and an HTML part, wrapped in a layout and
+// translated per locale. The directory looks like this:
+//
+//	layouts/<layout>.txt, layouts/<layout>.html  define "layout", the page around the email
+//	partials/*.txt, partials/*.html              shared snippets such as "button"
+//	<locale>/_strings.tmpl                       strings the layouts use, e.g. "greeting"
+//	<locale>/<name>.txt                          defines "subject" and "body"
+//	<locale>/<name>.html                         defines "title" and "content"
+//
+// A template missing in a locale falls back to the default locale.
+package emailtemplate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+const stringsFile = "_strings.tmpl"
+
+// Email is a rendered email. Body is the message on its own, without the
+// layout, e.g. for an in-app notification.
+type Email struct {
+	Subject string
+	Body    string
+	Text    string
+	HTML    string
+}
+
+// Registry holds the parsed templates. Register every template, then Load
+// the directory once before rendering.
+type Registry struct {
+	dir           string
+	defaultLocale string
+	locales       []string
+	layouts       map[string]string
+	variants      map[string]*variant
+}
+
+type variant struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// New creates a registry for the templates in dir. defaultLocale must be one
+// of locales.
+func New(dir, defaultLocale string, locales ...string) *Registry {
+	return &Registry{
+		dir:           dir,
+		defaultLocale: defaultLocale,
+		locales:       locales,
+		layouts:       make(map[string]string),
+		variants:      make(map[string]*variant),
+	}
+}
+
+// Register adds a template rendered in the given layout.
+func (r *Registry) Register(name, layout string) {
+	r.layouts[name] = layout
+}
+
+// Names returns the registered templates in alphabetical order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.layouts))
+	for name := range r.layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Locales returns the supported locales.
+func (r *Registry) Locales() []string {
+	return slices.Clone(r.locales)
+}
+
+// Load parses every registered template in every locale. The default
+// locale has to have all of them.
+func (r *Registry) Load() error {
+	if !slices.Contains(r.locales, r.defaultLocale) {
+		return fmt.Errorf("default locale %q is not supported", r.defaultLocale)
+	}
+
+	variants := make(map[string]*variant)
+	for name, layout := range r.layouts {
+		for _, locale := range r.locales {
+			textFile := filepath.Join(r.dir, locale, name+".txt")
+			if _, err := os.Stat(textFile); errors.Is(err, os.ErrNotExist) && locale != r.defaultLocale {
+				continue
+			}
+			v, err := r.parse(name, layout, locale)
+			if err != nil {
+				return fmt.Errorf("template %s (%s): %w", name, locale, err)
+			}
+			variants[name+"/"+locale] = v
+		}
+	}
+	r.variants = variants
+	return nil
+}
+
+func (r *Registry) parse(name, layout, locale string) (*variant, error) {
+	files := func(ext string) ([]string, error) {
+		partials, err := filepath.Glob(filepath.Join(r.dir, "partials", "*"+ext))
+		if err != nil {
+			return nil, err
+		}
+		files := []string{filepath.Join(r.dir, "layouts", layout+ext)}
+		files = append(files, partials...)
+		return append(files, filepath.Join(r.dir, locale, stringsFile), filepath.Join(r.dir, locale, name+ext)), nil
+	}
+
+	textFiles, err := files(".txt")
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New(name).Funcs(funcs).ParseFiles(textFiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlFiles, err := files(".html")
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.New(name).Funcs(funcs).ParseFiles(htmlFiles...)
+	if err != nil {
+		return nil, err
+	}
+	return &variant{text: text, html: html}, nil
+}
+
+// Locale maps a preference such as "ja" or "id-ID" to a supported locale,
+// or the default one.
+func (r *Registry) Locale(preference string) string {
+	preference = strings.ToLower(strings.TrimSpace(preference))
+	if i := strings.IndexAny(preference, "-_"); i >= 0 {
+		preference = preference[:i]
+	}
+	if slices.Contains(r.locales, preference) {
+		return preference
+	}
+	return r.defaultLocale
+}
+
+// Render renders a template in the preferred locale. data is passed to the
+// templates with Locale set to the locale actually used.
+func (r *Registry) Render(name, locale string, data map[string]interface{}) (*Email, error) {
+	locale = r.Locale(locale)
+	v, ok := r.variants[name+"/"+locale]
+	if !ok {
+		locale = r.defaultLocale
+		if v, ok = r.variants[name+"/"+locale]; !ok {
+			return nil, fmt.Errorf("email template %q not found", name)
+		}
+	}
+
+	values := make(map[string]interface{}, len(data)+1)
+	for key, value := range data {
+		values[key] = value
+	}
+	values["Locale"] = locale
+
+	var subject, body, text, html bytes.Buffer
+	if err := v.text.ExecuteTemplate(&subject, "subject", values); err != nil {
+		return nil, err
+	}
+	if err := v.text.ExecuteTemplate(&body, "body", values); err != nil {
+		return nil, err
+	}
+	if err := v.text.ExecuteTemplate(&text, "layout", values); err != nil {
+		return nil, err
+	}
+	if err := v.html.ExecuteTemplate(&html, "layout", values); err != nil {
+		return nil, err
+	}
+	return &Email{
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    strings.TrimSpace(html.String()) + "\n",
+	}, nil
+}
+
+// funcs are available in every template. dict and list build the
+// arguments of partials, e.g. {{ template "button" dict "url" .Link "label" "Open" }}.
+var funcs = map[string]interface{}{
+	"dict": func(pairs ...interface{}) (map[string]interface{}, error) {
+		if len(pairs)%2 != 0 {
+			return nil, errors.New("dict needs key and value pairs")
+		}
+		dict := make(map[string]interface{}, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			key, ok := pairs[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("dict key %v is not a string", pairs[i])
+			}
+			dict[key] = pairs[i+1]
+		}
+		return dict, nil
+	},
+	"list": func(items ...interface{}) []interface{} {
+		return items
+	},
+}
@@ -0,0 +1,360 @@
+// Package smtptest runs an in-process SMTP server for tests. It speaks
+// enough ESMTP for net/smtp (EHLO, STARTTLS, AUTH PLAIN, MAIL, RCPT, DATA),
+// records every accepted message and can be told to reject deliveries.
+package smtptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is an email accepted by the server.
+type Message struct {
+	From string
+	To   []string
+	// Data is the raw message as sent after DATA, headers included.
+	Data string
+}
+
+// Header returns the value of a message header, or "" if it is missing.
+// Encoded words such as =?UTF-8?q?...?= are decoded.
+func (m Message) Header(key string) string {
+	msg, err := mail.ReadMessage(strings.NewReader(m.Data))
+	if err != nil {
+		return ""
+	}
+	value := msg.Header.Get(key)
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(value); err == nil {
+		return decoded
+	}
+	return value
+}
+
+// Part is one part of a multipart message.
+type Part struct {
+	ContentType string
+	Body        string
+}
+
+// Parts returns the parts of a multipart message, with their transfer
+// encoding undone.
+func (m Message) Parts() ([]Part, error) {
+	msg, err := mail.ReadMessage(strings.NewReader(m.Data))
+	if err != nil {
+		return nil, err
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, errors.New("smtptest: message is not multipart")
+	}
+
+	var parts []Part
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, Part{ContentType: part.Header.Get("Content-Type"), Body: string(body)})
+	}
+}
+
+// Body returns the message without its headers.
+func (m Message) Body() string {
+	msg, err := mail.ReadMessage(strings.NewReader(m.Data))
+	if err != nil {
+		return ""
+	}
+	body, _ := io.ReadAll(msg.Body)
+	return string(body)
+}
+
+// Server is a fake SMTP server listening on 127.0.0.1. Clients connect in
+// plain text and upgrade with STARTTLS using a self-signed certificate, so
+// they have to skip certificate verification.
+type Server struct {
+	// Username and Password are the credentials AUTH PLAIN accepts. When
+	// Username is empty any credentials are accepted.
+	Username string
+	Password string
+
+	listener  net.Listener
+	tlsConfig *tls.Config
+
+	mu       sync.Mutex
+	messages []Message
+	failures int
+
+	wg sync.WaitGroup
+}
+
+// NewServer starts a server on a random local port. It panics if the
+// server cannot start, like httptest.NewServer.
+func NewServer() *Server {
+	cert, err := selfSignedCert()
+	if err != nil {
+		panic("smtptest: failed to create certificate: " + err.Error())
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("smtptest: failed to listen: " + err.Error())
+	}
+
+	s := &Server{
+		listener:  listener,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Host returns the address the server listens on.
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on.
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops the server and waits for open sessions to end.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// Messages returns the messages accepted so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// FailNext makes the next n deliveries fail with a temporary error at
+// DATA, as a mail server that is down or overloaded would.
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// session is the state of one client connection.
+type session struct {
+	conn   net.Conn
+	text   *textproto.Conn
+	tls    bool
+	authed bool
+	from   string
+	to     []string
+}
+
+func (s *Server) handle(conn net.Conn) {
+	sess := &session{conn: conn, text: textproto.NewConn(conn)}
+	defer func() { sess.text.Close() }()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	sess.reply(220, "smtptest ESMTP ready")
+	for {
+		line, err := sess.text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			sess.reset()
+			if sess.tls {
+				sess.text.PrintfLine("250-smtptest\r\n250 AUTH PLAIN")
+			} else {
+				sess.text.PrintfLine("250-smtptest\r\n250-STARTTLS\r\n250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			if sess.tls {
+				sess.reply(503, "already running TLS")
+				continue
+			}
+			sess.reply(220, "ready to start TLS")
+			tlsConn := tls.Server(sess.conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			sess.conn = tlsConn
+			sess.text = textproto.NewConn(tlsConn)
+			sess.tls = true
+			sess.authed = false
+			sess.reset()
+		case "AUTH":
+			s.auth(sess, arg)
+		case "MAIL":
+			if !sess.authed {
+				sess.reply(530, "authentication required")
+				continue
+			}
+			sess.from = address(arg, "FROM:")
+			sess.reply(250, "ok")
+		case "RCPT":
+			if sess.from == "" {
+				sess.reply(503, "need MAIL first")
+				continue
+			}
+			sess.to = append(sess.to, address(arg, "TO:"))
+			sess.reply(250, "ok")
+		case "DATA":
+			s.data(sess)
+		case "RSET":
+			sess.reset()
+			sess.reply(250, "ok")
+		case "NOOP":
+			sess.reply(250, "ok")
+		case "QUIT":
+			sess.reply(221, "bye")
+			return
+		default:
+			sess.reply(502, "command not implemented")
+		}
+	}
+}
+
+func (s *Server) auth(sess *session, arg string) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mechanism, "PLAIN") {
+		sess.reply(504, "unrecognized authentication type")
+		return
+	}
+	if initial == "" {
+		sess.reply(334, "")
+		line, err := sess.text.ReadLine()
+		if err != nil {
+			return
+		}
+		initial = line
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(initial)
+	if err != nil {
+		sess.reply(501, "invalid credentials encoding")
+		return
+	}
+	// identity \0 username \0 password
+	parts := strings.Split(string(decoded), "\x00")
+	if len(parts) != 3 || (s.Username != "" && (parts[1] != s.Username || parts[2] != s.Password)) {
+		sess.reply(535, "authentication failed")
+		return
+	}
+	sess.authed = true
+	sess.reply(235, "authentication successful")
+}
+
+func (s *Server) data(sess *session) {
+	if len(sess.to) == 0 {
+		sess.reply(503, "need RCPT first")
+		return
+	}
+
+	s.mu.Lock()
+	fail := s.failures > 0
+	if fail {
+		s.failures--
+	}
+	s.mu.Unlock()
+	if fail {
+		sess.reset()
+		sess.reply(451, "temporary failure, try again later")
+		return
+	}
+
+	sess.reply(354, "end data with <CR><LF>.<CR><LF>")
+	data, err := sess.text.ReadDotBytes()
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, Message{From: sess.from, To: sess.to, Data: string(data)})
+	s.mu.Unlock()
+	sess.reset()
+	sess.reply(250, "ok: queued")
+}
+
+func (sess *session) reply(code int, msg string) {
+	sess.text.PrintfLine("%d %s", code, msg)
+}
+
+func (sess *session) reset() {
+	sess.from = ""
+	sess.to = nil
+}
+
+// address takes the mailbox out of "FROM:<a@b>" or "TO:<a@b>".
+func address(arg, prefix string) string {
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = arg[len(prefix):]
+	}
+	arg, _, _ = strings.Cut(strings.TrimSpace(arg), " ")
+	return strings.Trim(arg, "<>")
+}
+
+func selfSignedCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"smtptest"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
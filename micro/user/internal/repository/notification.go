@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"auth/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepository stores notification preferences and the delivery
+// log.
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+// SavePreferences creates or updates the given preferences of the user.
+func (r *NotificationRepository) SavePreferences(ctx context.Context, preferences []models.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&preferences).Error
+}
+
+// CreateLog records a delivery. It reports false when the event was
+// already delivered on the channel.
+func (r *NotificationRepository) CreateLog(ctx context.Context, entry *models.NotificationLog) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	return result.RowsAffected > 0, result.Error
+}
+
+// SetLogStatus stores the outcome of a delivery.
+func (r *NotificationRepository) SetLogStatus(ctx context.Context, id uint, status, deliveryErr string, sentAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.NotificationLog{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "error": deliveryErr, "sent_at": sentAt}).Error
+}
+
+// GetLogs returns delivery log entries, newest first. Zero values leave a
+// filter out.
+func (r *NotificationRepository) GetLogs(ctx context.Context, userID uint, status, eventType string, page, limit int) ([]models.NotificationLog, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := r.db.WithContext(ctx).Model(&models.NotificationLog{})
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []models.NotificationLog
+	err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&logs).Error
+	return logs, total, err
+}
+
+// CreateNotification adds a notification to the user's notification
+// center. It reports false when one with the same type and reference
+// already exists.
+func (r *NotificationRepository) CreateNotification(ctx context.Context, notification *models.Notification) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(notification)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetNotifications returns the user's notifications, newest first.
+func (r *NotificationRepository) GetNotifications(ctx context.Context, userID uint, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := r.db.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.Notification
+	err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (r *NotificationRepository) CountUnreadNotifications(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkNotificationRead marks one of the user's notifications read. It
+// reports false when the user has no such notification; marking a read
+// one again is not an error.
+func (r *NotificationRepository) MarkNotificationRead(ctx context.Context, userID, id uint, at time.Time) (bool, error) {
+	var notification models.Notification
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&notification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if notification.ReadAt != nil {
+		return true, nil
+	}
+	err = r.db.WithContext(ctx).Model(&notification).Update("read_at", at).Error
+	return err == nil, err
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user read
+// and returns how many there were.
+func (r *NotificationRepository) MarkAllNotificationsRead(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", at)
+	return result.RowsAffected, result.Error
+}
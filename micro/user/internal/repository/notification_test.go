@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"auth/internal/models"
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// dryRunDB builds SQL without a database and records each statement. load,
+// if set, stands in for the database when a query runs.
+func dryRunDB(t *testing.T, load func(tx *gorm.DB)) (*gorm.DB, *[]string) {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/users",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statements []string
+	record := func(tx *gorm.DB) {
+		statements = append(statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	}
+	callbacks := db.Callback()
+	err = callbacks.Query().After("gorm:query").Register("test:load", func(tx *gorm.DB) {
+		record(tx)
+		if load != nil {
+			load(tx)
+		}
+	})
+	if err == nil {
+		err = callbacks.Create().After("gorm:create").Register("test:record", record)
+	}
+	if err == nil {
+		err = callbacks.Update().After("gorm:update").Register("test:record", record)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, &statements
+}
+
+func TestNotificationReferenceIsUnique(t *testing.T) {
+	s, err := schema.Parse(&models.Notification{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := s.LookIndex("idx_notification_reference")
+	if index == nil {
+		t.Fatal("idx_notification_reference not found")
+	}
+	var columns []string
+	for _, field := range index.Fields {
+		columns = append(columns, field.DBName)
+	}
+	if index.Class != "UNIQUE" || !reflect.DeepEqual(columns, []string{"user_id", "type", "reference"}) {
+		t.Errorf("index is %s %v, want UNIQUE [user_id type reference]", index.Class, columns)
+	}
+}
+
+func TestCreateNotificationIgnoresDuplicates(t *testing.T) {
+	db, statements := dryRunDB(t, nil)
+	reference := "refund:4"
+	notification := &models.Notification{UserID: 7, Type: "payment_refund", Reference: &reference, Title: "Refunded"}
+
+	created, err := NewNotificationRepository(db).CreateNotification(context.Background(), notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created {
+		t.Error("CreateNotification() reported a row that was not inserted")
+	}
+	if len(*statements) != 1 || !strings.Contains((*statements)[0], "ON DUPLICATE KEY UPDATE `id`=`id`") {
+		t.Errorf("statements = %q, want an insert that ignores duplicates", *statements)
+	}
+}
+
+func TestMarkNotificationRead(t *testing.T) {
+	readAt := time.Date(2025, time.March, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		load       func(tx *gorm.DB)
+		wantFound  bool
+		wantUpdate bool
+	}{
+		{
+			name: "unread",
+			load: func(tx *gorm.DB) {
+				tx.Statement.Dest.(*models.Notification).ID = 5
+			},
+			wantFound:  true,
+			wantUpdate: true,
+		},
+		{
+			name: "already read",
+			load: func(tx *gorm.DB) {
+				notification := tx.Statement.Dest.(*models.Notification)
+				notification.ID = 5
+				notification.ReadAt = &readAt
+			},
+			wantFound: true,
+		},
+		{
+			name: "not the user's",
+			load: func(tx *gorm.DB) {
+				tx.AddError(gorm.ErrRecordNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := dryRunDB(t, tt.load)
+			found, err := NewNotificationRepository(db).MarkNotificationRead(context.Background(), 7, 5, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found != tt.wantFound {
+				t.Errorf("found = %v, want %v", found, tt.wantFound)
+			}
+			if len(*statements) == 0 || !strings.Contains((*statements)[0], "WHERE id = 5 AND user_id = 7") {
+				t.Fatalf("statements = %q, want the lookup scoped to the user", *statements)
+			}
+			updated := len(*statements) == 2 && strings.HasPrefix((*statements)[1], "UPDATE `notifications` SET `read_at`=")
+			if updated != tt.wantUpdate || len(*statements) > 2 {
+				t.Errorf("statements = %q, want update %v", *statements, tt.wantUpdate)
+			}
+		})
+	}
+}
+
+func TestMarkAllNotificationsRead(t *testing.T) {
+	db, statements := dryRunDB(t, nil)
+	if _, err := NewNotificationRepository(db).MarkAllNotificationsRead(context.Background(), 7, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) != 1 || !strings.HasSuffix((*statements)[0], "WHERE user_id = 7 AND read_at IS NULL") {
+		t.Errorf("statements = %q, want only the user's unread notifications updated", *statements)
+	}
+}
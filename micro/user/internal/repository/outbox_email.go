@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"auth/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxEmailRepository stores outgoing emails until a worker sent them.
+type OutboxEmailRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxEmailRepository(db *gorm.DB) *OutboxEmailRepository {
+	return &OutboxEmailRepository{db: db}
+}
+
+func (r *OutboxEmailRepository) Create(ctx context.Context, email *models.OutboxEmail) error {
+	return r.db.WithContext(ctx).Create(email).Error
+}
+
+// GetDue returns up to limit emails ready for an attempt: queued ones whose
+// time has come and sending ones whose worker lock ran out.
+func (r *OutboxEmailRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]models.OutboxEmail, error) {
+	var emails []models.OutboxEmail
+	err := r.db.WithContext(ctx).
+		Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_until < ?)",
+			models.OutboxQueued, now, models.OutboxSending, now).
+		Order("next_attempt_at").Order("id").Limit(limit).Find(&emails).Error
+	return emails, err
+}
+
+// Claim locks the email for one attempt and reports whether it was still
+// due, so that two workers never send the same email at once.
+func (r *OutboxEmailRepository) Claim(ctx context.Context, id uint, now, lockedUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.OutboxEmail{}).
+		Where("id = ? AND ((status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_until < ?))",
+			id, models.OutboxQueued, now, models.OutboxSending, now).
+		Updates(map[string]interface{}{"status": models.OutboxSending, "locked_until": lockedUntil})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SaveOutcome stores the result of an attempt. Once the email was sent or
+// gave up, the linked notification log entry gets the same outcome.
+func (r *OutboxEmailRepository) SaveOutcome(ctx context.Context, email *models.OutboxEmail) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(email).Select("status", "attempts", "next_attempt_at", "locked_until", "last_error", "sent_at").
+			Updates(email).Error
+		if err != nil || email.NotificationLogID == nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		switch email.Status {
+		case models.OutboxSent:
+			updates = map[string]interface{}{"status": models.DeliverySent, "error": "", "sent_at": email.SentAt}
+		case models.OutboxDead:
+			updates = map[string]interface{}{"status": models.DeliveryFailed, "error": email.LastError}
+		default:
+			return nil
+		}
+		return tx.Model(&models.NotificationLog{}).Where("id = ?", *email.NotificationLogID).Updates(updates).Error
+	})
+}
+
+// GetEmails returns outbox emails, newest first, optionally of one status.
+func (r *OutboxEmailRepository) GetEmails(ctx context.Context, status string, page, limit int) ([]models.OutboxEmail, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := r.db.WithContext(ctx).Model(&models.OutboxEmail{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var emails []models.OutboxEmail
+	err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&emails).Error
+	return emails, total, err
+}
+
+// Requeue gives a dead email a fresh set of attempts.
+func (r *OutboxEmailRepository) Requeue(ctx context.Context, id uint, now time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.OutboxEmail{}).
+		Where("id = ? AND status = ?", id, models.OutboxDead).
+		Updates(map[string]interface{}{
+			"status":          models.OutboxQueued,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"locked_until":    nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := r.db.WithContext(ctx).Model(&models.OutboxEmail{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("email not found")
+		}
+		return errors.New("only dead emails can be retried")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"auth/internal/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ProgressRepository stores student profiles, level history and the skill
+// assessments teachers submit after lessons.
+type ProgressRepository struct {
+	db *gorm.DB
+}
+
+func NewProgressRepository(db *gorm.DB) *ProgressRepository {
+	return &ProgressRepository{db: db}
+}
+
+// GetStudentProfile returns the profile of the student, or nil when they
+// have not filled it in yet.
+func (r *ProgressRepository) GetStudentProfile(ctx context.Context, userID uint) (*models.StudentProfile, error) {
+	var profile models.StudentProfile
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// SaveStudentProfile stores the profile and, when given, the level change
+// that comes with it.
+func (r *ProgressRepository) SaveStudentProfile(ctx context.Context, profile *models.StudentProfile, change *models.LevelHistory) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(profile).Error; err != nil {
+			return err
+		}
+		if change != nil {
+			return tx.Create(change).Error
+		}
+		return nil
+	})
+}
+
+func (r *ProgressRepository) GetLevelHistory(ctx context.Context, userID uint) ([]models.LevelHistory, error) {
+	var history []models.LevelHistory
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at").Order("id").Find(&history).Error
+	return history, err
+}
+
+func (r *ProgressRepository) GetAssessmentByBooking(ctx context.Context, bookingID uint) (*models.SkillAssessment, error) {
+	var assessment models.SkillAssessment
+	err := r.db.WithContext(ctx).Where("booking_id = ?", bookingID).First(&assessment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &assessment, nil
+}
+
+// SaveAssessment stores the assessment and records its estimated level in
+// the student's level history when it differs from the latest entry.
+func (r *ProgressRepository) SaveAssessment(ctx context.Context, assessment *models.SkillAssessment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(assessment).Error; err != nil {
+			return err
+		}
+		if assessment.EstimatedLevel == "" {
+			return nil
+		}
+
+		var latest models.LevelHistory
+		err := tx.Where("user_id = ? AND source = ?", assessment.UserID, models.LevelSourceAssessment).
+			Order("id DESC").First(&latest).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && latest.Level == assessment.EstimatedLevel {
+			return nil
+		}
+		return tx.Create(&models.LevelHistory{
+			UserID:       assessment.UserID,
+			Level:        assessment.EstimatedLevel,
+			Source:       models.LevelSourceAssessment,
+			AssessmentID: &assessment.ID,
+		}).Error
+	})
+}
+
+// GetAssessments returns the student's assessments, latest lesson first.
+func (r *ProgressRepository) GetAssessments(ctx context.Context, userID uint) ([]models.SkillAssessment, error) {
+	var assessments []models.SkillAssessment
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("lesson_date DESC").Order("id DESC").Find(&assessments).Error
+	return assessments, err
+}
@@ -72,6 +72,14 @@ func (r *UserRepository) GetUserById(ctx context.Context, id uint) (*models.User
 	return &user, nil
 }
 
+func (r *UserRepository) GetUsersByIDs(ctx context.Context, ids []uint) ([]models.User, error) {
+	var users []models.User
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
 	return r.db.WithContext(ctx).Save(user).Error
 }
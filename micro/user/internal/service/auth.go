@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -38,3 +39,53 @@ func (c *JWTConfig) ValidateToken(tokenString string) (*jwt.Token, error) {
 		return []byte(c.SecretKey), nil
 	})
 }
+
+// streamTokenPurpose marks the short-lived tokens that only open event
+// streams.
+const streamTokenPurpose = "stream"
+
+// StreamTokenTTL is how long a stream token can be used to open a stream.
+// A stream opened with it stays open after it expired.
+const StreamTokenTTL = time.Minute
+
+// GenerateStreamToken issues a token that opens the event streams of the
+// user. Browsers cannot set headers on an EventSource, so it is passed as
+// the token query parameter instead of the user's access token.
+func (c *JWTConfig) GenerateStreamToken(userID, role string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(StreamTokenTTL)
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"role":    role,
+		"purpose": streamTokenPurpose,
+		"exp":     expiresAt.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(c.SecretKey))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// ValidateStreamToken validates a token from GenerateStreamToken and
+// returns its claims. Access tokens are rejected.
+func (c *JWTConfig) ValidateStreamToken(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(c.SecretKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !IsStreamToken(claims) {
+		return nil, errors.New("not a stream token")
+	}
+	return claims, nil
+}
+
+// IsStreamToken reports whether the claims are of a stream token, which
+// must not be accepted as an access token.
+func IsStreamToken(claims jwt.MapClaims) bool {
+	return claims["purpose"] == streamTokenPurpose
+}
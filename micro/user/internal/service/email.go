@@ -1,66 +1,291 @@
 package service
 
 import (
+	"auth/internal/models"
+	"auth/internal/pkg/emailtemplate"
+	"auth/internal/repository"
 	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net"
 	"net/smtp"
-	"path/filepath"
-	"text/template"
+	"net/textproto"
 	"time"
 )
 
+const (
+	outboxTick           = 10 * time.Second
+	outboxBatch          = 50
+	defaultOutboxRetries = 6
+	defaultOutboxBackoff = time.Minute
+	// maxOutboxBackoff caps the wait between two attempts.
+	maxOutboxBackoff = time.Hour
+)
+
 type EmailService struct {
 	smtpHost           string
 	smtpPort           int
 	smtpUsername       string
 	smtpPassword       string
 	fromEmail          string
-	templatePath       string
+	templates          *emailtemplate.Registry
 	logoURL            string
 	timeOutDuration    int
 	insecureSkipVerify bool
 	useTLS             bool
+
+	// Emails are queued in the outbox and sent by RunOutbox, retried with
+	// exponential backoff from retryBase until maxAttempts.
+	outbox      *repository.OutboxEmailRepository
+	maxAttempts int
+	retryBase   time.Duration
 }
 
 func NewEmailService(
 	smtpHost string,
 	smtpPort, timeOutDuration int,
-	smtpUsername, smtpPassword, fromEmail, templatePath, logoURL string,
+	smtpUsername, smtpPassword, fromEmail string,
+	templates *emailtemplate.Registry,
+	logoURL string,
 	insecureSkipVerify, useTLS bool,
+	outbox *repository.OutboxEmailRepository,
+	maxAttempts int,
+	retryBase time.Duration,
 ) *EmailService {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultOutboxRetries
+	}
+	if retryBase <= 0 {
+		retryBase = defaultOutboxBackoff
+	}
 	return &EmailService{
 		smtpHost:           smtpHost,
 		smtpPort:           smtpPort,
 		smtpUsername:       smtpUsername,
 		smtpPassword:       smtpPassword,
 		fromEmail:          fromEmail,
-		templatePath:       templatePath,
+		templates:          templates,
 		logoURL:            logoURL,
 		timeOutDuration:    timeOutDuration,
 		useTLS:             useTLS,             // Use TLS if port is 465
 		insecureSkipVerify: insecureSkipVerify, // Set to true if you want to skip TLS verification
+		outbox:             outbox,
+		maxAttempts:        maxAttempts,
+		retryBase:          retryBase,
 	}
 }
 
-func (s *EmailService) SendPasswordResetEmail(toEmail, resetLink string) error {
-	log.Printf("Sending password reset email to %s", toEmail)
+// SendPasswordResetEmail sends the reset link in the user's language.
+func (s *EmailService) SendPasswordResetEmail(toEmail, name, locale, resetLink string) error {
+	email, err := s.Render(emailTemplateResetPassword, locale, map[string]interface{}{
+		"Name":      name,
+		"ResetLink": resetLink,
+	})
+	if err != nil {
+		log.Printf("Template rendering error: %v", err)
+		return err
+	}
 
-	subject := "Reset Password - Booking App"
+	if err := s.SendEmail(toEmail, email, nil); err != nil {
+		log.Printf("Password reset email to %s could not be queued: %v", toEmail, err)
+		return err
+	}
+	log.Printf("Password reset email queued for %s", toEmail)
+	return nil
+}
+
+// Render renders a registered email template in the preferred locale. The
+// app name, logo and year the layouts show are added to data.
+func (s *EmailService) Render(name, locale string, data map[string]interface{}) (*emailtemplate.Email, error) {
+	if s.templates == nil {
+		return nil, errors.New("email templates not loaded")
+	}
+	values := map[string]interface{}{
+		"AppName": emailAppName,
+		"LogoURL": s.logoURL,
+		"Year":    fmt.Sprintf("%d", time.Now().Year()),
+	}
+	for key, value := range data {
+		values[key] = value
+	}
+	return s.templates.Render(name, locale, values)
+}
 
-	htmlBody, err := s.renderEmailTemplate(resetLink)
+// SendEmail sends a rendered email as multipart/alternative with its text
+// and HTML parts. The delivery log entry of a notification, if given,
+// follows the outcome.
+func (s *EmailService) SendEmail(toEmail string, email *emailtemplate.Email, notificationLogID *uint) error {
+	contentType, body, err := multipartAlternative(email.Text, email.HTML)
 	if err != nil {
-		log.Printf("Template rendering error: %v", err)
 		return err
 	}
+	if err := s.send(toEmail, email.Subject+" - "+emailAppName, contentType, body, notificationLogID); err != nil {
+		log.Printf("Email to %s could not be queued: %v", toEmail, err)
+		return err
+	}
+	return nil
+}
+
+// multipartAlternative builds a MIME body with a plain-text and an HTML
+// part, the latter preferred by clients that can show it.
+func multipartAlternative(text, html string) (string, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return "", "", err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return "", "", err
+		}
+		if err := qp.Close(); err != nil {
+			return "", "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", "", err
+	}
+	return "multipart/alternative; boundary=" + writer.Boundary(), buf.String(), nil
+}
+
+// send queues the email in the outbox. Without an outbox it is sent right
+// away.
+func (s *EmailService) send(toEmail, subject, contentType, body string, notificationLogID *uint) error {
+	if s.outbox == nil {
+		return s.deliver(toEmail, subject, contentType, body)
+	}
+	return s.outbox.Create(context.Background(), &models.OutboxEmail{
+		ToEmail:           toEmail,
+		Subject:           subject,
+		ContentType:       contentType,
+		Body:              body,
+		Status:            models.OutboxQueued,
+		NextAttemptAt:     time.Now(),
+		NotificationLogID: notificationLogID,
+	})
+}
+
+// RunOutbox sends the queued emails until the process exits.
+func (s *EmailService) RunOutbox() {
+	ticker := time.NewTicker(outboxTick)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.processOutbox(time.Now())
+	}
+}
+
+func (s *EmailService) processOutbox(now time.Time) {
+	ctx := context.Background()
+	emails, err := s.outbox.GetDue(ctx, now, outboxBatch)
+	if err != nil {
+		log.Printf("Email outbox: failed to get due emails: %v", err)
+		return
+	}
+
+	// A worker that dies mid-send leaves the email locked; it is picked up
+	// again once the lock runs out.
+	lease := time.Duration(s.timeOutDuration)*time.Second + time.Minute
+	for i := range emails {
+		email := &emails[i]
+		claimed, err := s.outbox.Claim(ctx, email.ID, now, time.Now().Add(lease))
+		if err != nil {
+			log.Printf("Email outbox: failed to claim email %d: %v", email.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		s.attempt(email, time.Now())
+		if err := s.outbox.SaveOutcome(ctx, email); err != nil {
+			log.Printf("Email outbox: failed to save outcome of email %d: %v", email.ID, err)
+		}
+	}
+}
+
+// attempt sends the email once and records the outcome on it: sent, queued
+// for another attempt after a backoff, or dead after the last attempt.
+func (s *EmailService) attempt(email *models.OutboxEmail, now time.Time) {
+	email.Attempts++
+	email.LockedUntil = nil
 
+	err := s.deliver(email.ToEmail, email.Subject, email.ContentType, email.Body)
+	if err == nil {
+		email.Status = models.OutboxSent
+		email.SentAt = &now
+		email.LastError = ""
+		log.Printf("Email %d successfully sent to %s", email.ID, email.ToEmail)
+		return
+	}
+
+	email.LastError = err.Error()
+	if email.Attempts >= s.maxAttempts {
+		email.Status = models.OutboxDead
+		log.Printf("Email %d to %s failed for good after %d attempts: %v", email.ID, email.ToEmail, email.Attempts, err)
+		return
+	}
+	email.Status = models.OutboxQueued
+	email.NextAttemptAt = now.Add(s.retryDelay(email.Attempts))
+	log.Printf("Email %d to %s failed, attempt %d: %v", email.ID, email.ToEmail, email.Attempts, err)
+}
+
+// retryDelay doubles the wait after every failed attempt.
+func (s *EmailService) retryDelay(attempts int) time.Duration {
+	delay := s.retryBase
+	for i := 1; i < attempts && delay < maxOutboxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxOutboxBackoff {
+		delay = maxOutboxBackoff
+	}
+	return delay
+}
+
+// GetOutbox lists outbox emails, newest first, optionally of one status.
+func (s *EmailService) GetOutbox(ctx context.Context, status string, page, limit int) ([]models.OutboxEmail, int64, error) {
+	emails, total, err := s.outbox.GetEmails(ctx, status, page, limit)
+	if err != nil {
+		log.Printf("Email outbox: failed to list emails: %v", err)
+		return nil, 0, errors.New("failed to get emails")
+	}
+	if emails == nil {
+		emails = []models.OutboxEmail{}
+	}
+	return emails, total, nil
+}
+
+// RetryEmail queues a dead email again with a fresh set of attempts.
+func (s *EmailService) RetryEmail(ctx context.Context, id uint) error {
+	if err := s.outbox.Requeue(ctx, id, time.Now()); err != nil {
+		switch err.Error() {
+		case "email not found", "only dead emails can be retried":
+			return err
+		}
+		log.Printf("Email outbox: failed to requeue email %d: %v", id, err)
+		return errors.New("failed to retry email")
+	}
+	return nil
+}
+
+// deliver sends the email over SMTP.
+func (s *EmailService) deliver(toEmail, subject, contentType, body string) error {
 	message := fmt.Sprintf(
 		"From: %s\r\nTo: %s\r\nSubject: %s\r\n"+
-			"MIME-Version: 1.0\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s",
-		s.fromEmail, toEmail, subject, htmlBody)
+			"MIME-Version: 1.0\r\nContent-Type: %s\r\n\r\n%s",
+		s.fromEmail, toEmail, mime.QEncoding.Encode("UTF-8", subject), contentType, body)
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.timeOutDuration)*time.Second)
 	defer cancel()
@@ -96,7 +321,6 @@ func (s *EmailService) SendPasswordResetEmail(toEmail, resetLink string) error {
 		log.Printf("Close error: %v", err)
 		return err
 	}
-	log.Printf("Password reset email successfully sent to %s", toEmail)
 	return nil
 }
 
@@ -146,23 +370,3 @@ func (s *EmailService) connectSMTP(ctx context.Context) (*smtp.Client, error) {
 
 	return client, nil
 }
-
-func (s *EmailService) renderEmailTemplate(resetLink string) (string, error) {
-	tmpl, err := template.ParseFiles(filepath.Clean(s.templatePath))
-	if err != nil {
-		return "", err
-	}
-
-	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, map[string]string{
-		"ResetLink": resetLink,
-		"LogoURL":   s.logoURL,
-		"Year":      fmt.Sprintf("%d", time.Now().Year()),
-		"AppName":   "Booking App",
-	})
-	if err != nil {
-		return "", err
-	}
-
-	return buf.String(), nil
-}
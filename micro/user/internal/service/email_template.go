@@ -0,0 +1,35 @@
+package service
+
+import (
+	"auth/internal/models"
+	"auth/internal/pkg/emailtemplate"
+)
+
+const (
+	defaultEmailTemplateDir = "templates/email"
+	emailAppName            = "Booking App"
+
+	emailTemplateResetPassword = "reset_password"
+
+	// Transactional emails are always sent; notification emails mention
+	// that they can be turned off.
+	layoutTransactional = "transactional"
+	layoutNotification  = "notification"
+)
+
+// LoadEmailTemplates registers every email the service sends, one per
+// notification event plus the password reset, and parses them from dir.
+func LoadEmailTemplates(dir string) (*emailtemplate.Registry, error) {
+	if dir == "" {
+		dir = defaultEmailTemplateDir
+	}
+	registry := emailtemplate.New(dir, models.DefaultLocale, models.Locales...)
+	registry.Register(emailTemplateResetPassword, layoutTransactional)
+	for _, event := range models.NotificationEvents {
+		registry.Register(event, layoutNotification)
+	}
+	if err := registry.Load(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
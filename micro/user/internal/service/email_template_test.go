@@ -0,0 +1,158 @@
+package service
+
+import (
+	"auth/internal/models"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testTemplateDir = "../../templates/email"
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/email")
+
+// goldenData is the data every template is rendered with. Year is fixed so
+// the golden files do not change every January.
+func goldenData(role string) map[string]interface{} {
+	name := "Siti Rahma"
+	if role == "teacher" {
+		name = "Tanaka Yuki"
+	}
+	return map[string]interface{}{
+		"Name":      name,
+		"Role":      role,
+		"Year":      "2025",
+		"ResetLink": "https://app.example.com/reset-password?token=abc123",
+		"Data": map[string]string{
+			"booking_id":          "42",
+			"lesson_date":         "2025-03-14",
+			"start_time":          "19:00",
+			"end_time":            "20:00",
+			"previous_date":       "2025-03-12",
+			"previous_start_time": "18:00",
+			"teacher_name":        "Tanaka Yuki",
+			"student_name":        "Siti Rahma",
+			"reason":              "Teacher is unwell",
+			"amount":              "Rp 150000",
+			"payment_id":          "7",
+			"payment_method":      "bank_transfer",
+			"paid_at":             "2025-03-10 09:30",
+			"option_count":        "2",
+			"deadline":            "2025-03-13 19:00",
+			"policy":              "cancel",
+			"refund_amount":       "Rp 150000",
+			"homework_title":      "Particles は and が",
+			"due_date":            "2025-03-21",
+		},
+	}
+}
+
+// goldenRoles lists the roles a template reads differently for.
+func goldenRoles(name string) []string {
+	switch name {
+	case emailTemplateResetPassword:
+		return []string{""}
+	case models.EventPaymentReceipt, models.EventRescheduleRequested, models.EventRescheduleWithdrawn,
+		models.EventHomeworkAssigned, models.EventHomeworkReviewed:
+		return []string{"student"}
+	case models.EventHomeworkSubmitted:
+		return []string{"teacher"}
+	}
+	return []string{"student", "teacher"}
+}
+
+func TestEmailTemplatesGolden(t *testing.T) {
+	templates, err := LoadEmailTemplates(testTemplateDir)
+	if err != nil {
+		t.Fatalf("LoadEmailTemplates: %v", err)
+	}
+	emailService := &EmailService{templates: templates, logoURL: "https://example.com/logo.png"}
+
+	for _, name := range templates.Names() {
+		for _, locale := range templates.Locales() {
+			for _, role := range goldenRoles(name) {
+				file := name + "." + locale
+				if role != "" {
+					file += "." + role
+				}
+				t.Run(file, func(t *testing.T) {
+					email, err := emailService.Render(name, locale, goldenData(role))
+					if err != nil {
+						t.Fatalf("Render: %v", err)
+					}
+					got := "Subject: " + email.Subject + "\n\n" +
+						"--- body ---\n" + email.Body + "\n\n" +
+						"--- text ---\n" + email.Text + "\n" +
+						"--- html ---\n" + email.HTML
+
+					golden := filepath.Join("testdata", "email", file+".golden")
+					if *update {
+						if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+							t.Fatal(err)
+						}
+						if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+							t.Fatal(err)
+						}
+						return
+					}
+					want, err := os.ReadFile(golden)
+					if err != nil {
+						t.Fatalf("%v (run go test with -update to create it)", err)
+					}
+					if got != string(want) {
+						t.Errorf("%s differs from the rendered email:\n%s", golden, got)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestEmailTemplateLocale(t *testing.T) {
+	templates, err := LoadEmailTemplates(testTemplateDir)
+	if err != nil {
+		t.Fatalf("LoadEmailTemplates: %v", err)
+	}
+
+	cases := map[string]string{
+		"ja":    "ja",
+		"id-ID": "id",
+		"EN_us": "en",
+		"fr":    models.DefaultLocale,
+		"":      models.DefaultLocale,
+	}
+	for preference, want := range cases {
+		email, err := templates.Render(emailTemplateResetPassword, preference, goldenData(""))
+		if err != nil {
+			t.Fatalf("Render(%q): %v", preference, err)
+		}
+		if !strings.Contains(email.HTML, `<html lang="`+want+`">`) {
+			t.Errorf("preference %q did not render in %s", preference, want)
+		}
+	}
+
+	if _, err := templates.Render("unknown", "en", nil); err == nil {
+		t.Error("expected an error for an unknown template")
+	}
+}
+
+func TestBookingCancelledWithoutRefund(t *testing.T) {
+	templates, err := LoadEmailTemplates(testTemplateDir)
+	if err != nil {
+		t.Fatalf("LoadEmailTemplates: %v", err)
+	}
+
+	data := goldenData("student")
+	delete(data["Data"].(map[string]string), "refund_amount")
+	for _, locale := range templates.Locales() {
+		email, err := templates.Render(models.EventBookingCancelled, locale, data)
+		if err != nil {
+			t.Fatalf("Render(%s): %v", locale, err)
+		}
+		if strings.Contains(email.Text, "Rp") || strings.Contains(email.HTML, "Rp") {
+			t.Errorf("%s mentions a refund that was not made:\n%s", locale, email.Text)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"auth/internal/models"
+	"auth/internal/pkg/smtptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestEmailService(t *testing.T, srv *smtptest.Server, maxAttempts int) *EmailService {
+	t.Helper()
+	templates, err := LoadEmailTemplates(testTemplateDir)
+	if err != nil {
+		t.Fatalf("LoadEmailTemplates: %v", err)
+	}
+	return NewEmailService(
+		srv.Host(),
+		srv.Port(),
+		5,
+		srv.Username,
+		srv.Password,
+		"noreply@example.com",
+		templates,
+		"https://example.com/logo.png",
+		true,  // the fake server uses a self-signed certificate
+		false, // connect in plain text and upgrade with STARTTLS
+		nil,
+		maxAttempts,
+		time.Minute,
+	)
+}
+
+func TestSendPasswordResetEmail(t *testing.T) {
+	srv := smtptest.NewServer()
+	defer srv.Close()
+	srv.Username, srv.Password = "mailer", "secret"
+
+	emailService := newTestEmailService(t, srv, 3)
+	link := "https://app.example.com/reset-password?token=abc123"
+	if err := emailService.SendPasswordResetEmail("student@example.com", "Siti", "ja", link); err != nil {
+		t.Fatalf("SendPasswordResetEmail: %v", err)
+	}
+
+	messages := srv.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.From != "noreply@example.com" {
+		t.Errorf("envelope from = %q", msg.From)
+	}
+	if len(msg.To) != 1 || msg.To[0] != "student@example.com" {
+		t.Errorf("envelope to = %v", msg.To)
+	}
+	if got := msg.Header("To"); got != "student@example.com" {
+		t.Errorf("To header = %q", got)
+	}
+	if got := msg.Header("Subject"); got != "パスワードの再設定 - Booking App" {
+		t.Errorf("Subject header = %q", got)
+	}
+	if got := msg.Header("Content-Type"); !strings.HasPrefix(got, "multipart/alternative; boundary=") {
+		t.Errorf("Content-Type header = %q", got)
+	}
+
+	parts, err := msg.Parts()
+	if err != nil {
+		t.Fatalf("Parts: %v", err)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want text and HTML", len(parts))
+	}
+	text, html := parts[0], parts[1]
+	if text.ContentType != "text/plain; charset=UTF-8" || html.ContentType != "text/html; charset=UTF-8" {
+		t.Errorf("part content types = %q, %q", text.ContentType, html.ContentType)
+	}
+	if !strings.HasPrefix(text.Body, "Siti 様") || !strings.Contains(text.Body, link) {
+		t.Errorf("text part = %q", text.Body)
+	}
+	if !strings.Contains(html.Body, `<html lang="ja">`) {
+		t.Error("HTML part is not in Japanese")
+	}
+	if !strings.Contains(html.Body, `href="`+link+`"`) {
+		t.Errorf("HTML part does not link to %s", link)
+	}
+	if !strings.Contains(html.Body, "https://example.com/logo.png") {
+		t.Error("HTML part does not contain the logo")
+	}
+}
+
+func TestSendRejectedCredentials(t *testing.T) {
+	srv := smtptest.NewServer()
+	defer srv.Close()
+	srv.Username, srv.Password = "mailer", "secret"
+
+	emailService := newTestEmailService(t, srv, 3)
+	emailService.smtpPassword = "wrong"
+	if err := emailService.SendPasswordResetEmail("student@example.com", "Siti", "en", "https://app.example.com/reset-password?token=abc123"); err == nil {
+		t.Fatal("expected an authentication error")
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Fatalf("got %d messages, want 0", n)
+	}
+}
+
+func TestOutboxAttemptRetriesWithBackoff(t *testing.T) {
+	srv := smtptest.NewServer()
+	defer srv.Close()
+	srv.FailNext(2)
+
+	emailService := newTestEmailService(t, srv, 5)
+	email := &models.OutboxEmail{
+		ID:          1,
+		ToEmail:     "student@example.com",
+		Subject:     "Booking confirmed - Booking App",
+		ContentType: "text/plain; charset=UTF-8",
+		Body:        "Your lesson is booked.",
+		Status:      models.OutboxSending,
+	}
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	emailService.attempt(email, now)
+	if email.Status != models.OutboxQueued || email.Attempts != 1 {
+		t.Fatalf("after 1st attempt: status %q, attempts %d", email.Status, email.Attempts)
+	}
+	if want := now.Add(time.Minute); !email.NextAttemptAt.Equal(want) {
+		t.Errorf("next attempt = %v, want %v", email.NextAttemptAt, want)
+	}
+	if !strings.Contains(email.LastError, "451") {
+		t.Errorf("last error = %q, want the server's 451 reply", email.LastError)
+	}
+
+	emailService.attempt(email, now)
+	if want := now.Add(2 * time.Minute); !email.NextAttemptAt.Equal(want) {
+		t.Errorf("next attempt = %v, want %v", email.NextAttemptAt, want)
+	}
+
+	emailService.attempt(email, now)
+	if email.Status != models.OutboxSent || email.SentAt == nil || email.LastError != "" {
+		t.Fatalf("after 3rd attempt: status %q, sent at %v, error %q", email.Status, email.SentAt, email.LastError)
+	}
+
+	messages := srv.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	if got := messages[0].Header("Content-Type"); got != "text/plain; charset=UTF-8" {
+		t.Errorf("Content-Type header = %q", got)
+	}
+	if got := strings.TrimSpace(messages[0].Body()); got != "Your lesson is booked." {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestOutboxAttemptDeadLetters(t *testing.T) {
+	srv := smtptest.NewServer()
+	defer srv.Close()
+	srv.FailNext(10)
+
+	emailService := newTestEmailService(t, srv, 3)
+	email := &models.OutboxEmail{ID: 2, ToEmail: "student@example.com", Subject: "Hi", ContentType: "text/plain; charset=UTF-8", Body: "Hi"}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		emailService.attempt(email, now)
+	}
+
+	if email.Status != models.OutboxDead || email.Attempts != 3 {
+		t.Fatalf("status %q, attempts %d, want dead after 3", email.Status, email.Attempts)
+	}
+	if email.SentAt != nil || email.LastError == "" {
+		t.Errorf("sent at %v, last error %q", email.SentAt, email.LastError)
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Fatalf("got %d messages, want 0", n)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	emailService := &EmailService{retryBase: time.Minute}
+	cases := map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		3:  4 * time.Minute,
+		6:  32 * time.Minute,
+		7:  time.Hour,
+		50: time.Hour,
+	}
+	for attempts, want := range cases {
+		if got := emailService.retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
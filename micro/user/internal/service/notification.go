@@ -0,0 +1,202 @@
+package service
+
+import (
+	"auth/internal/models"
+	"auth/internal/pkg/emailtemplate"
+	"auth/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// NotificationService delivers booking and payment events to users by
+// email and in-app, as far as their preferences allow, and logs every
+// delivery. Emails go through the outbox; in-app notifications land in the
+// user's notification center and are pushed to their open streams.
+type NotificationService struct {
+	repoNotification *repository.NotificationRepository
+	repoUser         *repository.UserRepository
+	emailService     *EmailService
+	hub              *notificationHub
+}
+
+func NewNotificationService(
+	repoNotification *repository.NotificationRepository,
+	repoUser *repository.UserRepository,
+	emailService *EmailService,
+) *NotificationService {
+	return &NotificationService{
+		repoNotification: repoNotification,
+		repoUser:         repoUser,
+		emailService:     emailService,
+		hub:              newNotificationHub(),
+	}
+}
+
+// SendEvent notifies the user of an event on every channel. Channels the
+// user turned off are logged as skipped, and an event already delivered
+// with the same reference is not sent again. Emails are queued and sent by
+// the outbox worker.
+func (s *NotificationService) SendEvent(ctx context.Context, req models.NotificationEventRequest) error {
+	if !slices.Contains(models.NotificationEvents, req.EventType) {
+		return fmt.Errorf("unknown event type %q", req.EventType)
+	}
+	if req.Role != "" && req.Role != "student" && req.Role != "teacher" {
+		return errors.New("role must be student or teacher")
+	}
+
+	user, err := s.repoUser.GetUserById(ctx, req.UserID)
+	if err != nil {
+		return errors.New(userNotFound)
+	}
+	enabled, err := s.enabledChannels(ctx, req.UserID, req.EventType)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", req.UserID).Msg("Failed to get notification preferences")
+		return errors.New("failed to get notification preferences")
+	}
+	// The email template of the event, in the user's language, also gives
+	// the subject and message of the in-app notification.
+	content, err := s.emailService.Render(req.EventType, user.Locale, map[string]interface{}{
+		"Name": user.Name,
+		"Role": req.Role,
+		"Data": req.Data,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("event", req.EventType).Msg("Failed to render notification")
+		return errors.New("failed to render notification")
+	}
+
+	now := time.Now()
+	for _, channel := range models.NotificationChannels {
+		entry := &models.NotificationLog{
+			UserID:    req.UserID,
+			EventType: req.EventType,
+			Channel:   channel,
+			Reference: req.Reference,
+			Subject:   content.Subject,
+			Body:      content.Body,
+			Status:    models.DeliveryPending,
+		}
+		switch {
+		case !enabled[channel]:
+			entry.Status = models.DeliverySkipped
+			entry.Error = "disabled by user"
+		case channel == models.ChannelInApp:
+			entry.Status = models.DeliverySent
+			entry.SentAt = &now
+		}
+
+		created, err := s.repoNotification.CreateLog(ctx, entry)
+		if err != nil {
+			log.Error().Err(err).Uint("user_id", req.UserID).Str("event", req.EventType).Msg("Failed to log notification")
+			return errors.New("failed to log notification")
+		}
+		if created && channel == models.ChannelEmail && entry.Status == models.DeliveryPending {
+			s.queueEmail(ctx, entry.ID, user.Email, content)
+		}
+		if created && channel == models.ChannelInApp && entry.Status == models.DeliverySent {
+			s.deliverInApp(ctx, entry.ID, req, content)
+		}
+	}
+	return nil
+}
+
+// GetPreferences returns the user's setting for every event and channel.
+func (s *NotificationService) GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error) {
+	saved, err := s.repoNotification.GetPreferences(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to get notification preferences")
+		return nil, errors.New("failed to get notification preferences")
+	}
+
+	disabled := make(map[string]bool, len(saved))
+	for _, preference := range saved {
+		if !preference.Enabled {
+			disabled[preference.EventType+"/"+preference.Channel] = true
+		}
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(models.NotificationEvents)*len(models.NotificationChannels))
+	for _, event := range models.NotificationEvents {
+		for _, channel := range models.NotificationChannels {
+			preferences = append(preferences, models.NotificationPreference{
+				UserID:    userID,
+				EventType: event,
+				Channel:   channel,
+				Enabled:   !disabled[event+"/"+channel],
+			})
+		}
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences turns the given events on or off per channel. Events
+// and channels left out keep their setting.
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uint, req models.NotificationPreferencesRequest) ([]models.NotificationPreference, error) {
+	preferences := make([]models.NotificationPreference, 0, len(req.Preferences))
+	for _, preference := range req.Preferences {
+		if !slices.Contains(models.NotificationEvents, preference.EventType) {
+			return nil, fmt.Errorf("unknown event type %q", preference.EventType)
+		}
+		if !slices.Contains(models.NotificationChannels, preference.Channel) {
+			return nil, fmt.Errorf("unknown channel %q", preference.Channel)
+		}
+		preferences = append(preferences, models.NotificationPreference{
+			UserID:    userID,
+			EventType: preference.EventType,
+			Channel:   preference.Channel,
+			Enabled:   preference.Enabled,
+			UpdatedAt: time.Now(),
+		})
+	}
+
+	if err := s.repoNotification.SavePreferences(ctx, preferences); err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to save notification preferences")
+		return nil, errors.New("failed to save notification preferences")
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+// GetLogs returns the notification log, newest first. A zero userID lists
+// every user's notifications.
+func (s *NotificationService) GetLogs(ctx context.Context, userID uint, status, eventType string, page, limit int) ([]models.NotificationLog, int64, error) {
+	logs, total, err := s.repoNotification.GetLogs(ctx, userID, status, eventType, page, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get notification logs")
+		return nil, 0, errors.New("failed to get notification logs")
+	}
+	if logs == nil {
+		logs = []models.NotificationLog{}
+	}
+	return logs, total, nil
+}
+
+func (s *NotificationService) enabledChannels(ctx context.Context, userID uint, eventType string) (map[string]bool, error) {
+	preferences, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := make(map[string]bool, len(models.NotificationChannels))
+	for _, preference := range preferences {
+		if preference.EventType == eventType {
+			enabled[preference.Channel] = preference.Enabled
+		}
+	}
+	return enabled, nil
+}
+
+// queueEmail hands the email to the outbox, which updates the log entry
+// once it was sent or gave up.
+func (s *NotificationService) queueEmail(ctx context.Context, logID uint, toEmail string, content *emailtemplate.Email) {
+	err := s.emailService.SendEmail(toEmail, content, &logID)
+	if err == nil {
+		return
+	}
+	if err := s.repoNotification.SetLogStatus(ctx, logID, models.DeliveryFailed, err.Error(), nil); err != nil {
+		log.Error().Err(err).Uint("log_id", logID).Msg("Failed to update notification log")
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"auth/internal/models"
+	"auth/internal/pkg/emailtemplate"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CreateNotification adds a notification to the user's notification center
+// and pushes it to their open streams. A notification whose type and
+// reference were seen before is ignored.
+func (s *NotificationService) CreateNotification(ctx context.Context, notification *models.Notification) error {
+	created, err := s.repoNotification.CreateNotification(ctx, notification)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", notification.UserID).Str("type", notification.Type).Msg("Failed to create notification")
+		return errors.New("failed to create notification")
+	}
+	if !created {
+		return nil
+	}
+
+	unread, err := s.repoNotification.CountUnreadNotifications(ctx, notification.UserID)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", notification.UserID).Msg("Failed to count unread notifications")
+	}
+	s.hub.publish(notification.UserID, models.NotificationStreamEvent{
+		Type:         models.NotificationStreamCreated,
+		Notification: notification,
+		UnreadCount:  unread,
+	})
+	return nil
+}
+
+// CheckServiceActivity validates an activity another service records for a
+// user. Only models.ServiceActivities are accepted, and the link of its
+// notification must be a path within the app, so a notification cannot
+// send the user to another site.
+func CheckServiceActivity(action string, notification *models.ActivityNotification) error {
+	if !slices.Contains(models.ServiceActivities, action) {
+		return fmt.Errorf("unknown activity %q", action)
+	}
+	if notification != nil && notification.Link != "" && !isAppPath(notification.Link) {
+		return errors.New("link must be a path starting with /")
+	}
+	return nil
+}
+
+// isAppPath reports whether link is a path on the app's own origin.
+// Browsers read "//host" and "/\host" as links to another host.
+func isAppPath(link string) bool {
+	if !strings.HasPrefix(link, "/") || strings.HasPrefix(link, "//") || strings.ContainsAny(link, "\\\r\n\t") {
+		return false
+	}
+	u, err := url.Parse(link)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
+
+// GetNotifications lists the user's notifications, newest first, with the
+// number of unread ones.
+func (s *NotificationService) GetNotifications(ctx context.Context, userID uint, unreadOnly bool, page, limit int) ([]models.Notification, int64, int64, error) {
+	notifications, total, err := s.repoNotification.GetNotifications(ctx, userID, unreadOnly, page, limit)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to get notifications")
+		return nil, 0, 0, errors.New("failed to get notifications")
+	}
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+	unread, err := s.GetUnreadNotificationCount(ctx, userID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return notifications, total, unread, nil
+}
+
+func (s *NotificationService) GetUnreadNotificationCount(ctx context.Context, userID uint) (int64, error) {
+	unread, err := s.repoNotification.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to count unread notifications")
+		return 0, errors.New("failed to count unread notifications")
+	}
+	return unread, nil
+}
+
+// MarkNotificationRead marks one of the user's notifications read and
+// returns the number still unread.
+func (s *NotificationService) MarkNotificationRead(ctx context.Context, userID, id uint) (int64, error) {
+	found, err := s.repoNotification.MarkNotificationRead(ctx, userID, id, time.Now())
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Uint("notification_id", id).Msg("Failed to mark notification read")
+		return 0, errors.New("failed to mark notification read")
+	}
+	if !found {
+		return 0, errors.New("notification not found")
+	}
+	return s.publishRead(ctx, userID, id)
+}
+
+// MarkAllNotificationsRead marks every notification of the user read.
+func (s *NotificationService) MarkAllNotificationsRead(ctx context.Context, userID uint) (int64, error) {
+	marked, err := s.repoNotification.MarkAllNotificationsRead(ctx, userID, time.Now())
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to mark notifications read")
+		return 0, errors.New("failed to mark notifications read")
+	}
+	if _, err := s.publishRead(ctx, userID, 0); err != nil {
+		return 0, err
+	}
+	return marked, nil
+}
+
+// SubscribeNotifications opens a stream of the user's notification events.
+// The returned function closes it.
+func (s *NotificationService) SubscribeNotifications(userID uint) (<-chan models.NotificationStreamEvent, func()) {
+	return s.hub.subscribe(userID)
+}
+
+// publishRead tells the user's other open streams, e.g. other tabs, that
+// notifications were read.
+func (s *NotificationService) publishRead(ctx context.Context, userID, id uint) (int64, error) {
+	unread, err := s.GetUnreadNotificationCount(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	s.hub.publish(userID, models.NotificationStreamEvent{
+		Type:           models.NotificationStreamRead,
+		NotificationID: id,
+		UnreadCount:    unread,
+	})
+	return unread, nil
+}
+
+// deliverInApp puts an event into the notification center. The event's
+// delivery log entry is marked failed if that does not work.
+func (s *NotificationService) deliverInApp(ctx context.Context, logID uint, req models.NotificationEventRequest, content *emailtemplate.Email) {
+	reference := req.Reference
+	err := s.CreateNotification(ctx, &models.Notification{
+		UserID:    req.UserID,
+		Type:      req.EventType,
+		Reference: &reference,
+		Title:     content.Subject,
+		Message:   content.Body,
+		Link:      notificationLink(req.Role),
+	})
+	if err == nil {
+		return
+	}
+	if err := s.repoNotification.SetLogStatus(ctx, logID, models.DeliveryFailed, err.Error(), nil); err != nil {
+		log.Error().Err(err).Uint("log_id", logID).Msg("Failed to update notification log")
+	}
+}
+
+// notificationLink is the page of the frontend where the user finds their
+// lessons.
+func notificationLink(role string) string {
+	if role == "teacher" {
+		return "/teacher/dashboard"
+	}
+	return "/bookings"
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"auth/internal/models"
+	"testing"
+)
+
+func TestCheckServiceActivity(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  string
+		link    string
+		wantErr bool
+	}{
+		{name: "known activity without link", action: models.ActivityPaymentRefund},
+		{name: "path in the app", action: models.ActivityWaitlistOffer, link: "/bookings?tab=upcoming"},
+		{name: "unknown activity", action: "account_locked", wantErr: true},
+		{name: "absolute url", action: models.ActivityPaymentRefund, link: "https://evil.example/login", wantErr: true},
+		{name: "javascript url", action: models.ActivityPaymentRefund, link: "javascript:alert(1)", wantErr: true},
+		{name: "protocol-relative url", action: models.ActivityPaymentRefund, link: "//evil.example", wantErr: true},
+		{name: "backslash host", action: models.ActivityPaymentRefund, link: `/\evil.example`, wantErr: true},
+		{name: "relative path", action: models.ActivityPaymentRefund, link: "bookings", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckServiceActivity(tt.action, &models.ActivityNotification{Title: "t", Link: tt.link})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckServiceActivity(%q, %q) error = %v, wantErr %v", tt.action, tt.link, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"auth/internal/models"
+	"sync"
+)
+
+// notificationStreamBuffer is how many events a slow stream may fall behind
+// before further events are dropped for it.
+const notificationStreamBuffer = 32
+
+// notificationHub fans notification events out to the open streams of each
+// user. It only reaches streams connected to this instance; clients that
+// miss events catch up by listing their notifications.
+type notificationHub struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan models.NotificationStreamEvent]struct{}
+}
+
+func newNotificationHub() *notificationHub {
+	return &notificationHub{subscribers: make(map[uint]map[chan models.NotificationStreamEvent]struct{})}
+}
+
+// subscribe opens a stream for the user. The returned function closes it.
+func (h *notificationHub) subscribe(userID uint) (<-chan models.NotificationStreamEvent, func()) {
+	events := make(chan models.NotificationStreamEvent, notificationStreamBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan models.NotificationStreamEvent]struct{})
+	}
+	h.subscribers[userID][events] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], events)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(events)
+		})
+	}
+}
+
+// publish sends the event to every stream of the user without blocking.
+func (h *notificationHub) publish(userID uint, event models.NotificationStreamEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for events := range h.subscribers[userID] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
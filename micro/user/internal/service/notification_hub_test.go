@@ -0,0 +1,62 @@
+package service
+
+import (
+	"auth/internal/models"
+	"testing"
+)
+
+func TestNotificationHub(t *testing.T) {
+	hub := newNotificationHub()
+	first, unsubscribeFirst := hub.subscribe(1)
+	second, unsubscribeSecond := hub.subscribe(1)
+	other, unsubscribeOther := hub.subscribe(2)
+	defer unsubscribeSecond()
+	defer unsubscribeOther()
+
+	hub.publish(1, models.NotificationStreamEvent{Type: models.NotificationStreamCreated, UnreadCount: 3})
+	for name, events := range map[string]<-chan models.NotificationStreamEvent{"first": first, "second": second} {
+		select {
+		case event := <-events:
+			if event.Type != models.NotificationStreamCreated || event.UnreadCount != 3 {
+				t.Errorf("%s stream got %+v", name, event)
+			}
+		default:
+			t.Errorf("%s stream got no event", name)
+		}
+	}
+	select {
+	case event := <-other:
+		t.Errorf("other user got %+v", event)
+	default:
+	}
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	if _, ok := <-first; ok {
+		t.Error("stream still open after unsubscribe")
+	}
+	hub.publish(1, models.NotificationStreamEvent{Type: models.NotificationStreamRead})
+	if event := <-second; event.Type != models.NotificationStreamRead {
+		t.Errorf("remaining stream got %+v", event)
+	}
+}
+
+func TestNotificationHubDropsWhenFull(t *testing.T) {
+	hub := newNotificationHub()
+	events, unsubscribe := hub.subscribe(1)
+
+	for i := 0; i < notificationStreamBuffer+5; i++ {
+		hub.publish(1, models.NotificationStreamEvent{Type: models.NotificationStreamCreated, UnreadCount: int64(i)})
+	}
+	if len(events) != notificationStreamBuffer {
+		t.Fatalf("buffered %d events, want %d", len(events), notificationStreamBuffer)
+	}
+	if event := <-events; event.UnreadCount != 0 {
+		t.Errorf("first event has unread count %d, want 0", event.UnreadCount)
+	}
+
+	unsubscribe()
+	if _, ok := hub.subscribers[1]; ok {
+		t.Error("unsubscribed user still in the hub")
+	}
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"auth/internal/infrastructure/booking"
+	"auth/internal/models"
+	"auth/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	studentNotFound = "student not found"
+
+	// recentAssessments caps the assessments listed in a progress report.
+	recentAssessments = 5
+)
+
+// ProgressService manages the learning profile of students and builds their
+// progress from completed bookings and teacher assessments.
+type ProgressService struct {
+	repoProgress   *repository.ProgressRepository
+	repoUser       *repository.UserRepository
+	serviceBooking *booking.Client
+}
+
+func NewProgressService(repoProgress *repository.ProgressRepository, repoUser *repository.UserRepository, serviceBooking *booking.Client) *ProgressService {
+	return &ProgressService{
+		repoProgress:   repoProgress,
+		repoUser:       repoUser,
+		serviceBooking: serviceBooking,
+	}
+}
+
+// GetStudentProfile returns the student's profile, empty when it was never
+// saved.
+func (s *ProgressService) GetStudentProfile(ctx context.Context, userID uint) (*models.StudentProfile, error) {
+	profile, err := s.repoProgress.GetStudentProfile(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to get student profile")
+		return nil, errors.New("failed to get student profile")
+	}
+	if profile == nil {
+		profile = &models.StudentProfile{UserID: userID}
+	}
+	return profile, nil
+}
+
+// UpdateStudentProfile saves the student's profile. A changed JLPT level is
+// recorded in the level history.
+func (s *ProgressService) UpdateStudentProfile(ctx context.Context, userID uint, req models.StudentProfileRequest) (*models.StudentProfile, error) {
+	level, err := jlptLevel(req.JLPTLevel)
+	if err != nil {
+		return nil, err
+	}
+	target, err := jlptLevel(req.TargetLevel)
+	if err != nil {
+		return nil, err
+	}
+	var targetDate *time.Time
+	if req.TargetDate != "" {
+		date, err := time.Parse("2006-01-02", req.TargetDate)
+		if err != nil {
+			return nil, errors.New("invalid target date, expected YYYY-MM-DD")
+		}
+		targetDate = &date
+	}
+
+	profile, err := s.GetStudentProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var change *models.LevelHistory
+	if level != "" && level != profile.JLPTLevel {
+		change = &models.LevelHistory{UserID: userID, Level: level, Source: models.LevelSourceSelf}
+	}
+	profile.JLPTLevel = level
+	profile.TargetLevel = target
+	profile.TargetDate = targetDate
+	profile.WeeklyLessonGoal = req.WeeklyLessonGoal
+	profile.Goals = strings.TrimSpace(req.Goals)
+
+	if err := s.repoProgress.SaveStudentProfile(ctx, profile, change); err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to save student profile")
+		return nil, errors.New("failed to save student profile")
+	}
+	return profile, nil
+}
+
+// SubmitAssessment records the skill assessment of a completed lesson. Only
+// the teacher who gave the lesson can assess it; submitting again updates
+// the assessment.
+func (s *ProgressService) SubmitAssessment(ctx context.Context, teacherUserID uint, req models.SkillAssessmentRequest) (*models.SkillAssessment, error) {
+	teacher, err := s.repoUser.GetUserById(ctx, teacherUserID)
+	if err != nil {
+		return nil, errors.New(userNotFound)
+	}
+	if teacher.Role != "teacher" {
+		return nil, errors.New("only teachers can submit assessments")
+	}
+	level, err := jlptLevel(req.EstimatedLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	lessons, err := s.completedLessons(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	var lesson *booking.CompletedLesson
+	for i := range lessons {
+		if lessons[i].BookingID == req.BookingID {
+			lesson = &lessons[i]
+			break
+		}
+	}
+	if lesson == nil {
+		return nil, errors.New("completed lesson not found")
+	}
+	if lesson.TeacherUserID != teacherUserID {
+		return nil, errors.New("you can only assess your own lessons")
+	}
+
+	assessment, err := s.repoProgress.GetAssessmentByBooking(ctx, req.BookingID)
+	if err != nil {
+		log.Error().Err(err).Uint("booking_id", req.BookingID).Msg("Failed to get assessment")
+		return nil, errors.New("failed to save assessment")
+	}
+	if assessment == nil {
+		assessment = &models.SkillAssessment{BookingID: req.BookingID}
+	}
+	assessment.UserID = req.UserID
+	assessment.TeacherID = lesson.TeacherID
+	assessment.TeacherUserID = teacherUserID
+	assessment.LessonDate = lesson.LessonDate
+	assessment.Vocabulary = req.Vocabulary
+	assessment.Grammar = req.Grammar
+	assessment.Listening = req.Listening
+	assessment.Speaking = req.Speaking
+	assessment.Reading = req.Reading
+	assessment.EstimatedLevel = level
+	assessment.Comment = strings.TrimSpace(req.Comment)
+
+	if err := s.repoProgress.SaveAssessment(ctx, assessment); err != nil {
+		log.Error().Err(err).Uint("booking_id", req.BookingID).Msg("Failed to save assessment")
+		return nil, errors.New("failed to save assessment")
+	}
+	return assessment, nil
+}
+
+// GetProgress returns the progress of the student to the student
+// themselves.
+func (s *ProgressService) GetProgress(ctx context.Context, userID uint) (*models.ProgressResponse, error) {
+	lessons, err := s.completedLessons(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildProgress(ctx, userID, lessons, time.Now())
+}
+
+// GetStudentProgress returns the progress of a student to an admin or to a
+// teacher who gave the student at least one lesson.
+func (s *ProgressService) GetStudentProgress(ctx context.Context, viewerID, studentID uint) (*models.ProgressResponse, error) {
+	if viewerID == studentID {
+		return s.GetProgress(ctx, studentID)
+	}
+	viewer, err := s.repoUser.GetUserById(ctx, viewerID)
+	if err != nil {
+		return nil, errors.New(userNotFound)
+	}
+	if viewer.Role != "admin" && viewer.Role != "teacher" {
+		return nil, errors.New(studentNotFound)
+	}
+	if _, err := s.repoUser.GetUserById(ctx, studentID); err != nil {
+		return nil, errors.New(studentNotFound)
+	}
+
+	lessons, err := s.completedLessons(studentID)
+	if err != nil {
+		return nil, err
+	}
+	if viewer.Role == "teacher" && !taughtBy(lessons, viewerID) {
+		return nil, errors.New(studentNotFound)
+	}
+	return s.buildProgress(ctx, studentID, lessons, time.Now())
+}
+
+func (s *ProgressService) completedLessons(userID uint) ([]booking.CompletedLesson, error) {
+	lessons, err := s.serviceBooking.GetCompletedLessons(userID)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to fetch completed lessons")
+		return nil, errors.New("failed to fetch completed lessons")
+	}
+	return lessons, nil
+}
+
+func (s *ProgressService) buildProgress(ctx context.Context, userID uint, lessons []booking.CompletedLesson, now time.Time) (*models.ProgressResponse, error) {
+	profile, err := s.GetStudentProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.repoProgress.GetLevelHistory(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to get level history")
+		return nil, errors.New("failed to get level history")
+	}
+	assessments, err := s.repoProgress.GetAssessments(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to get assessments")
+		return nil, errors.New("failed to get assessments")
+	}
+
+	progress := &models.ProgressResponse{
+		UserID:           userID,
+		LessonsCompleted: len(lessons),
+		WeeklyLessonGoal: profile.WeeklyLessonGoal,
+		CurrentLevel:     profile.JLPTLevel,
+		TargetLevel:      profile.TargetLevel,
+		TargetDate:       profile.TargetDate,
+		LevelHistory:     history,
+		SkillAverages:    skillAverages(assessments),
+		AssessmentCount:  len(assessments),
+	}
+	if progress.LevelHistory == nil {
+		progress.LevelHistory = []models.LevelHistory{}
+	}
+
+	thisWeek := weekStart(now)
+	weeks := make([]time.Time, 0, len(lessons))
+	minutes := 0
+	for _, lesson := range lessons {
+		minutes += lesson.DurationMinutes
+		date, err := time.Parse("2006-01-02", lesson.LessonDate)
+		if err != nil {
+			continue
+		}
+		if progress.FirstLessonDate == "" {
+			progress.FirstLessonDate = lesson.LessonDate
+		}
+		progress.LastLessonDate = lesson.LessonDate
+		week := weekStart(date)
+		if week.Equal(thisWeek) {
+			progress.LessonsThisWeek++
+		}
+		weeks = append(weeks, week)
+	}
+	progress.HoursStudied = math.Round(float64(minutes)/60*10) / 10
+	progress.CurrentStreak, progress.LongestStreak = weeklyStreaks(weeks, thisWeek)
+
+	for _, assessment := range assessments {
+		if assessment.EstimatedLevel != "" {
+			progress.EstimatedLevel = assessment.EstimatedLevel
+			break
+		}
+	}
+	if len(assessments) > recentAssessments {
+		assessments = assessments[:recentAssessments]
+	}
+	progress.RecentAssessments = assessments
+	if progress.RecentAssessments == nil {
+		progress.RecentAssessments = []models.SkillAssessment{}
+	}
+	return progress, nil
+}
+
+// jlptLevel normalises a JLPT level such as "n3"; empty means unset.
+func jlptLevel(level string) (string, error) {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if level == "" {
+		return "", nil
+	}
+	for _, known := range models.JLPTLevels {
+		if level == known {
+			return level, nil
+		}
+	}
+	return "", fmt.Errorf("invalid JLPT level %q, expected one of %s", level, strings.Join(models.JLPTLevels, ", "))
+}
+
+func taughtBy(lessons []booking.CompletedLesson, teacherUserID uint) bool {
+	for _, lesson := range lessons {
+		if lesson.TeacherUserID == teacherUserID {
+			return true
+		}
+	}
+	return false
+}
+
+// weekStart returns the Monday of the ISO week of t.
+func weekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// weeklyStreaks returns the current and longest run of consecutive weeks in
+// weeks, in any order and with duplicates. The current run only counts when
+// it reaches this week or the one before, so a streak is not lost mid-week.
+func weeklyStreaks(weeks []time.Time, thisWeek time.Time) (current, longest int) {
+	weeks = slices.Clone(weeks)
+	slices.SortFunc(weeks, func(a, b time.Time) int { return a.Compare(b) })
+
+	run := 0
+	var last time.Time
+	for _, week := range weeks {
+		switch {
+		case run > 0 && week.Equal(last):
+			continue
+		case run > 0 && week.Equal(last.AddDate(0, 0, 7)):
+			run++
+		default:
+			run = 1
+		}
+		last = week
+		if run > longest {
+			longest = run
+		}
+	}
+	if run > 0 && !last.Before(thisWeek.AddDate(0, 0, -7)) {
+		current = run
+	}
+	return current, longest
+}
+
+func skillAverages(assessments []models.SkillAssessment) models.SkillAverages {
+	var sums, counts [5]int
+	for _, a := range assessments {
+		for i, score := range []int{a.Vocabulary, a.Grammar, a.Listening, a.Speaking, a.Reading} {
+			if score > 0 {
+				sums[i] += score
+				counts[i]++
+			}
+		}
+	}
+	average := func(i int) float64 {
+		if counts[i] == 0 {
+			return 0
+		}
+		return math.Round(float64(sums[i])/float64(counts[i])*10) / 10
+	}
+	return models.SkillAverages{
+		Vocabulary: average(0),
+		Grammar:    average(1),
+		Listening:  average(2),
+		Speaking:   average(3),
+		Reading:    average(4),
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"auth/internal/models"
+	"testing"
+	"time"
+)
+
+func TestWeeklyStreaks(t *testing.T) {
+	thisWeek := time.Date(2025, time.March, 17, 0, 0, 0, 0, time.UTC)
+	week := func(weeksAgo int) time.Time {
+		return thisWeek.AddDate(0, 0, -7*weeksAgo)
+	}
+
+	tests := []struct {
+		name        string
+		weeks       []time.Time
+		wantCurrent int
+		wantLongest int
+	}{
+		{name: "no lessons"},
+		{
+			name:        "run up to this week",
+			weeks:       []time.Time{week(2), week(1), week(0)},
+			wantCurrent: 3,
+			wantLongest: 3,
+		},
+		{
+			name:        "run up to last week still counts",
+			weeks:       []time.Time{week(2), week(1)},
+			wantCurrent: 2,
+			wantLongest: 2,
+		},
+		{
+			name:        "run that ended two weeks ago",
+			weeks:       []time.Time{week(3), week(2)},
+			wantCurrent: 0,
+			wantLongest: 2,
+		},
+		{
+			name:        "unsorted weeks with duplicates",
+			weeks:       []time.Time{week(0), week(5), week(1), week(0), week(6), week(7), week(1)},
+			wantCurrent: 2,
+			wantLongest: 3,
+		},
+		{
+			name:        "gap breaks the run",
+			weeks:       []time.Time{week(4), week(2), week(0)},
+			wantCurrent: 1,
+			wantLongest: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := weeklyStreaks(tt.weeks, thisWeek)
+			if current != tt.wantCurrent || longest != tt.wantLongest {
+				t.Errorf("weeklyStreaks() = %d, %d, want %d, %d", current, longest, tt.wantCurrent, tt.wantLongest)
+			}
+		})
+	}
+}
+
+func TestWeeklyStreaksKeepsInput(t *testing.T) {
+	thisWeek := time.Date(2025, time.March, 17, 0, 0, 0, 0, time.UTC)
+	weeks := []time.Time{thisWeek, thisWeek.AddDate(0, 0, -7)}
+	weeklyStreaks(weeks, thisWeek)
+	if !weeks[0].Equal(thisWeek) {
+		t.Error("weeklyStreaks() reordered its input")
+	}
+}
+
+func TestSkillAverages(t *testing.T) {
+	got := skillAverages([]models.SkillAssessment{
+		{Vocabulary: 3, Grammar: 2, Listening: 4, Speaking: 0, Reading: 5},
+		{Vocabulary: 4, Grammar: 3, Listening: 0, Speaking: 0, Reading: 5},
+		{Vocabulary: 4, Grammar: 0, Listening: 0, Speaking: 0, Reading: 4},
+	})
+	want := models.SkillAverages{Vocabulary: 3.7, Grammar: 2.5, Listening: 4, Speaking: 0, Reading: 4.7}
+	if got != want {
+		t.Errorf("skillAverages() = %+v, want %+v", got, want)
+	}
+
+	if got := skillAverages(nil); got != (models.SkillAverages{}) {
+		t.Errorf("skillAverages(nil) = %+v, want zero", got)
+	}
+}
+
+func TestJLPTLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "N3", want: "N3"},
+		{in: " n1 ", want: "N1"},
+		{in: "", want: ""},
+		{in: "N6", wantErr: true},
+		{in: "A1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := jlptLevel(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("jlptLevel(%q) = %q, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("jlptLevel(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"auth/internal/infrastructure/statistic"
+	"auth/internal/infrastructure/teacher"
 	"auth/internal/models"
 	"auth/internal/repository"
 	"context"
@@ -21,6 +22,9 @@ import (
 const (
 	failedToRegisterUser = "failed to register user"
 	userNotFound         = "user not found"
+
+	// maxUserBatch caps the ids of one GetUsersByIDs call.
+	maxUserBatch = 100
 )
 
 type UserService struct {
@@ -31,6 +35,7 @@ type UserService struct {
 	serviceStatistic *statistic.Client
 	activityRepo     *repository.ActivityLogRepository
 	favoriteRepo     *repository.FavoriteRepository
+	serviceTeacher   *teacher.Client
 }
 
 func NewUserService(
@@ -41,6 +46,7 @@ func NewUserService(
 	serviceStatistic *statistic.Client,
 	activityRepo *repository.ActivityLogRepository,
 	favoriteRepo *repository.FavoriteRepository,
+	serviceTeacher *teacher.Client,
 ) *UserService {
 	return &UserService{
 		repoUser:         repoUser,
@@ -50,6 +56,7 @@ func NewUserService(
 		serviceStatistic: serviceStatistic,
 		activityRepo:     activityRepo,
 		favoriteRepo:     favoriteRepo,
+		serviceTeacher:   serviceTeacher,
 	}
 }
 
@@ -85,6 +92,7 @@ func (s *UserService) Register(ctx context.Context, req models.RegisterRequest)
 		Email:        req.Email,
 		PasswordHash: string(hashedPassword),
 		Role:         role,
+		Locale:       models.DefaultLocale,
 	}
 
 	if err := s.repoUser.CreateUser(ctx, newUser); err != nil {
@@ -92,12 +100,15 @@ func (s *UserService) Register(ctx context.Context, req models.RegisterRequest)
 		return nil, errors.New(failedToRegisterUser)
 	}
 
+	s.provisionTeacher(newUser, newUser.ID)
+
 	return &models.UserResponse{
 		Id:           newUser.ID,
 		Name:         newUser.Name,
 		Email:        newUser.Email,
 		Role:         newUser.Role,
 		ProfileImage: newUser.ProfileImage,
+		Locale:       newUser.Locale,
 		CreatedAt:    newUser.CreatedAt,
 		UpdatedAt:    newUser.UpdatedAt,
 	}, nil
@@ -132,6 +143,7 @@ func (s *UserService) Login(ctx context.Context, req models.LoginRequest) (*mode
 			Email:        user.Email,
 			Role:         user.Role,
 			ProfileImage: user.ProfileImage,
+			Locale:       user.Locale,
 			CreatedAt:    user.CreatedAt,
 			UpdatedAt:    user.UpdatedAt,
 		},
@@ -151,11 +163,44 @@ func (s *UserService) GetUserById(ctx context.Context, id uint) (*models.UserRes
 		Email:        user.Email,
 		Role:         user.Role,
 		ProfileImage: user.ProfileImage,
+		Locale:       user.Locale,
 		CreatedAt:    user.CreatedAt,
 		UpdatedAt:    user.UpdatedAt,
 	}, nil
 }
 
+// GetUsersByIDs looks up several users at once for other services. Unknown
+// ids are left out of the result.
+func (s *UserService) GetUsersByIDs(ctx context.Context, ids []uint) ([]models.UserResponse, error) {
+	if len(ids) == 0 {
+		return []models.UserResponse{}, nil
+	}
+	if len(ids) > maxUserBatch {
+		return nil, fmt.Errorf("at most %d users can be requested at once", maxUserBatch)
+	}
+
+	users, err := s.repoUser.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get users by ids")
+		return nil, errors.New("failed to get users")
+	}
+
+	responses := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, models.UserResponse{
+			Id:           user.ID,
+			Name:         user.Name,
+			Email:        user.Email,
+			Role:         user.Role,
+			ProfileImage: user.ProfileImage,
+			Locale:       user.Locale,
+			CreatedAt:    user.CreatedAt,
+			UpdatedAt:    user.UpdatedAt,
+		})
+	}
+	return responses, nil
+}
+
 func (s *UserService) ProcessForgotPassword(ctx context.Context, email string) error {
 	user, err := s.repoUser.GetByEmail(ctx, email)
 	if err != nil {
@@ -183,9 +228,13 @@ func (s *UserService) ProcessForgotPassword(ctx context.Context, email string) e
 	// The path can be adjusted to match the actual route in the Vue app.
 	resetLink := fmt.Sprintf("%s/recover-password?token=%s", strings.TrimRight(frontendURL, "/"), token)
 
-	// Send the password reset email asynchronously. The email template
-	// expects a full URL for ResetLink, not just a token.
-	go s.emailService.SendPasswordResetEmail(email, resetLink)
+	// Queue the password reset email; the outbox retries it until SMTP
+	// takes it. The email template expects a full URL for ResetLink, not
+	// just a token.
+	if err := s.emailService.SendPasswordResetEmail(email, user.Name, user.Locale, resetLink); err != nil {
+		log.Error().Err(err).Msg("Failed to queue password reset email")
+		return errors.New("failed to send password reset email")
+	}
 
 	return nil
 }
@@ -229,18 +278,28 @@ func (s *UserService) UpdateProfile(ctx context.Context, userID uint, req models
 	if req.ProfileImage != "" {
 		user.ProfileImage = req.ProfileImage
 	}
+	if req.Locale != "" {
+		user.Locale = req.Locale
+	}
 
 	if err := s.repoUser.UpdateUser(ctx, user); err != nil {
 		log.Error().Err(err).Msg("Failed to update user")
 		return nil, errors.New("failed to update profile")
 	}
 
+	if user.Role == "teacher" && s.serviceTeacher != nil {
+		if err := s.serviceTeacher.SyncTeacherProfile(user.ID, user.Name, user.ProfileImage); err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to sync teacher profile")
+		}
+	}
+
 	return &models.UserResponse{
 		Id:           user.ID,
 		Name:         user.Name,
 		Email:        user.Email,
 		Role:         user.Role,
 		ProfileImage: user.ProfileImage,
+		Locale:       user.Locale,
 		CreatedAt:    user.CreatedAt,
 		UpdatedAt:    user.UpdatedAt,
 	}, nil
@@ -307,7 +366,7 @@ func (s *UserService) GetDashboardStats(ctx *gin.Context) (*models.DashboardStat
 	return stats, nil
 }
 
-func (s *UserService) CreateUser(ctx context.Context, req models.CreateUserRequest) (*models.UserResponse, error) {
+func (s *UserService) CreateUser(ctx context.Context, actorID uint, req models.CreateUserRequest) (*models.UserResponse, error) {
 	existingUser, _ := s.repoUser.GetByEmail(ctx, req.Email)
 	if existingUser != nil {
 		return nil, errors.New("user already exists")
@@ -324,6 +383,7 @@ func (s *UserService) CreateUser(ctx context.Context, req models.CreateUserReque
 		Email:        req.Email,
 		PasswordHash: string(hashedPassword),
 		Role:         req.Role,
+		Locale:       models.DefaultLocale,
 	}
 
 	if err := s.repoUser.CreateUser(ctx, newUser); err != nil {
@@ -331,18 +391,21 @@ func (s *UserService) CreateUser(ctx context.Context, req models.CreateUserReque
 		return nil, errors.New("failed to create user")
 	}
 
+	s.provisionTeacher(newUser, actorID)
+
 	return &models.UserResponse{
 		Id:           newUser.ID,
 		Name:         newUser.Name,
 		Email:        newUser.Email,
 		Role:         newUser.Role,
 		ProfileImage: newUser.ProfileImage,
+		Locale:       newUser.Locale,
 		CreatedAt:    newUser.CreatedAt,
 		UpdatedAt:    newUser.UpdatedAt,
 	}, nil
 }
 
-func (s *UserService) UpdateUser(ctx context.Context, userID uint, req models.UpdateUserAdminRequest) (*models.UserResponse, error) {
+func (s *UserService) UpdateUser(ctx context.Context, actorID, userID uint, req models.UpdateUserAdminRequest) (*models.UserResponse, error) {
 	user, err := s.repoUser.GetUserById(ctx, userID)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get user by id")
@@ -357,6 +420,14 @@ func (s *UserService) UpdateUser(ctx context.Context, userID uint, req models.Up
 		}
 	}
 
+	// A demoted teacher's profile is archived before the role changes so it
+	// never stays public for a user that is no longer a teacher.
+	if user.Role == "teacher" && req.Role != "teacher" {
+		if err := s.archiveTeacher(user.ID, actorID, "user role changed to "+req.Role); err != nil {
+			return nil, err
+		}
+	}
+
 	user.Name = req.Name
 	user.Email = req.Email
 	user.Role = req.Role
@@ -370,24 +441,35 @@ func (s *UserService) UpdateUser(ctx context.Context, userID uint, req models.Up
 		return nil, errors.New("failed to update user")
 	}
 
+	// Provisioning is idempotent and also refreshes name and avatar, so it
+	// runs on every save of a teacher.
+	s.provisionTeacher(user, actorID)
+
 	return &models.UserResponse{
 		Id:           user.ID,
 		Name:         user.Name,
 		Email:        user.Email,
 		Role:         user.Role,
 		ProfileImage: user.ProfileImage,
+		Locale:       user.Locale,
 		CreatedAt:    user.CreatedAt,
 		UpdatedAt:    user.UpdatedAt,
 	}, nil
 }
 
-func (s *UserService) DeleteUser(ctx context.Context, userID uint) error {
+func (s *UserService) DeleteUser(ctx context.Context, actorID, userID uint) error {
 	user, err := s.repoUser.GetUserById(ctx, userID)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get user by id")
 		return errors.New("user not found")
 	}
 
+	if user.Role == "teacher" {
+		if err := s.archiveTeacher(user.ID, actorID, "user account deleted"); err != nil {
+			return err
+		}
+	}
+
 	if err := s.repoUser.DeleteUser(ctx, user); err != nil {
 		log.Error().Err(err).Msg("Failed to delete user")
 		return errors.New("failed to delete user")
@@ -475,3 +557,51 @@ func (s *UserService) GetFavoriteTeachers(ctx context.Context, userID int) ([]in
 	}
 	return s.favoriteRepo.GetFavoritesByUser(ctx, userID)
 }
+
+// SyncProfileFromTeacher applies a name or avatar change made on the teacher
+// profile. It does not call back into the teacher service.
+func (s *UserService) SyncProfileFromTeacher(ctx context.Context, userID uint, name, profileImage string) error {
+	user, err := s.repoUser.GetUserById(ctx, userID)
+	if err != nil {
+		return errors.New(userNotFound)
+	}
+
+	if name != "" {
+		user.Name = name
+	}
+	if profileImage != "" {
+		user.ProfileImage = profileImage
+	}
+
+	if err := s.repoUser.UpdateUser(ctx, user); err != nil {
+		log.Error().Err(err).Msg("Failed to update user")
+		return errors.New("failed to update profile")
+	}
+	return nil
+}
+
+// provisionTeacher creates or restores the teacher profile of a teacher
+// user. Failures are only logged: the call is idempotent, so saving the user
+// again or submitting a teacher application repairs the link.
+func (s *UserService) provisionTeacher(user *models.User, actorID uint) {
+	if user.Role != "teacher" || s.serviceTeacher == nil {
+		return
+	}
+	if err := s.serviceTeacher.ProvisionTeacher(user.ID, user.Name, user.ProfileImage, actorID); err != nil {
+		log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to provision teacher profile")
+	}
+}
+
+// archiveTeacher hides the teacher profile of a user that stops being a
+// teacher. Unlike provisioning it must succeed, otherwise the profile would
+// stay public.
+func (s *UserService) archiveTeacher(userID, actorID uint, reason string) error {
+	if s.serviceTeacher == nil {
+		return nil
+	}
+	if err := s.serviceTeacher.ArchiveTeacher(userID, actorID, reason); err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to archive teacher profile")
+		return errors.New("failed to archive teacher profile")
+	}
+	return nil
+}